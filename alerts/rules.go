@@ -0,0 +1,168 @@
+// Package alerts 告警规则：每次轮询后检查实盘连输、当日盈亏、开奖停更、车型遗漏和特殊奖项，
+// 生成告警记录并通过通知渠道发送
+package alerts
+
+import (
+	"benz-sniper/engine"
+	"benz-sniper/models"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// 规则类型
+const (
+	KindLossStreak    = "real_loss_streak" // 实盘连输期数（按策略）
+	KindDailyPnL      = "daily_real_pnl"   // 当前营业日实盘盈亏（按策略或合计）
+	KindRoundStale    = "round_stale"      // 距最新一期开奖的秒数
+	KindCarAbsent     = "car_absent"       // 车型连续未开出的期数
+	KindSpecialReward = "special_reward"   // 开出特殊奖项（不比较阈值）
+)
+
+// 告警级别
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// DefaultCooldown 未指定时的冷却时间（秒）
+const DefaultCooldown = 300
+
+// maxRoundsThreshold 连输/遗漏期数阈值上限（检查时最多读取这么多期）
+const maxRoundsThreshold = 2000
+
+// kindSpec 规则类型的默认比较方式和检查对象
+type kindSpec struct {
+	operator string   // 默认比较方式（为空表示不比较阈值）
+	targets  []string // 允许的检查对象（nil 表示不限制）
+	noTarget bool     // 不支持检查对象
+}
+
+var kindSpecs = map[string]kindSpec{
+	KindLossStreak:    {operator: ">="},
+	KindDailyPnL:      {operator: "<"},
+	KindRoundStale:    {operator: ">=", noTarget: true},
+	KindCarAbsent:     {operator: ">=", targets: engine.BET_LABELS},
+	KindSpecialReward: {targets: engine.SPECIAL_REWARDS},
+}
+
+// Kinds 全部规则类型
+func Kinds() []string {
+	return []string{KindLossStreak, KindDailyPnL, KindRoundStale, KindCarAbsent, KindSpecialReward}
+}
+
+// Normalize 补全默认值并校验规则（channels 为已配置的渠道名称），失败时返回 *engine.ValidationError
+func Normalize(rule *models.AlertRule, channels []string) error {
+	var errs []engine.FieldError
+	invalid := func(field string, value any, format string, args ...any) {
+		errs = append(errs, engine.FieldError{Field: field, Value: value, Message: fmt.Sprintf(format, args...)})
+	}
+
+	rule.Name = strings.TrimSpace(rule.Name)
+	if rule.Name == "" || utf8.RuneCountInString(rule.Name) > 100 {
+		invalid("name", rule.Name, "规则名称不能为空，最多 100 个字符")
+	}
+
+	spec, ok := kindSpecs[rule.Kind]
+	if !ok {
+		invalid("kind", rule.Kind, "规则类型只能是 %s", strings.Join(Kinds(), " / "))
+	}
+
+	if rule.Severity == "" {
+		rule.Severity = SeverityWarning
+	}
+	if !slices.Contains([]string{SeverityInfo, SeverityWarning, SeverityCritical}, rule.Severity) {
+		invalid("severity", rule.Severity, "级别只能是 info / warning / critical")
+	}
+
+	if rule.Cooldown < 0 {
+		invalid("cooldown", rule.Cooldown, "冷却时间不能为负数")
+	}
+
+	rule.Channels = strings.Join(splitChannels(rule.Channels), ",")
+	for _, name := range splitChannels(rule.Channels) {
+		if !slices.Contains(channels, name) {
+			invalid("channels", name, "通知渠道未配置，可用: %s", strings.Join(channels, " / "))
+		}
+	}
+
+	if ok {
+		rule.Target = strings.TrimSpace(rule.Target)
+		switch {
+		case spec.noTarget && rule.Target != "":
+			invalid("target", rule.Target, "%s 不支持检查对象", rule.Kind)
+		case spec.targets != nil && rule.Target != "" && !slices.Contains(spec.targets, rule.Target):
+			invalid("target", rule.Target, "检查对象只能是 %s", strings.Join(spec.targets, " / "))
+		}
+
+		if spec.operator == "" {
+			rule.Operator, rule.Threshold = "", 0
+		} else {
+			if rule.Operator == "" {
+				rule.Operator = spec.operator
+			}
+			if !slices.Contains([]string{">=", ">", "<=", "<"}, rule.Operator) {
+				invalid("operator", rule.Operator, "比较方式只能是 >= / > / <= / <")
+			}
+			errs = append(errs, checkThreshold(rule)...)
+		}
+	}
+
+	if len(errs) > 0 {
+		return &engine.ValidationError{Errors: errs}
+	}
+	return nil
+}
+
+// checkThreshold 按规则类型校验阈值
+func checkThreshold(rule *models.AlertRule) []engine.FieldError {
+	value := rule.Threshold
+	invalid := func(message string) []engine.FieldError {
+		return []engine.FieldError{{Field: "threshold", Value: value, Message: message}}
+	}
+	switch rule.Kind {
+	case KindLossStreak, KindCarAbsent:
+		if value < 1 || value > maxRoundsThreshold || value != math.Trunc(value) {
+			return invalid(fmt.Sprintf("期数阈值必须是 1 到 %d 之间的整数", maxRoundsThreshold))
+		}
+	case KindRoundStale:
+		if value <= 0 {
+			return invalid("秒数阈值必须大于 0")
+		}
+	}
+	return nil
+}
+
+// splitChannels 解析逗号分隔的渠道名称
+func splitChannels(value string) []string {
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// compare 按比较方式判断取值是否满足阈值
+func compare(operator string, value, threshold float64) bool {
+	switch operator {
+	case ">=":
+		return value >= threshold
+	case ">":
+		return value > threshold
+	case "<=":
+		return value <= threshold
+	case "<":
+		return value < threshold
+	}
+	return false
+}
+
+// roundsLimit 判断连输/遗漏期数需要读取的期数（比较方式为 > 时需要多读一期）
+func roundsLimit(rule models.AlertRule) int {
+	return min(int(rule.Threshold)+1, maxRoundsThreshold+1)
+}
@@ -0,0 +1,67 @@
+package alerts
+
+import (
+	"benz-sniper/engine"
+	"benz-sniper/models"
+	"errors"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	channels := []string{"smtp", "webhook"}
+
+	rule := &models.AlertRule{Name: " 大三元 ", Kind: KindSpecialReward, Target: "大三元", Operator: ">", Threshold: 5, Channels: "webhook, smtp,webhook"}
+	if err := Normalize(rule, channels); err != nil {
+		t.Fatalf("Normalize() error = %v", err)
+	}
+	if rule.Name != "大三元" || rule.Operator != "" || rule.Threshold != 0 || rule.Severity != SeverityWarning || rule.Channels != "webhook,smtp" {
+		t.Errorf("特殊奖项规则 = %+v, want 不比较阈值、渠道去重", rule)
+	}
+
+	rule = &models.AlertRule{Name: "停更", Kind: KindRoundStale, Threshold: 90}
+	if err := Normalize(rule, channels); err != nil || rule.Operator != ">=" {
+		t.Errorf("停更规则 = %+v, %v", rule, err)
+	}
+
+	tests := []struct {
+		name  string
+		rule  models.AlertRule
+		field string
+	}{
+		{"未知类型", models.AlertRule{Name: "x", Kind: "win_streak"}, "kind"},
+		{"停更不支持对象", models.AlertRule{Name: "x", Kind: KindRoundStale, Target: "热门3码", Threshold: 60}, "target"},
+		{"连输期数为小数", models.AlertRule{Name: "x", Kind: KindLossStreak, Threshold: 2.5}, "threshold"},
+		{"遗漏期数超过上限", models.AlertRule{Name: "x", Kind: KindCarAbsent, Threshold: maxRoundsThreshold + 1}, "threshold"},
+		{"比较方式", models.AlertRule{Name: "x", Kind: KindDailyPnL, Operator: "=="}, "operator"},
+		{"级别", models.AlertRule{Name: "x", Kind: KindDailyPnL, Severity: "fatal"}, "severity"},
+		{"冷却时间", models.AlertRule{Name: "x", Kind: KindDailyPnL, Cooldown: -1}, "cooldown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var invalid *engine.ValidationError
+			err := Normalize(&tt.rule, channels)
+			if !errors.As(err, &invalid) || len(invalid.Errors) != 1 || invalid.Errors[0].Field != tt.field {
+				t.Errorf("Normalize() error = %v, want 只有 %s 字段错误", err, tt.field)
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	cases := []struct {
+		operator string
+		value    float64
+		want     bool
+	}{
+		{">=", 3, true}, {">", 3, false}, {"<=", 3, true}, {"<", 3, false}, {"<", -2001, true}, {"==", 3, false},
+	}
+	for _, c := range cases {
+		threshold := 3.0
+		if c.value < 0 {
+			threshold = -2000
+		}
+		if got := compare(c.operator, c.value, threshold); got != c.want {
+			t.Errorf("compare(%q, %v, %v) = %v, want %v", c.operator, c.value, threshold, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,526 @@
+package alerts
+
+import (
+	"benz-sniper/database"
+	"benz-sniper/engine"
+	"benz-sniper/metrics"
+	"benz-sniper/models"
+	"benz-sniper/notify"
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// deliveryTimeout 单条告警通知的超时时间
+const deliveryTimeout = 30 * time.Second
+
+// Options 告警参数
+type Options struct {
+	Channels []notify.Channel // 通知渠道（规则未指定渠道时发送到全部渠道，为空时只记录不通知）
+}
+
+// Service 告警规则管理和检查（Observe 注册为引擎的轮询回调）
+type Service struct {
+	manager  *engine.StrategyManager
+	store    database.Store
+	clock    engine.Clock
+	channels []notify.Channel
+
+	mu    sync.Mutex
+	rules []models.AlertRule     // 已启用的规则（nil 表示需要重新加载）
+	fired map[firedKey]time.Time // 每条规则和对象最近一次告警的时间（冷却使用）
+	wg    sync.WaitGroup         // 正在发送的通知
+}
+
+// firedKey 冷却的粒度：规则 + 触发对象
+type firedKey struct {
+	ruleID  uint
+	subject string
+}
+
+// trigger 一次满足条件的检查结果
+type trigger struct {
+	subject string
+	value   float64
+	roundID string
+	message string
+}
+
+// NewService 创建告警服务（使用策略管理器的时钟）
+func NewService(manager *engine.StrategyManager, store database.Store, opts Options) *Service {
+	return &Service{
+		manager:  manager,
+		store:    store,
+		clock:    manager.Clock(),
+		channels: opts.Channels,
+		fired:    make(map[firedKey]time.Time),
+	}
+}
+
+// ChannelNames 已配置的通知渠道名称
+func (s *Service) ChannelNames() []string {
+	names := make([]string, 0, len(s.channels))
+	for _, ch := range s.channels {
+		if !slices.Contains(names, ch.Name()) {
+			names = append(names, ch.Name())
+		}
+	}
+	return names
+}
+
+// ListRules 全部告警规则
+func (s *Service) ListRules() ([]models.AlertRule, error) {
+	return s.store.ListAlertRules()
+}
+
+// GetRule 按ID查询告警规则
+func (s *Service) GetRule(id uint) (*models.AlertRule, error) {
+	return s.store.GetAlertRule(id)
+}
+
+// CreateRule 校验并创建告警规则
+func (s *Service) CreateRule(rule *models.AlertRule) error {
+	if err := Normalize(rule, s.ChannelNames()); err != nil {
+		return err
+	}
+	now := s.clock.Now().Local()
+	rule.CreatedAt, rule.UpdatedAt = &now, &now
+	if err := s.store.CreateAlertRule(rule); err != nil {
+		return err
+	}
+	s.invalidate()
+	slog.Info("🔔 告警规则已创建", "rule_id", rule.ID, "name", rule.Name, "kind", rule.Kind, "severity", rule.Severity)
+	return nil
+}
+
+// UpdateRule 校验并整条更新告警规则（冷却状态保留）
+func (s *Service) UpdateRule(rule *models.AlertRule) error {
+	if err := Normalize(rule, s.ChannelNames()); err != nil {
+		return err
+	}
+	now := s.clock.Now().Local()
+	rule.UpdatedAt = &now
+	if err := s.store.UpdateAlertRule(rule); err != nil {
+		return err
+	}
+	s.invalidate()
+	slog.Info("🔔 告警规则已修改", "rule_id", rule.ID, "name", rule.Name, "enabled", rule.Enabled)
+	return nil
+}
+
+// DeleteRule 删除告警规则（已有的告警记录保留）
+func (s *Service) DeleteRule(id uint) error {
+	if err := s.store.DeleteAlertRule(id); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.rules = nil
+	for key := range s.fired {
+		if key.ruleID == id {
+			delete(s.fired, key)
+		}
+	}
+	s.mu.Unlock()
+	slog.Info("🔕 告警规则已删除", "rule_id", id)
+	return nil
+}
+
+// ListAlerts 分页查询告警记录
+func (s *Service) ListAlerts(query database.AlertQuery) ([]models.Alert, int64, error) {
+	return s.store.ListAlerts(query)
+}
+
+// Wait 等待正在发送的通知完成（测试和退出时使用）
+func (s *Service) Wait() {
+	s.wg.Wait()
+}
+
+// invalidate 规则变更后重新加载
+func (s *Service) invalidate() {
+	s.mu.Lock()
+	s.rules = nil
+	s.mu.Unlock()
+}
+
+// enabledRules 已启用的规则（缓存到下次变更）
+func (s *Service) enabledRules() ([]models.AlertRule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.rules != nil {
+		return s.rules, nil
+	}
+	all, err := s.store.ListAlertRules()
+	if err != nil {
+		return nil, err
+	}
+	s.rules = make([]models.AlertRule, 0, len(all))
+	for _, rule := range all {
+		if rule.Enabled {
+			s.rules = append(s.rules, rule)
+		}
+	}
+	return s.rules, nil
+}
+
+// Observe 检查全部已启用的规则（引擎每次轮询后调用）
+func (s *Service) Observe(ev engine.TickEvent) {
+	rules, err := s.enabledRules()
+	if err != nil {
+		slog.Error("❌ 加载告警规则失败", "error", err)
+		return
+	}
+	check := &evaluation{event: ev, absence: make(map[int]map[string]int)}
+	for _, rule := range rules {
+		triggers, err := s.evaluate(rule, check)
+		if err != nil {
+			slog.Error("❌ 告警规则检查失败", "rule_id", rule.ID, "kind", rule.Kind, "error", err)
+			continue
+		}
+		for _, t := range triggers {
+			s.fire(rule, t, ev.At)
+		}
+	}
+}
+
+// evaluation 一次轮询内的检查（多条规则共用查询结果）
+type evaluation struct {
+	event   engine.TickEvent
+	absence map[int]map[string]int // 读取期数 -> 各车型连续未开出的期数
+}
+
+// hasBets 本次轮询是否有策略参与结算
+func (e *evaluation) hasBets() bool {
+	for _, r := range e.event.Settled {
+		if r.HasBets {
+			return true
+		}
+	}
+	return false
+}
+
+// evaluate 按规则类型检查，返回满足条件的对象
+func (s *Service) evaluate(rule models.AlertRule, check *evaluation) ([]trigger, error) {
+	ev := check.event
+	switch rule.Kind {
+	case KindRoundStale:
+		if ev.RoundAt.IsZero() {
+			return nil, nil
+		}
+		stale := ev.At.Sub(ev.RoundAt)
+		value := math.Floor(stale.Seconds())
+		if !compare(rule.Operator, value, rule.Threshold) {
+			return nil, nil
+		}
+		return []trigger{{
+			value:   value,
+			roundID: ev.RoundID,
+			message: fmt.Sprintf("已 %s 没有新期号（最新期号 %s）", stale.Truncate(time.Second), ev.RoundID),
+		}}, nil
+
+	case KindSpecialReward:
+		var triggers []trigger
+		for _, r := range ev.Settled {
+			if r.SpecialReward == "" || (rule.Target != "" && r.SpecialReward != rule.Target) {
+				continue
+			}
+			triggers = append(triggers, trigger{
+				subject: r.SpecialReward,
+				value:   1,
+				roundID: r.RoundID,
+				message: fmt.Sprintf("期号 %s 开出 %s（%s）", r.RoundID, r.SpecialReward, strings.Join(r.Winners, ",")),
+			})
+		}
+		return triggers, nil
+
+	case KindLossStreak:
+		if !check.hasBets() {
+			return nil, nil
+		}
+		return s.lossStreaks(rule, ev)
+
+	case KindDailyPnL:
+		if !check.hasBets() {
+			return nil, nil
+		}
+		return s.dailyPnL(rule, ev)
+
+	case KindCarAbsent:
+		if !ev.NewRound {
+			return nil, nil
+		}
+		return s.carAbsence(rule, check)
+	}
+	return nil, fmt.Errorf("未知规则类型: %s", rule.Kind)
+}
+
+// lossStreaks 本次轮询结算了实盘注单的策略的连输期数（只统计实盘记录）
+func (s *Service) lossStreaks(rule models.AlertRule, ev engine.TickEvent) ([]trigger, error) {
+	settled := make(map[string]bool, len(ev.Settled))
+	for _, r := range ev.Settled {
+		settled[r.RoundID] = true
+	}
+	names := []string{rule.Target}
+	if rule.Target == "" {
+		names = names[:0]
+		for _, st := range s.manager.GetState().Strategies {
+			names = append(names, st.Name)
+		}
+	}
+
+	var triggers []trigger
+	for _, name := range names {
+		records, _, err := s.store.ListHistory(database.HistoryQuery{
+			HistoryFilter: database.HistoryFilter{Strategy: name, Status: database.StatusFilter(engine.StatusReal)},
+			SkipCount:     true,
+			Limit:         roundsLimit(rule),
+		})
+		if err != nil {
+			return nil, err
+		}
+		// 最近一条实盘记录不是本次结算的，说明连输期数没有变化
+		if len(records) == 0 || !settled[records[0].RoundID] {
+			continue
+		}
+		streak := 0
+		for _, h := range records {
+			if h.Result != "输" {
+				break
+			}
+			streak++
+		}
+		if compare(rule.Operator, float64(streak), rule.Threshold) {
+			triggers = append(triggers, trigger{
+				subject: name,
+				value:   float64(streak),
+				roundID: records[0].RoundID,
+				message: fmt.Sprintf("%s 实盘连输 %d 期（最近期号 %s）", name, streak, records[0].RoundID),
+			})
+		}
+	}
+	return triggers, nil
+}
+
+// dailyPnL 当前营业日的实盘盈亏（指定策略或全部策略合计）
+func (s *Service) dailyPnL(rule models.AlertRule, ev engine.TickEvent) ([]trigger, error) {
+	cal := database.GetCalendar()
+	start := cal.PeriodStart(ev.At, database.PeriodDay)
+	filter := database.HistoryFilter{Strategy: rule.Target, From: start.Local(), To: cal.NextPeriod(start, database.PeriodDay).Local()}
+	report, err := s.manager.GetPeriodReport(filter, database.PeriodDay)
+	if err != nil {
+		return nil, err
+	}
+	value := report.Summary.TotalProfit
+	if !compare(rule.Operator, value, rule.Threshold) {
+		return nil, nil
+	}
+	label := rule.Target
+	if label == "" {
+		label = "全部策略"
+	}
+	return []trigger{{
+		subject: rule.Target,
+		value:   value,
+		roundID: ev.RoundID,
+		message: fmt.Sprintf("%s 营业日 %s 实盘盈亏 %.2f", label, cal.PeriodKey(start, database.PeriodDay), value),
+	}}, nil
+}
+
+// carAbsence 车型连续未开出的期数（最近一期开出为 0，读取范围内都没开出时为读取到的期数）
+func (s *Service) carAbsence(rule models.AlertRule, check *evaluation) ([]trigger, error) {
+	limit := roundsLimit(rule)
+	absence, ok := check.absence[limit]
+	if !ok {
+		rounds, err := s.store.RecentRounds(limit)
+		if err != nil {
+			return nil, err
+		}
+		roundIDs := make([]string, len(rounds))
+		for i, r := range rounds {
+			roundIDs[i] = r.RoundID
+		}
+		winners, err := s.store.WinnersByRounds(roundIDs)
+		if err != nil {
+			return nil, err
+		}
+		byRound := make(map[string][]string, len(rounds))
+		for _, w := range winners {
+			// 与结算一致：抓取的车型名称先清理再比较
+			byRound[w.RoundID] = append(byRound[w.RoundID], engine.CleanName(w.WinnerName))
+		}
+
+		absence = make(map[string]int, len(engine.BET_LABELS))
+		for _, label := range engine.BET_LABELS {
+			absence[label] = len(rounds)
+		}
+		seen := make(map[string]bool, len(engine.BET_LABELS))
+		for i, r := range rounds { // 按期号降序
+			for _, name := range byRound[r.RoundID] {
+				if _, known := absence[name]; known && !seen[name] {
+					seen[name] = true
+					absence[name] = i
+				}
+			}
+		}
+		check.absence[limit] = absence
+	}
+
+	cars := engine.BET_LABELS
+	if rule.Target != "" {
+		cars = []string{rule.Target}
+	}
+	var triggers []trigger
+	for _, car := range cars {
+		n := absence[car]
+		if compare(rule.Operator, float64(n), rule.Threshold) {
+			triggers = append(triggers, trigger{
+				subject: car,
+				value:   float64(n),
+				roundID: check.event.RoundID,
+				message: fmt.Sprintf("%s 已连续 %d 期未开出", car, n),
+			})
+		}
+	}
+	return triggers, nil
+}
+
+// fire 记录告警并发送通知（同一规则和对象在冷却期内只告警一次）
+func (s *Service) fire(rule models.AlertRule, t trigger, at time.Time) {
+	key := firedKey{ruleID: rule.ID, subject: t.subject}
+	last := s.lastFired(key)
+	if rule.Cooldown > 0 && !last.IsZero() && at.Sub(last) < time.Duration(rule.Cooldown)*time.Second {
+		return
+	}
+
+	createdAt := at.Local()
+	alert := &models.Alert{
+		RuleID:    rule.ID,
+		RuleName:  rule.Name,
+		Kind:      rule.Kind,
+		Severity:  rule.Severity,
+		Subject:   t.subject,
+		Value:     t.value,
+		Threshold: rule.Threshold,
+		Message:   t.message,
+		RoundID:   t.roundID,
+		CreatedAt: &createdAt,
+	}
+	if err := s.store.CreateAlert(alert); err != nil {
+		slog.Error("❌ 保存告警记录失败", "rule_id", rule.ID, "error", err)
+		return
+	}
+	s.mu.Lock()
+	s.fired[key] = at
+	s.mu.Unlock()
+
+	metrics.AlertsFired.WithLabelValues(rule.Kind, rule.Severity).Inc()
+	slog.Log(context.Background(), severityLevel(rule.Severity), "🚨 告警",
+		"alert_id", alert.ID, "rule_id", rule.ID, "rule", rule.Name, "severity", rule.Severity,
+		"subject", t.subject, "value", t.value, "message", t.message)
+	s.deliver(rule, alert)
+}
+
+// lastFired 最近一次告警的时间（内存中没有时查询告警记录，重启后冷却仍然有效）
+func (s *Service) lastFired(key firedKey) time.Time {
+	s.mu.Lock()
+	last, ok := s.fired[key]
+	s.mu.Unlock()
+	if ok {
+		return last
+	}
+
+	alerts, _, err := s.store.ListAlerts(database.AlertQuery{RuleID: key.ruleID, Subject: key.subject, Limit: 1})
+	if err != nil {
+		slog.Error("❌ 查询告警记录失败", "rule_id", key.ruleID, "error", err)
+		return time.Time{}
+	}
+	if len(alerts) > 0 && alerts[0].CreatedAt != nil {
+		last = *alerts[0].CreatedAt
+	}
+	s.mu.Lock()
+	s.fired[key] = last
+	s.mu.Unlock()
+	return last
+}
+
+// deliver 异步发送通知并记录结果（不阻塞引擎轮询）
+func (s *Service) deliver(rule models.AlertRule, alert *models.Alert) {
+	channels := s.channelsFor(rule)
+	if len(channels) == 0 {
+		return
+	}
+	msg := message(alert)
+	id := alert.ID
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), deliveryTimeout)
+		defer cancel()
+
+		err := notify.SendAll(ctx, channels, msg)
+		var deliveredAt *time.Time
+		if err == nil {
+			now := s.clock.Now().Local()
+			deliveredAt = &now
+		} else {
+			slog.Error("❌ 告警通知失败", "alert_id", id, "error", err)
+		}
+		if updateErr := s.store.UpdateAlertDelivery(id, deliveredAt, notify.Summary(err, 500)); updateErr != nil {
+			slog.Error("❌ 记录通知结果失败", "alert_id", id, "error", updateErr)
+		}
+	}()
+}
+
+// channelsFor 规则使用的通知渠道（未指定时使用全部渠道）
+func (s *Service) channelsFor(rule models.AlertRule) []notify.Channel {
+	names := splitChannels(rule.Channels)
+	if len(names) == 0 {
+		return s.channels
+	}
+	channels := make([]notify.Channel, 0, len(names))
+	for _, ch := range s.channels {
+		if slices.Contains(names, ch.Name()) {
+			channels = append(channels, ch)
+		}
+	}
+	return channels
+}
+
+// message 告警通知：邮件和 Webhook 纯文本为告警内容，Webhook 另带完整字段
+func message(alert *models.Alert) notify.Message {
+	subject := fmt.Sprintf("[%s] %s", strings.ToUpper(alert.Severity), alert.RuleName)
+	return notify.Message{
+		Subject: subject,
+		Text:    fmt.Sprintf("%s\n%s", alert.Message, alert.CreatedAt.Format(time.DateTime)),
+		Payload: map[string]any{
+			"event":      "alert",
+			"id":         alert.ID,
+			"rule_id":    alert.RuleID,
+			"rule":       alert.RuleName,
+			"kind":       alert.Kind,
+			"severity":   alert.Severity,
+			"subject":    alert.Subject,
+			"value":      alert.Value,
+			"threshold":  alert.Threshold,
+			"message":    alert.Message,
+			"round_id":   alert.RoundID,
+			"created_at": alert.CreatedAt,
+		},
+	}
+}
+
+// severityLevel 告警级别对应的日志级别
+func severityLevel(severity string) slog.Level {
+	switch severity {
+	case SeverityCritical:
+		return slog.LevelError
+	case SeverityWarning:
+		return slog.LevelWarn
+	}
+	return slog.LevelInfo
+}
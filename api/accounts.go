@@ -0,0 +1,72 @@
+package api
+
+import (
+	"benz-sniper/database"
+	"benz-sniper/engine"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AccountLedgerResponse 账号流水响应（流水明细分页，汇总、异动和每日汇总不分页）
+type AccountLedgerResponse struct {
+	engine.AccountLedger
+	Total      int `json:"total"`       // 流水条数
+	TotalPages int `json:"total_pages"` // 总页数
+	Page       int `json:"page"`        // 当前页码
+	PageSize   int `json:"page_size"`   // 每页大小
+}
+
+// ListAccounts 账号列表及累计下注、派彩、净盈亏和最新余额（支持 from / to 按上传时间筛选）
+func (h *Handler) ListAccounts(c *gin.Context) {
+	from, to, ok := timeRange(c)
+	if !ok {
+		return
+	}
+	accounts, err := h.manager.ListAccounts(from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "查询账号失败: " + err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"accounts": accounts,
+	})
+}
+
+// GetAccountLedger 账号流水：余额轨迹、充值/提现检测和每日汇总
+func (h *Handler) GetAccountLedger(c *gin.Context) {
+	from, to, ok := timeRange(c)
+	if !ok {
+		return
+	}
+	ledger, err := h.manager.AccountLedger(c.Param("account"), from, to)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, database.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"success": false,
+			"message": "查询账号流水失败: " + err.Error(),
+		})
+		return
+	}
+
+	page, pageSize := pageParams(c)
+	total := len(ledger.Entries)
+	start, end := pageBounds(total, page, pageSize)
+	ledger.Entries = ledger.Entries[start:end]
+
+	c.JSON(http.StatusOK, AccountLedgerResponse{
+		AccountLedger: *ledger,
+		Total:         total,
+		TotalPages:    (total + pageSize - 1) / pageSize,
+		Page:          page,
+		PageSize:      pageSize,
+	})
+}
@@ -0,0 +1,73 @@
+package api_test
+
+import (
+	"benz-sniper/api"
+	"benz-sniper/engine"
+	"benz-sniper/testutil"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestAccountLedger(t *testing.T) {
+	testutil.EachDriver(t, testAccountLedger)
+}
+
+func testAccountLedger(t *testing.T, h *testutil.Harness) {
+	r1, r2, r3 := h.Draw("红宝马"), h.Draw("红宝马"), h.Draw("红宝马")
+	upload := func(round, account string, bet, payout, balance float64) {
+		h.JSON(http.MethodPost, "/api/user-bets", map[string]any{
+			"round_id": round, "user_account": account, "bet_amount": bet, "payout_amount": payout, "balance": balance,
+		}, &struct{}{})
+	}
+	// 乱序上传，流水按期号排序
+	upload(r2, "acc-1", 100, 2200, 3000)
+	upload(r1, "acc-1", 100, 0, 900)
+	upload(r1, "acc-2", 50, 0, 0) // 未上报余额
+	// 第二天：推算余额 2900，上报 3400 → 充值 500
+	h.Clock.Advance(24 * time.Hour)
+	upload(r3, "acc-1", 100, 0, 3400)
+
+	var list struct {
+		Accounts []engine.AccountSummary `json:"accounts"`
+	}
+	h.JSON(http.MethodGet, "/api/accounts", nil, &list)
+	if len(list.Accounts) != 2 || list.Accounts[0].Account != "acc-1" || list.Accounts[1].Account != "acc-2" {
+		t.Fatalf("账号列表 = %+v", list.Accounts)
+	}
+	acc1 := list.Accounts[0]
+	if acc1.Bets != 3 || acc1.TotalStake != 300 || acc1.TotalPayout != 2200 || acc1.Net != 1900 ||
+		acc1.Deposits != 500 || acc1.Balance != 3400 {
+		t.Errorf("acc-1 汇总 = %+v", acc1)
+	}
+
+	var ledger api.AccountLedgerResponse
+	h.JSON(http.MethodGet, "/api/accounts/acc-1/ledger", nil, &ledger)
+	if ledger.Total != 3 || ledger.Entries[0].RoundID != r3 || ledger.Entries[2].RoundID != r1 {
+		t.Fatalf("流水 = %+v, want %s..%s 降序", ledger.Entries, r3, r1)
+	}
+	if e := ledger.Entries[1]; e.ExpectedBalance == nil || *e.ExpectedBalance != 3000 || e.Adjustment != 0 || e.CumNet != 2000 {
+		t.Errorf("%s 流水 = %+v, want 推算余额 3000 无异动", r2, e)
+	}
+	if len(ledger.Jumps) != 1 || ledger.Jumps[0].Kind != engine.BalanceDeposit || ledger.Jumps[0].Amount != 500 {
+		t.Errorf("余额异动 = %+v, want 充值 500", ledger.Jumps)
+	}
+	if len(ledger.Daily) != 2 || ledger.Daily[0].Deposits != 500 || ledger.Daily[1].Net != 2000 || ledger.Daily[1].ClosingBalance != 3000 {
+		t.Errorf("每日汇总 = %+v", ledger.Daily)
+	}
+
+	// 时间范围只含第二天，异动仍按前一期余额推算
+	from := h.Clock.Now().Format("2006-01-02")
+	h.JSON(http.MethodGet, "/api/accounts/acc-1/ledger?from="+from, nil, &ledger)
+	if ledger.Total != 1 || len(ledger.Jumps) != 1 || ledger.TotalStake != 100 {
+		t.Errorf("第二天流水 = %+v", ledger)
+	}
+	h.JSON(http.MethodGet, "/api/accounts?from="+from, nil, &list)
+	if len(list.Accounts) != 1 {
+		t.Errorf("第二天账号 = %+v, want 仅 acc-1", list.Accounts)
+	}
+
+	if rec := h.Do(http.MethodGet, "/api/accounts/nobody/ledger", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("未知账号 = %d, want 404", rec.Code)
+	}
+}
@@ -0,0 +1,222 @@
+package api
+
+import (
+	"benz-sniper/alerts"
+	"benz-sniper/database"
+	"benz-sniper/engine"
+	"benz-sniper/models"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetAlerts 设置告警服务（未设置时告警接口返回 503）
+func (h *Handler) SetAlerts(s *alerts.Service) {
+	h.alerts = s
+}
+
+// alertsReady 检查告警服务是否可用
+func (h *Handler) alertsReady(c *gin.Context) bool {
+	if h.alerts == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"success": false, "message": "告警服务未启动"})
+		return false
+	}
+	return true
+}
+
+// alertRuleRequest 创建/修改告警规则请求（修改时整条替换）
+type alertRuleRequest struct {
+	Name      string  `json:"name"`
+	Kind      string  `json:"kind"`      // real_loss_streak / daily_real_pnl / round_stale / car_absent / special_reward
+	Target    string  `json:"target"`    // 策略/车型/特殊奖项（为空表示全部）
+	Operator  string  `json:"operator"`  // >= / > / <= / <（默认按规则类型）
+	Threshold float64 `json:"threshold"` // 阈值（期数/金额/秒）
+	Severity  string  `json:"severity"`  // info / warning（默认）/ critical
+	Cooldown  *int    `json:"cooldown"`  // 冷却时间（秒，默认 300，0 表示每次满足条件都告警）
+	Channels  string  `json:"channels"`  // 通知渠道（逗号分隔，为空表示全部）
+	Enabled   *bool   `json:"enabled"`   // 是否启用（默认启用）
+}
+
+// rule 转为告警规则
+func (r alertRuleRequest) rule() *models.AlertRule {
+	cooldown := alerts.DefaultCooldown
+	if r.Cooldown != nil {
+		cooldown = *r.Cooldown
+	}
+	return &models.AlertRule{
+		Name:      r.Name,
+		Kind:      r.Kind,
+		Target:    r.Target,
+		Operator:  r.Operator,
+		Threshold: r.Threshold,
+		Severity:  r.Severity,
+		Cooldown:  cooldown,
+		Channels:  r.Channels,
+		Enabled:   r.Enabled == nil || *r.Enabled,
+	}
+}
+
+// alertError 告警接口的错误响应（校验失败 400，规则不存在 404）
+func alertError(c *gin.Context, err error) {
+	var invalid *engine.ValidationError
+	if errors.As(err, &invalid) {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "告警规则校验失败", "errors": invalid.Errors})
+		return
+	}
+	if errors.Is(err, database.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "告警规则不存在"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error()})
+}
+
+// alertRuleID 解析路径中的规则ID
+func alertRuleID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "规则ID格式错误"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// ListAlertRules 告警规则列表（附带可用的规则类型和通知渠道）
+func (h *Handler) ListAlertRules(c *gin.Context) {
+	if !h.alertsReady(c) {
+		return
+	}
+	rules, err := h.alerts.ListRules()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "查询失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"rules":    rules,
+		"kinds":    alerts.Kinds(),
+		"channels": h.alerts.ChannelNames(),
+	})
+}
+
+// CreateAlertRule 创建告警规则
+func (h *Handler) CreateAlertRule(c *gin.Context) {
+	if !h.alertsReady(c) {
+		return
+	}
+	var req alertRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "请求参数错误: " + err.Error()})
+		return
+	}
+	rule := req.rule()
+	if principal := PrincipalFrom(c); principal != nil {
+		rule.CreatedBy = principal.Name
+	}
+	if err := h.alerts.CreateRule(rule); err != nil {
+		alertError(c, err)
+		return
+	}
+	h.recordAudit(c, AuditAlertRuleSave, nil, rule)
+	c.JSON(http.StatusOK, gin.H{"success": true, "rule": rule})
+}
+
+// UpdateAlertRule 修改告警规则（整条替换）
+func (h *Handler) UpdateAlertRule(c *gin.Context) {
+	if !h.alertsReady(c) {
+		return
+	}
+	id, ok := alertRuleID(c)
+	if !ok {
+		return
+	}
+	var req alertRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "请求参数错误: " + err.Error()})
+		return
+	}
+	before, err := h.alerts.GetRule(id)
+	if err != nil {
+		alertError(c, err)
+		return
+	}
+	rule := req.rule()
+	rule.ID = id
+	if err := h.alerts.UpdateRule(rule); err != nil {
+		alertError(c, err)
+		return
+	}
+	h.recordAudit(c, AuditAlertRuleSave, before, rule)
+	c.JSON(http.StatusOK, gin.H{"success": true, "rule": rule})
+}
+
+// DeleteAlertRule 删除告警规则（已有的告警记录保留）
+func (h *Handler) DeleteAlertRule(c *gin.Context) {
+	if !h.alertsReady(c) {
+		return
+	}
+	id, ok := alertRuleID(c)
+	if !ok {
+		return
+	}
+	before, err := h.alerts.GetRule(id)
+	if err != nil {
+		alertError(c, err)
+		return
+	}
+	if err := h.alerts.DeleteRule(id); err != nil {
+		alertError(c, err)
+		return
+	}
+	h.recordAudit(c, AuditAlertRuleDelete, before, nil)
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "告警规则已删除"})
+}
+
+// AlertsResponse 告警记录响应
+type AlertsResponse struct {
+	Alerts     []models.Alert `json:"alerts"`
+	Total      int64          `json:"total"`       // 总记录数
+	TotalPages int            `json:"total_pages"` // 总页数
+	Page       int            `json:"page"`        // 当前页码
+	PageSize   int            `json:"page_size"`   // 每页大小
+}
+
+// ListAlerts 查询告警记录（支持 rule_id / severity / subject / from / to 筛选和分页）
+func (h *Handler) ListAlerts(c *gin.Context) {
+	if !h.alertsReady(c) {
+		return
+	}
+	page, pageSize := pageParams(c)
+	query := database.AlertQuery{
+		Severity: c.Query("severity"),
+		Subject:  c.Query("subject"),
+		Offset:   (page - 1) * pageSize,
+		Limit:    pageSize,
+	}
+	if value := c.Query("rule_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "rule_id 格式错误: " + value})
+			return
+		}
+		query.RuleID = uint(id)
+	}
+	var ok bool
+	if query.From, query.To, ok = timeRange(c); !ok {
+		return
+	}
+
+	records, total, err := h.alerts.ListAlerts(query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "查询失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, AlertsResponse{
+		Alerts:     records,
+		Total:      total,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+		Page:       page,
+		PageSize:   pageSize,
+	})
+}
@@ -0,0 +1,182 @@
+package api_test
+
+import (
+	"benz-sniper/alerts"
+	"benz-sniper/api"
+	"benz-sniper/engine"
+	"benz-sniper/models"
+	"benz-sniper/notify"
+	"benz-sniper/testutil"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAlertRules(t *testing.T) {
+	smtpServer := testutil.NewSMTPServer(t)
+	webhook := testutil.NewWebhookServer(t)
+	opts := testutil.Options{Alerts: alerts.Options{
+		Channels: []notify.Channel{
+			notify.NewSMTP(notify.SMTPConfig{Addr: smtpServer.Addr, From: "sniper@example.com", To: []string{"ops@example.com"}}),
+			notify.NewWebhook(webhook.URL),
+		},
+	}}
+	// 两种存储共用 SMTP/Webhook 替身，每次先清空
+	testutil.EachDriverWith(t, opts, func(t *testing.T, h *testutil.Harness) {
+		smtpServer.Reset()
+		webhook.Reset()
+
+		var created struct {
+			Rule models.AlertRule `json:"rule"`
+		}
+		createRule := func(body map[string]any) models.AlertRule {
+			t.Helper()
+			h.JSON(http.MethodPost, "/api/alert-rules", body, &created)
+			return created.Rule
+		}
+		streak := createRule(map[string]any{"name": "热门3码连输", "kind": alerts.KindLossStreak, "target": "热门3码",
+			"threshold": 2, "severity": alerts.SeverityCritical, "cooldown": 0})
+		pnl := createRule(map[string]any{"name": "当日亏损", "kind": alerts.KindDailyPnL, "target": "热门3码", "threshold": 0})
+		special := createRule(map[string]any{"name": "特殊奖项", "kind": alerts.KindSpecialReward, "severity": alerts.SeverityInfo,
+			"channels": "webhook", "cooldown": 0})
+		stale := createRule(map[string]any{"name": "停更", "kind": alerts.KindRoundStale, "threshold": 120, "cooldown": 600})
+		if pnl.Operator != "<" || pnl.Severity != alerts.SeverityWarning || pnl.Cooldown != alerts.DefaultCooldown || !pnl.Enabled || pnl.CreatedBy == "" {
+			t.Errorf("默认值 = %+v", pnl)
+		}
+
+		// 校验失败返回全部字段错误
+		rec := h.Do(http.MethodPost, "/api/alert-rules", map[string]any{"name": "", "kind": alerts.KindCarAbsent,
+			"target": "蓝奔驰", "threshold": 1.5, "channels": "sms"})
+		var invalid struct {
+			Errors []engine.FieldError `json:"errors"`
+		}
+		_ = json.Unmarshal(rec.Body.Bytes(), &invalid)
+		if rec.Code != http.StatusBadRequest || len(invalid.Errors) != 4 {
+			t.Errorf("无效规则 = %d %s", rec.Code, rec.Body.String())
+		}
+		if rec := h.DoWith(http.MethodPost, "/api/alert-rules", map[string]any{"name": "x", "kind": alerts.KindRoundStale, "threshold": 60}, nil); rec.Code == http.StatusOK {
+			t.Errorf("匿名创建规则 = %d, want 拒绝", rec.Code)
+		}
+
+		// 实盘连输：只统计实盘记录，第二次实盘输时达到阈值；当日亏损在冷却期内只告警一次
+		h.PromoteToReal("热门3码")
+		h.Draw(h.LosingCar())
+		h.PromoteToReal("热门3码")
+		lossRound := h.Draw(h.LosingCar())
+		h.DrawSpecial("大三元", "红奔驰", "红宝马", "红奥迪")
+
+		alertsOf := func(query string) []models.Alert {
+			t.Helper()
+			var resp api.AlertsResponse
+			h.JSON(http.MethodGet, "/api/alerts?"+query, nil, &resp)
+			return resp.Alerts
+		}
+		ruleAlerts := func(rule models.AlertRule) []models.Alert {
+			t.Helper()
+			return alertsOf("rule_id=" + strconv.FormatUint(uint64(rule.ID), 10))
+		}
+		if got := ruleAlerts(streak); len(got) != 1 || got[0].Value != 2 || got[0].RoundID != lossRound || got[0].Subject != "热门3码" {
+			t.Errorf("连输告警 = %+v", got)
+		}
+		if got := ruleAlerts(pnl); len(got) != 1 || got[0].Value >= 0 {
+			t.Errorf("当日亏损告警 = %+v, want 1 条（冷却期内不重复）", got)
+		}
+		if got := ruleAlerts(special); len(got) != 1 || got[0].Subject != "大三元" || !strings.Contains(got[0].Message, "红宝马") {
+			t.Errorf("特殊奖项告警 = %+v", got)
+		}
+		if got := alertsOf("severity=critical"); len(got) != 1 || got[0].RuleID != streak.ID {
+			t.Errorf("severity=critical = %+v", got)
+		}
+
+		// 开奖停更：冷却期内只告警一次，冷却结束后再次告警
+		h.Clock.Advance(3 * time.Minute)
+		h.Engine.Tick()
+		h.Clock.Advance(time.Minute)
+		h.Engine.Tick()
+		if got := ruleAlerts(stale); len(got) != 1 || got[0].Value < 120 {
+			t.Errorf("停更告警 = %+v", got)
+		}
+
+		// 通知：规则未指定渠道时发送到全部渠道，指定 webhook 的只推送 Webhook
+		h.Alerts.Wait()
+		if n := len(smtpServer.Messages()); n != 3 {
+			t.Errorf("邮件 = %d 封, want 3（连输、亏损、停更）", n)
+		}
+		hooks := webhook.Payloads()
+		rules := make(map[any]bool)
+		for _, hook := range hooks {
+			if hook["event"] == "alert" {
+				rules[hook["rule"]] = true
+			}
+		}
+		if len(hooks) != 4 || !rules["特殊奖项"] || !rules["热门3码连输"] {
+			t.Errorf("webhook = %v", hooks)
+		}
+		if got := ruleAlerts(streak); got[0].DeliveredAt == nil || got[0].DeliveryError != "" {
+			t.Errorf("通知结果 = %+v", got[0])
+		}
+
+		smtpServer.Reject("mailbox full")
+		h.Clock.Advance(10 * time.Minute)
+		h.Engine.Tick()
+		h.Alerts.Wait()
+		if got := ruleAlerts(stale); len(got) != 2 || got[0].DeliveredAt != nil || !strings.Contains(got[0].DeliveryError, "mailbox full") {
+			t.Errorf("冷却结束后的停更告警 = %+v", got)
+		}
+		smtpServer.Reject("")
+
+		// 车型遗漏：新期号时检查
+		absent := createRule(map[string]any{"name": "遗漏", "kind": alerts.KindCarAbsent, "target": engine.BET_LABELS[0], "threshold": 3})
+		for i := 0; i < 3; i++ {
+			h.Draw(engine.BET_LABELS[1])
+		}
+		if got := ruleAlerts(absent); len(got) != 1 || got[0].Subject != engine.BET_LABELS[0] || got[0].Value < 3 {
+			t.Errorf("遗漏告警 = %+v", got)
+		}
+		// 抓取的车型名称先清理再统计：带空白的 " 红宝马 " 算作开出红宝马
+		cleaned := createRule(map[string]any{"name": "红宝马遗漏", "kind": alerts.KindCarAbsent, "target": "红宝马", "threshold": 2})
+		h.Draw(" 红宝马 ")
+		h.Draw(engine.BET_LABELS[1])
+		if got := ruleAlerts(cleaned); len(got) != 0 {
+			t.Errorf("开出红宝马后 1 期即告警 = %+v", got)
+		}
+		h.Draw(engine.BET_LABELS[1])
+		if got := ruleAlerts(cleaned); len(got) != 1 || got[0].Value != 2 {
+			t.Errorf("红宝马遗漏告警 = %+v, want 遗漏 2 期", got)
+		}
+
+		// 停用和删除规则；删除后告警记录保留
+		path := "/api/alert-rules/" + strconv.FormatUint(uint64(stale.ID), 10)
+		h.JSON(http.MethodPut, path, map[string]any{"name": "停更", "kind": alerts.KindRoundStale, "threshold": 120, "enabled": false}, &created)
+		if created.Rule.Enabled || created.Rule.CreatedBy != stale.CreatedBy {
+			t.Errorf("修改后 = %+v", created.Rule)
+		}
+		h.Clock.Advance(time.Hour)
+		h.Engine.Tick()
+		if got := ruleAlerts(stale); len(got) != 2 {
+			t.Errorf("停用后仍然告警: %d 条", len(got))
+		}
+		if rec := h.Do(http.MethodDelete, path, nil); rec.Code != http.StatusOK {
+			t.Errorf("DELETE = %d", rec.Code)
+		}
+		for method, code := range map[string]int{http.MethodDelete: http.StatusNotFound, http.MethodPut: http.StatusNotFound} {
+			if rec := h.Do(method, path, map[string]any{"name": "停更", "kind": alerts.KindRoundStale, "threshold": 60}); rec.Code != code {
+				t.Errorf("%s 已删除的规则 = %d, want %d", method, rec.Code, code)
+			}
+		}
+		var list struct {
+			Rules    []models.AlertRule `json:"rules"`
+			Channels []string           `json:"channels"`
+		}
+		h.JSON(http.MethodGet, "/api/alert-rules", nil, &list)
+		if len(list.Rules) != 5 || strings.Join(list.Channels, ",") != "smtp,webhook" {
+			t.Errorf("规则列表 = %+v", list)
+		}
+		if got := ruleAlerts(stale); len(got) != 2 {
+			t.Errorf("删除规则后告警记录 = %d 条, want 保留 2 条", len(got))
+		}
+	})
+}
@@ -0,0 +1,169 @@
+package api
+
+import (
+	"benz-sniper/auth"
+	"benz-sniper/database"
+	"benz-sniper/models"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 审计操作类型
+const (
+	AuditConfigUpdate    = "config.update"     // 修改配置
+	AuditStrategyToggle  = "strategy.toggle"   // 启用/停用策略
+	AuditHistoryClear    = "history.clear"     // 清空历史记录
+	AuditUserBetUpload   = "user_bet.upload"   // 上传用户派彩
+	AuditAPIKeyCreate    = "api_key.create"    // 创建 API 密钥
+	AuditAPIKeyRevoke    = "api_key.revoke"    // 吊销 API 密钥
+	AuditUserCreate      = "user.create"       // 创建用户
+	AuditSessionRestore  = "session.restore"   // 恢复归档会话
+	AuditSessionPurge    = "session.purge"     // 彻底删除归档会话
+	AuditConfigRollback  = "config.rollback"   // 回滚配置版本
+	AuditProfileSave     = "profile.save"      // 创建/修改配置方案
+	AuditProfileDelete   = "profile.delete"    // 删除配置方案
+	AuditProfileApply    = "profile.apply"     // 手动启用配置方案
+	AuditUserBetBatch    = "user_bet.batch"    // 批量上传用户派彩
+	AuditReportGenerate  = "report.generate"   // 手动生成定时报表
+	AuditAlertRuleSave   = "alert_rule.save"   // 创建/修改告警规则
+	AuditAlertRuleDelete = "alert_rule.delete" // 删除告警规则
+)
+
+// recordAudit 写入审计日志（before/after 序列化为 JSON，nil 记为空；写入失败只记录日志，不影响操作结果）
+func (h *Handler) recordAudit(c *gin.Context, action string, before, after any) {
+	principal := PrincipalFrom(c)
+	if principal == nil {
+		principal = &auth.Principal{Kind: auth.KindAnonymous, Name: auth.KindAnonymous}
+	}
+
+	now := h.clock.Now()
+	entry := &models.AuditLog{
+		Actor:     principal.Name,
+		ActorKind: principal.Kind,
+		Role:      string(principal.Role),
+		Action:    action,
+		Before:    auditJSON(before),
+		After:     auditJSON(after),
+		SourceIP:  c.ClientIP(),
+		CreatedAt: &now,
+	}
+	if err := h.audit.CreateAudit(entry); err != nil {
+		requestLog(c).Error("❌ 写入审计日志失败", "action", action, "actor", entry.Actor, "error", err)
+	}
+}
+
+// auditJSON 序列化审计数据
+func auditJSON(v any) string {
+	if v == nil {
+		return ""
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// AuditResponse 审计日志响应
+type AuditResponse struct {
+	Records    []models.AuditLog `json:"records"`
+	Total      int64             `json:"total"`       // 总记录数
+	TotalPages int               `json:"total_pages"` // 总页数
+	Page       int               `json:"page"`        // 当前页码
+	PageSize   int               `json:"page_size"`   // 每页大小
+}
+
+// GetAudit 查询审计日志（支持 actor / action / from / to 筛选和分页）
+func (h *Handler) GetAudit(c *gin.Context) {
+	page, pageSize := pageParams(c)
+
+	query := database.AuditQuery{
+		Actor:  c.Query("actor"),
+		Action: c.Query("action"),
+		Offset: (page - 1) * pageSize,
+		Limit:  pageSize,
+	}
+	var ok bool
+	if query.From, query.To, ok = timeRange(c); !ok {
+		return
+	}
+
+	records, total, err := h.audit.ListAudit(query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "查询失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, AuditResponse{
+		Records:    records,
+		Total:      total,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+		Page:       page,
+		PageSize:   pageSize,
+	})
+}
+
+// pageParams 解析分页参数（page 默认 1，page_size 默认 20、上限 100）
+func pageParams(c *gin.Context) (int, int) {
+	page, pageSize := 1, 20
+	if p, err := strconv.Atoi(c.Query("page")); err == nil && p > 0 {
+		page = p
+	}
+	if ps, err := strconv.Atoi(c.Query("page_size")); err == nil && ps > 0 {
+		pageSize = ps
+		if pageSize > 100 {
+			pageSize = 100
+		}
+	}
+	return page, pageSize
+}
+
+// pageBounds 内存分页的切片范围 [start, end)
+func pageBounds(total, page, pageSize int) (int, int) {
+	start := (page - 1) * pageSize
+	if start > total {
+		start = total
+	}
+	end := start + pageSize
+	if end > total {
+		end = total
+	}
+	return start, end
+}
+
+// timeRange 读取 from / to 查询参数（格式错误时写入 400 响应并返回 false）
+func timeRange(c *gin.Context) (from, to time.Time, ok bool) {
+	var err error
+	if from, err = parseTimeParam(c.Query("from")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "from 格式错误: " + err.Error()})
+		return from, to, false
+	}
+	if to, err = parseTimeParam(c.Query("to")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "to 格式错误: " + err.Error()})
+		return from, to, false
+	}
+	return from, to, true
+}
+
+// parseTimeParam 解析时间参数（RFC3339 或 YYYY-MM-DD 营业日，空值返回零值）
+// 日期表示营业日的开始（营业时区的日切时间），统一转为本地时间，与入库时间一致（SQLite 按文本比较时间）
+func parseTimeParam(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.Local(), nil
+	}
+	t, err := database.GetCalendar().DayStart(value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.Local(), nil
+}
@@ -0,0 +1,91 @@
+package api_test
+
+import (
+	"benz-sniper/api"
+	"benz-sniper/engine"
+	"benz-sniper/models"
+	"benz-sniper/testutil"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestAuditLogRecordsActions(t *testing.T) {
+	testutil.EachDriver(t, testAuditLogRecordsActions)
+}
+
+func testAuditLogRecordsActions(t *testing.T, h *testutil.Harness) {
+	h.Draw("红宝马")
+	roundID := h.Draw(h.LosingCar())
+	start := h.Clock.Now()
+
+	h.JSON(http.MethodPost, "/api/config", map[string]any{"entry_condition": 3, "hot3_enabled": false}, &struct{}{})
+	h.Clock.Advance(time.Minute)
+	h.JSON(http.MethodPost, "/api/user-bets", map[string]any{"round_id": roundID, "user_account": "acc-1", "bet_amount": 300}, &struct{}{})
+	h.Clock.Advance(time.Minute)
+	// 未配置可信代理时伪造的 X-Forwarded-For 不影响来源IP
+	forged := http.Header{api.APIKeyHeader: {h.APIKey}, "X-Forwarded-For": {"203.0.113.9"}}
+	if rec := h.DoWith(http.MethodPost, "/api/history/clear", nil, forged); rec.Code != http.StatusOK {
+		t.Fatalf("清空历史 = %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var all api.AuditResponse
+	h.JSON(http.MethodGet, "/api/audit", nil, &all)
+	// 修改配置 + 停用热门3码 + 上传派彩 + 清空历史（另有均衡4码未变化，不应记录）
+	if all.Total != 4 {
+		t.Fatalf("audit total = %d, want 4: %+v", all.Total, all.Records)
+	}
+	if all.Records[0].Action != api.AuditHistoryClear {
+		t.Errorf("最新一条 = %s, want %s", all.Records[0].Action, api.AuditHistoryClear)
+	}
+	for _, r := range all.Records {
+		if r.Actor != "harness" || r.ActorKind != "api_key" || r.Role != "admin" || r.SourceIP != "192.0.2.1" {
+			t.Errorf("审计身份 = %+v", r)
+		}
+	}
+
+	var clear api.AuditResponse
+	h.JSON(http.MethodGet, "/api/audit?action="+api.AuditHistoryClear, nil, &clear)
+	var archived models.HistorySession
+	if clear.Total != 1 || json.Unmarshal([]byte(clear.Records[0].After), &archived) != nil || archived.Bets != 2 {
+		t.Errorf("history.clear 记录 = %+v, want 归档 2 条", clear.Records)
+	}
+
+	var cfg api.AuditResponse
+	h.JSON(http.MethodGet, "/api/audit?action="+api.AuditConfigUpdate, nil, &cfg)
+	var before, after engine.StrategyConfig
+	if cfg.Total != 1 {
+		t.Fatalf("config.update total = %d, want 1", cfg.Total)
+	}
+	json.Unmarshal([]byte(cfg.Records[0].Before), &before)
+	json.Unmarshal([]byte(cfg.Records[0].After), &after)
+	if before.EntryCondition != 2 || after.EntryCondition != 3 || !before.Hot3Enabled || after.Hot3Enabled {
+		t.Errorf("config.update before=%+v after=%+v", before, after)
+	}
+
+	// 时间范围：只包含上传派彩那一分钟
+	var window api.AuditResponse
+	q := url.Values{
+		"from": {start.Add(30 * time.Second).Format(time.RFC3339)},
+		"to":   {start.Add(90 * time.Second).Format(time.RFC3339)},
+	}
+	h.JSON(http.MethodGet, "/api/audit?"+q.Encode(), nil, &window)
+	if window.Total != 1 || window.Records[0].Action != api.AuditUserBetUpload {
+		t.Errorf("时间筛选 = %+v, want 仅 user_bet.upload", window.Records)
+	}
+
+	var byActor api.AuditResponse
+	h.JSON(http.MethodGet, "/api/audit?actor=someone-else", nil, &byActor)
+	if byActor.Total != 0 {
+		t.Errorf("actor 筛选 total = %d, want 0", byActor.Total)
+	}
+
+	if rec := h.Do(http.MethodGet, "/api/audit?from=yesterday", nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("错误时间格式 = %d, want 400", rec.Code)
+	}
+	if rec := h.DoWith(http.MethodGet, "/api/audit", nil, nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("匿名查询审计日志 = %d, want 401", rec.Code)
+	}
+}
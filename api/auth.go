@@ -0,0 +1,331 @@
+package api
+
+import (
+	"benz-sniper/auth"
+	"benz-sniper/database"
+	"benz-sniper/logging"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// SessionCookie 登录会话 Cookie 名称
+	SessionCookie = "benz_session"
+	// APIKeyHeader API 密钥请求头
+	APIKeyHeader = "X-API-Key"
+
+	principalKey = "principal" // gin.Context 中保存调用方身份的键
+)
+
+// PrincipalFrom 当前请求的调用方身份（未认证时为 nil）
+func PrincipalFrom(c *gin.Context) *auth.Principal {
+	if v, ok := c.Get(principalKey); ok {
+		if p, ok := v.(*auth.Principal); ok {
+			return p
+		}
+	}
+	return nil
+}
+
+// authenticate 认证中间件：依次识别 API 密钥、Bearer 令牌和会话 Cookie
+// 携带了凭证但无效时直接返回 401，未携带凭证时使用匿名身份
+func (h *Handler) authenticate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, presented, err := h.resolvePrincipal(c)
+		if err != nil {
+			if !errors.Is(err, auth.ErrInvalidCredentials) {
+				requestLog(c).Error("❌ 认证失败", "error", err)
+			}
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"message": "凭证无效或已过期",
+			})
+			return
+		}
+		if !presented {
+			principal = h.auth.Anonymous()
+		}
+		if principal != nil {
+			c.Set(principalKey, principal)
+			ctx := c.Request.Context()
+			c.Request = c.Request.WithContext(logging.WithLogger(ctx, logging.FromContext(ctx).With("actor", principal.Name)))
+		}
+		c.Next()
+	}
+}
+
+// resolvePrincipal 从请求中解析凭证（presented=false 表示未携带任何凭证）
+func (h *Handler) resolvePrincipal(c *gin.Context) (*auth.Principal, bool, error) {
+	if key := c.GetHeader(APIKeyHeader); key != "" {
+		p, err := h.auth.AuthenticateAPIKey(key)
+		return p, true, err
+	}
+	if token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok && token != "" {
+		// Bearer 既可以是 API 密钥，也可以是登录令牌
+		if strings.HasPrefix(token, auth.APIKeyPrefix) {
+			p, err := h.auth.AuthenticateAPIKey(token)
+			return p, true, err
+		}
+		p, err := h.auth.AuthenticateSession(token)
+		return p, true, err
+	}
+	if token, err := c.Cookie(SessionCookie); err == nil && token != "" {
+		p, err := h.auth.AuthenticateSession(token)
+		return p, true, err
+	}
+	return nil, false, nil
+}
+
+// hasRole 调用方是否具备指定角色（未认证时为 false）
+func hasRole(c *gin.Context, role auth.Role) bool {
+	principal := PrincipalFrom(c)
+	return principal != nil && principal.Role.Allows(role)
+}
+
+// requireRole 要求调用方具备指定角色
+func requireRole(role auth.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal := PrincipalFrom(c)
+		if principal == nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"message": "请先登录",
+			})
+			return
+		}
+		if !principal.Role.Allows(role) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"message": "权限不足，需要 " + string(role) + " 角色",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// LoginRequest 登录请求
+type LoginRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// Login 用户名密码登录（返回令牌，同时写入 HttpOnly Cookie 供页面使用）
+func (h *Handler) Login(c *gin.Context) {
+	var req LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	token, session, user, err := h.auth.Login(req.Username, req.Password)
+	if err != nil {
+		if !errors.Is(err, auth.ErrInvalidCredentials) {
+			requestLog(c).Error("❌ 登录失败", "error", err)
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"message": "用户名或密码错误",
+		})
+		return
+	}
+
+	requestLog(c).Info("🔐 用户登录", "user", user.Username, "role", user.Role)
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(SessionCookie, token, int(h.auth.SessionTTL().Seconds()), "/", "", c.Request.TLS != nil, true)
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"token":      token,
+		"expires_at": session.ExpiresAt,
+		"user":       user,
+	})
+}
+
+// Logout 退出登录
+func (h *Handler) Logout(c *gin.Context) {
+	token, _ := c.Cookie(SessionCookie)
+	if bearer, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok {
+		token = bearer
+	}
+	if token != "" {
+		if err := h.auth.Logout(token); err != nil {
+			requestLog(c).Error("❌ 退出登录失败", "error", err)
+		}
+	}
+	c.SetCookie(SessionCookie, "", -1, "/", "", c.Request.TLS != nil, true)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "已退出登录",
+	})
+}
+
+// Me 当前调用方身份
+func (h *Handler) Me(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"principal": PrincipalFrom(c),
+	})
+}
+
+// CreateAPIKeyRequest 创建 API 密钥请求
+type CreateAPIKeyRequest struct {
+	Name string `json:"name" binding:"required"` // 用途说明
+	Role string `json:"role" binding:"required"` // viewer / operator / admin
+}
+
+// ListAPIKeys 列出 API 密钥（不含明文）
+func (h *Handler) ListAPIKeys(c *gin.Context) {
+	keys, err := h.auth.ListAPIKeys()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "查询失败: " + err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"keys":    keys,
+	})
+}
+
+// CreateAPIKey 创建 API 密钥（明文只在响应中返回一次）
+func (h *Handler) CreateAPIKey(c *gin.Context) {
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+	role, err := auth.ParseRole(req.Role)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "未知角色: " + req.Role,
+		})
+		return
+	}
+
+	plain, key, err := h.auth.CreateAPIKey(req.Name, role, PrincipalFrom(c).Name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "创建失败: " + err.Error(),
+		})
+		return
+	}
+
+	requestLog(c).Info("🔑 创建 API 密钥", "name", key.Name, "role", key.Role)
+	h.recordAudit(c, AuditAPIKeyCreate, nil, key)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"api_key": plain,
+		"key":     key,
+	})
+}
+
+// RevokeAPIKey 吊销 API 密钥
+func (h *Handler) RevokeAPIKey(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "无效的密钥ID",
+		})
+		return
+	}
+
+	if err := h.auth.RevokeAPIKey(uint(id)); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, database.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"success": false,
+			"message": "吊销失败: " + err.Error(),
+		})
+		return
+	}
+
+	requestLog(c).Info("🔑 吊销 API 密钥", "id", id)
+	h.recordAudit(c, AuditAPIKeyRevoke, gin.H{"id": id}, nil)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "API 密钥已吊销",
+	})
+}
+
+// CreateUserRequest 创建用户请求
+type CreateUserRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	Role     string `json:"role" binding:"required"` // viewer / operator / admin
+}
+
+// ListUsers 列出用户
+func (h *Handler) ListUsers(c *gin.Context) {
+	users, err := h.auth.ListUsers()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "查询失败: " + err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"users":   users,
+	})
+}
+
+// CreateUser 创建用户
+func (h *Handler) CreateUser(c *gin.Context) {
+	var req CreateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+	role, err := auth.ParseRole(req.Role)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "未知角色: " + req.Role,
+		})
+		return
+	}
+
+	user, err := h.auth.CreateUser(req.Username, req.Password, role)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, database.ErrDuplicate):
+			status = http.StatusConflict
+		case errors.Is(err, auth.ErrEmptyUsername), errors.Is(err, auth.ErrWeakPassword), errors.Is(err, auth.ErrInvalidRole):
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{
+			"success": false,
+			"message": "创建失败: " + err.Error(),
+		})
+		return
+	}
+
+	requestLog(c).Info("🔐 创建用户", "user", user.Username, "role", user.Role)
+	h.recordAudit(c, AuditUserCreate, nil, user)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"user":    user,
+	})
+}
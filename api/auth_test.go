@@ -0,0 +1,252 @@
+package api_test
+
+import (
+	"benz-sniper/api"
+	"benz-sniper/auth"
+	"benz-sniper/testutil"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// bearer 构造 Bearer 认证请求头
+func bearer(token string) http.Header {
+	return http.Header{"Authorization": {"Bearer " + token}}
+}
+
+func TestAnonymousOnlyReadsDashboard(t *testing.T) {
+	h := testutil.New(t, testutil.Options{})
+
+	for _, path := range []string{"/api/status", "/api/predictions", "/api/history", "/api/report", "/api/config", "/api/next-prediction"} {
+		if rec := h.DoWith(http.MethodGet, path, nil, nil); rec.Code != http.StatusOK {
+			t.Errorf("匿名 GET %s = %d, want 200", path, rec.Code)
+		}
+	}
+	// 默认不给匿名角色：看板之外的接口（账号流水、导出、对账等）都要求登录
+	for _, path := range []string{"/api/accounts", "/api/export/history", "/api/reconciliation", "/api/reports", "/api/alert-rules", "/api/auth/me"} {
+		if rec := h.DoWith(http.MethodGet, path, nil, nil); rec.Code != http.StatusUnauthorized {
+			t.Errorf("匿名 GET %s = %d, want 401", path, rec.Code)
+		}
+	}
+
+	if rec := h.DoWith(http.MethodGet, "/api/status", nil, http.Header{api.APIKeyHeader: {"bzk_invalid"}}); rec.Code != http.StatusUnauthorized {
+		t.Errorf("无效密钥 = %d, want 401", rec.Code)
+	}
+}
+
+func TestAnonymousHistoryHidesUserBets(t *testing.T) {
+	h := testutil.New(t, testutil.Options{})
+	h.Draw("红宝马")
+	roundID := h.Draw(h.LosingCar())
+	if rec := h.Do(http.MethodPost, "/api/user-bets", map[string]any{
+		"round_id":      roundID,
+		"user_account":  "acc-1",
+		"bet_amount":    300,
+		"payout_amount": 0,
+		"balance":       9700,
+	}); rec.Code != http.StatusOK {
+		t.Fatalf("上传派彩 = %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// 匿名调用方看不到账号、金额和余额
+	rec := h.DoWith(http.MethodGet, "/api/history", nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("匿名 GET /api/history = %d, want 200", rec.Code)
+	}
+	var anon struct {
+		Records []map[string]json.RawMessage `json:"records"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &anon); err != nil {
+		t.Fatal(err)
+	}
+	if len(anon.Records) == 0 {
+		t.Fatal("匿名历史记录为空")
+	}
+	for _, r := range anon.Records {
+		if _, ok := r["user_bets"]; ok {
+			t.Errorf("匿名历史记录包含 user_bets: %s", r["user_bets"])
+		}
+	}
+	if rec := h.DoWith(http.MethodGet, "/api/history?session_id=1", nil, nil); rec.Code != http.StatusForbidden {
+		t.Errorf("匿名 GET /api/history?session_id=1 = %d, want 403", rec.Code)
+	}
+
+	// 已认证的调用方仍能看到派彩记录
+	var page api.HistoryResponse
+	h.JSON(http.MethodGet, "/api/history", nil, &page)
+	found := false
+	for _, r := range page.Records {
+		if r.RoundID == roundID && len(r.UserBets) == 1 {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("登录后 %s 的 user_bets 缺失", roundID)
+	}
+}
+
+func TestAnonymousViewerIsReadOnly(t *testing.T) {
+	h := testutil.New(t, testutil.Options{AnonymousRole: auth.RoleViewer})
+
+	if rec := h.DoWith(http.MethodGet, "/api/accounts", nil, nil); rec.Code != http.StatusOK {
+		t.Errorf("匿名 viewer GET /api/accounts = %d, want 200", rec.Code)
+	}
+	writes := []struct{ method, path string }{
+		{http.MethodPost, "/api/config"},
+		{http.MethodPost, "/api/history/clear"},
+		{http.MethodPost, "/api/user-bets"},
+		{http.MethodGet, "/api/auth/keys"},
+	}
+	for _, w := range writes {
+		if rec := h.DoWith(w.method, w.path, map[string]any{}, nil); rec.Code != http.StatusForbidden {
+			t.Errorf("匿名 %s %s = %d, want 403", w.method, w.path, rec.Code)
+		}
+	}
+}
+
+func TestLoginSessionLifecycle(t *testing.T) {
+	h := testutil.New(t, testutil.Options{})
+	if _, err := h.Auth.CreateUser("alice", "secret-pass", auth.RoleAdmin); err != nil {
+		t.Fatal(err)
+	}
+
+	if rec := h.DoWith(http.MethodPost, "/api/auth/login", map[string]any{"username": "alice", "password": "wrong-pass"}, nil); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("错误密码登录 = %d, want 401", rec.Code)
+	}
+
+	rec := h.DoWith(http.MethodPost, "/api/auth/login", map[string]any{"username": "alice", "password": "secret-pass"}, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("登录 = %d: %s", rec.Code, rec.Body.String())
+	}
+	var cookie *http.Cookie
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == api.SessionCookie {
+			cookie = c
+		}
+	}
+	if cookie == nil || !cookie.HttpOnly || cookie.Value == "" {
+		t.Fatalf("登录 Cookie = %+v, want HttpOnly 会话", cookie)
+	}
+
+	// Cookie 和 Bearer 都能以管理员身份修改配置
+	header := http.Header{"Cookie": {cookie.Name + "=" + cookie.Value}}
+	if rec := h.DoWith(http.MethodPost, "/api/config", map[string]any{"entry_condition": 3}, header); rec.Code != http.StatusOK {
+		t.Errorf("会话 Cookie 修改配置 = %d, want 200", rec.Code)
+	}
+	var me struct {
+		Principal auth.Principal `json:"principal"`
+	}
+	rec = h.DoWith(http.MethodGet, "/api/auth/me", nil, bearer(cookie.Value))
+	if err := json.Unmarshal(rec.Body.Bytes(), &me); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("GET /api/auth/me = %d: %s", rec.Code, rec.Body.String())
+	}
+	if me.Principal.Kind != auth.KindUser || me.Principal.Name != "alice" || me.Principal.Role != auth.RoleAdmin {
+		t.Errorf("Bearer 身份 = %+v, want alice/admin", me.Principal)
+	}
+
+	// 会话过期后返回 401
+	h.Clock.Advance(h.Auth.SessionTTL() + time.Second)
+	if rec := h.DoWith(http.MethodGet, "/api/status", nil, bearer(cookie.Value)); rec.Code != http.StatusUnauthorized {
+		t.Errorf("过期会话 = %d, want 401", rec.Code)
+	}
+}
+
+func TestLogoutRevokesSession(t *testing.T) {
+	h := testutil.New(t, testutil.Options{})
+	if _, err := h.Auth.CreateUser("bob", "secret-pass", auth.RoleViewer); err != nil {
+		t.Fatal(err)
+	}
+	token, _, _, err := h.Auth.Login("bob", "secret-pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rec := h.DoWith(http.MethodPost, "/api/config", map[string]any{}, bearer(token)); rec.Code != http.StatusForbidden {
+		t.Errorf("viewer 修改配置 = %d, want 403", rec.Code)
+	}
+	if rec := h.DoWith(http.MethodPost, "/api/auth/logout", nil, bearer(token)); rec.Code != http.StatusOK {
+		t.Fatalf("退出登录 = %d", rec.Code)
+	}
+	if rec := h.DoWith(http.MethodGet, "/api/status", nil, bearer(token)); rec.Code != http.StatusUnauthorized {
+		t.Errorf("退出后 = %d, want 401", rec.Code)
+	}
+}
+
+func TestAPIKeyManagement(t *testing.T) {
+	testutil.EachDriver(t, testAPIKeyManagement)
+}
+
+func testAPIKeyManagement(t *testing.T, h *testutil.Harness) {
+	h.Draw("红宝马")
+	roundID := h.Draw(h.LosingCar())
+
+	var created struct {
+		Success bool   `json:"success"`
+		APIKey  string `json:"api_key"`
+		Key     struct {
+			ID uint `json:"id"`
+		} `json:"key"`
+	}
+	h.JSON(http.MethodPost, "/api/auth/keys", map[string]any{"name": "bet-client", "role": "operator"}, &created)
+	if !created.Success || created.APIKey == "" {
+		t.Fatalf("创建密钥 = %+v", created)
+	}
+	if rec := h.Do(http.MethodPost, "/api/auth/keys", map[string]any{"name": "x", "role": "root"}); rec.Code != http.StatusBadRequest {
+		t.Errorf("未知角色 = %d, want 400", rec.Code)
+	}
+
+	operator := http.Header{api.APIKeyHeader: {created.APIKey}}
+	bet := map[string]any{"round_id": roundID, "user_account": "acc-1", "bet_amount": 300}
+	if rec := h.DoWith(http.MethodPost, "/api/user-bets", bet, operator); rec.Code != http.StatusOK {
+		t.Errorf("operator 上传派彩 = %d, want 200", rec.Code)
+	}
+	if rec := h.DoWith(http.MethodPost, "/api/history/clear", nil, operator); rec.Code != http.StatusForbidden {
+		t.Errorf("operator 清空历史 = %d, want 403", rec.Code)
+	}
+
+	// 列表不返回明文，吊销后立即失效
+	rec := h.Do(http.MethodGet, "/api/auth/keys", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("列出密钥 = %d", rec.Code)
+	}
+	if body := rec.Body.String(); len(body) == 0 || strings.Contains(body, created.APIKey) {
+		t.Error("密钥列表泄露了明文")
+	}
+	path := "/api/auth/keys/" + strconv.FormatUint(uint64(created.Key.ID), 10)
+	if rec := h.Do(http.MethodDelete, path, nil); rec.Code != http.StatusOK {
+		t.Fatalf("吊销密钥 = %d", rec.Code)
+	}
+	if rec := h.DoWith(http.MethodPost, "/api/user-bets", bet, operator); rec.Code != http.StatusUnauthorized {
+		t.Errorf("吊销后 = %d, want 401", rec.Code)
+	}
+	if rec := h.Do(http.MethodDelete, "/api/auth/keys/9999", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("吊销不存在的密钥 = %d, want 404", rec.Code)
+	}
+}
+
+func TestBootstrapAdminIsIdempotent(t *testing.T) {
+	h := testutil.New(t, testutil.Options{})
+	for i := 0; i < 2; i++ {
+		if err := h.Auth.Bootstrap("admin", "bootstrap-pass", "bzk_from_env"); err != nil {
+			t.Fatalf("第 %d 次 Bootstrap() = %v", i+1, err)
+		}
+	}
+
+	users, _ := h.Auth.ListUsers()
+	if len(users) != 1 || users[0].Username != "admin" || users[0].Role != string(auth.RoleAdmin) {
+		t.Errorf("users = %+v, want 单个 admin", users)
+	}
+	header := http.Header{api.APIKeyHeader: {"bzk_from_env"}}
+	if rec := h.DoWith(http.MethodPost, "/api/history/clear", nil, header); rec.Code != http.StatusOK {
+		t.Errorf("环境变量密钥清空历史 = %d, want 200", rec.Code)
+	}
+	if rec := h.Do(http.MethodPost, "/api/auth/users", map[string]any{"username": "admin", "password": "another-pass", "role": "viewer"}); rec.Code != http.StatusConflict {
+		t.Errorf("重复用户名 = %d, want 409", rec.Code)
+	}
+	if rec := h.Do(http.MethodPost, "/api/auth/users", map[string]any{"username": "carol", "password": "short", "role": "viewer"}); rec.Code != http.StatusBadRequest {
+		t.Errorf("弱密码 = %d, want 400", rec.Code)
+	}
+}
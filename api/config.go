@@ -0,0 +1,228 @@
+package api
+
+import (
+	"benz-sniper/database"
+	"benz-sniper/engine"
+	"benz-sniper/models"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConfigVersionsResponse 配置版本分页响应
+type ConfigVersionsResponse struct {
+	Versions   []models.ConfigVersion `json:"versions"`
+	Total      int64                  `json:"total"`       // 总版本数
+	TotalPages int                    `json:"total_pages"` // 总页数
+	Page       int                    `json:"page"`        // 当前页码
+	PageSize   int                    `json:"page_size"`   // 每页大小
+}
+
+// ConfigCommentRequest 回滚/启用方案的可选说明
+type ConfigCommentRequest struct {
+	Comment string `json:"comment"`
+}
+
+// ProfileRequest 创建或修改配置方案请求（名称取自路径，数值范围与 POST /api/config 相同）
+type ProfileRequest struct {
+	EntryCondition     int     `json:"entry_condition" binding:"required"`      // 连赢几把进场
+	ExitCondition      int     `json:"exit_condition" binding:"required"`       // 连输几把离场
+	Hot3BetAmount      float64 `json:"hot3_bet_amount" binding:"required"`      // 热门3码下注金额
+	Balanced4BetAmount float64 `json:"balanced4_bet_amount" binding:"required"` // 均衡4码下注金额
+	Hot3Enabled        *bool   `json:"hot3_enabled"`                            // 热门3码启用（默认启用）
+	Balanced4Enabled   *bool   `json:"balanced4_enabled"`                       // 均衡4码启用（默认启用）
+	ScheduleStart      string  `json:"schedule_start"`                          // 每日启用时刻 HH:MM
+	ScheduleEnd        string  `json:"schedule_end"`                            // 每日结束时刻 HH:MM
+}
+
+// bindComment 读取可选的 comment 请求体（无请求体时返回空说明）
+func bindComment(c *gin.Context) (string, bool) {
+	var req ConfigCommentRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "请求参数错误: " + err.Error(),
+			})
+			return "", false
+		}
+	}
+	return req.Comment, true
+}
+
+// configChange 以当前调用方构造配置变更说明
+func configChange(c *gin.Context, comment string) engine.ConfigChange {
+	change := engine.ConfigChange{Comment: comment}
+	if principal := PrincipalFrom(c); principal != nil {
+		change.CreatedBy = principal.Name
+	}
+	return change
+}
+
+// configError 配置相关错误响应（校验失败返回 400 和字段级错误，不存在返回 404）
+func configError(c *gin.Context, err error) {
+	var invalid *engine.ValidationError
+	if errors.As(err, &invalid) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "配置校验失败",
+			"errors":  invalid.Errors,
+		})
+		return
+	}
+
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, database.ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, engine.ErrEmptyProfileName), errors.Is(err, engine.ErrInvalidSchedule):
+		status = http.StatusBadRequest
+	}
+	c.JSON(status, gin.H{
+		"success": false,
+		"message": err.Error(),
+	})
+}
+
+// ListConfigVersions 配置版本列表（按版本号降序分页）
+func (h *Handler) ListConfigVersions(c *gin.Context) {
+	page, pageSize := pageParams(c)
+	versions, total, err := h.manager.ListConfigVersions((page-1)*pageSize, pageSize)
+	if err != nil {
+		configError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, ConfigVersionsResponse{
+		Versions:   versions,
+		Total:      total,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+		Page:       page,
+		PageSize:   pageSize,
+	})
+}
+
+// RollbackConfig 回滚到指定版本（生成一个新版本，版本历史不会被改写）
+func (h *Handler) RollbackConfig(c *gin.Context) {
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "无效的版本号",
+		})
+		return
+	}
+	comment, ok := bindComment(c)
+	if !ok {
+		return
+	}
+
+	before := h.manager.GetConfig()
+	config, created, err := h.manager.RollbackConfig(version, configChange(c, comment))
+	if err != nil {
+		configError(c, err)
+		return
+	}
+	if created != nil {
+		h.recordAudit(c, AuditConfigRollback, before, created)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "配置已回滚到版本 " + strconv.Itoa(version),
+		"config":  config,
+		"version": created,
+	})
+}
+
+// ListProfiles 配置方案列表（active 为当前生效的方案）
+func (h *Handler) ListProfiles(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"profiles": h.manager.ListProfiles(),
+		"active":   h.manager.ActiveProfile(),
+	})
+}
+
+// SaveProfile 创建或修改配置方案
+func (h *Handler) SaveProfile(c *gin.Context) {
+	var req ProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	profile := &models.ConfigProfile{
+		Name:               c.Param("name"),
+		EntryCondition:     req.EntryCondition,
+		ExitCondition:      req.ExitCondition,
+		Hot3BetAmount:      req.Hot3BetAmount,
+		Balanced4BetAmount: req.Balanced4BetAmount,
+		Hot3Enabled:        req.Hot3Enabled == nil || *req.Hot3Enabled,
+		Balanced4Enabled:   req.Balanced4Enabled == nil || *req.Balanced4Enabled,
+		ScheduleStart:      req.ScheduleStart,
+		ScheduleEnd:        req.ScheduleEnd,
+	}
+	// 覆盖已有方案时记录修改前的内容
+	var before any
+	for _, p := range h.manager.ListProfiles() {
+		if p.Name == profile.Name {
+			before = p
+			break
+		}
+	}
+	if err := h.manager.SaveProfile(profile); err != nil {
+		configError(c, err)
+		return
+	}
+	h.recordAudit(c, AuditProfileSave, before, profile)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"profile": profile,
+	})
+}
+
+// DeleteProfile 删除配置方案
+func (h *Handler) DeleteProfile(c *gin.Context) {
+	name := c.Param("name")
+	if err := h.manager.DeleteProfile(name); err != nil {
+		configError(c, err)
+		return
+	}
+	h.recordAudit(c, AuditProfileDelete, gin.H{"name": name}, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "配置方案已删除",
+	})
+}
+
+// ActivateProfile 手动启用配置方案
+func (h *Handler) ActivateProfile(c *gin.Context) {
+	comment, ok := bindComment(c)
+	if !ok {
+		return
+	}
+
+	before := h.manager.GetConfig()
+	config, created, err := h.manager.ActivateProfile(c.Param("name"), configChange(c, comment))
+	if err != nil {
+		configError(c, err)
+		return
+	}
+	if created != nil {
+		h.recordAudit(c, AuditProfileApply, before, created)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "已启用方案 " + c.Param("name"),
+		"config":  config,
+		"version": created,
+	})
+}
@@ -0,0 +1,188 @@
+package api_test
+
+import (
+	"benz-sniper/api"
+	"benz-sniper/engine"
+	"benz-sniper/models"
+	"benz-sniper/testutil"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestConfigVersionsAndRollback(t *testing.T) {
+	testutil.EachDriver(t, testConfigVersionsAndRollback)
+}
+
+func testConfigVersionsAndRollback(t *testing.T, h *testutil.Harness) {
+	initial := h.Manager.GetConfig()
+	h.JSON(http.MethodPost, "/api/config", map[string]any{"entry_condition": 4, "comment": "更保守"}, &struct{}{})
+	h.JSON(http.MethodPost, "/api/config", map[string]any{"hot3_bet_amount": 300}, &struct{}{})
+	// 未变化的提交不产生新版本
+	h.JSON(http.MethodPost, "/api/config", map[string]any{"hot3_bet_amount": 300}, &struct{}{})
+
+	var versions api.ConfigVersionsResponse
+	h.JSON(http.MethodGet, "/api/config/versions", nil, &versions)
+	if versions.Total != 3 {
+		t.Fatalf("versions total = %d, want 3: %+v", versions.Total, versions.Versions)
+	}
+	latest, second, first := versions.Versions[0], versions.Versions[1], versions.Versions[2]
+	if first.Version != 1 || first.Source != engine.ConfigSourceInitial || first.EntryCondition != initial.EntryCondition {
+		t.Errorf("版本1 = %+v, want 初始配置", first)
+	}
+	if second.Comment != "更保守" || second.CreatedBy != "harness" || second.Source != engine.ConfigSourceAPI {
+		t.Errorf("版本2 = %+v", second)
+	}
+	if latest.Version != 3 || latest.Hot3BetAmount != 300 || latest.EntryCondition != 4 {
+		t.Errorf("版本3 = %+v", latest)
+	}
+
+	var rolled struct {
+		Config  engine.StrategyConfig `json:"config"`
+		Version struct {
+			Version int    `json:"version"`
+			Source  string `json:"source"`
+		} `json:"version"`
+	}
+	h.JSON(http.MethodPost, "/api/config/rollback/1", nil, &rolled)
+	if rolled.Config != initial || h.Manager.GetConfig() != initial {
+		t.Errorf("回滚后配置 = %+v, want %+v", rolled.Config, initial)
+	}
+	if rolled.Version.Version != 4 || rolled.Version.Source != engine.ConfigSourceRollback {
+		t.Errorf("回滚版本 = %+v, want 版本4 (rollback)", rolled.Version)
+	}
+
+	if rec := h.Do(http.MethodPost, "/api/config/rollback/99", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("回滚不存在的版本 = %d, want 404", rec.Code)
+	}
+	if rec := h.DoWith(http.MethodPost, "/api/config/rollback/2", nil, nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("匿名回滚 = %d, want 401", rec.Code)
+	}
+}
+
+func TestConfigProfiles(t *testing.T) {
+	testutil.EachDriver(t, testConfigProfiles)
+}
+
+func testConfigProfiles(t *testing.T, h *testutil.Harness) {
+	// 夜间方案跨零点；harness 时钟从 12:00 开始
+	h.JSON(http.MethodPut, "/api/config/profiles/conservative", map[string]any{
+		"entry_condition": 4, "exit_condition": 1, "hot3_bet_amount": 50, "balanced4_bet_amount": 50,
+		"balanced4_enabled": false, "schedule_start": "22:00", "schedule_end": "06:00",
+	}, &struct{}{})
+	h.JSON(http.MethodPut, "/api/config/profiles/aggressive", map[string]any{
+		"entry_condition": 1, "exit_condition": 2, "hot3_bet_amount": 500, "balanced4_bet_amount": 400,
+	}, &struct{}{})
+	if rec := h.Do(http.MethodPut, "/api/config/profiles/bad", map[string]any{
+		"entry_condition": 1, "exit_condition": 1, "hot3_bet_amount": 10, "balanced4_bet_amount": 10,
+		"schedule_start": "25:00", "schedule_end": "06:00",
+	}); rec.Code != http.StatusBadRequest {
+		t.Errorf("错误时段 = %d, want 400", rec.Code)
+	}
+
+	// 手动启用
+	h.JSON(http.MethodPost, "/api/config/profiles/aggressive/activate", nil, &struct{}{})
+	if cfg := h.Manager.GetConfig(); cfg.EntryCondition != 1 || cfg.Hot3BetAmount != 500 || !cfg.Balanced4Enabled {
+		t.Errorf("启用 aggressive 后配置 = %+v", cfg)
+	}
+	var list struct {
+		Active   string `json:"active"`
+		Profiles []struct {
+			Name string `json:"name"`
+		} `json:"profiles"`
+	}
+	h.JSON(http.MethodGet, "/api/config/profiles", nil, &list)
+	if list.Active != "aggressive" || len(list.Profiles) != 2 || list.Profiles[0].Name != "aggressive" {
+		t.Errorf("方案列表 = %+v", list)
+	}
+
+	// 重复启用当前方案不产生新版本，也不记录审计
+	h.JSON(http.MethodPost, "/api/config/profiles/aggressive/activate", nil, &struct{}{})
+	var applied api.AuditResponse
+	h.JSON(http.MethodGet, "/api/audit?action="+api.AuditProfileApply, nil, &applied)
+	if applied.Total != 1 || applied.Records[0].After == "null" {
+		t.Errorf("profile.apply 记录 = %+v, want 1 条", applied.Records)
+	}
+
+	// 覆盖已有方案时审计记录修改前的内容
+	h.JSON(http.MethodPut, "/api/config/profiles/aggressive", map[string]any{
+		"entry_condition": 1, "exit_condition": 3, "hot3_bet_amount": 500, "balanced4_bet_amount": 400,
+	}, &struct{}{})
+	var saved api.AuditResponse
+	h.JSON(http.MethodGet, "/api/audit?action="+api.AuditProfileSave, nil, &saved)
+	var previous models.ConfigProfile
+	if saved.Total != 3 || json.Unmarshal([]byte(saved.Records[0].Before), &previous) != nil || previous.ExitCondition != 2 {
+		t.Errorf("profile.save 记录 = %+v, want 覆盖前 exit_condition=2", saved.Records)
+	}
+
+	// 时段外引擎不切换，进入时段后自动启用
+	h.Engine.Tick()
+	if h.Manager.ActiveProfile() != "aggressive" {
+		t.Errorf("时段外方案 = %q, want aggressive", h.Manager.ActiveProfile())
+	}
+	h.Clock.Set(time.Date(2026, 1, 1, 22, 30, 0, 0, time.Local))
+	h.Engine.Tick()
+	cfg := h.Manager.GetConfig()
+	if h.Manager.ActiveProfile() != "conservative" || cfg.EntryCondition != 4 || cfg.Balanced4Enabled {
+		t.Errorf("22:30 配置 = %+v (方案 %q), want conservative", cfg, h.Manager.ActiveProfile())
+	}
+
+	// 时段内手动修改不会被下一次轮询覆盖
+	h.JSON(http.MethodPost, "/api/config", map[string]any{"entry_condition": 3, "balanced4_enabled": false}, &struct{}{})
+	h.Clock.Advance(time.Hour)
+	h.Engine.Tick()
+	if cfg := h.Manager.GetConfig(); cfg.EntryCondition != 3 || h.Manager.ActiveProfile() != "" {
+		t.Errorf("手动修改后配置 = %+v (方案 %q)", cfg, h.Manager.ActiveProfile())
+	}
+
+	var versions api.ConfigVersionsResponse
+	h.JSON(http.MethodGet, "/api/config/versions?page_size=2", nil, &versions)
+	if versions.Total != 4 || versions.Versions[1].Source != engine.ConfigSourceSchedule || versions.Versions[1].Profile != "conservative" {
+		t.Errorf("版本 = %+v, want 初始/方案/定时/手动 共 4 个", versions.Versions)
+	}
+
+	h.JSON(http.MethodDelete, "/api/config/profiles/conservative", nil, &struct{}{})
+	if rec := h.Do(http.MethodPost, "/api/config/profiles/conservative/activate", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("启用已删除的方案 = %d, want 404", rec.Code)
+	}
+}
+
+func TestUpdateConfigValidation(t *testing.T) {
+	h := testutil.New(t, testutil.Options{})
+	before := h.Manager.GetConfig()
+
+	rec := h.Do(http.MethodPost, "/api/config", map[string]any{"entry_condition": 0, "hot3_bet_amount": 55, "balanced4_enabled": false})
+	var invalid struct {
+		Success bool                `json:"success"`
+		Errors  []engine.FieldError `json:"errors"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &invalid); err != nil || rec.Code != http.StatusBadRequest {
+		t.Fatalf("非法配置 = %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(invalid.Errors) != 2 || invalid.Errors[0].Field != "entry_condition" || invalid.Errors[1].Field != "hot3_bet_amount" {
+		t.Errorf("字段错误 = %+v", invalid.Errors)
+	}
+	if h.Manager.GetConfig() != before {
+		t.Errorf("校验失败后配置被修改: %+v", h.Manager.GetConfig())
+	}
+
+	// 预览：返回生效配置和差异，但不保存、不产生版本
+	var preview struct {
+		DryRun  bool                  `json:"dry_run"`
+		Config  engine.StrategyConfig `json:"config"`
+		Changes []engine.FieldChange  `json:"changes"`
+	}
+	h.JSON(http.MethodPost, "/api/config?dry_run=true", map[string]any{"exit_condition": 3, "hot3_enabled": false}, &preview)
+	if !preview.DryRun || preview.Config.ExitCondition != 3 || preview.Config.Hot3Enabled || len(preview.Changes) != 2 {
+		t.Errorf("预览 = %+v", preview)
+	}
+	if h.Manager.GetConfig() != before {
+		t.Errorf("预览后配置被修改: %+v", h.Manager.GetConfig())
+	}
+	var versions api.ConfigVersionsResponse
+	h.JSON(http.MethodGet, "/api/config/versions", nil, &versions)
+	if versions.Total != 1 {
+		t.Errorf("预览后版本数 = %d, want 1", versions.Total)
+	}
+}
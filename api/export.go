@@ -0,0 +1,280 @@
+package api
+
+import (
+	"benz-sniper/database"
+	"benz-sniper/engine"
+	"benz-sniper/export"
+	"benz-sniper/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 导出数据集
+const (
+	ExportHistory  = "history"   // 策略下注历史
+	ExportReport   = "report"    // 财务报表（section=summary/daily/strategies）
+	ExportUserBets = "user-bets" // 用户派彩记录
+	ExportRounds   = "rounds"    // 开奖数据（获胜项合并为一列）
+	ExportWinners  = "winners"   // 获胜项（每个获胜车型一行）
+)
+
+// ExportWriteTimeout 导出响应的写超时（覆盖服务器默认的 WriteTimeout，大数据量流式导出需要更长时间）
+const ExportWriteTimeout = 10 * time.Minute
+
+// exportSink 数据集写入目标：逐行写入，每批结束后 Flush 推送给客户端
+type exportSink struct {
+	writer export.Writer
+	flush  func() error
+}
+
+func (s *exportSink) Row(values ...any) error {
+	return s.writer.WriteRow(values...)
+}
+
+func (s *exportSink) Flush() error {
+	return s.flush()
+}
+
+// exportSource 一个可导出的数据集
+type exportSource struct {
+	name    string                       // 文件名前缀和工作表名称
+	columns []string                     // 列名
+	rows    func(sink *exportSink) error // 分批写入数据行
+}
+
+// Export 导出数据：GET /api/export/:dataset?format=csv|xlsx|ndjson&from=&to=
+//
+// 数据分批从存储读取并直接写入响应；参数错误返回 400，开始输出后出错只能中断响应。
+func (h *Handler) Export(c *gin.Context) {
+	format, err := export.ParseFormat(c.Query("format"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	from, to, ok := timeRange(c)
+	if !ok {
+		return
+	}
+	source, err := h.exportSource(c, from, to)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("%s-%s.%s", source.name, h.clock.Now().Format("20060102-150405"), format)
+	c.Header("Content-Type", format.ContentType())
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Header("Cache-Control", "no-cache")
+	c.Status(http.StatusOK)
+
+	// 写超时按实际时间计算，不使用策略时钟
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Now().Add(ExportWriteTimeout)); err != nil &&
+		!errors.Is(err, http.ErrNotSupported) {
+		requestLog(c).Warn("⚠️ 延长导出写超时失败", "error", err)
+	}
+
+	writer, err := export.NewWriter(format, c.Writer, source.name)
+	if err == nil {
+		err = writer.WriteHeader(source.columns)
+	}
+	if err == nil {
+		err = source.rows(&exportSink{writer: writer, flush: func() error {
+			if err := writer.Flush(); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+			return nil
+		}})
+	}
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		requestLog(c).Error("❌ 导出失败", "dataset", source.name, "format", format, "error", err)
+		c.Abort()
+		return
+	}
+	requestLog(c).Info("📤 导出完成", "dataset", source.name, "format", format)
+}
+
+// exportSource 按路径参数选择数据集
+func (h *Handler) exportSource(c *gin.Context, from, to time.Time) (*exportSource, error) {
+	switch c.Param("dataset") {
+	case ExportHistory:
+		filter, err := historyFilterParams(c)
+		if err != nil {
+			return nil, err
+		}
+		return h.historyExport(filter), nil
+	case ExportReport:
+		return h.reportExport(c.DefaultQuery("section", "daily"), from, to)
+	case ExportUserBets:
+		return h.userBetExport(database.UserBetQuery{Account: c.Query("account"), From: from, To: to}), nil
+	case ExportRounds:
+		return h.roundExport(database.RoundQuery{From: from, To: to}), nil
+	case ExportWinners:
+		return h.winnerExport(database.RoundQuery{From: from, To: to}), nil
+	}
+	return nil, fmt.Errorf("未知的导出数据集: %s（可选 %s / %s / %s / %s / %s）", c.Param("dataset"),
+		ExportHistory, ExportReport, ExportUserBets, ExportRounds, ExportWinners)
+}
+
+func (h *Handler) historyExport(filter database.HistoryFilter) *exportSource {
+	return &exportSource{
+		name: "history",
+		columns: []string{"id", "round_id", "strategy", "status", "predictions", "winners", "special_reward",
+			"result", "bet_amount", "profit", "virtual_profit", "total_profit", "session_id", "created_at"},
+		rows: func(sink *exportSink) error {
+			return h.manager.EachHistory(filter, func(records []models.StrategyHistory) error {
+				for _, r := range records {
+					status := "虚盘"
+					if r.Status == engine.StatusReal {
+						status = "实盘"
+					}
+					if err := sink.Row(r.ID, r.RoundID, r.Strategy, status, jsonList(r.Predictions), jsonList(r.Winners),
+						r.SpecialReward, r.Result, r.BetAmount, r.Profit, r.VirtualProfit, r.TotalProfit, r.SessionID, r.CreatedAt); err != nil {
+						return err
+					}
+				}
+				return sink.Flush()
+			})
+		},
+	}
+}
+
+func (h *Handler) reportExport(section string, from, to time.Time) (*exportSource, error) {
+	report := h.manager.GetRangeReport(from, to)
+	switch section {
+	case "summary":
+		s := report.Summary
+		return &exportSource{
+			name:    "report-summary",
+			columns: []string{"total_bets", "total_wins", "win_rate", "total_profit", "virtual_profit"},
+			rows: func(sink *exportSink) error {
+				return sink.Row(s.TotalBets, s.TotalWins, roundRate(s.WinRate), s.TotalProfit, s.VirtualProfit)
+			},
+		}, nil
+	case "daily":
+		return &exportSource{
+			name:    "report-daily",
+			columns: []string{"date", "bets", "wins", "win_rate", "profit", "virtual_profit"},
+			rows: func(sink *exportSink) error {
+				for _, d := range report.Daily {
+					if err := sink.Row(d.Date, d.TotalBets, d.TotalWins, roundRate(d.WinRate), d.TotalProfit, d.VirtualProfit); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		}, nil
+	case "strategies":
+		strategies := report.Strategies
+		sort.Slice(strategies, func(i, j int) bool { return strategies[i].Name < strategies[j].Name })
+		return &exportSource{
+			name:    "report-strategies",
+			columns: []string{"name", "total_bets", "total_wins", "win_rate", "total_profit", "virtual_profit", "status", "current_predictions"},
+			rows: func(sink *exportSink) error {
+				for _, s := range strategies {
+					if err := sink.Row(s.Name, s.TotalBets, s.TotalWins, roundRate(s.WinRate), s.TotalProfit, s.VirtualProfit,
+						s.StatusText, strings.Join(s.CurrentPredictions, ",")); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		}, nil
+	}
+	return nil, fmt.Errorf("未知的报表部分: %s（可选 summary / daily / strategies）", section)
+}
+
+func (h *Handler) userBetExport(query database.UserBetQuery) *exportSource {
+	return &exportSource{
+		name: "user-bets",
+		columns: []string{"id", "round_id", "user_account", "bet_amount", "payout_amount", "balance", "items",
+			"idempotency_key", "created_at", "updated_at"},
+		rows: func(sink *exportSink) error {
+			return h.manager.EachUserBet(query, func(bets []models.UserBet) error {
+				for _, b := range bets {
+					items := make([]string, 0, len(b.Items))
+					for _, item := range b.Items {
+						items = append(items, item.Car+":"+strconv.FormatFloat(item.Stake, 'f', -1, 64))
+					}
+					key := ""
+					if b.IdempotencyKey != nil {
+						key = *b.IdempotencyKey
+					}
+					if err := sink.Row(b.ID, b.RoundID, b.UserAccount, b.BetAmount, b.PayoutAmount, b.Balance,
+						strings.Join(items, ","), key, b.CreatedAt, b.UpdatedAt); err != nil {
+						return err
+					}
+				}
+				return sink.Flush()
+			})
+		},
+	}
+}
+
+func (h *Handler) roundExport(query database.RoundQuery) *exportSource {
+	return &exportSource{
+		name: "rounds",
+		columns: []string{"round_id", "timestamp", "result_type", "result_name", "winners", "total_input",
+			"total_output", "house_net", "created_at"},
+		rows: func(sink *exportSink) error {
+			return h.manager.EachRound(query, func(rounds []models.GameRound, winners map[string][]models.GameWinner) error {
+				for _, r := range rounds {
+					names := make([]string, 0, len(winners[r.RoundID]))
+					for _, w := range winners[r.RoundID] {
+						names = append(names, w.WinnerName)
+					}
+					if err := sink.Row(r.RoundID, r.Timestamp, r.ResultType, r.ResultName, strings.Join(names, ","),
+						r.TotalInput, r.TotalOutput, r.HouseNet, r.CreatedAt); err != nil {
+						return err
+					}
+				}
+				return sink.Flush()
+			})
+		},
+	}
+}
+
+func (h *Handler) winnerExport(query database.RoundQuery) *exportSource {
+	return &exportSource{
+		name:    "winners",
+		columns: []string{"round_id", "position", "winner_id", "winner_name", "created_at"},
+		rows: func(sink *exportSink) error {
+			return h.manager.EachRound(query, func(rounds []models.GameRound, winners map[string][]models.GameWinner) error {
+				for _, r := range rounds {
+					for _, w := range winners[r.RoundID] {
+						if err := sink.Row(w.RoundID, w.Position, w.WinnerID, w.WinnerName, w.CreatedAt); err != nil {
+							return err
+						}
+					}
+				}
+				return sink.Flush()
+			})
+		},
+	}
+}
+
+// jsonList 把 JSON 字符串数组转为逗号分隔文本（无法解析时原样返回）
+func jsonList(value string) string {
+	var items []string
+	if err := json.Unmarshal([]byte(value), &items); err != nil {
+		return value
+	}
+	return strings.Join(items, ",")
+}
+
+// roundRate 命中率保留两位小数
+func roundRate(rate float64) float64 {
+	return math.Round(rate*100) / 100
+}
@@ -0,0 +1,121 @@
+package api_test
+
+import (
+	"benz-sniper/api"
+	"benz-sniper/testutil"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestExport(t *testing.T) {
+	testutil.EachDriver(t, testExport)
+}
+
+// exportCSV 导出 CSV 并解析为行（去掉 BOM 和表头）
+func exportCSV(t *testing.T, h *testutil.Harness, path string) [][]string {
+	t.Helper()
+	rec := h.Do(http.MethodGet, path, nil)
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/csv") {
+		t.Fatalf("%s = %d %s: %s", path, rec.Code, rec.Header().Get("Content-Type"), rec.Body.String())
+	}
+	rows, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(rec.Body.String(), "\uFEFF"))).ReadAll()
+	if err != nil || len(rows) == 0 {
+		t.Fatalf("%s 解析失败: %v", path, err)
+	}
+	return rows[1:]
+}
+
+func testExport(t *testing.T, h *testutil.Harness) {
+	first := h.Draw("红宝马", "绿奔驰")
+	settled := h.Draw("红奥迪")
+	h.Clock.Advance(24 * time.Hour)
+	second := h.Draw("黄大众")
+	h.JSON(http.MethodPost, "/api/user-bets", map[string]any{
+		"round_id": second, "user_account": "acc-1", "bet_amount": 40, "payout_amount": 160,
+		"items": []map[string]any{{"car": "黄大众", "stake": 40}},
+	}, &struct{}{})
+
+	var page struct {
+		Total int64 `json:"total"`
+	}
+	h.JSON(http.MethodGet, "/api/history", nil, &page)
+	history := exportCSV(t, h, "/api/export/history")
+	if int64(len(history)) != page.Total || history[0][1] != second {
+		t.Errorf("历史导出 %d 行（首行 %v）, want %d 行", len(history), history[0], page.Total)
+	}
+	// 日期范围：只含第一天
+	today := h.Clock.Now().Format("2006-01-02")
+	filtered := exportCSV(t, h, "/api/export/history?strategy=热门3码&to="+today)
+	if len(filtered) != 1 || filtered[0][1] != settled || filtered[0][2] != "热门3码" {
+		t.Errorf("筛选后的历史 = %v, want %s 热门3码", filtered, settled)
+	}
+
+	winners := exportCSV(t, h, "/api/export/winners")
+	if len(winners) != 4 || winners[0][0] != first || winners[1][3] != "绿奔驰" {
+		t.Errorf("获胜项导出 = %v", winners)
+	}
+	if daily := exportCSV(t, h, "/api/export/report?section=daily&from="+today); len(daily) > 1 {
+		t.Errorf("每日报表导出 = %v, want 最多 1 天", daily)
+	}
+
+	rec := h.Do(http.MethodGet, "/api/export/user-bets?format=ndjson", nil)
+	var bet map[string]any
+	if err := json.Unmarshal(bytes.TrimSpace(rec.Body.Bytes()), &bet); err != nil || bet["items"] != "黄大众:40" || bet["round_id"] != second {
+		t.Errorf("派彩 NDJSON = %s (%v)", rec.Body.String(), err)
+	}
+
+	rec = h.Do(http.MethodGet, "/api/export/rounds?format=xlsx", nil)
+	f, err := excelize.OpenReader(rec.Body)
+	if err != nil {
+		t.Fatalf("开奖 XLSX: %v", err)
+	}
+	defer f.Close()
+	rows, err := f.GetRows("rounds")
+	if err != nil || len(rows) != 4 || rows[1][0] != first || rows[1][4] != "红宝马,绿奔驰" {
+		t.Errorf("开奖 XLSX 行 = %q (%v)", rows, err)
+	}
+
+	for _, path := range []string{"/api/export/history?format=pdf", "/api/export/unknown", "/api/export/report?section=x"} {
+		if rec := h.Do(http.MethodGet, path, nil); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s = %d, want 400", path, rec.Code)
+		}
+	}
+}
+
+// deadlineRecorder 记录 SetWriteDeadline 设置的写超时
+type deadlineRecorder struct {
+	*httptest.ResponseRecorder
+	deadline time.Time
+}
+
+func (r *deadlineRecorder) SetWriteDeadline(deadline time.Time) error {
+	r.deadline = deadline
+	return nil
+}
+
+func TestExportExtendsWriteDeadline(t *testing.T) {
+	h := testutil.New(t, testutil.Options{})
+	h.Draw("红宝马")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/export/rounds", nil)
+	req.Header.Set(api.APIKeyHeader, h.APIKey)
+	rec := &deadlineRecorder{ResponseRecorder: httptest.NewRecorder()}
+	start := time.Now()
+	h.Router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("导出 = %d: %s", rec.Code, rec.Body.String())
+	}
+	// 服务器默认写超时只有 10 秒，导出应延长到 ExportWriteTimeout
+	if rec.deadline.Before(start.Add(api.ExportWriteTimeout)) {
+		t.Errorf("写超时 = %v, want 不早于 %v", rec.deadline, start.Add(api.ExportWriteTimeout))
+	}
+}
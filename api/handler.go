@@ -1,7 +1,11 @@
 package api
 
 import (
+	"benz-sniper/alerts"
+	"benz-sniper/auth"
+	"benz-sniper/database"
 	"benz-sniper/engine"
+	"benz-sniper/reports"
 	"net/http"
 	"strconv"
 	"time"
@@ -12,22 +16,37 @@ import (
 // Handler API处理器
 type Handler struct {
 	manager *engine.StrategyManager
+	auth    *auth.Service
+	audit   database.AuditStore
+	clock   engine.Clock
+	engine  *engine.Engine   // 分析引擎（健康检查使用）
+	reports *reports.Service // 定时报表（日报/周报）
+	alerts  *alerts.Service  // 告警规则
 }
 
-// New 创建API处理器实例
-func New(manager *engine.StrategyManager) *Handler {
-	return &Handler{manager: manager}
+// New 创建API处理器实例（使用策略管理器的时钟）
+func New(manager *engine.StrategyManager, authService *auth.Service, audit database.AuditStore) *Handler {
+	return &Handler{manager: manager, auth: authService, audit: audit, clock: manager.Clock()}
 }
 
 // StatusResponse 状态响应
 type StatusResponse struct {
-	RoundID         string                   `json:"round_id"`
-	NextRound       string                   `json:"next_round"`
-	UpdatedAt       string                   `json:"updated_at"`
-	TimePassed      int                      `json:"time_passed"`
-	Countdown       int                      `json:"countdown"`
-	Strategies      []engine.StrategyResult  `json:"strategies"`
-	TotalRealProfit float64                  `json:"total_real_profit"` // 所有实盘注单总盈利
+	RoundID            string                  `json:"round_id"`
+	NextRound          string                  `json:"next_round"`
+	UpdatedAt          string                  `json:"updated_at"`
+	TimePassed         int                     `json:"time_passed"`
+	Countdown          int                     `json:"countdown"`
+	Strategies         []engine.StrategyResult `json:"strategies"`
+	TotalRealProfit    float64                 `json:"total_real_profit"`    // 所有实盘注单总盈利
+	TotalVirtualProfit float64                 `json:"total_virtual_profit"` // 所有注单纸面总盈亏（与实盘对比进出场规则的效果）
+
+	NextDrawAt       string  `json:"next_draw_at"`      // 预测下一期开奖时间（RFC3339）
+	BettingClosesAt  string  `json:"betting_closes_at"` // 预测停止下注时间（RFC3339）
+	IntervalEstimate float64 `json:"interval_estimate"` // 开奖间隔估计（秒，中位数）
+	IntervalJitter   float64 `json:"interval_jitter"`   // 开奖间隔抖动（秒）
+	Confidence       float64 `json:"confidence"`        // 预测置信度（0~1）
+	BettingClosing   bool    `json:"betting_closing"`   // 下注窗口即将关闭
+	Warning          string  `json:"warning,omitempty"` // 告警信息
 }
 
 // GetStatus 获取当前状态（读锁）
@@ -50,10 +69,22 @@ func (h *Handler) GetStatus(c *gin.Context) {
 
 	// 计算时间相关
 	timePassed := s.SystemUptime // 使用系统运行时长
-	roundTimePassed := int(time.Since(s.UpdatedAt).Seconds())
-	countdown := 24 - roundTimePassed // 减去10秒偏移量来同步实际游戏
-	if countdown < 0 {
-		countdown = 0
+	// 倒计时 = 预测停止下注时间 - 当前时间（预测基于开奖时间戳学习到的间隔）
+	now := h.clock.Now()
+	countdown := s.Timing.Countdown(now)
+	closing := s.RoundID != "" && s.Timing.Closing(now)
+	warning := ""
+	if closing {
+		warning = "下注窗口即将关闭"
+		if countdown == 0 {
+			warning = "下注窗口已关闭，等待开奖"
+		}
+	}
+
+	nextDrawAt, closesAt := "", ""
+	if !s.Timing.NextDrawAt.IsZero() {
+		nextDrawAt = s.Timing.NextDrawAt.Format(time.RFC3339)
+		closesAt = s.Timing.BettingClosesAt().Format(time.RFC3339)
 	}
 
 	// 计算下一期号
@@ -64,17 +95,23 @@ func (h *Handler) GetStatus(c *gin.Context) {
 		}
 	}
 
-	// 计算所有实盘注单的总盈利
-	totalRealProfit := h.manager.GetTotalRealProfit()
-
 	c.JSON(http.StatusOK, StatusResponse{
-		RoundID:         s.RoundID,
-		NextRound:       nextRound,
-		UpdatedAt:       s.UpdatedAt.Format("15:04:05"),
-		TimePassed:      timePassed,
-		Countdown:       countdown,
-		Strategies:      s.Strategies,
-		TotalRealProfit: totalRealProfit,
+		RoundID:            s.RoundID,
+		NextRound:          nextRound,
+		UpdatedAt:          s.UpdatedAt.Format("15:04:05"),
+		TimePassed:         timePassed,
+		Countdown:          countdown,
+		Strategies:         s.Strategies,
+		TotalRealProfit:    s.TotalRealProfit,
+		TotalVirtualProfit: s.TotalVirtualProfit,
+
+		NextDrawAt:       nextDrawAt,
+		BettingClosesAt:  closesAt,
+		IntervalEstimate: s.Timing.Interval.Seconds(),
+		IntervalJitter:   s.Timing.Jitter.Seconds(),
+		Confidence:       s.Timing.Confidence,
+		BettingClosing:   closing,
+		Warning:          warning,
 	})
 }
 
@@ -131,65 +168,118 @@ func (h *Handler) GetPredictions(c *gin.Context) {
 // HistoryResponse 历史记录响应
 type HistoryResponse struct {
 	Records    []engine.HistoryRecord `json:"records"`
-	Total      int64                  `json:"total"`       // 总记录数
-	TotalPages int                    `json:"total_pages"` // 总页数
-	Page       int                    `json:"page"`        // 当前页码
-	PageSize   int                    `json:"page_size"`   // 每页大小
+	Total      int64                  `json:"total"`                 // 总记录数（游标分页时为 -1）
+	TotalPages int                    `json:"total_pages"`           // 总页数
+	Page       int                    `json:"page"`                  // 当前页码
+	PageSize   int                    `json:"page_size"`             // 每页大小
+	NextCursor string                 `json:"next_cursor,omitempty"` // 下一页游标（按时间排序时）
+	HasMore    bool                   `json:"has_more"`              // 是否还有更多记录
 }
 
-// GetHistory 获取历史记录（读锁，支持分页和筛选）
+// GetHistory 获取历史记录（读锁，支持分页、筛选、排序和游标分页）
 func (h *Handler) GetHistory(c *gin.Context) {
 	// 禁止缓存，确保每次获取最新数据
 	c.Header("Cache-Control", "no-cache, no-store, must-revalidate")
 	c.Header("Pragma", "no-cache")
 	c.Header("Expires", "0")
-	
-	// 获取查询参数
-	page := 1
-	pageSize := 20
-	realOnly := false
-	
-	if pageStr := c.Query("page"); pageStr != "" {
-		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
-			page = p
-		}
+
+	params, err := historyQueryParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "请求参数错误: " + err.Error(),
+		})
+		return
 	}
-	
-	if pageSizeStr := c.Query("page_size"); pageSizeStr != "" {
-		if ps, err := strconv.Atoi(pageSizeStr); err == nil && ps > 0 {
-			pageSize = ps
-			if pageSize > 100 {
-				pageSize = 100
-			}
+
+	// 用户派彩记录和归档会话与 /api/accounts 同级，需要 viewer 角色
+	if !hasRole(c, auth.RoleViewer) {
+		if params.SessionID != 0 {
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"message": "权限不足，查询归档会话需要 viewer 角色",
+			})
+			return
 		}
-	}
-	
-	if realOnlyStr := c.Query("real_only"); realOnlyStr == "true" || realOnlyStr == "1" {
-		realOnly = true
+		params.OmitUserBets = true
 	}
 
 	// 读取历史记录
-	result := h.manager.GetHistory(engine.HistoryQueryParams{
-		Page:     page,
-		PageSize: pageSize,
-		RealOnly: realOnly,
-	})
+	result := h.manager.GetHistory(params)
 
-	c.JSON(http.StatusOK, HistoryResponse{
+	resp := HistoryResponse{
 		Records:    result.Records,
 		Total:      result.Total,
 		TotalPages: result.TotalPages,
 		Page:       result.Page,
 		PageSize:   result.PageSize,
-	})
+		NextCursor: result.NextCursor,
+		HasMore:    result.HasMore,
+	}
+	if params.OmitUserBets {
+		c.JSON(http.StatusOK, publicHistoryResponseFrom(resp))
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// publicHistoryRecord 未授权调用方看到的历史记录（遮蔽 user_bets 字段）
+type publicHistoryRecord struct {
+	engine.HistoryRecord
+	UserBets *struct{} `json:"user_bets,omitempty"`
 }
 
-// ClearHistory 清空历史记录（写锁）
+// publicHistoryResponse 未授权调用方看到的历史记录响应
+type publicHistoryResponse struct {
+	HistoryResponse
+	Records []publicHistoryRecord `json:"records"`
+}
+
+// publicHistoryResponseFrom 去掉用户派彩记录
+func publicHistoryResponseFrom(resp HistoryResponse) publicHistoryResponse {
+	records := make([]publicHistoryRecord, len(resp.Records))
+	for i, r := range resp.Records {
+		records[i] = publicHistoryRecord{HistoryRecord: r}
+	}
+	return publicHistoryResponse{HistoryResponse: resp, Records: records}
+}
+
+// ClearHistoryRequest 清空历史请求（可选）
+type ClearHistoryRequest struct {
+	Name string `json:"name"` // 归档会话名称（为空时按归档时间命名）
+}
+
+// ClearHistory 清空历史记录：归档到新会话，旧记录仍可通过 /api/sessions 查询、恢复或彻底删除
 func (h *Handler) ClearHistory(c *gin.Context) {
-	h.manager.ClearHistory()
+	var req ClearHistoryRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "请求参数错误: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	actor := ""
+	if p := PrincipalFrom(c); p != nil {
+		actor = p.Name
+	}
+	session, err := h.manager.ArchiveHistory(req.Name, actor)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "清空失败: " + err.Error(),
+		})
+		return
+	}
+	h.recordAudit(c, AuditHistoryClear, nil, session)
+
 	c.JSON(http.StatusOK, gin.H{
-		"message": "历史记录已清空",
+		"message": "历史记录已清空（已归档）",
 		"success": true,
+		"session": session,
 	})
 }
 
@@ -213,16 +303,17 @@ func (h *Handler) GetReport(c *gin.Context) {
 	})
 }
 
-// GetConfig 获取当前配置
+// GetConfig 获取当前配置（附带取值范围，便于页面校验）
 func (h *Handler) GetConfig(c *gin.Context) {
 	config := h.manager.GetConfig()
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"config":  config,
+		"limits":  h.manager.ConfigLimits(),
 	})
 }
 
-// UpdateConfigRequest 更新配置请求
+// UpdateConfigRequest 更新配置请求（省略的字段保持不变）
 type UpdateConfigRequest struct {
 	EntryCondition     *int     `json:"entry_condition"`      // 连赢几把进场
 	ExitCondition      *int     `json:"exit_condition"`       // 连输几把离场
@@ -230,9 +321,11 @@ type UpdateConfigRequest struct {
 	Balanced4BetAmount *float64 `json:"balanced4_bet_amount"` // 均衡4码下注金额
 	Hot3Enabled        *bool    `json:"hot3_enabled"`         // 热门3码启用
 	Balanced4Enabled   *bool    `json:"balanced4_enabled"`    // 均衡4码启用
+	Comment            string   `json:"comment"`              // 修改说明（写入配置版本）
+	DryRun             bool     `json:"dry_run"`              // 只预览生效配置和差异，不保存
 }
 
-// UpdateConfig 更新配置
+// UpdateConfig 更新配置（支持部分更新；校验失败返回字段级错误；dry_run 只返回差异）
 func (h *Handler) UpdateConfig(c *gin.Context) {
 	var req UpdateConfigRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -243,46 +336,55 @@ func (h *Handler) UpdateConfig(c *gin.Context) {
 		return
 	}
 
-	// 获取当前配置
-	currentConfig := h.manager.GetConfig()
-
-	// 构建新配置（支持部分更新）
-	newConfig := engine.StrategyConfig{
-		EntryCondition:     currentConfig.EntryCondition,
-		ExitCondition:      currentConfig.ExitCondition,
-		Hot3BetAmount:      currentConfig.Hot3BetAmount,
-		Balanced4BetAmount: currentConfig.Balanced4BetAmount,
-		Hot3Enabled:        currentConfig.Hot3Enabled,
-		Balanced4Enabled:   currentConfig.Balanced4Enabled,
-	}
-
-	// 更新提供的字段
-	if req.EntryCondition != nil && *req.EntryCondition > 0 {
-		newConfig.EntryCondition = *req.EntryCondition
+	patch := engine.ConfigPatch{
+		EntryCondition:     req.EntryCondition,
+		ExitCondition:      req.ExitCondition,
+		Hot3BetAmount:      req.Hot3BetAmount,
+		Balanced4BetAmount: req.Balanced4BetAmount,
+		Hot3Enabled:        req.Hot3Enabled,
+		Balanced4Enabled:   req.Balanced4Enabled,
 	}
-	if req.ExitCondition != nil && *req.ExitCondition > 0 {
-		newConfig.ExitCondition = *req.ExitCondition
+	dryRun := req.DryRun || c.Query("dry_run") == "true"
+	result, err := h.manager.PatchConfig(patch, configChange(c, req.Comment), dryRun)
+	if err != nil {
+		configError(c, err)
+		return
 	}
-	if req.Hot3BetAmount != nil && *req.Hot3BetAmount > 0 {
-		newConfig.Hot3BetAmount = *req.Hot3BetAmount
+
+	if dryRun {
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"dry_run": true,
+			"message": "预览：配置未保存",
+			"current": result.Before,
+			"config":  result.After,
+			"changes": result.Changes,
+		})
+		return
 	}
-	if req.Balanced4BetAmount != nil && *req.Balanced4BetAmount > 0 {
-		newConfig.Balanced4BetAmount = *req.Balanced4BetAmount
+
+	// 审计：配置变更和策略启停分别记录
+	currentConfig, updatedConfig := result.Before, result.After
+	if updatedConfig != currentConfig {
+		h.recordAudit(c, AuditConfigUpdate, currentConfig, updatedConfig)
 	}
-	if req.Hot3Enabled != nil {
-		newConfig.Hot3Enabled = *req.Hot3Enabled
+	if updatedConfig.Hot3Enabled != currentConfig.Hot3Enabled {
+		h.recordAudit(c, AuditStrategyToggle,
+			gin.H{"strategy": "热门3码", "enabled": currentConfig.Hot3Enabled},
+			gin.H{"strategy": "热门3码", "enabled": updatedConfig.Hot3Enabled})
 	}
-	if req.Balanced4Enabled != nil {
-		newConfig.Balanced4Enabled = *req.Balanced4Enabled
+	if updatedConfig.Balanced4Enabled != currentConfig.Balanced4Enabled {
+		h.recordAudit(c, AuditStrategyToggle,
+			gin.H{"strategy": "均衡4码", "enabled": currentConfig.Balanced4Enabled},
+			gin.H{"strategy": "均衡4码", "enabled": updatedConfig.Balanced4Enabled})
 	}
 
-	// 更新配置
-	updatedConfig := h.manager.UpdateConfig(newConfig)
-
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "配置已更新",
 		"config":  updatedConfig,
+		"changes": result.Changes,
+		"version": result.Version,
 	})
 }
 
@@ -295,13 +397,28 @@ func (h *Handler) GetNextPrediction(c *gin.Context) {
 	})
 }
 
-// UploadUserBetRequest 上传用户派彩请求
+// UploadUserBetRequest 上传用户派彩请求（同一期号和账号重复上传时覆盖）
 type UploadUserBetRequest struct {
-	RoundID      string  `json:"round_id" binding:"required"`      // 期号
-	UserAccount  string  `json:"user_account" binding:"required"`  // 用户账号
-	BetAmount    float64 `json:"bet_amount" binding:"required"`    // 下注金额
-	PayoutAmount float64 `json:"payout_amount"`                    // 派彩金额
-	Balance      float64 `json:"balance"`                          // 剩余余额
+	RoundID        string               `json:"round_id" binding:"required"`     // 期号
+	UserAccount    string               `json:"user_account" binding:"required"` // 用户账号
+	BetAmount      float64              `json:"bet_amount" binding:"required"`   // 下注金额
+	PayoutAmount   float64              `json:"payout_amount"`                   // 派彩金额
+	Balance        float64              `json:"balance"`                         // 剩余余额
+	Items          []engine.UserBetItem `json:"items"`                           // 按车型的下注明细（可选，提供时校验合计和派彩）
+	IdempotencyKey string               `json:"idempotency_key"`                 // 幂等键（也可用 Idempotency-Key 请求头）
+}
+
+// record 转换为引擎记录
+func (req UploadUserBetRequest) record() engine.UserBetRecord {
+	return engine.UserBetRecord{
+		RoundID:        req.RoundID,
+		UserAccount:    req.UserAccount,
+		BetAmount:      req.BetAmount,
+		PayoutAmount:   req.PayoutAmount,
+		Balance:        req.Balance,
+		Items:          req.Items,
+		IdempotencyKey: req.IdempotencyKey,
+	}
 }
 
 // UploadUserBet 上传用户派彩记录
@@ -314,42 +431,97 @@ func (h *Handler) UploadUserBet(c *gin.Context) {
 		})
 		return
 	}
+	if req.IdempotencyKey == "" {
+		req.IdempotencyKey = c.GetHeader("Idempotency-Key")
+	}
 
 	// 保存用户派彩记录
-	err := h.manager.SaveUserBet(engine.UserBetRecord{
-		RoundID:      req.RoundID,
-		UserAccount:  req.UserAccount,
-		BetAmount:    req.BetAmount,
-		PayoutAmount: req.PayoutAmount,
-		Balance:      req.Balance,
-	})
-
+	record := req.record()
+	result, err := h.manager.SaveUserBet(record)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "保存失败: " + err.Error(),
-		})
+		userBetError(c, err)
 		return
 	}
 
+	if result.Status != database.UpsertUnchanged {
+		h.recordAudit(c, AuditUserBetUpload, nil, record)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "用户派彩记录已保存",
+		"result":  result,
 	})
 }
 
-// SetupRoutes 设置路由
+// SetupRoutes 设置路由（看板接口公开，其余按角色分组：viewer 只读，operator 上传派彩，admin 修改配置和管理密钥）
 func (h *Handler) SetupRoutes(router *gin.Engine) {
+	router.GET("/healthz", h.Healthz) // 进程存活
+	router.GET("/readyz", h.Readyz)   // 就绪检查
+
 	api := router.Group("/api")
+	api.Use(h.authenticate())
 	{
+		api.POST("/auth/login", h.Login)   // 登录（无需凭证）
+		api.POST("/auth/logout", h.Logout) // 退出登录
+		api.GET("/health", h.GetHealth)    // 引擎健康详情（无需凭证）
+
+		// 看板页面轮询的接口（无需凭证）
 		api.GET("/status", h.GetStatus)
 		api.GET("/predictions", h.GetPredictions)
-		api.GET("/history", h.GetHistory)
-		api.POST("/history/clear", h.ClearHistory)
+		api.GET("/history", h.GetHistory) // 未授权时不含 user_bets，不能查询归档会话
 		api.GET("/report", h.GetReport)
-		api.GET("/config", h.GetConfig)           // 获取配置
-		api.POST("/config", h.UpdateConfig)       // 更新配置
+		api.GET("/config", h.GetConfig)                  // 获取配置
 		api.GET("/next-prediction", h.GetNextPrediction) // 获取下一期预测
-		api.POST("/user-bets", h.UploadUserBet)   // 上传用户派彩记录
+
+		viewer := api.Group("", requireRole(auth.RoleViewer))
+		viewer.GET("/auth/me", h.Me)
+		viewer.GET("/report/performance", h.GetPerformanceReport) // 绩效报表
+		viewer.GET("/report/periods", h.GetPeriodReport)          // 按小时/日/周/月分组的报表
+
+		operator := api.Group("", requireRole(auth.RoleOperator))
+		operator.POST("/user-bets", h.UploadUserBet)        // 上传用户派彩记录
+		operator.POST("/user-bets/batch", h.UploadUserBets) // 批量上传用户派彩记录
+
+		admin := api.Group("", requireRole(auth.RoleAdmin))
+		admin.POST("/history/clear", h.ClearHistory)
+		admin.POST("/config", h.UpdateConfig) // 更新配置
+		admin.GET("/auth/keys", h.ListAPIKeys)
+		admin.POST("/auth/keys", h.CreateAPIKey)
+		admin.DELETE("/auth/keys/:id", h.RevokeAPIKey)
+		admin.GET("/auth/users", h.ListUsers)
+		admin.POST("/auth/users", h.CreateUser)
+		admin.GET("/audit", h.GetAudit)              // 审计日志
+		admin.GET("/admin/log-level", h.GetLogLevel) // 当前日志级别
+		admin.PUT("/admin/log-level", h.SetLogLevel) // 运行时修改日志级别
+
+		viewer.GET("/sessions", h.ListSessions)                // 归档会话列表
+		viewer.GET("/sessions/:id/report", h.GetSessionReport) // 归档会话报表
+		admin.POST("/sessions/:id/restore", h.RestoreSession)  // 恢复归档会话
+		admin.DELETE("/sessions/:id", h.PurgeSession)          // 彻底删除归档会话
+
+		viewer.GET("/config/versions", h.ListConfigVersions)             // 配置版本历史
+		viewer.GET("/config/profiles", h.ListProfiles)                   // 配置方案列表
+		admin.POST("/config/rollback/:version", h.RollbackConfig)        // 回滚到指定版本
+		admin.PUT("/config/profiles/:name", h.SaveProfile)               // 创建/修改方案
+		admin.DELETE("/config/profiles/:name", h.DeleteProfile)          // 删除方案
+		admin.POST("/config/profiles/:name/activate", h.ActivateProfile) // 手动启用方案
+
+		viewer.GET("/reconciliation", h.GetReconciliation) // 用户下注对账
+
+		viewer.GET("/accounts", h.ListAccounts)                     // 账号汇总
+		viewer.GET("/accounts/:account/ledger", h.GetAccountLedger) // 账号流水
+
+		viewer.GET("/export/:dataset", h.Export) // 导出 CSV / XLSX / NDJSON
+
+		viewer.GET("/reports", h.ListReports)        // 日报/周报列表
+		viewer.GET("/reports/:id", h.DownloadReport) // 下载日报/周报（HTML / CSV）
+		operator.POST("/reports", h.GenerateReport)  // 手动生成日报/周报
+
+		viewer.GET("/alerts", h.ListAlerts)                 // 告警记录
+		viewer.GET("/alert-rules", h.ListAlertRules)        // 告警规则列表
+		admin.POST("/alert-rules", h.CreateAlertRule)       // 创建告警规则
+		admin.PUT("/alert-rules/:id", h.UpdateAlertRule)    // 修改告警规则
+		admin.DELETE("/alert-rules/:id", h.DeleteAlertRule) // 删除告警规则
 	}
 }
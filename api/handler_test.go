@@ -0,0 +1,262 @@
+package api_test
+
+import (
+	"benz-sniper/api"
+	"benz-sniper/engine"
+	"benz-sniper/testutil"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestGetStatus(t *testing.T) {
+	h := testutil.New(t, testutil.Options{})
+
+	var empty api.StatusResponse
+	h.JSON(http.MethodGet, "/api/status", nil, &empty)
+	if empty.RoundID != "" || len(empty.Strategies) != 0 {
+		t.Fatalf("空状态 = %+v, want 无期号无策略", empty)
+	}
+
+	h.PromoteToReal("热门3码")
+	car := h.WinningCar("热门3码")
+	roundID := h.Draw(car)
+
+	var status api.StatusResponse
+	h.JSON(http.MethodGet, "/api/status", nil, &status)
+	if status.RoundID != roundID {
+		t.Errorf("round_id = %s, want %s", status.RoundID, roundID)
+	}
+	num, _ := strconv.Atoi(roundID)
+	if status.NextRound != strconv.Itoa(num+1) {
+		t.Errorf("next_round = %s, want %d", status.NextRound, num+1)
+	}
+	if len(status.Strategies) != 2 {
+		t.Fatalf("len(strategies) = %d, want 2", len(status.Strategies))
+	}
+	hot3 := h.Strategy("热门3码")
+	if status.TotalRealProfit < hot3.RealProfit || hot3.RealProfit <= 0 {
+		t.Errorf("total_real_profit = %.2f, 热门3码 real_profit = %.2f", status.TotalRealProfit, hot3.RealProfit)
+	}
+}
+
+func TestGetPredictionsAndNextPrediction(t *testing.T) {
+	h := testutil.New(t, testutil.Options{})
+	h.Draw("红宝马")
+
+	// 全部虚盘：没有可下注的预测
+	var preds api.PredictionsResponse
+	h.JSON(http.MethodGet, "/api/predictions", nil, &preds)
+	if len(preds.Predictions) != 0 {
+		t.Errorf("虚盘时 predictions = %v, want empty", preds.Predictions)
+	}
+
+	h.PromoteToReal("热门3码")
+	hot3 := h.Strategy("热门3码")
+	state := h.Manager.GetState()
+	num, _ := strconv.Atoi(state.RoundID)
+
+	h.JSON(http.MethodGet, "/api/predictions", nil, &preds)
+	if preds.Round != strconv.Itoa(num+1) {
+		t.Errorf("round = %s, want %d", preds.Round, num+1)
+	}
+	for _, p := range hot3.Predictions {
+		if preds.Predictions[p] != 100 {
+			t.Errorf("predictions[%s] = %d, want 100", p, preds.Predictions[p])
+		}
+	}
+
+	var next struct {
+		Success bool                        `json:"success"`
+		Data    engine.NextPredictionResult `json:"data"`
+	}
+	h.JSON(http.MethodGet, "/api/next-prediction", nil, &next)
+	if !next.Success || next.Data.Round != strconv.Itoa(num+1) {
+		t.Fatalf("next-prediction = %+v", next)
+	}
+	found := false
+	for _, s := range next.Data.Strategies {
+		if s.Name == "热门3码" {
+			found = true
+			if s.BetAmount != 100 || len(s.Predictions) != 3 {
+				t.Errorf("热门3码 = %+v, want bet_amount=100 and 3 predictions", s)
+			}
+		}
+	}
+	if !found {
+		t.Error("next-prediction 缺少实盘策略 热门3码")
+	}
+
+	// 禁用后不再返回
+	h.JSON(http.MethodPost, "/api/config", map[string]any{"hot3_enabled": false}, &struct{}{})
+	h.JSON(http.MethodGet, "/api/next-prediction", nil, &next)
+	for _, s := range next.Data.Strategies {
+		if s.Name == "热门3码" {
+			t.Error("禁用后 next-prediction 仍返回 热门3码")
+		}
+	}
+}
+
+func TestHistoryPaginationAndClear(t *testing.T) {
+	h := testutil.New(t, testutil.Options{})
+	h.Draw("红宝马")
+	for i := 0; i < 5; i++ {
+		h.Draw(h.LosingCar())
+	}
+
+	var page api.HistoryResponse
+	h.JSON(http.MethodGet, "/api/history?page=2&page_size=4", nil, &page)
+	if page.Total != 10 || page.TotalPages != 3 || page.Page != 2 || page.PageSize != 4 {
+		t.Fatalf("分页 = total %d pages %d page %d size %d, want 10/3/2/4",
+			page.Total, page.TotalPages, page.Page, page.PageSize)
+	}
+	if len(page.Records) != 4 {
+		t.Errorf("len(records) = %d, want 4", len(page.Records))
+	}
+	for _, r := range page.Records {
+		if r.Result != "输" || r.StatusText != "虚盘观望" || r.UserBets == nil {
+			t.Errorf("record = %+v, want 虚盘输 with non-nil user_bets", r)
+		}
+	}
+
+	h.JSON(http.MethodGet, "/api/history?page_size=1000", nil, &page)
+	if page.PageSize != 100 {
+		t.Errorf("page_size = %d, want 100（上限）", page.PageSize)
+	}
+
+	h.JSON(http.MethodGet, "/api/history?real_only=true", nil, &page)
+	if page.Total != 0 {
+		t.Errorf("real_only total = %d, want 0", page.Total)
+	}
+
+	var cleared struct {
+		Success bool `json:"success"`
+	}
+	h.JSON(http.MethodPost, "/api/history/clear", nil, &cleared)
+	if !cleared.Success {
+		t.Error("clear success = false")
+	}
+	h.JSON(http.MethodGet, "/api/history", nil, &page)
+	if page.Total != 0 || len(page.Records) != 0 {
+		t.Errorf("清空后 total = %d, want 0", page.Total)
+	}
+}
+
+func TestGetReport(t *testing.T) {
+	h := testutil.New(t, testutil.Options{})
+	h.PromoteToReal("热门3码")
+	h.Draw(h.WinningCar("热门3码"))
+	h.Draw(h.LosingCar())
+
+	var report api.ReportResponse
+	h.JSON(http.MethodGet, "/api/report", nil, &report)
+
+	hot3 := h.Strategy("热门3码")
+	var hot3Report *engine.StrategyReportItem
+	for i := range report.Strategies {
+		if report.Strategies[i].Name == "热门3码" {
+			hot3Report = &report.Strategies[i]
+		}
+	}
+	if hot3Report == nil {
+		t.Fatal("report 缺少 热门3码")
+	}
+	if hot3Report.TotalBets != 2 || hot3Report.TotalWins != 1 || hot3Report.WinRate != 50 {
+		t.Errorf("热门3码 report = %+v, want 2 bets 1 win 50%%", hot3Report)
+	}
+	if hot3Report.TotalProfit != hot3.RealProfit {
+		t.Errorf("total_profit = %.2f, want %.2f", hot3Report.TotalProfit, hot3.RealProfit)
+	}
+	if report.Summary.TotalBets < 2 || len(report.Daily) != 1 || report.Daily[0].Date != "2026-01-01" {
+		t.Errorf("summary = %+v daily = %+v", report.Summary, report.Daily)
+	}
+}
+
+func TestConfigPartialUpdate(t *testing.T) {
+	h := testutil.New(t, testutil.Options{})
+
+	var got struct {
+		Success bool                  `json:"success"`
+		Config  engine.StrategyConfig `json:"config"`
+	}
+	h.JSON(http.MethodGet, "/api/config", nil, &got)
+	if got.Config != engine.DefaultStrategyConfig() {
+		t.Fatalf("默认配置 = %+v", got.Config)
+	}
+
+	h.JSON(http.MethodPost, "/api/config", map[string]any{
+		"entry_condition": 3,
+		"hot3_bet_amount": 50,
+	}, &got)
+	want := engine.DefaultStrategyConfig()
+	want.EntryCondition = 3
+	want.Hot3BetAmount = 50
+	if got.Config != want {
+		t.Errorf("更新后配置 = %+v, want %+v", got.Config, want)
+	}
+
+	// 重新加载：配置已持久化
+	reloaded := engine.NewStrategyManager(h.Store).GetConfig()
+	if reloaded != want {
+		t.Errorf("持久化配置 = %+v, want %+v", reloaded, want)
+	}
+
+	if rec := h.Do(http.MethodPost, "/api/config", "not-json"); rec.Code != http.StatusBadRequest {
+		t.Errorf("错误请求体返回 %d, want 400", rec.Code)
+	}
+}
+
+func TestUploadUserBet(t *testing.T) {
+	h := testutil.New(t, testutil.Options{})
+	h.Draw("红宝马")
+	roundID := h.Draw(h.LosingCar())
+
+	var resp struct {
+		Success bool `json:"success"`
+	}
+	h.JSON(http.MethodPost, "/api/user-bets", map[string]any{
+		"round_id":      roundID,
+		"user_account":  "acc-1",
+		"bet_amount":    300,
+		"payout_amount": 0,
+		"balance":       9700,
+	}, &resp)
+	if !resp.Success {
+		t.Fatal("upload success = false")
+	}
+
+	var page api.HistoryResponse
+	h.JSON(http.MethodGet, "/api/history", nil, &page)
+	for _, r := range page.Records {
+		if r.RoundID != roundID {
+			continue
+		}
+		if len(r.UserBets) != 1 || r.UserBets[0].UserAccount != "acc-1" || r.UserBets[0].BetAmount != 300 {
+			t.Errorf("user_bets = %+v, want acc-1/300", r.UserBets)
+		}
+	}
+
+	if rec := h.Do(http.MethodPost, "/api/user-bets", map[string]any{"round_id": roundID}); rec.Code != http.StatusBadRequest {
+		t.Errorf("缺少必填字段返回 %d, want 400", rec.Code)
+	}
+}
+
+func TestStatusCountdownFollowsRoundTimestamp(t *testing.T) {
+	h := testutil.New(t, testutil.Options{})
+	h.Draw("红宝马")
+
+	// 倒计时由注入的时钟和开奖时间戳计算
+	h.Clock.Advance(5 * time.Second)
+	var status api.StatusResponse
+	h.JSON(http.MethodGet, "/api/status", nil, &status)
+	if status.Countdown != 24-5 {
+		t.Errorf("countdown = %d, want %d", status.Countdown, 24-5)
+	}
+
+	h.Clock.Advance(time.Minute)
+	h.JSON(http.MethodGet, "/api/status", nil, &status)
+	if status.Countdown != 0 {
+		t.Errorf("超时后 countdown = %d, want 0", status.Countdown)
+	}
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"benz-sniper/engine"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetEngine 设置分析引擎（就绪和健康检查使用）
+func (h *Handler) SetEngine(e *engine.Engine) {
+	h.engine = e
+}
+
+// Healthz 进程存活检查（只要能响应就返回 200）
+func (h *Handler) Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": engine.HealthOK})
+}
+
+// Readyz 就绪检查：数据库可达、表结构已迁移、配置已加载（失败返回 503）
+func (h *Handler) Readyz(c *gin.Context) {
+	h.healthResponse(c, func(e *engine.Engine) engine.HealthReport { return e.Readiness() })
+}
+
+// GetHealth 引擎健康详情：最近成功轮询、最新期号、数据新鲜度、积压的待结算期号（不健康返回 503）
+func (h *Handler) GetHealth(c *gin.Context) {
+	h.healthResponse(c, func(e *engine.Engine) engine.HealthReport { return e.Health() })
+}
+
+// healthResponse 输出检查报告（未设置引擎时视为不健康）
+func (h *Handler) healthResponse(c *gin.Context, check func(*engine.Engine) engine.HealthReport) {
+	if h.engine == nil {
+		c.JSON(http.StatusServiceUnavailable, engine.HealthReport{
+			Status:    engine.HealthUnhealthy,
+			CheckedAt: h.clock.Now(),
+			Checks:    []engine.HealthCheck{{Name: "engine", Status: engine.HealthUnhealthy, Message: "引擎未启动"}},
+		})
+		return
+	}
+
+	report := check(h.engine)
+	status := http.StatusOK
+	if !report.Healthy() {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
@@ -0,0 +1,93 @@
+package api_test
+
+import (
+	"benz-sniper/database"
+	"benz-sniper/engine"
+	"benz-sniper/models"
+	"benz-sniper/testutil"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// healthReport 请求健康检查接口（匿名访问），返回状态码和各项检查结果
+func healthReport(t *testing.T, h *testutil.Harness, path string) (int, engine.HealthReport, map[string]string) {
+	t.Helper()
+	rec := h.DoWith(http.MethodGet, path, nil, nil)
+	var report engine.HealthReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("解析 %s 响应失败: %v\n%s", path, err, rec.Body.String())
+	}
+	checks := make(map[string]string)
+	for _, c := range report.Checks {
+		checks[c.Name] = c.Status
+	}
+	return rec.Code, report, checks
+}
+
+func TestHealth(t *testing.T) {
+	testutil.EachDriver(t, testHealth)
+}
+
+func testHealth(t *testing.T, h *testutil.Harness) {
+	if rec := h.DoWith(http.MethodGet, "/healthz", nil, nil); rec.Code != http.StatusOK {
+		t.Errorf("/healthz = %d, want 200", rec.Code)
+	}
+	if code, _, checks := healthReport(t, h, "/readyz"); code != http.StatusOK || len(checks) != 3 {
+		t.Errorf("/readyz = %d %v, want 200", code, checks)
+	}
+
+	// 还没有开奖数据
+	if code, _, checks := healthReport(t, h, "/api/health"); code != http.StatusServiceUnavailable || checks["data_freshness"] != engine.HealthUnhealthy {
+		t.Errorf("无数据时 /api/health = %d %v, want 503", code, checks)
+	}
+
+	h.Draw("红宝马")
+	last := h.Draw("绿奔驰")
+	code, report, _ := healthReport(t, h, "/api/health")
+	if code != http.StatusOK || report.Engine.LastRoundID != last || len(report.Engine.Pending) != 0 {
+		t.Fatalf("正常运行时 /api/health = %d %+v", code, report)
+	}
+
+	// 写入没有获胜项的一期：进入待结算，超过阈值后视为积压
+	at := h.Clock.Now().Add(testutil.RoundInterval)
+	h.Clock.Set(at)
+	if err := h.Store.SaveRound(&models.GameRound{RoundID: "9000", Timestamp: at.Unix(), ResultName: "红宝马", CreatedAt: &at}, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	h.Engine.Tick()
+	h.Clock.Advance(engine.DefaultHealthThresholds().SettlementMaxAge + time.Second)
+	h.Engine.Tick()
+	code, report, checks := healthReport(t, h, "/api/health")
+	if code != http.StatusServiceUnavailable || checks["pending_settlements"] != engine.HealthUnhealthy ||
+		checks["tick"] != engine.HealthOK || len(report.Engine.Pending) != 1 {
+		t.Errorf("结算积压时 /api/health = %d %v %+v", code, checks, report.Engine)
+	}
+	// 数据过期（超过 3 倍开奖间隔没有新期号）
+	if checks["data_freshness"] != engine.HealthUnhealthy {
+		t.Errorf("数据新鲜度 = %s, want unhealthy", checks["data_freshness"])
+	}
+
+	// 引擎停止轮询
+	h.Clock.Advance(time.Minute)
+	if _, _, checks := healthReport(t, h, "/api/health"); checks["tick"] != engine.HealthUnhealthy {
+		t.Errorf("停止轮询后 tick = %s, want unhealthy", checks["tick"])
+	}
+}
+
+func TestHealthDatabaseDown(t *testing.T) {
+	h := testutil.New(t, testutil.Options{Driver: database.DriverSQLite})
+	h.Draw("红宝马")
+	h.Store.Close()
+	h.Engine.Tick()
+
+	code, report, checks := healthReport(t, h, "/api/health")
+	if code != http.StatusServiceUnavailable || checks["database"] != engine.HealthUnhealthy ||
+		report.Engine.ConsecutiveFailures != 1 || report.Engine.LastError == "" {
+		t.Errorf("数据库不可用时 /api/health = %d %v %+v", code, checks, report.Engine)
+	}
+	if code, _, checks := healthReport(t, h, "/readyz"); code != http.StatusServiceUnavailable || checks["database"] != engine.HealthUnhealthy {
+		t.Errorf("数据库不可用时 /readyz = %d %v", code, checks)
+	}
+}
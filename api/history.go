@@ -0,0 +1,107 @@
+package api
+
+import (
+	"benz-sniper/database"
+	"benz-sniper/engine"
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// historyFilterParams 历史记录筛选参数（GetHistory 和导出共用）
+//
+// strategy、real_only、session_id、result（赢/输）、from / to、round_from / round_to（期号，含）、
+// special（特殊奖项名称，any 表示任意特殊奖项）、min_profit（最低盈亏）
+func historyFilterParams(c *gin.Context) (database.HistoryFilter, error) {
+	filter := database.HistoryFilter{
+		Strategy:  c.Query("strategy"),
+		RoundFrom: c.Query("round_from"),
+		RoundTo:   c.Query("round_to"),
+	}
+	if realOnly := c.Query("real_only"); realOnly == "true" || realOnly == "1" {
+		filter.Status = database.StatusFilter(engine.StatusReal)
+	}
+	if sessionStr := c.Query("session_id"); sessionStr != "" {
+		id, err := strconv.ParseUint(sessionStr, 10, 64)
+		if err != nil {
+			return filter, fmt.Errorf("session_id 格式错误: %s", sessionStr)
+		}
+		filter.SessionID = uint(id)
+	}
+	switch result := c.Query("result"); result {
+	case "", "赢", "输":
+		filter.Result = result
+	default:
+		return filter, fmt.Errorf("result 只能是 赢 或 输: %s", result)
+	}
+	switch special := c.Query("special"); special {
+	case "any", database.SpecialAny:
+		filter.SpecialReward = database.SpecialAny
+	default:
+		filter.SpecialReward = special
+	}
+	if minStr := c.Query("min_profit"); minStr != "" {
+		minProfit, err := strconv.ParseFloat(minStr, 64)
+		if err != nil {
+			return filter, fmt.Errorf("min_profit 格式错误: %s", minStr)
+		}
+		filter.MinProfit = &minProfit
+	}
+
+	var err error
+	if filter.From, err = parseTimeParam(c.Query("from")); err != nil {
+		return filter, fmt.Errorf("from 格式错误: %w", err)
+	}
+	if filter.To, err = parseTimeParam(c.Query("to")); err != nil {
+		return filter, fmt.Errorf("to 格式错误: %w", err)
+	}
+	return filter, nil
+}
+
+// historyQueryParams 历史记录查询参数：筛选条件加分页和排序
+//
+// 偏移分页：page、page_size（最大 100）；游标分页：带 cursor 参数（第一页传空值），使用响应中的 next_cursor 翻页，
+// 不统计总数。sort=created_at（默认）/ profit / bet_amount，order=desc（默认）/ asc；游标分页只支持按时间排序。
+func historyQueryParams(c *gin.Context) (engine.HistoryQueryParams, error) {
+	page, pageSize := pageParams(c)
+	params := engine.HistoryQueryParams{Page: page, PageSize: pageSize}
+
+	filter, err := historyFilterParams(c)
+	if err != nil {
+		return params, err
+	}
+	params.RealOnly = filter.Status != nil
+	params.SessionID = filter.SessionID
+	params.Filter = filter
+
+	switch sort := c.DefaultQuery("sort", database.HistorySortTime); sort {
+	case database.HistorySortTime, database.HistorySortProfit, database.HistorySortBet:
+		params.Sort = sort
+	default:
+		return params, fmt.Errorf("sort 只能是 %s / %s / %s: %s",
+			database.HistorySortTime, database.HistorySortProfit, database.HistorySortBet, sort)
+	}
+	switch order := c.DefaultQuery("order", "desc"); order {
+	case "desc":
+	case "asc":
+		params.Asc = true
+	default:
+		return params, fmt.Errorf("order 只能是 asc / desc: %s", order)
+	}
+
+	cursor, ok := c.GetQuery("cursor")
+	if !ok {
+		return params, nil
+	}
+	if params.Sort != database.HistorySortTime {
+		return params, fmt.Errorf("游标分页只支持按 %s 排序", database.HistorySortTime)
+	}
+	params.Cursor = true
+	if cursor != "" {
+		if params.After, err = database.ParseHistoryCursor(cursor); err != nil {
+			return params, err
+		}
+	}
+	return params, nil
+}
@@ -0,0 +1,113 @@
+package api_test
+
+import (
+	"benz-sniper/api"
+	"benz-sniper/testutil"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestHistoryFiltersAndCursor(t *testing.T) {
+	testutil.EachDriver(t, testHistoryFiltersAndCursor)
+}
+
+func testHistoryFiltersAndCursor(t *testing.T, h *testutil.Harness) {
+	cars := []string{"红宝马", "黄大众", "绿奔驰", "红奥迪", "黄宝马"}
+	var rounds []string
+	for i := 0; i < 10; i++ {
+		rounds = append(rounds, h.Draw(cars[i%len(cars)]))
+	}
+	special := h.DrawSpecial("大三元", "红奔驰", "红宝马", "红奥迪")
+
+	var all api.HistoryResponse
+	h.JSON(http.MethodGet, "/api/history?page_size=100", nil, &all)
+	if all.Total < 20 || all.HasMore {
+		t.Fatalf("全部历史 total=%d has_more=%v", all.Total, all.HasMore)
+	}
+
+	// 游标分页与偏移分页顺序一致、不重复
+	var paged []string
+	cursor := ""
+	for i := 0; ; i++ {
+		var page api.HistoryResponse
+		h.JSON(http.MethodGet, "/api/history?page_size=3&cursor="+url.QueryEscape(cursor), nil, &page)
+		if page.Total != -1 {
+			t.Errorf("游标分页 total = %d, want -1", page.Total)
+		}
+		for _, r := range page.Records {
+			paged = append(paged, r.RoundID+"/"+r.Strategy)
+		}
+		if !page.HasMore {
+			break
+		}
+		if page.NextCursor == "" || i > 20 {
+			t.Fatalf("第 %d 页 has_more 但没有 next_cursor", i)
+		}
+		cursor = page.NextCursor
+	}
+	if len(paged) != len(all.Records) {
+		t.Fatalf("游标分页共 %d 条, want %d", len(paged), len(all.Records))
+	}
+	for i, r := range all.Records {
+		if paged[i] != r.RoundID+"/"+r.Strategy {
+			t.Errorf("第 %d 条 = %s, want %s/%s", i, paged[i], r.RoundID, r.Strategy)
+		}
+	}
+
+	// 筛选条件
+	check := func(query string, match func(api.HistoryResponse) bool) {
+		t.Helper()
+		var resp api.HistoryResponse
+		h.JSON(http.MethodGet, "/api/history?page_size=100&"+query, nil, &resp)
+		if len(resp.Records) == 0 || int64(len(resp.Records)) != resp.Total || !match(resp) {
+			t.Errorf("%s => total=%d %+v", query, resp.Total, resp.Records)
+		}
+	}
+	check("strategy="+url.QueryEscape("热门3码")+"&result="+url.QueryEscape("输"), func(resp api.HistoryResponse) bool {
+		for _, r := range resp.Records {
+			if r.Strategy != "热门3码" || r.Result != "输" {
+				return false
+			}
+		}
+		return true
+	})
+	check("round_from="+rounds[3]+"&round_to="+rounds[5], func(resp api.HistoryResponse) bool {
+		for _, r := range resp.Records {
+			if r.RoundID < rounds[3] || r.RoundID > rounds[5] {
+				return false
+			}
+		}
+		return len(resp.Records) == 3*2 // 每期两个策略
+	})
+	check("special=any", func(resp api.HistoryResponse) bool {
+		for _, r := range resp.Records {
+			if r.RoundID != special || r.SpecialReward == "" {
+				return false
+			}
+		}
+		return true
+	})
+	check("min_profit=0", func(resp api.HistoryResponse) bool {
+		for _, r := range resp.Records {
+			if r.Profit < 0 {
+				return false
+			}
+		}
+		return true
+	})
+	check("sort=profit&order=asc", func(resp api.HistoryResponse) bool {
+		for i := 1; i < len(resp.Records); i++ {
+			if resp.Records[i].Profit < resp.Records[i-1].Profit {
+				return false
+			}
+		}
+		return resp.NextCursor == ""
+	})
+
+	for _, query := range []string{"result=x", "sort=name", "order=up", "min_profit=abc", "cursor=abc", "sort=profit&cursor="} {
+		if rec := h.Do(http.MethodGet, "/api/history?"+query, nil); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s = %d, want 400", query, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"benz-sniper/logging"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditLogLevel 修改日志级别的审计操作类型
+const AuditLogLevel = "log.level"
+
+// LogLevelRequest 修改日志级别请求
+type LogLevelRequest struct {
+	Level string `json:"level" binding:"required"` // debug / info / warn / error
+}
+
+// requestLog 当前请求的 logger（带 request_id）
+func requestLog(c *gin.Context) *slog.Logger {
+	return logging.FromContext(c.Request.Context())
+}
+
+// GetLogLevel 当前日志级别
+func (h *Handler) GetLogLevel(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"level":   logging.Level(),
+	})
+}
+
+// SetLogLevel 运行时修改日志级别（无需重启）
+func (h *Handler) SetLogLevel(c *gin.Context) {
+	var req LogLevelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	before := logging.Level()
+	if err := logging.SetLevel(req.Level); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+	after := logging.Level()
+	if after != before {
+		h.recordAudit(c, AuditLogLevel, gin.H{"level": before}, gin.H{"level": after})
+		requestLog(c).Warn("🔧 日志级别已修改", "from", before, "to", after)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"level":   after,
+	})
+}
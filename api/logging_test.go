@@ -0,0 +1,49 @@
+package api_test
+
+import (
+	"benz-sniper/api"
+	"benz-sniper/logging"
+	"benz-sniper/testutil"
+	"net/http"
+	"testing"
+)
+
+func TestLogLevel(t *testing.T) {
+	h := testutil.New(t, testutil.Options{})
+	initial := logging.Level()
+	t.Cleanup(func() { logging.SetLevel(initial) })
+
+	var resp struct {
+		Level string `json:"level"`
+	}
+	h.JSON(http.MethodPut, "/api/admin/log-level", map[string]any{"level": "debug"}, &resp)
+	if resp.Level != "debug" || logging.Level() != "debug" {
+		t.Errorf("修改后级别 = %s (全局 %s), want debug", resp.Level, logging.Level())
+	}
+	h.JSON(http.MethodGet, "/api/admin/log-level", nil, &resp)
+	if resp.Level != "debug" {
+		t.Errorf("查询级别 = %s, want debug", resp.Level)
+	}
+
+	if rec := h.Do(http.MethodPut, "/api/admin/log-level", map[string]any{"level": "loud"}); rec.Code != http.StatusBadRequest {
+		t.Errorf("未知级别 = %d, want 400", rec.Code)
+	}
+	if rec := h.DoWith(http.MethodPut, "/api/admin/log-level", map[string]any{"level": "error"}, nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("匿名修改级别 = %d, want 401", rec.Code)
+	}
+	if logging.Level() != "debug" {
+		t.Errorf("失败的请求修改了级别: %s", logging.Level())
+	}
+
+	var audit api.AuditResponse
+	h.JSON(http.MethodGet, "/api/audit?action="+api.AuditLogLevel, nil, &audit)
+	if audit.Total != 1 {
+		t.Errorf("日志级别审计 = %d 条, want 1", audit.Total)
+	}
+
+	// 每个响应都带请求 ID，调用方提供时沿用
+	rec := h.DoWith(http.MethodGet, "/api/status", nil, http.Header{logging.RequestIDHeader: {"trace-1"}})
+	if got := rec.Header().Get(logging.RequestIDHeader); got != "trace-1" {
+		t.Errorf("请求 ID = %q, want trace-1", got)
+	}
+}
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"benz-sniper/engine"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetPerformanceReport 绩效报表：回撤、连赢连输、夏普比率、ROI 和收益曲线
+// 支持与 /api/history 相同的筛选参数，mode=real（默认）/virtual，points 为收益曲线最多点数
+func (h *Handler) GetPerformanceReport(c *gin.Context) {
+	filter, err := historyFilterParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	points := 0
+	if value := c.Query("points"); value != "" {
+		if points, err = strconv.Atoi(value); err != nil || points <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "points 必须是正整数: " + value})
+			return
+		}
+	}
+
+	mode := c.DefaultQuery("mode", engine.PerformanceModeReal)
+	if mode != engine.PerformanceModeReal && mode != engine.PerformanceModeVirtual {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "mode 只能是 real 或 virtual: " + mode})
+		return
+	}
+
+	report, err := h.manager.GetPerformanceReport(filter, mode, points)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "查询失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
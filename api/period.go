@@ -0,0 +1,30 @@
+package api
+
+import (
+	"benz-sniper/database"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetPeriodReport 按小时/日/周/月分组的报表（营业时区和日切时间由 REPORT_TIMEZONE / REPORT_DAY_CUTOFF 配置）
+// unit=hour/day（默认）/week/month，支持 strategy、session_id、from、to 等筛选参数（日期表示营业日的开始）
+func (h *Handler) GetPeriodReport(c *gin.Context) {
+	filter, err := historyFilterParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	unit := c.DefaultQuery("unit", database.PeriodDay)
+	if !database.ValidPeriod(unit) {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "unit 只能是 hour / day / week / month: " + unit})
+		return
+	}
+
+	report, err := h.manager.GetPeriodReport(filter, unit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "查询失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
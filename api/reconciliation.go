@@ -0,0 +1,63 @@
+package api
+
+import (
+	"benz-sniper/engine"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultReconcileDays 未指定 from 时默认对账的天数（含今天）
+const DefaultReconcileDays = 7
+
+// ReconciliationResponse 对账响应（差异明细按 account / kind 筛选后分页，汇总不受筛选影响）
+type ReconciliationResponse struct {
+	engine.ReconciliationReport
+	Total      int `json:"total"`       // 筛选后的差异条数
+	TotalPages int `json:"total_pages"` // 总页数
+	Page       int `json:"page"`        // 当前页码
+	PageSize   int `json:"page_size"`   // 每页大小
+}
+
+// GetReconciliation 用户下注与策略注额对账（from / to 默认最近 7 天，支持 account / kind 筛选差异）
+func (h *Handler) GetReconciliation(c *gin.Context) {
+	from, to, ok := timeRange(c)
+	if !ok {
+		return
+	}
+	if from.IsZero() {
+		now := h.clock.Now().Local()
+		from = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local).AddDate(0, 0, 1-DefaultReconcileDays)
+	}
+
+	report, err := h.manager.Reconcile(from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "对账失败: " + err.Error(),
+		})
+		return
+	}
+
+	account, kind := c.Query("account"), c.Query("kind")
+	filtered := make([]engine.Discrepancy, 0, len(report.Discrepancies))
+	for _, d := range report.Discrepancies {
+		if (account == "" || d.Account == account) && (kind == "" || d.Kind == kind) {
+			filtered = append(filtered, d)
+		}
+	}
+
+	page, pageSize := pageParams(c)
+	total := len(filtered)
+	start, end := pageBounds(total, page, pageSize)
+	report.Discrepancies = filtered[start:end]
+
+	c.JSON(http.StatusOK, ReconciliationResponse{
+		ReconciliationReport: *report,
+		Total:                total,
+		TotalPages:           (total + pageSize - 1) / pageSize,
+		Page:                 page,
+		PageSize:             pageSize,
+	})
+}
@@ -0,0 +1,132 @@
+package api_test
+
+import (
+	"benz-sniper/api"
+	"benz-sniper/database"
+	"benz-sniper/engine"
+	"benz-sniper/testutil"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestReconciliation(t *testing.T) {
+	testutil.EachDriver(t, testReconciliation)
+}
+
+// expectedStake 一期实盘记录的应下注额和应派彩
+func expectedStake(t *testing.T, h *testutil.Harness, roundID string) (stake, payout float64) {
+	t.Helper()
+	records, _, err := h.Store.ListHistory(database.HistoryQuery{
+		HistoryFilter: database.HistoryFilter{Status: database.StatusFilter(engine.StatusReal)},
+		Limit:         -1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range records {
+		if r.RoundID == roundID {
+			stake += r.BetAmount
+			payout += r.BetAmount + r.Profit
+		}
+	}
+	if stake == 0 {
+		t.Fatalf("期号 %s 没有实盘记录", roundID)
+	}
+	return stake, payout
+}
+
+func testReconciliation(t *testing.T, h *testutil.Harness) {
+	h.PromoteToReal("热门3码")
+	won := h.Draw(h.WinningCar("热门3码"))
+	lost := h.Draw(h.LosingCar())
+	wonStake, wonPayout := expectedStake(t, h, won)
+	lostStake, _ := expectedStake(t, h, lost)
+
+	upload := func(round, account string, bet, payout float64) {
+		h.JSON(http.MethodPost, "/api/user-bets", map[string]any{
+			"round_id": round, "user_account": account, "bet_amount": bet, "payout_amount": payout,
+		}, &struct{}{})
+	}
+	upload(won, "acc-1", wonStake, wonPayout)     // 完全一致
+	upload(won, "acc-2", wonStake*2, wonPayout*2) // 多下注，派彩按比例正确
+	upload(lost, "acc-1", lostStake, 50)          // 输了却有派彩
+	upload("99999", "acc-2", 100, 0)              // 没有实盘信号的期号
+	// acc-2 漏下 lost 期
+
+	var resp api.ReconciliationResponse
+	h.JSON(http.MethodGet, "/api/reconciliation", nil, &resp)
+
+	want := map[string]string{
+		won + "/acc-2":  engine.DiscrepancyOverBet,
+		lost + "/acc-1": engine.DiscrepancyPayoutMismatch,
+		lost + "/acc-2": engine.DiscrepancyMissingBet,
+		"99999/acc-2":   engine.DiscrepancyUnexpectedBet,
+	}
+	if resp.Total != len(want) {
+		t.Fatalf("差异 = %+v, want %d 条", resp.Discrepancies, len(want))
+	}
+	for _, d := range resp.Discrepancies {
+		if kind := want[d.RoundID+"/"+d.Account]; kind != d.Kind {
+			t.Errorf("差异 %s/%s = %s, want %s", d.RoundID, d.Account, d.Kind, kind)
+		}
+		if d.Kind == engine.DiscrepancyMissingBet && d.Expected != lostStake {
+			t.Errorf("漏单应下注 = %.2f, want %.2f", d.Expected, lostStake)
+		}
+	}
+
+	s := resp.Summary
+	if len(resp.Daily) != 1 || resp.Daily[0].Discrepancies != len(want) || s.ByKind[engine.DiscrepancyOverBet] != 1 {
+		t.Errorf("汇总 = %+v, 每日 = %+v", s, resp.Daily)
+	}
+	if len(resp.Accounts) != 2 || s.ActualStake != wonStake*3+lostStake+100 {
+		t.Errorf("账号 = %v, 实际下注 = %.2f", resp.Accounts, s.ActualStake)
+	}
+
+	var filtered api.ReconciliationResponse
+	h.JSON(http.MethodGet, "/api/reconciliation?account=acc-2&kind="+engine.DiscrepancyMissingBet, nil, &filtered)
+	if filtered.Total != 1 || filtered.Discrepancies[0].RoundID != lost {
+		t.Errorf("筛选差异 = %+v", filtered.Discrepancies)
+	}
+	if rec := h.Do(http.MethodGet, "/api/reconciliation?from=bad", nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("错误时间格式 = %d, want 400", rec.Code)
+	}
+}
+
+func TestReconciliationBusinessDay(t *testing.T) {
+	testutil.EachDriver(t, testReconciliationBusinessDay)
+}
+
+func testReconciliationBusinessDay(t *testing.T, h *testutil.Harness) {
+	// 早上 6 点日切：5:30 开奖的一期属于前一个营业日
+	if err := database.SetCalendar("Asia/Shanghai", 6); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = database.SetCalendar("", 0) })
+	shanghai, _ := time.LoadLocation("Asia/Shanghai")
+
+	h.PromoteToReal("热门3码")
+	// 开奖后立即上传，流水按上传时间归入同一营业日
+	upload := func(round string) {
+		stake, payout := expectedStake(t, h, round)
+		h.JSON(http.MethodPost, "/api/user-bets", map[string]any{
+			"round_id": round, "user_account": "acc-1", "bet_amount": stake, "payout_amount": payout,
+		}, &struct{}{})
+	}
+	h.Clock.Set(time.Date(2026, 1, 5, 5, 30, 0, 0, shanghai).Local())
+	upload(h.Draw(h.WinningCar("热门3码")))
+	h.Clock.Set(time.Date(2026, 1, 5, 6, 10, 0, 0, shanghai).Local())
+	upload(h.Draw(h.LosingCar()))
+
+	var resp api.ReconciliationResponse
+	h.JSON(http.MethodGet, "/api/reconciliation", nil, &resp)
+	if len(resp.Daily) != 2 || resp.Daily[0].Date != "2026-01-05" || resp.Daily[1].Date != "2026-01-04" {
+		t.Errorf("每日汇总 = %+v, want 营业日 2026-01-05 / 2026-01-04", resp.Daily)
+	}
+
+	var ledger api.AccountLedgerResponse
+	h.JSON(http.MethodGet, "/api/accounts/acc-1/ledger", nil, &ledger)
+	if len(ledger.Daily) != 2 || ledger.Daily[0].Date != "2026-01-05" || ledger.Daily[1].Date != "2026-01-04" {
+		t.Errorf("流水每日汇总 = %+v, want 营业日 2026-01-05 / 2026-01-04", ledger.Daily)
+	}
+}
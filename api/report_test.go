@@ -0,0 +1,349 @@
+package api_test
+
+import (
+	"benz-sniper/api"
+	"benz-sniper/database"
+	"benz-sniper/engine"
+	"benz-sniper/models"
+	"benz-sniper/testutil"
+	"net/http"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestReportReadsDailyStats(t *testing.T) {
+	testutil.EachDriver(t, testReportReadsDailyStats)
+}
+
+func testReportReadsDailyStats(t *testing.T, h *testutil.Harness) {
+	// 跨越零点，产生两天的记录
+	h.PromoteToReal("热门3码")
+	h.Draw(h.WinningCar("热门3码"))
+	h.Clock.Set(time.Date(2026, 1, 1, 23, 59, 0, 0, time.Local))
+	h.Draw(h.LosingCar())
+	h.Draw(h.WinningCar("均衡4码"))
+	assertReportMatchesHistory(t, h, 0)
+
+	days, err := h.Store.DailyStats(database.DailyStatFilter{Status: database.StatusFilter(engine.StatusReal)})
+	if err != nil || len(days) == 0 {
+		t.Fatalf("DailyStats() = %+v, err=%v", days, err)
+	}
+
+	// 归档：聚合随记录迁入会话
+	var cleared struct {
+		Session models.HistorySession `json:"session"`
+	}
+	h.JSON(http.MethodPost, "/api/history/clear", nil, &cleared)
+	var report api.ReportResponse
+	h.JSON(http.MethodGet, "/api/report", nil, &report)
+	if report.Summary.TotalBets != 0 || len(report.Daily) != 0 {
+		t.Errorf("归档后当前报表 = %+v, want 空", report)
+	}
+	assertReportMatchesHistory(t, h, cleared.Session.ID)
+
+	// 新记录与恢复的记录落在同一天，合并为一行
+	h.Draw(h.LosingCar())
+	sessionPath := "/api/sessions/" + strconv.FormatUint(uint64(cleared.Session.ID), 10)
+	h.JSON(http.MethodPost, sessionPath+"/restore", nil, &struct{}{})
+	assertReportMatchesHistory(t, h, 0)
+
+	// 重建结果与增量维护一致
+	before := dailyStatRows(t, h.Store)
+	if _, err := h.Manager.RebuildDailyStats(); err != nil {
+		t.Fatalf("RebuildDailyStats() error = %v", err)
+	}
+	if after := dailyStatRows(t, h.Store); !reflect.DeepEqual(before, after) {
+		t.Errorf("重建后聚合 = %+v, want %+v", after, before)
+	}
+
+	// 不在零点的时间范围回退到明细统计
+	from := time.Date(2026, 1, 1, 23, 0, 0, 0, time.Local)
+	want, err := h.Store.HistoryStats(database.HistoryFilter{Status: database.StatusFilter(engine.StatusReal), From: from})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := h.Manager.GetRangeReport(from, time.Time{}).Summary; got.TotalBets != want.Bets || got.TotalProfit != want.Profit {
+		t.Errorf("GetRangeReport(23:00) = %+v, want %+v", got, want)
+	}
+}
+
+// assertReportMatchesHistory 报表（读取每日聚合）与明细统计一致
+func assertReportMatchesHistory(t *testing.T, h *testutil.Harness, sessionID uint) {
+	t.Helper()
+	filter := database.HistoryFilter{Status: database.StatusFilter(engine.StatusReal), SessionID: sessionID}
+	total, err := h.Store.HistoryStats(filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byStrategy, err := h.Store.HistoryStatsByStrategy(filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total.Bets == 0 {
+		t.Fatal("没有实盘记录")
+	}
+
+	var report engine.SessionReport
+	h.JSON(http.MethodGet, "/api/sessions/"+strconv.FormatUint(uint64(sessionID), 10)+"/report", nil, &report)
+	if report.Summary.TotalBets != total.Bets || report.Summary.TotalWins != total.Wins || report.Summary.TotalProfit != total.Profit {
+		t.Errorf("summary = %+v, want %+v", report.Summary, total)
+	}
+	// 只列出有实盘注单的日期（纸面盈亏含当天的虚盘注单）
+	var want []engine.DailyReportItem
+	for _, day := range historyByDay(t, h.Store, sessionID) {
+		if day.TotalBets > 0 {
+			want = append(want, day)
+		}
+	}
+	if len(report.Daily) != len(want) {
+		t.Fatalf("daily = %+v, want %+v", report.Daily, want)
+	}
+	for i, item := range report.Daily {
+		item.WinRate = 0
+		if item != want[i] {
+			t.Errorf("daily[%d] = %+v, want %+v", i, item, want[i])
+		}
+	}
+	for _, stat := range byStrategy {
+		found := false
+		for _, item := range report.Strategies {
+			if item.Name == stat.Key {
+				found = true
+				if item.TotalBets != stat.Bets || item.TotalProfit != stat.Profit {
+					t.Errorf("strategy %s = %+v, want %+v", stat.Key, item, stat)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("报表缺少策略 %s", stat.Key)
+		}
+	}
+}
+
+// historyByDay 由明细按营业日分组的期望每日报表（按日期降序，不含命中率）
+func historyByDay(t *testing.T, store database.Store, sessionID uint) []engine.DailyReportItem {
+	t.Helper()
+	records, _, err := store.ListHistory(database.HistoryQuery{HistoryFilter: database.HistoryFilter{SessionID: sessionID}, Limit: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var days []engine.DailyReportItem
+	for _, r := range records {
+		day := database.StatDay(*r.CreatedAt)
+		if len(days) == 0 || days[len(days)-1].Date != day {
+			days = append(days, engine.DailyReportItem{Date: day})
+		}
+		item := &days[len(days)-1]
+		item.VirtualProfit += r.VirtualProfit
+		if r.Status == engine.StatusReal {
+			item.TotalBets++
+			item.TotalProfit += r.Profit
+			if r.Result == "赢" {
+				item.TotalWins++
+			}
+		}
+	}
+	return days
+}
+
+// dailyStatRows 当前全部聚合行（忽略ID和更新时间，便于比较）
+func dailyStatRows(t *testing.T, store database.Store) []models.DailyStrategyStat {
+	t.Helper()
+	var rows []models.DailyStrategyStat
+	for _, status := range []int{engine.StatusVirtual, engine.StatusReal} {
+		stats, err := store.DailyStats(database.DailyStatFilter{Status: database.StatusFilter(status)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, stat := range stats {
+			stat.ID, stat.UpdatedAt = 0, nil
+			rows = append(rows, stat)
+		}
+	}
+	return rows
+}
+
+func TestPerformanceReport(t *testing.T) {
+	testutil.EachDriver(t, testPerformanceReport)
+}
+
+func testPerformanceReport(t *testing.T, h *testutil.Harness) {
+	h.PromoteToReal("热门3码")
+	h.Draw(h.WinningCar("热门3码"))
+	h.Draw(h.LosingCar())
+
+	var status api.StatusResponse
+	h.JSON(http.MethodGet, "/api/status", nil, &status)
+	var report engine.PerformanceReport
+	h.JSON(http.MethodGet, "/api/report/performance", nil, &report)
+	realStats, err := h.Store.HistoryStats(database.HistoryFilter{Status: database.StatusFilter(engine.StatusReal)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	overall := report.Overall
+	if report.Mode != engine.PerformanceModeReal || overall.Bets != realStats.Bets || overall.TotalProfit != status.TotalRealProfit {
+		t.Errorf("overall = %+v, want %d 注, 盈亏 %.2f", overall, realStats.Bets, status.TotalRealProfit)
+	}
+	if overall.MaxDrawdown <= 0 || overall.LongestLossStreak < 1 || len(overall.Equity) != int(overall.Rounds) {
+		t.Errorf("实盘赢一期后输一期: overall = %+v", overall)
+	}
+	if last := overall.Equity[len(overall.Equity)-1]; last.Equity != overall.TotalProfit {
+		t.Errorf("收益曲线终点 = %.2f, want %.2f", last.Equity, overall.TotalProfit)
+	}
+
+	// 虚盘按实盘规则计算假设盈亏（记录中的盈亏为0）
+	h.JSON(http.MethodGet, "/api/report/performance?mode=virtual&strategy=热门3码&points=2", nil, &report)
+	if len(report.Strategies) != 1 || report.Strategies[0].Name != "热门3码" {
+		t.Fatalf("strategies = %+v, want 只有热门3码", report.Strategies)
+	}
+	virtual := report.Strategies[0]
+	if virtual.Bets == 0 || virtual.Wins == 0 || virtual.TotalProfit <= 0 || len(virtual.Equity) > 2 {
+		t.Errorf("虚盘绩效 = %+v, want 晋级前连赢有正收益且曲线不超过2点", virtual.PerformanceStats)
+	}
+
+	for _, query := range []string{"mode=all", "points=0", "points=abc", "from=bad"} {
+		if rec := h.Do(http.MethodGet, "/api/report/performance?"+query, nil); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s = %d, want 400", query, rec.Code)
+		}
+	}
+}
+
+func TestVirtualProfit(t *testing.T) {
+	testutil.EachDriver(t, testVirtualProfit)
+}
+
+func testVirtualProfit(t *testing.T, h *testutil.Harness) {
+	h.PromoteToReal("热门3码")
+	h.Draw(h.WinningCar("热门3码"))
+	h.Draw(h.LosingCar())
+
+	all, err := h.Store.HistoryStats(database.HistoryFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var status api.StatusResponse
+	h.JSON(http.MethodGet, "/api/status", nil, &status)
+	if status.TotalVirtualProfit != all.VirtualProfit || status.TotalVirtualProfit == status.TotalRealProfit {
+		t.Errorf("total_virtual_profit = %.2f, want %.2f（应与实盘 %.2f 不同）",
+			status.TotalVirtualProfit, all.VirtualProfit, status.TotalRealProfit)
+	}
+	var report api.ReportResponse
+	h.JSON(http.MethodGet, "/api/report", nil, &report)
+	if report.Summary.VirtualProfit != all.VirtualProfit || report.Summary.TotalProfit != status.TotalRealProfit {
+		t.Errorf("summary = %+v, want 纸面 %.2f / 实盘 %.2f", report.Summary, all.VirtualProfit, status.TotalRealProfit)
+	}
+
+	// 模拟升级前的记录（纸面盈亏为0），回填后恢复
+	records, _, err := h.Store.ListHistory(database.HistoryQuery{Limit: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	zeroed := make(map[uint]float64)
+	for _, r := range records {
+		zeroed[r.ID] = 0
+	}
+	if err := h.Store.SetVirtualProfits(zeroed); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.Store.RebuildDailyStats(); err != nil {
+		t.Fatal(err)
+	}
+	updated, err := h.Manager.BackfillVirtualProfit()
+	if err != nil || updated == 0 {
+		t.Fatalf("BackfillVirtualProfit() = %d, %v", updated, err)
+	}
+	h.JSON(http.MethodGet, "/api/report", nil, &report)
+	if report.Summary.VirtualProfit != all.VirtualProfit {
+		t.Errorf("回填后纸面盈亏 = %.2f, want %.2f", report.Summary.VirtualProfit, all.VirtualProfit)
+	}
+	if got := h.Manager.GetState().TotalVirtualProfit; got != all.VirtualProfit {
+		t.Errorf("回填后 TotalVirtualProfit = %.2f, want %.2f", got, all.VirtualProfit)
+	}
+	if again, err := h.Manager.BackfillVirtualProfit(); err != nil || again != 0 {
+		t.Errorf("重复回填 = %d, %v, want 0", again, err)
+	}
+}
+
+func TestPeriodReport(t *testing.T) {
+	testutil.EachDriver(t, testPeriodReport)
+}
+
+func testPeriodReport(t *testing.T, h *testutil.Harness) {
+	// 营业时区北京时间、早上 6 点日切（与进程时区无关）
+	if err := database.SetCalendar("Asia/Shanghai", 6); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = database.SetCalendar("", 0) })
+	shanghai, _ := time.LoadLocation("Asia/Shanghai")
+
+	h.PromoteToReal("热门3码")
+	// 时钟与生产环境一样使用本地时间（SQLite 按文本比较时间）
+	// 周一 5:30 属于周日的营业日，6:10 开始新的营业日和新的一周；2 月 1 日开始新的一月
+	h.Clock.Set(time.Date(2026, 1, 5, 5, 30, 0, 0, shanghai).Local())
+	h.Draw(h.WinningCar("热门3码"))
+	h.Clock.Set(time.Date(2026, 1, 5, 6, 10, 0, 0, shanghai).Local())
+	h.Draw(h.LosingCar())
+	h.Clock.Set(time.Date(2026, 2, 1, 6, 30, 0, 0, shanghai).Local())
+	h.Draw(h.WinningCar("均衡4码"))
+
+	// 按日分组与明细一致，日期为营业日
+	var days engine.PeriodReport
+	h.JSON(http.MethodGet, "/api/report/periods", nil, &days)
+	want := historyByDay(t, h.Store, 0)
+	if days.Unit != database.PeriodDay || days.Timezone != "Asia/Shanghai" || days.DayCutoff != 6 || len(days.Items) != len(want) {
+		t.Fatalf("periods = %+v, want %d 天", days, len(want))
+	}
+	for i, item := range days.Items {
+		if item.Period != want[i].Date || item.TotalBets != want[i].TotalBets || item.TotalProfit != want[i].TotalProfit ||
+			item.VirtualProfit != want[i].VirtualProfit {
+			t.Errorf("items[%d] = %+v, want %+v", i, item, want[i])
+		}
+	}
+	keys := func(report engine.PeriodReport) map[string]bool {
+		set := make(map[string]bool)
+		for _, item := range report.Items {
+			set[item.Period] = true
+		}
+		return set
+	}
+	if got := keys(days); !got["2026-01-04"] || !got["2026-01-05"] || !got["2026-02-01"] {
+		t.Errorf("营业日 = %v, want 包含 2026-01-04 / 2026-01-05 / 2026-02-01", got)
+	}
+	var report api.ReportResponse
+	h.JSON(http.MethodGet, "/api/report", nil, &report)
+	if days.Summary.TotalBets != report.Summary.TotalBets || days.Summary.VirtualProfit != report.Summary.VirtualProfit {
+		t.Errorf("summary = %+v, want %+v", days.Summary, report.Summary)
+	}
+
+	var weeks, months engine.PeriodReport
+	h.JSON(http.MethodGet, "/api/report/periods?unit=week", nil, &weeks)
+	if got := keys(weeks); !got["2026-W01"] || !got["2026-W02"] {
+		t.Errorf("周 = %v, want 包含 2026-W01 / 2026-W02", got)
+	}
+	for _, item := range weeks.Items {
+		if item.Period == "2026-W02" && !item.Start.Equal(time.Date(2026, 1, 5, 6, 0, 0, 0, shanghai)) {
+			t.Errorf("2026-W02 start = %v, want 周一 6:00", item.Start)
+		}
+	}
+	h.JSON(http.MethodGet, "/api/report/periods?unit=month", nil, &months)
+	if got := keys(months); !got["2026-01"] || !got["2026-02"] {
+		t.Errorf("月 = %v, want 包含 2026-01 / 2026-02", got)
+	}
+
+	// 整营业日范围读取每日聚合，按小时分组读取明细，两者合计一致
+	var fromDays, fromHours engine.PeriodReport
+	h.JSON(http.MethodGet, "/api/report/periods?from=2026-01-05", nil, &fromDays)
+	h.JSON(http.MethodGet, "/api/report/periods?unit=hour&from=2026-01-05", nil, &fromHours)
+	if fromDays.Summary != fromHours.Summary || fromDays.Summary.TotalBets == 0 || keys(fromDays)["2026-01-04"] {
+		t.Errorf("from=2026-01-05: 按日 %+v, 按小时 %+v", fromDays.Summary, fromHours.Summary)
+	}
+	if !keys(fromHours)["2026-01-05 06:00"] {
+		t.Errorf("小时 = %v, want 包含 2026-01-05 06:00", keys(fromHours))
+	}
+
+	if rec := h.Do(http.MethodGet, "/api/report/periods?unit=year", nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("unit=year = %d, want 400", rec.Code)
+	}
+}
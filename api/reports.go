@@ -0,0 +1,141 @@
+package api
+
+import (
+	"benz-sniper/database"
+	"benz-sniper/engine"
+	"benz-sniper/models"
+	"benz-sniper/reports"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 报表列表默认和最多返回的条数
+const (
+	defaultReportLimit = 30
+	maxReportLimit     = 400
+)
+
+// SetReports 设置定时报表服务（未设置时报表接口返回 503）
+func (h *Handler) SetReports(s *reports.Service) {
+	h.reports = s
+}
+
+// reportsReady 检查报表服务是否可用
+func (h *Handler) reportsReady(c *gin.Context) bool {
+	if h.reports == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"success": false, "message": "报表服务未启动"})
+		return false
+	}
+	return true
+}
+
+// ListReports 已生成的日报/周报列表（kind=eod/eow 筛选，limit 默认 30），按周期降序
+func (h *Handler) ListReports(c *gin.Context) {
+	if !h.reportsReady(c) {
+		return
+	}
+	kind := c.Query("kind")
+	if _, ok := engine.ReportPeriodUnit(kind); kind != "" && !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "kind 只能是 eod 或 eow: " + kind})
+		return
+	}
+	limit := defaultReportLimit
+	if value := c.Query("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "limit 必须是正整数: " + value})
+			return
+		}
+		limit = min(n, maxReportLimit)
+	}
+	list, err := h.reports.List(kind, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "查询失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "reports": list})
+}
+
+// DownloadReport 下载报表：format=html（默认，直接在浏览器打开）/ csv（附件）/ json（元数据）
+func (h *Handler) DownloadReport(c *gin.Context) {
+	if !h.reportsReady(c) {
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "报表ID格式错误"})
+		return
+	}
+	report, err := h.reports.Get(uint(id))
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "报表不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "查询失败: " + err.Error()})
+		return
+	}
+
+	switch format := c.DefaultQuery("format", "html"); format {
+	case "html":
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(report.HTML))
+	case "csv":
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="report-%s-%s.csv"`, report.Kind, report.Period))
+		c.Data(http.StatusOK, "text/csv; charset=utf-8", []byte(report.CSV))
+	case "json":
+		c.JSON(http.StatusOK, gin.H{"success": true, "report": report})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "format 只能是 html / csv / json: " + format})
+	}
+}
+
+// generateReportRequest 手动生成报表请求
+type generateReportRequest struct {
+	Kind    string `json:"kind"`    // eod / eow
+	At      string `json:"at"`      // 周期内的任一时间（RFC3339 或 YYYY-MM-DD 营业日），默认最近一个已结束的周期
+	Deliver bool   `json:"deliver"` // 生成后通过已配置的渠道投递
+}
+
+// GenerateReport 手动生成（或重新生成）日报/周报，同一周期已存在时覆盖
+func (h *Handler) GenerateReport(c *gin.Context) {
+	if !h.reportsReady(c) {
+		return
+	}
+	var req generateReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "参数错误: " + err.Error()})
+		return
+	}
+	unit, ok := engine.ReportPeriodUnit(req.Kind)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "kind 只能是 eod 或 eow: " + req.Kind})
+		return
+	}
+	at, err := parseTimeParam(req.At)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "at 格式错误: " + err.Error()})
+		return
+	}
+	if at.IsZero() {
+		cal := database.GetCalendar()
+		at = cal.PeriodStart(h.clock.Now(), unit).Add(-time.Nanosecond)
+	}
+
+	report, err := h.reports.Generate(c.Request.Context(), req.Kind, at, req.Deliver)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "生成报表失败: " + err.Error()})
+		return
+	}
+	h.recordAudit(c, AuditReportGenerate, nil, reportAudit(report))
+	c.JSON(http.StatusOK, gin.H{"success": true, "report": report})
+}
+
+// reportAudit 审计日志中记录的报表信息（不含正文）
+func reportAudit(r *models.Report) gin.H {
+	return gin.H{"id": r.ID, "kind": r.Kind, "period": r.Period, "delivered_at": r.DeliveredAt, "delivery_error": r.DeliveryError}
+}
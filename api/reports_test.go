@@ -0,0 +1,119 @@
+package api_test
+
+import (
+	"benz-sniper/database"
+	"benz-sniper/engine"
+	"benz-sniper/models"
+	"benz-sniper/notify"
+	"benz-sniper/reports"
+	"benz-sniper/testutil"
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScheduledReports(t *testing.T) {
+	smtpServer := testutil.NewSMTPServer(t)
+	webhook := testutil.NewWebhookServer(t)
+	opts := testutil.Options{Reports: reports.Options{
+		Kinds: []string{engine.ReportKindDaily},
+		Channels: []notify.Channel{
+			notify.NewSMTP(notify.SMTPConfig{Addr: smtpServer.Addr, From: "sniper@example.com", To: []string{"ops@example.com"}}),
+			notify.NewWebhook(webhook.URL),
+		},
+		BaseURL: "http://sniper.example.com",
+	}}
+	// 两种存储共用 SMTP/Webhook 替身，每次先清空
+	testutil.EachDriverWith(t, opts, func(t *testing.T, h *testutil.Harness) {
+		smtpServer.Reset()
+		webhook.Reset()
+		h.PromoteToReal("热门3码")
+		h.Draw(h.WinningCar("热门3码"))
+		h.DrawSpecial("大三元", "红奔驰", "红宝马", "红奥迪")
+		h.Draw(h.LosingCar())
+
+		// 营业日结束后等待 Delay 才生成当天的日报（之前补生成的是再前一天）
+		ctx := context.Background()
+		if err := h.Reports.RunDue(ctx, time.Date(2026, 1, 2, 0, 1, 0, 0, time.Local)); err != nil {
+			t.Fatalf("周期刚结束 RunDue() error = %v", err)
+		}
+		if _, err := h.Store.FindReport(engine.ReportKindDaily, "2026-01-01"); err != database.ErrNotFound {
+			t.Fatalf("Delay 之前不应生成 2026-01-01 的日报, err = %v", err)
+		}
+		if err := h.Reports.RunDue(ctx, time.Date(2026, 1, 2, 0, 10, 0, 0, time.Local)); err != nil {
+			t.Fatalf("RunDue() error = %v", err)
+		}
+
+		var list struct {
+			Reports []models.Report `json:"reports"`
+		}
+		h.JSON(http.MethodGet, "/api/reports?kind=eod", nil, &list)
+		if len(list.Reports) != 2 || list.Reports[1].Period != "2025-12-31" || list.Reports[1].Bets != 0 {
+			t.Fatalf("reports = %+v, want 2026-01-01 和 2025-12-31 两份日报", list.Reports)
+		}
+		report := list.Reports[0]
+		var period engine.PeriodReport
+		h.JSON(http.MethodGet, "/api/report/periods?from=2026-01-01&to=2026-01-02", nil, &period)
+		if report.Kind != engine.ReportKindDaily || report.Period != "2026-01-01" || report.DeliveredAt == nil || report.DeliveryError != "" ||
+			report.Bets != period.Summary.TotalBets || report.Profit != period.Summary.TotalProfit || report.VirtualProfit != period.Summary.VirtualProfit {
+			t.Errorf("report = %+v, want 与周期报表 %+v 一致且已投递", report, period.Summary)
+		}
+
+		// 邮件正文为 HTML，附带 CSV；Webhook 带下载链接
+		messages := smtpServer.Messages()
+		if len(messages) != 2 || !strings.Contains(messages[1].Data, "report-eod-2026-01-01.csv") {
+			t.Fatalf("邮件 = %d 封, want 2 封并附带 CSV", len(messages))
+		}
+		hooks := webhook.Payloads()
+		if len(hooks) != 2 || hooks[1]["period"] != "2026-01-01" ||
+			hooks[1]["csv_url"] != "http://sniper.example.com/api/reports/"+strconv.FormatUint(uint64(report.ID), 10)+"?format=csv" {
+			t.Errorf("webhook = %v", hooks)
+		}
+
+		// 已生成的周期不会重复生成和投递
+		if err := h.Reports.RunDue(ctx, time.Date(2026, 1, 2, 8, 0, 0, 0, time.Local)); err != nil {
+			t.Fatal(err)
+		}
+		if n := len(smtpServer.Messages()); n != 2 {
+			t.Errorf("重复 RunDue 后邮件 = %d 封, want 2", n)
+		}
+
+		// 下载 HTML / CSV
+		path := "/api/reports/" + strconv.FormatUint(uint64(report.ID), 10)
+		html := h.Do(http.MethodGet, path, nil)
+		if html.Code != http.StatusOK || !strings.HasPrefix(html.Header().Get("Content-Type"), "text/html") ||
+			!strings.Contains(html.Body.String(), "热门3码") || !strings.Contains(html.Body.String(), "大三元") {
+			t.Errorf("HTML = %d %s", html.Code, html.Header().Get("Content-Type"))
+		}
+		csv := h.Do(http.MethodGet, path+"?format=csv", nil)
+		if csv.Code != http.StatusOK || !strings.HasPrefix(csv.Body.String(), "\uFEFF") || !strings.Contains(csv.Body.String(), "热门3码,") ||
+			!strings.Contains(csv.Header().Get("Content-Disposition"), "report-eod-2026-01-01.csv") {
+			t.Errorf("CSV = %d %q", csv.Code, csv.Body.String())
+		}
+		for query, code := range map[string]int{path + "?format=pdf": http.StatusBadRequest, "/api/reports/999999": http.StatusNotFound, "/api/reports/abc": http.StatusBadRequest} {
+			if rec := h.Do(http.MethodGet, query, nil); rec.Code != code {
+				t.Errorf("GET %s = %d, want %d", query, rec.Code, code)
+			}
+		}
+
+		// 手动生成周报（不投递）；投递失败记录在报表上
+		var generated struct {
+			Report models.Report `json:"report"`
+		}
+		h.JSON(http.MethodPost, "/api/reports", map[string]any{"kind": "eow", "at": "2026-01-01"}, &generated)
+		if generated.Report.Period != "2026-W01" || generated.Report.Bets != report.Bets || generated.Report.DeliveredAt != nil {
+			t.Errorf("周报 = %+v", generated.Report)
+		}
+		smtpServer.Reject("mailbox full")
+		h.JSON(http.MethodPost, "/api/reports", map[string]any{"kind": "eod", "at": "2026-01-01", "deliver": true}, &generated)
+		if generated.Report.ID != report.ID || generated.Report.DeliveredAt != nil || !strings.Contains(generated.Report.DeliveryError, "mailbox full") {
+			t.Errorf("投递失败的日报 = %+v", generated.Report)
+		}
+		if rec := h.Do(http.MethodPost, "/api/reports", map[string]any{"kind": "eom"}); rec.Code != http.StatusBadRequest {
+			t.Errorf("kind=eom = %d, want 400", rec.Code)
+		}
+	})
+}
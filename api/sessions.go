@@ -0,0 +1,113 @@
+package api
+
+import (
+	"benz-sniper/database"
+	"benz-sniper/models"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListSessions 归档会话列表（同时返回当前记录的实盘统计）
+func (h *Handler) ListSessions(c *gin.Context) {
+	sessions, err := h.manager.ListSessions()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "查询失败: " + err.Error(),
+		})
+		return
+	}
+	if sessions == nil {
+		sessions = []models.HistorySession{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"current":  h.manager.GetReportSummary(),
+		"sessions": sessions,
+	})
+}
+
+// GetSessionReport 归档会话报表（id=0 为当前记录）
+func (h *Handler) GetSessionReport(c *gin.Context) {
+	id, ok := sessionIDParam(c)
+	if !ok {
+		return
+	}
+	report, err := h.manager.GetSessionReport(id)
+	if err != nil {
+		sessionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// RestoreSession 恢复归档会话（记录并入当前历史）
+func (h *Handler) RestoreSession(c *gin.Context) {
+	id, ok := sessionIDParam(c)
+	if !ok {
+		return
+	}
+	session, err := h.manager.RestoreSession(id)
+	if err != nil {
+		sessionError(c, err)
+		return
+	}
+	h.recordAudit(c, AuditSessionRestore, session, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "归档会话已恢复",
+		"session": session,
+	})
+}
+
+// PurgeSession 彻底删除归档会话及其记录
+func (h *Handler) PurgeSession(c *gin.Context) {
+	id, ok := sessionIDParam(c)
+	if !ok {
+		return
+	}
+	session, err := h.manager.PurgeSession(id)
+	if err != nil {
+		sessionError(c, err)
+		return
+	}
+	h.recordAudit(c, AuditSessionPurge, session, nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "归档会话已彻底删除",
+		"session": session,
+	})
+}
+
+// sessionIDParam 解析路径中的会话ID（失败时已写入 400 响应）
+func sessionIDParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "无效的会话ID",
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// sessionError 归档会话操作失败响应
+func sessionError(c *gin.Context, err error) {
+	if errors.Is(err, database.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "归档会话不存在",
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"success": false,
+		"message": "操作失败: " + err.Error(),
+	})
+}
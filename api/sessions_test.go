@@ -0,0 +1,95 @@
+package api_test
+
+import (
+	"benz-sniper/api"
+	"benz-sniper/engine"
+	"benz-sniper/models"
+	"benz-sniper/testutil"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestClearArchivesIntoSession(t *testing.T) {
+	testutil.EachDriver(t, testClearArchivesIntoSession)
+}
+
+func testClearArchivesIntoSession(t *testing.T, h *testutil.Harness) {
+	h.PromoteToReal("热门3码")
+	h.Draw(h.WinningCar("热门3码"))
+	var status api.StatusResponse
+	h.JSON(http.MethodGet, "/api/status", nil, &status)
+	realProfit := status.TotalRealProfit
+
+	var before api.HistoryResponse
+	h.JSON(http.MethodGet, "/api/history", nil, &before)
+
+	var cleared struct {
+		Success bool                  `json:"success"`
+		Session models.HistorySession `json:"session"`
+	}
+	h.JSON(http.MethodPost, "/api/history/clear", map[string]any{"name": "第一轮"}, &cleared)
+	session := cleared.Session
+	if session.ID == 0 || session.Name != "第一轮" || session.Bets != before.Total || session.RealProfit != realProfit {
+		t.Fatalf("归档会话 = %+v, want %d 条, 实盘 %.2f", session, before.Total, realProfit)
+	}
+	sessionPath := "/api/sessions/" + strconv.FormatUint(uint64(session.ID), 10)
+
+	// 当前视图从零开始
+	h.JSON(http.MethodGet, "/api/status", nil, &status)
+	if status.TotalRealProfit != 0 || h.Strategy("热门3码").RealProfit != 0 {
+		t.Errorf("归档后 total_real_profit = %.2f, want 0", status.TotalRealProfit)
+	}
+	var current api.HistoryResponse
+	h.JSON(http.MethodGet, "/api/history", nil, &current)
+	if current.Total != 0 {
+		t.Errorf("归档后当前 history total = %d, want 0", current.Total)
+	}
+
+	// 归档记录仍可查询
+	var archived api.HistoryResponse
+	h.JSON(http.MethodGet, "/api/history?session_id="+strconv.FormatUint(uint64(session.ID), 10), nil, &archived)
+	if archived.Total != before.Total {
+		t.Errorf("归档 history total = %d, want %d", archived.Total, before.Total)
+	}
+	var report engine.SessionReport
+	h.JSON(http.MethodGet, sessionPath+"/report", nil, &report)
+	if report.Session == nil || report.Summary.TotalProfit != realProfit {
+		t.Errorf("会话报表 = %+v, want 实盘盈利 %.2f", report, realProfit)
+	}
+	var list struct {
+		Sessions []models.HistorySession `json:"sessions"`
+	}
+	h.JSON(http.MethodGet, "/api/sessions", nil, &list)
+	if len(list.Sessions) != 1 || list.Sessions[0].ID != session.ID {
+		t.Errorf("sessions = %+v", list.Sessions)
+	}
+
+	// 恢复：记录并入当前历史
+	h.Draw(h.LosingCar())
+	h.JSON(http.MethodGet, "/api/history", nil, &current)
+	newRecords := current.Total
+	h.JSON(http.MethodPost, sessionPath+"/restore", nil, &struct{}{})
+	h.JSON(http.MethodGet, "/api/history", nil, &current)
+	if current.Total != before.Total+newRecords {
+		t.Errorf("恢复后 history total = %d, want %d", current.Total, before.Total+newRecords)
+	}
+	if rec := h.Do(http.MethodGet, sessionPath+"/report", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("恢复后会话报表 = %d, want 404", rec.Code)
+	}
+
+	// 彻底删除：记录不可恢复
+	h.JSON(http.MethodPost, "/api/history/clear", nil, &cleared)
+	path := "/api/sessions/" + strconv.FormatUint(uint64(cleared.Session.ID), 10)
+	if rec := h.DoWith(http.MethodDelete, path, nil, nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("匿名删除会话 = %d, want 401", rec.Code)
+	}
+	h.JSON(http.MethodDelete, path, nil, &struct{}{})
+	h.JSON(http.MethodGet, "/api/history?session_id="+strconv.FormatUint(uint64(cleared.Session.ID), 10), nil, &archived)
+	if archived.Total != 0 {
+		t.Errorf("删除后归档 history total = %d, want 0", archived.Total)
+	}
+	if rec := h.Do(http.MethodDelete, path, nil); rec.Code != http.StatusNotFound {
+		t.Errorf("重复删除 = %d, want 404", rec.Code)
+	}
+}
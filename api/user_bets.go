@@ -0,0 +1,86 @@
+package api
+
+import (
+	"benz-sniper/database"
+	"benz-sniper/engine"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UploadUserBetsRequest 批量上传用户派彩请求（每条的 idempotency_key 独立生效）
+type UploadUserBetsRequest struct {
+	Bets []UploadUserBetRequest `json:"bets" binding:"required,min=1,dive"`
+}
+
+// UploadUserBetsResponse 批量上传结果
+type UploadUserBetsResponse struct {
+	Success   bool                   `json:"success"`
+	Results   []engine.UserBetResult `json:"results"`   // 与请求顺序一致
+	Created   int                    `json:"created"`   // 新建条数
+	Updated   int                    `json:"updated"`   // 覆盖条数
+	Unchanged int                    `json:"unchanged"` // 幂等重放条数
+}
+
+// userBetError 派彩上传错误响应（校验失败返回 400 和字段级错误，幂等键冲突返回 409）
+func userBetError(c *gin.Context, err error) {
+	var invalid *engine.ValidationError
+	if errors.As(err, &invalid) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "派彩记录校验失败",
+			"errors":  invalid.Errors,
+		})
+		return
+	}
+	if errors.Is(err, database.ErrDuplicate) {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"message": "幂等键已被其他期号或账号使用，或同一记录正在并发上传，请重试",
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"success": false,
+		"message": "保存失败: " + err.Error(),
+	})
+}
+
+// UploadUserBets 批量上传用户派彩记录（全部校验通过才写入）
+func (h *Handler) UploadUserBets(c *gin.Context) {
+	var req UploadUserBetsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "请求参数错误: " + err.Error(),
+		})
+		return
+	}
+
+	records := make([]engine.UserBetRecord, 0, len(req.Bets))
+	for _, bet := range req.Bets {
+		records = append(records, bet.record())
+	}
+	results, err := h.manager.SaveUserBets(records)
+	if err != nil {
+		userBetError(c, err)
+		return
+	}
+
+	resp := UploadUserBetsResponse{Success: true, Results: results}
+	for _, r := range results {
+		switch r.Status {
+		case database.UpsertCreated:
+			resp.Created++
+		case database.UpsertUpdated:
+			resp.Updated++
+		case database.UpsertUnchanged:
+			resp.Unchanged++
+		}
+	}
+	if resp.Created+resp.Updated > 0 {
+		h.recordAudit(c, AuditUserBetBatch, nil, resp)
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,165 @@
+package api_test
+
+import (
+	"benz-sniper/api"
+	"benz-sniper/database"
+	"benz-sniper/engine"
+	"benz-sniper/testutil"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestUserBetUpload(t *testing.T) {
+	testutil.EachDriver(t, testUserBetUpload)
+}
+
+// fieldErrors 读取 400 响应中的字段错误
+func fieldErrors(t *testing.T, h *testutil.Harness, path string, body any) []engine.FieldError {
+	t.Helper()
+	rec := h.Do(http.MethodPost, path, body)
+	var resp struct {
+		Errors []engine.FieldError `json:"errors"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != http.StatusBadRequest {
+		t.Fatalf("%s = %d: %s, want 400", path, rec.Code, rec.Body.String())
+	}
+	return resp.Errors
+}
+
+func testUserBetUpload(t *testing.T, h *testutil.Harness) {
+	round := h.Draw("红宝马")
+	other := h.Draw("黄大众")
+
+	// 红宝马赔率 22：派彩 = 22 × 100
+	bet := map[string]any{
+		"round_id": round, "user_account": "acc-1", "bet_amount": 150, "payout_amount": 2200, "balance": 1000,
+		"items":           []map[string]any{{"car": "红宝马", "stake": 100}, {"car": "绿奔驰", "stake": 50}},
+		"idempotency_key": "upload-1",
+	}
+	var first struct {
+		Result engine.UserBetResult `json:"result"`
+	}
+	h.JSON(http.MethodPost, "/api/user-bets", bet, &first)
+	if first.Result.Status != database.UpsertCreated {
+		t.Fatalf("首次上传 = %+v, want created", first.Result)
+	}
+
+	// 同一幂等键重放不修改
+	var replay struct {
+		Result engine.UserBetResult `json:"result"`
+	}
+	h.JSON(http.MethodPost, "/api/user-bets", bet, &replay)
+	if replay.Result.Status != database.UpsertUnchanged || replay.Result.ID != first.Result.ID {
+		t.Errorf("重放 = %+v, want unchanged #%d", replay.Result, first.Result.ID)
+	}
+
+	// 同一期号和账号换一个键重新上传：覆盖原记录和明细
+	bet["idempotency_key"] = "upload-2"
+	bet["bet_amount"], bet["payout_amount"] = 100, 2200
+	bet["items"] = []map[string]any{{"car": "红宝马", "stake": 100}}
+	var updated struct {
+		Result engine.UserBetResult `json:"result"`
+	}
+	h.JSON(http.MethodPost, "/api/user-bets", bet, &updated)
+	if updated.Result.Status != database.UpsertUpdated || updated.Result.ID != first.Result.ID {
+		t.Errorf("覆盖 = %+v, want updated #%d", updated.Result, first.Result.ID)
+	}
+	bets, err := h.Store.UserBetsByRounds([]string{round})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bets) != 1 || bets[0].BetAmount != 100 || len(bets[0].Items) != 1 || bets[0].Items[0].Car != "红宝马" {
+		t.Errorf("覆盖后记录 = %+v", bets)
+	}
+
+	// 幂等键用于其他期号
+	if rec := h.Do(http.MethodPost, "/api/user-bets", map[string]any{
+		"round_id": other, "user_account": "acc-1", "bet_amount": 10, "idempotency_key": "upload-2",
+	}); rec.Code != http.StatusConflict {
+		t.Errorf("幂等键冲突 = %d, want 409", rec.Code)
+	}
+
+	// 明细合计不符、车型未知、派彩与开奖结果不符
+	errs := fieldErrors(t, h, "/api/user-bets", map[string]any{
+		"round_id": round, "user_account": "acc-2", "bet_amount": 200, "payout_amount": 0,
+		"items": []map[string]any{{"car": "红宝马", "stake": 100}, {"car": "蓝奔驰", "stake": 100}},
+	})
+	if len(errs) != 1 || errs[0].Field != "items[1].car" {
+		t.Errorf("未知车型错误 = %+v", errs)
+	}
+	errs = fieldErrors(t, h, "/api/user-bets", map[string]any{
+		"round_id": round, "user_account": "acc-2", "bet_amount": 200, "payout_amount": 0,
+		"items": []map[string]any{{"car": "红宝马", "stake": 100}},
+	})
+	if len(errs) != 2 || errs[0].Field != "items" || errs[1].Field != "payout_amount" {
+		t.Errorf("合计/派彩错误 = %+v", errs)
+	}
+
+	// 批量：任意一条校验失败则全部不写入
+	errs = fieldErrors(t, h, "/api/user-bets/batch", map[string]any{"bets": []map[string]any{
+		{"round_id": other, "user_account": "acc-2", "bet_amount": 40, "payout_amount": 160,
+			"items": []map[string]any{{"car": "黄大众", "stake": 40}}},
+		{"round_id": "99999", "user_account": "acc-2", "bet_amount": 10,
+			"items": []map[string]any{{"car": "红宝马", "stake": 10}}},
+	}})
+	if len(errs) != 1 || errs[0].Field != "bets[1].round_id" {
+		t.Errorf("批量错误 = %+v, want 未开奖期号", errs)
+	}
+	if bets, _ := h.Store.UserBetsByRounds([]string{other}); len(bets) != 0 {
+		t.Errorf("批量校验失败仍写入了 %d 条", len(bets))
+	}
+
+	// 批量：同一期号和账号重复，错误信息与字段名一样使用 bets[i]
+	errs = fieldErrors(t, h, "/api/user-bets/batch", map[string]any{"bets": []map[string]any{
+		{"round_id": other, "user_account": "acc-2", "bet_amount": 10},
+		{"round_id": other, "user_account": "acc-2", "bet_amount": 20},
+	}})
+	if len(errs) != 1 || errs[0].Field != "bets[1].round_id" || !strings.Contains(errs[0].Message, "bets[0]") {
+		t.Errorf("重复记录错误 = %+v, want 指向 bets[0]", errs)
+	}
+
+	// 批量：校验通过但写入时第二条的幂等键冲突，第一条也不保存
+	if rec := h.Do(http.MethodPost, "/api/user-bets/batch", map[string]any{"bets": []map[string]any{
+		{"round_id": other, "user_account": "acc-3", "bet_amount": 10},
+		{"round_id": other, "user_account": "acc-4", "bet_amount": 10, "idempotency_key": "upload-2"},
+	}}); rec.Code != http.StatusConflict {
+		t.Errorf("批量幂等键冲突 = %d, want 409", rec.Code)
+	}
+	if bets, _ := h.Store.UserBetsByRounds([]string{other}); len(bets) != 0 {
+		t.Errorf("批量写入失败仍保存了 %d 条", len(bets))
+	}
+
+	var batch api.UploadUserBetsResponse
+	h.JSON(http.MethodPost, "/api/user-bets/batch", map[string]any{"bets": []map[string]any{
+		{"round_id": other, "user_account": "acc-2", "bet_amount": 40, "payout_amount": 160,
+			"items": []map[string]any{{"car": "黄大众", "stake": 40}}},
+		{"round_id": round, "user_account": "acc-1", "bet_amount": 100, "payout_amount": 2200, "idempotency_key": "upload-2"},
+		{"round_id": round, "user_account": "acc-2", "bet_amount": 50},
+	}}, &batch)
+	if batch.Created != 2 || batch.Unchanged != 1 || len(batch.Results) != 3 || batch.Results[1].Status != database.UpsertUnchanged {
+		t.Errorf("批量结果 = %+v", batch)
+	}
+
+	var page api.HistoryResponse
+	h.JSON(http.MethodGet, "/api/history?page_size=100", nil, &page)
+	for _, r := range page.Records {
+		if r.RoundID == other && (len(r.UserBets) != 1 || len(r.UserBets[0].Items) != 1) {
+			t.Errorf("历史记录中的派彩 = %+v, want 带明细的 acc-2", r.UserBets)
+		}
+	}
+
+	// 抓取的车型名称带空白时按清理后的名称校验派彩（与结算一致）
+	padded := h.Draw(" 黄宝马 ")
+	var cleaned struct {
+		Result engine.UserBetResult `json:"result"`
+	}
+	h.JSON(http.MethodPost, "/api/user-bets", map[string]any{
+		"round_id": padded, "user_account": "acc-1", "bet_amount": 10, "payout_amount": engine.REAL_ODDS["黄宝马"] * 10,
+		"items": []map[string]any{{"car": "黄宝马", "stake": 10}},
+	}, &cleaned)
+	if cleaned.Result.Status != database.UpsertCreated {
+		t.Errorf("清理名称后上传 = %+v, want created", cleaned.Result)
+	}
+}
@@ -0,0 +1,337 @@
+package auth
+
+import (
+	"benz-sniper/database"
+	"benz-sniper/models"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Role 角色（权限依次递增）
+type Role string
+
+const (
+	RoleViewer   Role = "viewer"   // 只读：状态、预测、历史、报表
+	RoleOperator Role = "operator" // 操作员：只读 + 上传用户派彩
+	RoleAdmin    Role = "admin"    // 管理员：修改配置、清空历史、管理密钥和用户
+)
+
+// roleLevels 角色权限等级
+var roleLevels = map[Role]int{
+	RoleViewer:   1,
+	RoleOperator: 2,
+	RoleAdmin:    3,
+}
+
+// APIKeyPrefix API 密钥明文前缀（便于在日志和配置中识别）
+const APIKeyPrefix = "bzk_"
+
+// MinPasswordLength 密码最小长度
+const MinPasswordLength = 8
+
+var (
+	// ErrInvalidCredentials 用户名、密码或令牌无效
+	ErrInvalidCredentials = errors.New("认证失败")
+	// ErrEmptyUsername 用户名为空
+	ErrEmptyUsername = errors.New("用户名不能为空")
+	// ErrInvalidRole 未知角色
+	ErrInvalidRole = errors.New("未知角色")
+	// ErrWeakPassword 密码太短
+	ErrWeakPassword = fmt.Errorf("密码长度至少 %d 位", MinPasswordLength)
+)
+
+// ParseRole 解析角色名
+func ParseRole(s string) (Role, error) {
+	role := Role(strings.ToLower(strings.TrimSpace(s)))
+	if _, ok := roleLevels[role]; !ok {
+		return "", ErrInvalidRole
+	}
+	return role, nil
+}
+
+// Allows 当前角色是否具备 required 的权限
+func (r Role) Allows(required Role) bool {
+	level, ok := roleLevels[r]
+	return ok && level >= roleLevels[required]
+}
+
+// 身份来源
+const (
+	KindAnonymous = "anonymous"
+	KindUser      = "user"
+	KindAPIKey    = "api_key"
+)
+
+// Principal 已认证的调用方
+type Principal struct {
+	Kind   string `json:"kind"` // anonymous / user / api_key
+	Name   string `json:"name"` // 用户名或密钥名称
+	Role   Role   `json:"role"`
+	UserID uint   `json:"user_id,omitempty"`
+	KeyID  uint   `json:"key_id,omitempty"`
+}
+
+// Options 认证参数
+type Options struct {
+	SessionTTL    time.Duration // 登录会话有效期（默认 24 小时）
+	AnonymousRole Role          // 未携带凭证时的角色（为空表示必须登录）
+}
+
+// Clock 当前时间来源（engine.Clock 满足此接口，测试时可注入手动时钟）
+type Clock interface {
+	Now() time.Time
+}
+
+// Service 认证服务（用户密码登录、会话和 API 密钥）
+type Service struct {
+	store database.AuthStore
+	clock Clock
+	opts  Options
+}
+
+// NewService 创建认证服务
+func NewService(store database.AuthStore, clock Clock, opts Options) *Service {
+	if opts.SessionTTL <= 0 {
+		opts.SessionTTL = 24 * time.Hour
+	}
+	return &Service{store: store, clock: clock, opts: opts}
+}
+
+// SessionTTL 登录会话有效期
+func (s *Service) SessionTTL() time.Duration {
+	return s.opts.SessionTTL
+}
+
+// Anonymous 未携带凭证时的身份（不允许匿名访问时返回 nil）
+func (s *Service) Anonymous() *Principal {
+	if s.opts.AnonymousRole == "" {
+		return nil
+	}
+	return &Principal{Kind: KindAnonymous, Name: KindAnonymous, Role: s.opts.AnonymousRole}
+}
+
+// Bootstrap 启动时创建管理员账号和管理员 API 密钥（已存在则跳过）
+func (s *Service) Bootstrap(username, password, apiKey string) error {
+	if password != "" {
+		_, err := s.CreateUser(username, password, RoleAdmin)
+		switch {
+		case err == nil:
+			slog.Info("🔐 已创建管理员账号", "user", username)
+		case errors.Is(err, database.ErrDuplicate):
+			// 已存在：不覆盖密码，避免重启时回滚管理员修改过的密码
+		default:
+			return fmt.Errorf("创建管理员账号失败: %w", err)
+		}
+	}
+
+	if apiKey != "" {
+		err := s.store.CreateAPIKey(&models.APIKey{
+			Name:      "bootstrap",
+			Prefix:    keyPrefix(apiKey),
+			KeyHash:   hashToken(apiKey),
+			Role:      string(RoleAdmin),
+			CreatedBy: "env",
+		})
+		switch {
+		case err == nil:
+			slog.Info("🔐 已注册管理员 API 密钥（来自环境变量）")
+		case errors.Is(err, database.ErrDuplicate):
+		default:
+			return fmt.Errorf("注册管理员 API 密钥失败: %w", err)
+		}
+	}
+
+	users, err := s.store.ListUsers()
+	if err != nil {
+		return err
+	}
+	keys, err := s.store.ListAPIKeys()
+	if err != nil {
+		return err
+	}
+	if len(users) == 0 && len(keys) == 0 {
+		slog.Warn("⚠️ 未配置管理员（ADMIN_PASSWORD / ADMIN_API_KEY），无法修改配置或清空历史")
+	}
+	return nil
+}
+
+// CreateUser 创建用户（用户名已存在返回 database.ErrDuplicate）
+func (s *Service) CreateUser(username, password string, role Role) (*models.User, error) {
+	username = strings.TrimSpace(username)
+	if username == "" {
+		return nil, ErrEmptyUsername
+	}
+	if len(password) < MinPasswordLength {
+		return nil, ErrWeakPassword
+	}
+	if _, ok := roleLevels[role]; !ok {
+		return nil, ErrInvalidRole
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	user := &models.User{
+		Username:     username,
+		PasswordHash: string(hash),
+		Role:         string(role),
+	}
+	if err := s.store.CreateUser(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// ListUsers 全部用户
+func (s *Service) ListUsers() ([]models.User, error) {
+	return s.store.ListUsers()
+}
+
+// Login 用户名密码登录，返回会话令牌（明文只返回这一次）
+func (s *Service) Login(username, password string) (string, *models.UserSession, *models.User, error) {
+	user, err := s.store.GetUserByName(strings.TrimSpace(username))
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			return "", nil, nil, ErrInvalidCredentials
+		}
+		return "", nil, nil, err
+	}
+	if user.Disabled || bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return "", nil, nil, ErrInvalidCredentials
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return "", nil, nil, err
+	}
+	now := s.clock.Now()
+	session := &models.UserSession{
+		TokenHash: hashToken(token),
+		UserID:    user.ID,
+		ExpiresAt: now.Add(s.opts.SessionTTL),
+		CreatedAt: &now,
+	}
+	if err := s.store.CreateSession(session); err != nil {
+		return "", nil, nil, err
+	}
+
+	// 顺便清理过期会话
+	if err := s.store.DeleteExpiredSessions(now); err != nil {
+		slog.Warn("⚠️ 清理过期会话失败", "error", err)
+	}
+	return token, session, user, nil
+}
+
+// Logout 注销会话
+func (s *Service) Logout(token string) error {
+	return s.store.DeleteSession(hashToken(token))
+}
+
+// AuthenticateSession 校验会话令牌
+func (s *Service) AuthenticateSession(token string) (*Principal, error) {
+	session, err := s.store.GetSessionByHash(hashToken(token))
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+	if !s.clock.Now().Before(session.ExpiresAt) {
+		return nil, ErrInvalidCredentials
+	}
+
+	user, err := s.store.GetUser(session.UserID)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+	if user.Disabled {
+		return nil, ErrInvalidCredentials
+	}
+	return &Principal{Kind: KindUser, Name: user.Username, Role: Role(user.Role), UserID: user.ID}, nil
+}
+
+// CreateAPIKey 生成 API 密钥（明文只返回这一次）
+func (s *Service) CreateAPIKey(name string, role Role, createdBy string) (string, *models.APIKey, error) {
+	if _, ok := roleLevels[role]; !ok {
+		return "", nil, ErrInvalidRole
+	}
+	token, err := randomToken()
+	if err != nil {
+		return "", nil, err
+	}
+	plain := APIKeyPrefix + token
+	key := &models.APIKey{
+		Name:      strings.TrimSpace(name),
+		Prefix:    keyPrefix(plain),
+		KeyHash:   hashToken(plain),
+		Role:      string(role),
+		CreatedBy: createdBy,
+	}
+	if err := s.store.CreateAPIKey(key); err != nil {
+		return "", nil, err
+	}
+	return plain, key, nil
+}
+
+// ListAPIKeys 全部 API 密钥（不含明文）
+func (s *Service) ListAPIKeys() ([]models.APIKey, error) {
+	return s.store.ListAPIKeys()
+}
+
+// RevokeAPIKey 吊销 API 密钥
+func (s *Service) RevokeAPIKey(id uint) error {
+	return s.store.RevokeAPIKey(id, s.clock.Now())
+}
+
+// AuthenticateAPIKey 校验 API 密钥
+func (s *Service) AuthenticateAPIKey(plain string) (*Principal, error) {
+	key, err := s.store.GetAPIKeyByHash(hashToken(plain))
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+	if key.RevokedAt != nil {
+		return nil, ErrInvalidCredentials
+	}
+	if err := s.store.TouchAPIKey(key.ID, s.clock.Now()); err != nil {
+		slog.Warn("⚠️ 更新密钥使用时间失败", "error", err)
+	}
+	return &Principal{Kind: KindAPIKey, Name: key.Name, Role: Role(key.Role), KeyID: key.ID}, nil
+}
+
+// randomToken 生成 32 字节随机令牌（十六进制）
+func randomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// hashToken 令牌哈希（令牌本身是高熵随机串，SHA-256 即可）
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// keyPrefix 密钥明文前缀（用于列表展示）
+func keyPrefix(plain string) string {
+	if len(plain) > 12 {
+		return plain[:12]
+	}
+	return plain
+}
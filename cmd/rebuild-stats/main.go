@@ -0,0 +1,54 @@
+package main
+
+import (
+	"benz-sniper/config"
+	"benz-sniper/database"
+	"benz-sniper/engine"
+	"benz-sniper/logging"
+	"flag"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// 每日策略聚合重建：清空 daily_strategy_stats 并由 strategy_history 重新汇总
+// 升级后回填历史数据，或聚合与明细不一致时修复（在单个事务内完成，报表不会读到半成品）
+//
+//	go run ./cmd/rebuild-stats
+//	go run ./cmd/rebuild-stats -virtual-profit   # 先按预测和开奖结果回填 virtual_profit（升级前的记录该列为0）
+func main() {
+	virtualProfit := flag.Bool("virtual-profit", false, "重建前先回填全部记录的纸面盈亏 virtual_profit")
+	flag.Parse()
+
+	cfg := config.Load()
+	if err := logging.Setup(cfg.LogFormat, cfg.LogLevel); err != nil {
+		fatal("❌ 日志初始化失败", "error", err)
+	}
+	if cfg.DBDriver == database.DriverMemory {
+		fatal("❌ 内存存储没有持久化数据，无需重建")
+	}
+	if err := database.Init(cfg); err != nil {
+		fatal("❌ 数据库初始化失败", "error", err)
+	}
+	defer database.Close()
+
+	start := time.Now()
+	if *virtualProfit {
+		updated, err := engine.NewStrategyManager(database.GetStore()).BackfillVirtualProfit()
+		if err != nil {
+			fatal("❌ 回填纸面盈亏失败", "error", err)
+		}
+		slog.Info("✅ 纸面盈亏已回填", "records", updated)
+	}
+	rows, err := database.GetStore().RebuildDailyStats()
+	if err != nil {
+		fatal("❌ 重建每日策略聚合失败", "error", err)
+	}
+	slog.Info("✅ 每日策略聚合已重建", "rows", rows, "elapsed", time.Since(start).Round(time.Millisecond))
+}
+
+// fatal 记录错误并退出
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
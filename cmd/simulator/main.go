@@ -0,0 +1,116 @@
+package main
+
+import (
+	"benz-sniper/config"
+	"benz-sniper/database"
+	"benz-sniper/engine"
+	"benz-sniper/logging"
+	"benz-sniper/simulator"
+	"context"
+	"flag"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// 开奖模拟器：按计划生成 game_rounds / game_winners / bet_distribution 数据
+//
+//	go run ./cmd/simulator -mode db -realtime          # 写库，供主服务轮询
+//	go run ./cmd/simulator -mode engine -rounds 500    # 进程内直接驱动引擎
+func main() {
+	mode := flag.String("mode", "db", "运行模式: db=只写数据库, engine=写库后直接驱动引擎（DB_DRIVER=memory 时只能用 engine）")
+	seed := flag.Int64("seed", 1, "随机种子（相同种子生成相同序列）")
+	rounds := flag.Int("rounds", 0, "生成期数（0=无限）")
+	realtime := flag.Bool("realtime", false, "按间隔生成（默认尽快生成）")
+	speed := flag.Float64("speed", 1, "按间隔生成时的倍速（配合主服务 CLOCK_SPEED 回放）")
+	interval := flag.Duration("interval", 34*time.Second, "每期间隔")
+	startRound := flag.Int64("start-round", 0, "起始期号（0=接着数据库最新期号）")
+	specialRate := flag.Float64("special-rate", 0.02, "特殊奖项出现概率（0~1）")
+	probs := flag.String("probs", "", "车型概率，如 \"红奔驰=0.02,黄大众=0.25\"（只覆盖列出的车型，其余按赔率反推，合并后归一化）")
+	flag.Parse()
+
+	cfg := config.Load()
+	if err := logging.Setup(cfg.LogFormat, cfg.LogLevel); err != nil {
+		fatal("❌ 日志初始化失败", "error", err)
+	}
+	probabilities, err := simulator.ParseProbabilities(*probs)
+	if err != nil {
+		fatal("❌ 车型概率解析失败", "probs", *probs, "error", err)
+	}
+	if *mode == "db" && cfg.DBDriver == database.DriverMemory {
+		fatal("❌ 内存存储无法与主服务共享数据，请使用 -mode engine 或改用 mysql/sqlite")
+	}
+	if err := database.Init(cfg); err != nil {
+		fatal("❌ 数据库初始化失败", "error", err)
+	}
+	defer database.Close()
+	store := database.GetStore()
+
+	simCfg := simulator.DefaultConfig()
+	simCfg.Seed = *seed
+	simCfg.Interval = *interval
+	simCfg.SpecialRate = *specialRate
+	simCfg.Probabilities = probabilities
+	simCfg.StartRoundID = *startRound
+	if simCfg.StartRoundID == 0 {
+		simCfg.StartRoundID = nextRoundFromStore(store)
+	}
+
+	sim := simulator.New(simCfg)
+	var sink simulator.Sink = simulator.NewStoreSink(store)
+
+	var manager *engine.StrategyManager
+	switch *mode {
+	case "db":
+	case "engine":
+		manager = engine.NewStrategyManager(store)
+		sink = simulator.NewEngineSink(sink, engine.New(store, manager))
+	default:
+		fatal("❌ 未知运行模式", "mode", *mode)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	slog.Info("🎲 模拟器启动", "mode", *mode, "seed", *seed, "start_round", simCfg.StartRoundID)
+	written, err := simulator.Run(ctx, sim, sink, simulator.RunOptions{
+		Rounds:   *rounds,
+		Realtime: *realtime,
+		Clock:    engine.NewScaledClock(simCfg.StartTime, *speed),
+	})
+	slog.Info("✅ 模拟结束", "rounds", written)
+	if err != nil {
+		fatal("❌ 模拟失败", "error", err)
+	}
+
+	if manager != nil {
+		summary := manager.GetReportSummary()
+		slog.Info("📊 实盘统计", "bets", summary.TotalBets, "wins", summary.TotalWins,
+			"win_rate", summary.WinRate, "profit", summary.TotalProfit)
+		for _, item := range manager.GetStrategyReport() {
+			slog.Info("📊 策略统计", "strategy", item.Name, "status", item.StatusText, "bets", item.TotalBets, "profit", item.TotalProfit)
+		}
+	}
+}
+
+// nextRoundFromStore 接着存储中最新期号继续生成，避免期号冲突
+func nextRoundFromStore(store database.Store) int64 {
+	latest, err := store.LatestRound()
+	if err != nil {
+		return simulator.DefaultConfig().StartRoundID
+	}
+	num, err := strconv.ParseInt(latest.RoundID, 10, 64)
+	if err != nil {
+		return simulator.DefaultConfig().StartRoundID
+	}
+	return num + 1
+}
+
+// fatal 记录错误并退出
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
@@ -1,19 +1,60 @@
 package config
 
 import (
-	"log"
+	"log/slog"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
+	DBDriver   string // 存储类型: mysql / sqlite / memory
 	DBHost     string
 	DBPort     string
 	DBUser     string
 	DBPassword string
 	DBName     string
+	SQLitePath string // SQLite 数据库文件路径
 	ServerPort string
+	ClockSpeed float64 // 时钟倍速（回放时加速，默认 1）
+	LogFormat  string  // 日志格式: text（logfmt）/ json
+	LogLevel   string  // 日志级别: debug / info / warn / error
+
+	ReportTimezone  string // 报表营业时区（IANA 名称，如 Asia/Shanghai；为空时使用系统时区）
+	ReportDayCutoff int    // 报表日切时间（0-23 点，营业日从该整点开始）
+
+	ReportSchedule   []string      // 定时生成的报表: eod（日报）/ eow（周报）
+	ReportDelay      time.Duration // 周期结束后多久生成报表
+	ReportBaseURL    string        // 通知中报表下载链接的前缀（如 http://host:8001）
+	ReportEmailTo    []string      // 报表邮件收件人（为空时不发邮件）
+	ReportWebhookURL string        // 报表 Webhook 地址（为空时不推送）
+
+	AlertEmailTo    []string // 告警邮件收件人（为空时不发邮件）
+	AlertWebhookURL string   // 告警 Webhook 地址（为空时不推送）
+
+	SMTPAddr     string // SMTP 服务器（host:port）
+	SMTPUsername string // SMTP 认证用户名（为空时不认证）
+	SMTPPassword string // SMTP 认证密码
+	SMTPFrom     string // 发件人
+
+	AdminUsername  string        // 启动时创建的管理员账号
+	AdminPassword  string        // 管理员密码（为空时不创建）
+	AdminAPIKey    string        // 启动时注册的管理员 API 密钥（为空时不注册）
+	AnonymousRole  string        // 未登录访问的角色: viewer / operator / admin / none（默认，只能访问看板接口）
+	SessionTTL     time.Duration // 登录会话有效期
+	CORSOrigins    []string      // 允许跨域的来源（* 表示任意来源，不携带凭证）
+	TrustedProxies []string      // 可信反向代理（IP 或 CIDR，只有来自这些地址的 X-Forwarded-For 才会被采信）
+
+	BetAmountMin  float64 // 配置中下注金额下限
+	BetAmountMax  float64 // 配置中下注金额上限
+	BetAmountStep float64 // 下注金额步长（0 表示不限制）
+
+	HealthTickStale        time.Duration // 超过该时长没有成功轮询视为不健康
+	HealthFreshnessFactor  float64       // 最新一期超过 开奖间隔×倍数 未更新视为数据过期
+	HealthSettlementMaxAge time.Duration // 待结算期号等待超过该时长视为不健康
 }
 
 var AppConfig *Config
@@ -24,16 +65,54 @@ func Load() *Config {
 	_ = godotenv.Load()
 
 	config := &Config{
+		DBDriver:   getEnv("DB_DRIVER", "mysql"),
 		DBHost:     getEnv("DB_HOST", "localhost"),
 		DBPort:     getEnv("DB_PORT", "3306"),
 		DBUser:     getEnv("DB_USER", "root"),
 		DBPassword: getEnv("DB_PASSWORD", ""),
 		DBName:     getEnv("DB_NAME", "benz_analysis"),
+		SQLitePath: getEnv("SQLITE_PATH", "benz_analysis.db"),
 		ServerPort: getEnv("SERVER_PORT", "8001"),
+		ClockSpeed: getEnvFloat("CLOCK_SPEED", 1),
+		LogFormat:  getEnv("LOG_FORMAT", "text"),
+		LogLevel:   getEnv("LOG_LEVEL", "info"),
+
+		ReportTimezone:  os.Getenv("REPORT_TIMEZONE"),
+		ReportDayCutoff: getEnvInt("REPORT_DAY_CUTOFF", 0),
+
+		ReportSchedule:   getEnvList("REPORT_SCHEDULE"),
+		ReportDelay:      getEnvDuration("REPORT_DELAY", 5*time.Minute),
+		ReportBaseURL:    strings.TrimSuffix(os.Getenv("REPORT_BASE_URL"), "/"),
+		ReportEmailTo:    getEnvList("REPORT_EMAIL_TO"),
+		ReportWebhookURL: os.Getenv("REPORT_WEBHOOK_URL"),
+
+		AlertEmailTo:    getEnvList("ALERT_EMAIL_TO"),
+		AlertWebhookURL: os.Getenv("ALERT_WEBHOOK_URL"),
+
+		SMTPAddr:     os.Getenv("SMTP_ADDR"),
+		SMTPUsername: os.Getenv("SMTP_USERNAME"),
+		SMTPPassword: os.Getenv("SMTP_PASSWORD"),
+		SMTPFrom:     getEnv("SMTP_FROM", "benz-sniper@localhost"),
+
+		AdminUsername:  getEnv("ADMIN_USERNAME", "admin"),
+		AdminPassword:  os.Getenv("ADMIN_PASSWORD"),
+		AdminAPIKey:    os.Getenv("ADMIN_API_KEY"),
+		AnonymousRole:  getEnv("AUTH_ANONYMOUS_ROLE", "none"),
+		SessionTTL:     getEnvDuration("SESSION_TTL", 24*time.Hour),
+		CORSOrigins:    getEnvList("CORS_ORIGINS"),
+		TrustedProxies: getEnvList("TRUSTED_PROXIES"),
+
+		BetAmountMin:  getEnvFloat("BET_AMOUNT_MIN", 10),
+		BetAmountMax:  getEnvFloat("BET_AMOUNT_MAX", 100000),
+		BetAmountStep: getEnvFloat("BET_AMOUNT_STEP", 10),
+
+		HealthTickStale:        getEnvDuration("HEALTH_TICK_STALE", 30*time.Second),
+		HealthFreshnessFactor:  getEnvFloat("HEALTH_FRESHNESS_FACTOR", 3),
+		HealthSettlementMaxAge: getEnvDuration("HEALTH_SETTLEMENT_MAX_AGE", 2*time.Minute),
 	}
 
 	AppConfig = config
-	log.Println("✅ 配置加载完成")
+	slog.Info("✅ 配置加载完成")
 	return config
 }
 
@@ -46,6 +125,44 @@ func getEnv(key, defaultValue string) string {
 	return value
 }
 
+// getEnvFloat 获取浮点型环境变量，不存在或格式错误时返回默认值
+func getEnvFloat(key string, defaultValue float64) float64 {
+	value, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvInt 获取整型环境变量，不存在或格式错误时返回默认值
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvDuration 获取时长型环境变量（如 12h、30m），不存在或格式错误时返回默认值
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvList 获取逗号分隔的列表型环境变量（忽略空项）
+func getEnvList(key string) []string {
+	var items []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 // GetDSN 获取 MySQL 连接字符串
 func (c *Config) GetDSN() string {
 	return c.DBUser + ":" + c.DBPassword + "@tcp(" + c.DBHost + ":" + c.DBPort + ")/" + c.DBName + "?charset=utf8mb4&parseTime=True&loc=Local"
@@ -0,0 +1,133 @@
+package database
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+	_ "time/tzdata" // 内置时区数据，精简镜像中也能加载 REPORT_TIMEZONE
+)
+
+// 报表统计周期
+const (
+	PeriodHour  = "hour"
+	PeriodDay   = "day"
+	PeriodWeek  = "week"  // 周一开始
+	PeriodMonth = "month" // 自然月（以营业日计）
+)
+
+// Calendar 报表营业日历：统计周期按营业时区划分，每个营业日从日切时间开始
+// 日期分组在 Go 中计算，与数据库服务器时区无关
+type Calendar struct {
+	Location   *time.Location
+	CutoffHour int // 日切时间（0-23 点）
+}
+
+var calendar atomic.Pointer[Calendar]
+
+// SetCalendar 设置报表营业日历（启动时调用；与每日聚合记录的日历不一致时，openGorm 会重建聚合）
+func SetCalendar(timezone string, cutoffHour int) error {
+	loc := time.Local
+	if timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(timezone); err != nil {
+			return fmt.Errorf("无效的报表时区 %s: %w", timezone, err)
+		}
+	}
+	if cutoffHour < 0 || cutoffHour > 23 {
+		return fmt.Errorf("日切时间必须在 0-23 之间: %d", cutoffHour)
+	}
+	calendar.Store(&Calendar{Location: loc, CutoffHour: cutoffHour})
+	return nil
+}
+
+// GetCalendar 当前报表营业日历（未设置时为系统时区、零点日切）
+func GetCalendar() Calendar {
+	if c := calendar.Load(); c != nil {
+		return *c
+	}
+	return Calendar{Location: time.Local}
+}
+
+// Signature 日历标识（时区@日切时间），记录在 stats_calendar 表中用于判断每日聚合是否需要重建
+func (c Calendar) Signature() string {
+	return fmt.Sprintf("%s@%02d", c.Location, c.CutoffHour)
+}
+
+// ValidPeriod 判断统计周期是否有效
+func ValidPeriod(unit string) bool {
+	switch unit {
+	case PeriodHour, PeriodDay, PeriodWeek, PeriodMonth:
+		return true
+	}
+	return false
+}
+
+// Day 时间所属的营业日（YYYY-MM-DD）
+func (c Calendar) Day(t time.Time) string {
+	return t.In(c.Location).Add(-time.Duration(c.CutoffHour) * time.Hour).Format("2006-01-02")
+}
+
+// DayStart 营业日的开始时间
+func (c Calendar) DayStart(day string) (time.Time, error) {
+	d, err := time.ParseInLocation("2006-01-02", day, c.Location)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return c.at(d), nil
+}
+
+// PeriodStart 时间所在统计周期的开始时间（营业时区）
+func (c Calendar) PeriodStart(t time.Time, unit string) time.Time {
+	local := t.In(c.Location)
+	if unit == PeriodHour {
+		return time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), 0, 0, 0, c.Location)
+	}
+	day := local.Add(-time.Duration(c.CutoffHour) * time.Hour)
+	switch unit {
+	case PeriodWeek:
+		day = day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case PeriodMonth:
+		day = day.AddDate(0, 0, 1-day.Day())
+	}
+	return c.at(day)
+}
+
+// NextPeriod 统计周期的结束时间（下一周期的开始）
+func (c Calendar) NextPeriod(start time.Time, unit string) time.Time {
+	switch unit {
+	case PeriodHour:
+		return start.Add(time.Hour)
+	case PeriodWeek:
+		return c.at(start.AddDate(0, 0, 7))
+	case PeriodMonth:
+		return c.at(start.AddDate(0, 1, 0))
+	default:
+		return c.at(start.AddDate(0, 0, 1))
+	}
+}
+
+// PeriodKey 统计周期的标识（小时 2006-01-02 15:00，日 2006-01-02，周 2006-W01，月 2006-01）
+func (c Calendar) PeriodKey(start time.Time, unit string) string {
+	start = start.In(c.Location)
+	switch unit {
+	case PeriodHour:
+		return start.Format("2006-01-02 15:00")
+	case PeriodWeek:
+		year, week := start.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case PeriodMonth:
+		return start.Format("2006-01")
+	default:
+		return start.Format("2006-01-02")
+	}
+}
+
+// IsDayBoundary 判断时间是否正好是营业日的开始
+func (c Calendar) IsDayBoundary(t time.Time) bool {
+	return t.Equal(c.PeriodStart(t, PeriodDay))
+}
+
+// at 日期当天日切时间对应的时刻
+func (c Calendar) at(day time.Time) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), c.CutoffHour, 0, 0, 0, c.Location)
+}
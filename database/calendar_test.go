@@ -0,0 +1,131 @@
+package database
+
+import (
+	"benz-sniper/config"
+	"benz-sniper/models"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCalendarPeriods(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cal := Calendar{Location: shanghai, CutoffHour: 6}
+	// 周一凌晨 5 点仍属于周日的营业日，也就属于上一周
+	at := time.Date(2026, 1, 5, 5, 30, 0, 0, shanghai)
+
+	tests := []struct {
+		unit       string
+		key        string
+		start, end time.Time
+	}{
+		{PeriodHour, "2026-01-05 05:00", time.Date(2026, 1, 5, 5, 0, 0, 0, shanghai), time.Date(2026, 1, 5, 6, 0, 0, 0, shanghai)},
+		{PeriodDay, "2026-01-04", time.Date(2026, 1, 4, 6, 0, 0, 0, shanghai), time.Date(2026, 1, 5, 6, 0, 0, 0, shanghai)},
+		{PeriodWeek, "2026-W01", time.Date(2025, 12, 29, 6, 0, 0, 0, shanghai), time.Date(2026, 1, 5, 6, 0, 0, 0, shanghai)},
+		{PeriodMonth, "2026-01", time.Date(2026, 1, 1, 6, 0, 0, 0, shanghai), time.Date(2026, 2, 1, 6, 0, 0, 0, shanghai)},
+	}
+	for _, tt := range tests {
+		// 传入 UTC 时间，结果与数据库和进程时区无关
+		start := cal.PeriodStart(at.UTC(), tt.unit)
+		if !start.Equal(tt.start) || cal.PeriodKey(start, tt.unit) != tt.key {
+			t.Errorf("%s: start = %v key = %s, want %v %s", tt.unit, start, cal.PeriodKey(start, tt.unit), tt.start, tt.key)
+		}
+		if end := cal.NextPeriod(start, tt.unit); !end.Equal(tt.end) {
+			t.Errorf("%s: end = %v, want %v", tt.unit, end, tt.end)
+		}
+	}
+	if day := cal.Day(at); day != "2026-01-04" {
+		t.Errorf("Day() = %s, want 2026-01-04", day)
+	}
+	if !cal.IsDayBoundary(time.Date(2026, 1, 4, 22, 0, 0, 0, time.UTC)) || cal.IsDayBoundary(at) {
+		t.Error("IsDayBoundary: 营业日从北京时间 6 点开始")
+	}
+}
+
+func TestCalendarDaylightSaving(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cal := Calendar{Location: newYork, CutoffHour: 4}
+	// 2026-03-08 凌晨 2 点切换夏令时，3 月 7 日的营业日（到 8 日 4 点）只有 23 小时
+	start, err := cal.DayStart("2026-03-07")
+	if err != nil {
+		t.Fatal(err)
+	}
+	end := cal.NextPeriod(start, PeriodDay)
+	if end.Sub(start) != 23*time.Hour || end.Hour() != 4 {
+		t.Errorf("夏令时营业日 = %v ~ %v, want 23 小时并在 4 点结束", start, end)
+	}
+	if got := cal.PeriodStart(end.Add(-time.Minute), PeriodDay); !got.Equal(start) {
+		t.Errorf("PeriodStart = %v, want %v", got, start)
+	}
+}
+
+func TestSetCalendar(t *testing.T) {
+	defer calendar.Store(nil)
+	if err := SetCalendar("Mars/Olympus", 0); err == nil {
+		t.Error("无效时区应返回错误")
+	}
+	if err := SetCalendar("", 24); err == nil {
+		t.Error("日切时间超出 0-23 应返回错误")
+	}
+	if err := SetCalendar("Asia/Shanghai", 6); err != nil {
+		t.Fatal(err)
+	}
+	if cal := GetCalendar(); cal.Location.String() != "Asia/Shanghai" || cal.CutoffHour != 6 {
+		t.Errorf("GetCalendar() = %+v", cal)
+	}
+}
+
+func TestReopenWithNewCutoffRebuildsDailyStats(t *testing.T) {
+	defer calendar.Store(nil)
+	path := filepath.Join(t.TempDir(), "calendar.db")
+	cfg := &config.Config{DBDriver: DriverSQLite, SQLitePath: path}
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dailyStats := func(store Store) []models.DailyStrategyStat {
+		t.Helper()
+		stats, err := store.DailyStats(DailyStatFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return stats
+	}
+
+	// 零点日切：凌晨 5 点的记录属于当天
+	if err := SetCalendar("Asia/Shanghai", 0); err != nil {
+		t.Fatal(err)
+	}
+	store, err := Open(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := time.Date(2026, 1, 5, 5, 0, 0, 0, shanghai)
+	if err := store.CreateHistory(&models.StrategyHistory{RoundID: "1000", Strategy: "热门3码", Result: "赢", BetAmount: 300, CreatedAt: &at}); err != nil {
+		t.Fatal(err)
+	}
+	if stats := dailyStats(store); len(stats) != 1 || stats[0].Day != "2026-01-05" {
+		t.Fatalf("零点日切聚合 = %+v, want 2026-01-05", stats)
+	}
+	store.Close()
+
+	// 改为 6 点日切后重新打开：聚合按新的营业日重建
+	if err := SetCalendar("Asia/Shanghai", 6); err != nil {
+		t.Fatal(err)
+	}
+	store, err = Open(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if stats := dailyStats(store); len(stats) != 1 || stats[0].Day != "2026-01-04" || stats[0].Bets != 1 {
+		t.Errorf("6 点日切聚合 = %+v, want 2026-01-04", stats)
+	}
+}
@@ -0,0 +1,104 @@
+package database
+
+import (
+	"benz-sniper/models"
+	"time"
+)
+
+// DailyStatFilter 每日策略聚合筛选条件
+type DailyStatFilter struct {
+	Strategy  string // 策略名称
+	Status    *int   // 0=虚盘, 1=实盘
+	SessionID uint   // 归档会话ID（0=当前，未归档）
+	FromDay   string // 起始日期（含，YYYY-MM-DD）
+	ToDay     string // 截止日期（不含，YYYY-MM-DD）
+}
+
+// StatDay 时间所属的统计日期（按报表营业日历，YYYY-MM-DD）
+func StatDay(t time.Time) string {
+	return GetCalendar().Day(t)
+}
+
+// dailyStatOf 单条历史记录对应的聚合增量
+func dailyStatOf(h *models.StrategyHistory) models.DailyStrategyStat {
+	stat := models.DailyStrategyStat{
+		Day:           StatDay(timeOf(h.CreatedAt)),
+		Strategy:      h.Strategy,
+		Status:        h.Status,
+		SessionID:     h.SessionID,
+		Bets:          1,
+		Profit:        h.Profit,
+		VirtualProfit: h.VirtualProfit,
+		BetAmount:     h.BetAmount,
+		UpdatedAt:     h.CreatedAt,
+	}
+	if h.Result == "赢" {
+		stat.Wins = 1
+	}
+	return stat
+}
+
+// rebuildBatchSize 重建聚合时每批读取的明细条数
+const rebuildBatchSize = 1000
+
+// dailyStatKey 聚合行的唯一键
+type dailyStatKey struct {
+	day       string
+	strategy  string
+	status    int
+	sessionID uint
+}
+
+// mergeDailyStat 把聚合增量合并到同键的行（index 记录键对应的下标）
+func mergeDailyStat(stats []models.DailyStrategyStat, index map[dailyStatKey]int, stat models.DailyStrategyStat) []models.DailyStrategyStat {
+	key := dailyStatKey{stat.Day, stat.Strategy, stat.Status, stat.SessionID}
+	i, ok := index[key]
+	if !ok {
+		index[key] = len(stats)
+		return append(stats, stat)
+	}
+	row := &stats[i]
+	row.Bets += stat.Bets
+	row.Wins += stat.Wins
+	row.Profit += stat.Profit
+	row.VirtualProfit += stat.VirtualProfit
+	row.BetAmount += stat.BetAmount
+	return stats
+}
+
+// matchDailyStat 判断聚合行是否满足筛选条件
+func matchDailyStat(stat models.DailyStrategyStat, filter DailyStatFilter) bool {
+	if filter.Strategy != "" && stat.Strategy != filter.Strategy {
+		return false
+	}
+	if filter.Status != nil && stat.Status != *filter.Status {
+		return false
+	}
+	if filter.FromDay != "" && stat.Day < filter.FromDay {
+		return false
+	}
+	if filter.ToDay != "" && stat.Day >= filter.ToDay {
+		return false
+	}
+	return stat.SessionID == filter.SessionID
+}
+
+// SumDailyStats 按分组键汇总聚合行（保持分组首次出现的顺序）
+func SumDailyStats(rows []models.DailyStrategyStat, keyOf func(models.DailyStrategyStat) string) []HistoryStat {
+	index := make(map[string]int)
+	stats := make([]HistoryStat, 0)
+	for _, row := range rows {
+		key := keyOf(row)
+		i, ok := index[key]
+		if !ok {
+			i = len(stats)
+			index[key] = i
+			stats = append(stats, HistoryStat{Key: key})
+		}
+		stats[i].Bets += row.Bets
+		stats[i].Wins += row.Wins
+		stats[i].Profit += row.Profit
+		stats[i].VirtualProfit += row.VirtualProfit
+	}
+	return stats
+}
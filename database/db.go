@@ -3,92 +3,258 @@ package database
 import (
 	"benz-sniper/config"
 	"benz-sniper/models"
-	"log"
+	"fmt"
+	"log/slog"
 	"time"
 
+	"github.com/glebarez/sqlite"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
-var DB *gorm.DB
+// 存储类型
+const (
+	DriverMySQL  = "mysql"
+	DriverSQLite = "sqlite"
+	DriverMemory = "memory"
+)
+
+var (
+	DB    *gorm.DB // GORM 连接（内存存储时为 nil）
+	store Store    // 当前存储实现
+)
 
-// Init 初始化数据库连接
+// Init 初始化存储（根据 DB_DRIVER 选择 MySQL / SQLite / 内存）
 func Init(cfg *config.Config) error {
-	dsn := cfg.GetDSN()
-	
+	// 营业日历决定每日聚合的日期，须在迁移回填前设置
+	if err := SetCalendar(cfg.ReportTimezone, cfg.ReportDayCutoff); err != nil {
+		return err
+	}
+	s, err := Open(cfg)
+	if err != nil {
+		return err
+	}
+
+	store = s
+	if gs, ok := s.(*GormStore); ok {
+		DB = gs.DB()
+	}
+	return nil
+}
+
+// Open 按配置创建存储实例（不修改全局变量）
+func Open(cfg *config.Config) (Store, error) {
+	switch cfg.DBDriver {
+	case DriverMemory:
+		slog.Info("✅ 使用内存存储（重启后数据丢失）")
+		return NewMemoryStore(), nil
+	case DriverSQLite:
+		return openGorm(sqlite.Open(cfg.SQLitePath), DriverSQLite)
+	case DriverMySQL, "":
+		return openGorm(mysql.Open(cfg.GetDSN()), DriverMySQL)
+	default:
+		return nil, fmt.Errorf("未知存储类型: %s", cfg.DBDriver)
+	}
+}
+
+// openGorm 连接数据库并迁移表结构
+func openGorm(dialector gorm.Dialector, dialect string) (*GormStore, error) {
 	// 配置 GORM（禁用缓存，确保每次查询都是新鲜的）
 	gormConfig := &gorm.Config{
 		Logger:                 logger.Default.LogMode(logger.Warn),
 		SkipDefaultTransaction: true,  // 跳过默认事务，提高性能
 		PrepareStmt:            false, // 禁用预编译语句缓存
+		TranslateError:         true,  // 唯一索引冲突转换为 gorm.ErrDuplicatedKey
 		NowFunc: func() time.Time {
 			return time.Now().Local()
 		},
 	}
 
 	// 连接数据库
-	db, err := gorm.Open(mysql.Open(dsn), gormConfig)
+	db, err := gorm.Open(dialector, gormConfig)
 	if err != nil {
-		log.Printf("❌ 数据库连接失败: %v", err)
-		return err
+		slog.Error("❌ 数据库连接失败", "error", err)
+		return nil, err
 	}
 
 	// 配置连接池
 	sqlDB, err := db.DB()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	sqlDB.SetMaxIdleConns(10)
-	sqlDB.SetMaxOpenConns(100)
-	sqlDB.SetConnMaxLifetime(time.Hour)
+	if dialect == DriverSQLite {
+		// SQLite 单写者，限制为单连接避免 database is locked
+		sqlDB.SetMaxOpenConns(1)
+	} else {
+		sqlDB.SetMaxIdleConns(10)
+		sqlDB.SetMaxOpenConns(100)
+		sqlDB.SetConnMaxLifetime(time.Hour)
+	}
+
+	slog.Info("✅ 数据库连接成功", "driver", dialect)
 
-	DB = db
-	log.Println("✅ 数据库连接成功")
+	// 数据库耗时指标
+	if err := registerMetrics(db); err != nil {
+		slog.Warn("⚠️ 注册数据库指标失败", "error", err)
+		return nil, err
+	}
 
-	// 自动迁移策略相关表
+	// 自动迁移策略相关表（聚合表首次创建时由已有历史记录回填；
+	// 纸面盈亏列首次添加时由策略管理器按预测和开奖结果回填，计算规则在 engine 中）
+	backfill := !db.Migrator().HasTable(&models.DailyStrategyStat{})
+	virtualProfitPending := db.Migrator().HasTable(&models.StrategyHistory{}) &&
+		!db.Migrator().HasColumn(&models.StrategyHistory{}, "virtual_profit")
+	if err := dedupeUserBets(db); err != nil {
+		slog.Error("❌ 清理重复的用户派彩记录失败", "error", err)
+		return nil, err
+	}
 	if err := AutoMigrate(db); err != nil {
-		log.Printf("⚠️ 数据库迁移失败: %v", err)
-		return err
+		slog.Warn("⚠️ 数据库迁移失败", "error", err)
+		return nil, err
 	}
 
-	return nil
+	// 营业日历（REPORT_TIMEZONE / REPORT_DAY_CUTOFF）修改后，已有聚合的日期已经不对，需要重建
+	if !backfill {
+		stale, err := statsCalendarStale(db)
+		if err != nil {
+			slog.Error("❌ 读取每日聚合营业日历失败", "error", err)
+			return nil, err
+		}
+		if stale {
+			slog.Warn("⚠️ 营业日历已变化，重建每日策略聚合", "calendar", GetCalendar().Signature())
+			backfill = true
+		}
+	}
+
+	store := NewGormStore(db)
+	store.virtualProfitPending = virtualProfitPending
+	if backfill {
+		rows, err := store.RebuildDailyStats()
+		if err != nil {
+			slog.Error("❌ 回填每日策略聚合失败", "error", err)
+			return nil, err
+		}
+		slog.Info("✅ 已回填每日策略聚合", "rows", rows)
+	}
+	return store, nil
 }
 
-// AutoMigrate 自动迁移表结构（仅迁移游戏相关表）
-func AutoMigrate(db *gorm.DB) error {
-	// 执行自动迁移（仅游戏相关表）
-	err := db.AutoMigrate(
+// migratedModels 自动迁移的模型（AutoMigrate 和 CheckSchema 共用）
+func migratedModels() []any {
+	return []any{
 		&models.GameRound{},
 		&models.GameWinner{},
 		&models.BetDistribution{},
 		&models.StrategyHistory{},
+		&models.DailyStrategyStat{},
+		&models.StatsCalendar{},
+		&models.HistorySession{},
 		&models.SystemConfig{},
+		&models.ConfigVersion{},
+		&models.ConfigProfile{},
 		&models.UserBet{},
-	)
-	
+		&models.UserBetItem{},
+		&models.User{},
+		&models.APIKey{},
+		&models.UserSession{},
+		&models.AuditLog{},
+		&models.Report{},
+		&models.AlertRule{},
+		&models.Alert{},
+	}
+}
+
+// dedupeUserBets 把 user_bets 的（期号, 账号）和幂等键改为唯一索引之前清理已有数据（只在唯一索引不存在时执行）：
+// 同一期号和账号只保留最后上传的一条，空幂等键改为 NULL，重复的幂等键只保留在最早的记录上，并删除旧的普通索引
+func dedupeUserBets(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable(&models.UserBet{}) || migrator.HasIndex(&models.UserBet{}, "uniq_user_bets_round_account") {
+		return nil
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var stale []uint
+		err := tx.Raw("SELECT id FROM user_bets WHERE id NOT IN (SELECT MAX(id) FROM user_bets GROUP BY round_id, user_account)").
+			Scan(&stale).Error
+		if err != nil {
+			return err
+		}
+		if len(stale) > 0 {
+			if tx.Migrator().HasTable(&models.UserBetItem{}) {
+				if err := tx.Where("user_bet_id IN ?", stale).Delete(&models.UserBetItem{}).Error; err != nil {
+					return err
+				}
+			}
+			if err := tx.Where("id IN ?", stale).Delete(&models.UserBet{}).Error; err != nil {
+				return err
+			}
+			slog.Warn("⚠️ 已删除重复的用户派彩记录", "rows", len(stale))
+		}
+
+		if tx.Migrator().HasColumn(&models.UserBet{}, "idempotency_key") {
+			if err := tx.Model(&models.UserBet{}).Where("idempotency_key = ?", "").
+				UpdateColumn("idempotency_key", gorm.Expr("NULL")).Error; err != nil {
+				return err
+			}
+			var reused []uint
+			err := tx.Raw("SELECT id FROM user_bets WHERE idempotency_key IS NOT NULL AND id NOT IN " +
+				"(SELECT MIN(id) FROM user_bets WHERE idempotency_key IS NOT NULL GROUP BY idempotency_key)").Scan(&reused).Error
+			if err != nil {
+				return err
+			}
+			if len(reused) > 0 {
+				if err := tx.Model(&models.UserBet{}).Where("id IN ?", reused).
+					UpdateColumn("idempotency_key", gorm.Expr("NULL")).Error; err != nil {
+					return err
+				}
+				slog.Warn("⚠️ 已清除重复的幂等键", "rows", len(reused))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// 旧索引与唯一索引列相同，AutoMigrate 之前删除（DDL 不放在事务里，MySQL 会隐式提交）
+	for _, index := range []string{"idx_user_bets_round_account", "idx_user_bets_idempotency_key"} {
+		if migrator.HasIndex(&models.UserBet{}, index) {
+			if err := migrator.DropIndex(&models.UserBet{}, index); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// AutoMigrate 自动迁移表结构（仅迁移游戏相关表）
+func AutoMigrate(db *gorm.DB) error {
+	// 执行自动迁移（仅游戏相关表）
+	err := db.AutoMigrate(migratedModels()...)
+
 	if err != nil {
-		log.Printf("❌ 数据库表迁移失败: %v", err)
+		slog.Error("❌ 数据库表迁移失败", "error", err)
 		return err
 	}
-	
-	log.Println("✅ 数据库表迁移完成")
+
+	slog.Info("✅ 数据库表迁移完成")
 	return nil
 }
 
-// Close 关闭数据库连接
+// Close 关闭存储
 func Close() error {
-	if DB != nil {
-		sqlDB, err := DB.DB()
-		if err != nil {
-			return err
-		}
-		return sqlDB.Close()
+	if store != nil {
+		return store.Close()
 	}
 	return nil
 }
 
-// GetDB 获取数据库实例
+// GetDB 获取 GORM 数据库实例（内存存储时为 nil）
 func GetDB() *gorm.DB {
 	return DB
 }
+
+// GetStore 获取当前存储实例
+func GetStore() Store {
+	return store
+}
@@ -0,0 +1,974 @@
+package database
+
+import (
+	"benz-sniper/models"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GormStore 基于 GORM 的存储实现（MySQL / SQLite 共用，日期分组在 Go 中按营业日历计算，不依赖方言函数）
+type GormStore struct {
+	db                   *gorm.DB
+	virtualProfitPending bool // 迁移新增了 virtual_profit 列，已有记录待回填
+}
+
+// NewGormStore 创建 GORM 存储
+func NewGormStore(db *gorm.DB) *GormStore {
+	return &GormStore{db: db}
+}
+
+// DB 底层数据库连接
+func (s *GormStore) DB() *gorm.DB {
+	return s.db
+}
+
+// Ping 检查数据库连接
+func (s *GormStore) Ping() error {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Ping()
+}
+
+// CheckSchema 检查迁移的数据表是否都存在
+func (s *GormStore) CheckSchema() error {
+	missing := make([]string, 0)
+	migrator := s.session().Migrator()
+	for _, model := range migratedModels() {
+		if !migrator.HasTable(model) {
+			stmt := &gorm.Statement{DB: s.db}
+			if err := stmt.Parse(model); err != nil {
+				return err
+			}
+			missing = append(missing, stmt.Schema.Table)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("缺少数据表: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// Close 关闭数据库连接
+func (s *GormStore) Close() error {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+// session 获取新的数据库会话（避免条件残留和任何缓存）
+func (s *GormStore) session() *gorm.DB {
+	return s.db.Session(&gorm.Session{NewDB: true})
+}
+
+// notFound 将 GORM 的未找到错误转换为 ErrNotFound
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// LatestRound 最新一期
+func (s *GormStore) LatestRound() (*models.GameRound, error) {
+	var round models.GameRound
+	if err := s.session().Order("round_id DESC").First(&round).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &round, nil
+}
+
+// RecentRounds 最近 limit 期（按期号降序）
+func (s *GormStore) RecentRounds(limit int) ([]models.GameRound, error) {
+	var rounds []models.GameRound
+	err := s.session().Order("round_id DESC").Limit(limit).Find(&rounds).Error
+	return rounds, err
+}
+
+// GetRound 按期号查询
+func (s *GormStore) GetRound(roundID string) (*models.GameRound, error) {
+	var round models.GameRound
+	if err := s.session().Where("round_id = ?", roundID).First(&round).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &round, nil
+}
+
+// WinnersByRounds 批量查询多期的获胜项
+func (s *GormStore) WinnersByRounds(roundIDs []string) ([]models.GameWinner, error) {
+	var winners []models.GameWinner
+	if len(roundIDs) == 0 {
+		return winners, nil
+	}
+	err := s.session().Where("round_id IN ?", roundIDs).Order("round_id, position").Find(&winners).Error
+	return winners, err
+}
+
+// ListRounds 按期号升序分批查询
+func (s *GormStore) ListRounds(query RoundQuery) ([]models.GameRound, error) {
+	db := s.session().Model(&models.GameRound{})
+	if !query.From.IsZero() {
+		db = db.Where("created_at >= ?", query.From)
+	}
+	if !query.To.IsZero() {
+		db = db.Where("created_at < ?", query.To)
+	}
+	if query.AfterRoundID != "" {
+		db = db.Where("round_id > ?", query.AfterRoundID)
+	}
+	if query.Limit > 0 {
+		db = db.Limit(query.Limit)
+	}
+	var rounds []models.GameRound
+	err := db.Order("round_id").Find(&rounds).Error
+	return rounds, err
+}
+
+// DistributionsByRound 查询单期投注分布
+func (s *GormStore) DistributionsByRound(roundID string) ([]models.BetDistribution, error) {
+	var distributions []models.BetDistribution
+	err := s.session().Where("round_id = ?", roundID).Order("option_id").Find(&distributions).Error
+	return distributions, err
+}
+
+// SaveRound 在同一事务中写入一期完整数据
+func (s *GormStore) SaveRound(round *models.GameRound, winners []models.GameWinner, distributions []models.BetDistribution) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		// 先写获胜项和分布，最后写期数，避免引擎读到不完整的一期
+		if len(winners) > 0 {
+			if err := tx.Create(&winners).Error; err != nil {
+				return err
+			}
+		}
+		if len(distributions) > 0 {
+			if err := tx.Create(&distributions).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(round).Error
+	})
+}
+
+// CreateHistory 写入一条历史记录，并在同一事务内累加每日策略聚合
+func (s *GormStore) CreateHistory(history *models.StrategyHistory) error {
+	if history.CreatedAt == nil {
+		history.CreatedAt = now()
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(history).Error; err != nil {
+			return err
+		}
+		return addDailyStat(tx, dailyStatOf(history))
+	})
+}
+
+// addDailyStat 累加一行每日策略聚合（不存在时创建）
+func addDailyStat(tx *gorm.DB, stat models.DailyStrategyStat) error {
+	return tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "day"}, {Name: "strategy"}, {Name: "status"}, {Name: "session_id"}},
+		DoUpdates: clause.Assignments(map[string]any{
+			"bets":           gorm.Expr("bets + ?", stat.Bets),
+			"wins":           gorm.Expr("wins + ?", stat.Wins),
+			"profit":         gorm.Expr("profit + ?", stat.Profit),
+			"virtual_profit": gorm.Expr("virtual_profit + ?", stat.VirtualProfit),
+			"bet_amount":     gorm.Expr("bet_amount + ?", stat.BetAmount),
+			"updated_at":     stat.UpdatedAt,
+		}),
+	}).Create(&stat).Error
+}
+
+// historyScope 应用历史记录筛选条件
+func (s *GormStore) historyScope(filter HistoryFilter) *gorm.DB {
+	query := s.session().Model(&models.StrategyHistory{}).Where("session_id = ?", filter.SessionID)
+	if filter.Strategy != "" {
+		query = query.Where("strategy = ?", filter.Strategy)
+	}
+	if filter.Status != nil {
+		query = query.Where("status = ?", *filter.Status)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at < ?", filter.To)
+	}
+	if filter.Result != "" {
+		query = query.Where("result = ?", filter.Result)
+	}
+	// 期号按数值比较：先比长度再比字符串
+	if filter.RoundFrom != "" {
+		n := len(filter.RoundFrom)
+		query = query.Where("(LENGTH(round_id) > ? OR (LENGTH(round_id) = ? AND round_id >= ?))", n, n, filter.RoundFrom)
+	}
+	if filter.RoundTo != "" {
+		n := len(filter.RoundTo)
+		query = query.Where("(LENGTH(round_id) < ? OR (LENGTH(round_id) = ? AND round_id <= ?))", n, n, filter.RoundTo)
+	}
+	switch filter.SpecialReward {
+	case "":
+	case SpecialAny:
+		query = query.Where("special_reward <> ''")
+	default:
+		query = query.Where("special_reward = ?", filter.SpecialReward)
+	}
+	if filter.MinProfit != nil {
+		query = query.Where("profit >= ?", *filter.MinProfit)
+	}
+	return query
+}
+
+// historyOrder 排序字段白名单（未知字段按 created_at）
+func historyOrder(sort string) string {
+	switch sort {
+	case HistorySortProfit, HistorySortBet:
+		return sort
+	}
+	return HistorySortTime
+}
+
+// ListHistory 分页查询历史记录
+func (s *GormStore) ListHistory(query HistoryQuery) ([]models.StrategyHistory, int64, error) {
+	column, dir, cmp := historyOrder(query.Sort), "DESC", "<"
+	if query.Asc {
+		dir, cmp = "ASC", ">"
+	}
+	if query.After != nil && column != HistorySortTime {
+		return nil, 0, ErrInvalidCursor
+	}
+
+	total := int64(-1)
+	if !query.SkipCount {
+		if err := s.historyScope(query.HistoryFilter).Count(&total).Error; err != nil {
+			return nil, 0, err
+		}
+	}
+
+	db := s.historyScope(query.HistoryFilter)
+	if query.After != nil {
+		db = db.Where("(created_at "+cmp+" ? OR (created_at = ? AND id "+cmp+" ?))",
+			query.After.CreatedAt, query.After.CreatedAt, query.After.ID)
+	} else {
+		db = db.Offset(query.Offset)
+	}
+	var records []models.StrategyHistory
+	err := db.Order(column + " " + dir + ", id " + dir).
+		Limit(query.Limit).
+		Find(&records).Error
+	return records, total, err
+}
+
+// ArchiveHistory 把当前记录归档到新会话
+func (s *GormStore) ArchiveHistory(session *models.HistorySession) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		current := func() *gorm.DB {
+			return tx.Model(&models.StrategyHistory{}).Where("session_id = 0")
+		}
+
+		var stat HistoryStat
+		if err := current().Select(statSelect).Scan(&stat).Error; err != nil {
+			return err
+		}
+		var realProfit float64
+		if err := current().Where("status = 1").Select("COALESCE(SUM(profit), 0)").Scan(&realProfit).Error; err != nil {
+			return err
+		}
+		var first models.StrategyHistory
+		if err := current().Order("created_at, id").Limit(1).Find(&first).Error; err != nil {
+			return err
+		}
+
+		session.Bets = stat.Bets
+		session.Wins = stat.Wins
+		session.Profit = stat.Profit
+		session.RealProfit = realProfit
+		session.StartedAt = first.CreatedAt
+		if err := tx.Create(session).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.DailyStrategyStat{}).Where("session_id = 0").Update("session_id", session.ID).Error; err != nil {
+			return err
+		}
+		return current().Update("session_id", session.ID).Error
+	})
+}
+
+// ListSessions 全部归档会话
+func (s *GormStore) ListSessions() ([]models.HistorySession, error) {
+	var sessions []models.HistorySession
+	err := s.session().Order("id DESC").Find(&sessions).Error
+	return sessions, err
+}
+
+// GetSession 按ID查询归档会话
+func (s *GormStore) GetSession(id uint) (*models.HistorySession, error) {
+	var session models.HistorySession
+	if err := s.session().First(&session, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &session, nil
+}
+
+// RestoreSession 恢复归档会话的记录
+func (s *GormStore) RestoreSession(id uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&models.HistorySession{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		if err := tx.Model(&models.StrategyHistory{}).Where("session_id = ?", id).Update("session_id", 0).Error; err != nil {
+			return err
+		}
+
+		// 会话的聚合并入当前聚合（同一天同一策略的行合并）
+		var stats []models.DailyStrategyStat
+		if err := tx.Where("session_id = ?", id).Find(&stats).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("session_id = ?", id).Delete(&models.DailyStrategyStat{}).Error; err != nil {
+			return err
+		}
+		for _, stat := range stats {
+			stat.ID = 0
+			stat.SessionID = 0
+			if err := addDailyStat(tx, stat); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// PurgeSession 彻底删除归档会话及其记录
+func (s *GormStore) PurgeSession(id uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&models.HistorySession{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		if err := tx.Where("session_id = ?", id).Delete(&models.DailyStrategyStat{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("session_id = ?", id).Delete(&models.StrategyHistory{}).Error
+	})
+}
+
+// SumProfit 累计盈亏
+func (s *GormStore) SumProfit(filter HistoryFilter) (float64, error) {
+	var total float64
+	err := s.historyScope(filter).Select("COALESCE(SUM(profit), 0)").Scan(&total).Error
+	return total, err
+}
+
+// statSelect 统计字段（命中次数定义：result='赢'）
+const statSelect = "COUNT(*) as bets, COALESCE(SUM(CASE WHEN result='赢' THEN 1 ELSE 0 END), 0) as wins, " +
+	"COALESCE(SUM(profit), 0) as profit, COALESCE(SUM(virtual_profit), 0) as virtual_profit"
+
+// HistoryStats 总体统计
+func (s *GormStore) HistoryStats(filter HistoryFilter) (HistoryStat, error) {
+	var stat HistoryStat
+	err := s.historyScope(filter).Select(statSelect).Scan(&stat).Error
+	return stat, err
+}
+
+// HistoryStatsByStrategy 按策略分组统计
+func (s *GormStore) HistoryStatsByStrategy(filter HistoryFilter) ([]HistoryStat, error) {
+	var stats []HistoryStat
+	err := s.historyScope(filter).
+		Select("strategy as stat_key, " + statSelect).
+		Group("strategy").
+		Order("strategy").
+		Scan(&stats).Error
+	return stats, err
+}
+
+// DailyStats 每日策略聚合
+func (s *GormStore) DailyStats(filter DailyStatFilter) ([]models.DailyStrategyStat, error) {
+	query := s.session().Where("session_id = ?", filter.SessionID)
+	if filter.Strategy != "" {
+		query = query.Where("strategy = ?", filter.Strategy)
+	}
+	if filter.Status != nil {
+		query = query.Where("status = ?", *filter.Status)
+	}
+	if filter.FromDay != "" {
+		query = query.Where("day >= ?", filter.FromDay)
+	}
+	if filter.ToDay != "" {
+		query = query.Where("day < ?", filter.ToDay)
+	}
+	var stats []models.DailyStrategyStat
+	err := query.Order("day DESC, strategy").Find(&stats).Error
+	return stats, err
+}
+
+// VirtualProfitBackfillPending 打开存储时迁移新增了 virtual_profit 列
+func (s *GormStore) VirtualProfitBackfillPending() bool {
+	return s.virtualProfitPending
+}
+
+// SetVirtualProfits 批量更新纸面盈亏（单个事务）
+func (s *GormStore) SetVirtualProfits(profits map[uint]float64) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		for id, profit := range profits {
+			if err := tx.Model(&models.StrategyHistory{}).Where("id = ?", id).Update("virtual_profit", profit).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// RebuildDailyStats 由历史记录重建每日策略聚合（按ID分批读取明细，在 Go 中按营业日汇总，分批写入，并记录所用营业日历）
+func (s *GormStore) RebuildDailyStats() (int, error) {
+	var stats []models.DailyStrategyStat
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&models.DailyStrategyStat{}).Error; err != nil {
+			return err
+		}
+		index := make(map[dailyStatKey]int)
+		var lastID uint
+		for {
+			var batch []models.StrategyHistory
+			if err := tx.Where("id > ?", lastID).Order("id").Limit(rebuildBatchSize).Find(&batch).Error; err != nil {
+				return err
+			}
+			for i := range batch {
+				stats = mergeDailyStat(stats, index, dailyStatOf(&batch[i]))
+			}
+			if len(batch) < rebuildBatchSize {
+				break
+			}
+			lastID = batch[len(batch)-1].ID
+		}
+		rebuiltAt := now()
+		if len(stats) > 0 {
+			for i := range stats {
+				stats[i].UpdatedAt = rebuiltAt
+			}
+			if err := tx.CreateInBatches(&stats, 500).Error; err != nil {
+				return err
+			}
+		}
+		// 记录本次汇总使用的营业日历
+		return tx.Save(&models.StatsCalendar{ID: 1, Signature: GetCalendar().Signature(), UpdatedAt: rebuiltAt}).Error
+	})
+	return len(stats), err
+}
+
+// statsCalendarStale 每日聚合是否按其他营业日历汇总（没有记录时视为过期，旧版本未记录日历）
+func statsCalendarStale(db *gorm.DB) (bool, error) {
+	var cal models.StatsCalendar
+	if err := db.First(&cal, 1).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return true, nil
+		}
+		return false, err
+	}
+	return cal.Signature != GetCalendar().Signature(), nil
+}
+
+// LoadConfig 读取配置
+func (s *GormStore) LoadConfig() (*models.SystemConfig, error) {
+	var cfg models.SystemConfig
+	if err := s.session().First(&cfg).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &cfg, nil
+}
+
+// SaveConfig 保存配置（存在则更新，不存在则创建）
+func (s *GormStore) SaveConfig(cfg *models.SystemConfig) error {
+	return s.session().Save(cfg).Error
+}
+
+// CreateConfigVersion 追加配置版本（在事务内分配版本号）
+func (s *GormStore) CreateConfigVersion(version *models.ConfigVersion) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var latest int
+		if err := tx.Model(&models.ConfigVersion{}).Select("COALESCE(MAX(version), 0)").Scan(&latest).Error; err != nil {
+			return err
+		}
+		version.Version = latest + 1
+		return tx.Create(version).Error
+	})
+}
+
+// ListConfigVersions 分页查询配置版本
+func (s *GormStore) ListConfigVersions(offset, limit int) ([]models.ConfigVersion, int64, error) {
+	var total int64
+	if err := s.session().Model(&models.ConfigVersion{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var versions []models.ConfigVersion
+	err := s.session().
+		Order("version DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&versions).Error
+	return versions, total, err
+}
+
+// GetConfigVersion 按版本号查询
+func (s *GormStore) GetConfigVersion(version int) (*models.ConfigVersion, error) {
+	var v models.ConfigVersion
+	if err := s.session().Where("version = ?", version).First(&v).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &v, nil
+}
+
+// SaveProfile 按名称创建或更新配置方案
+func (s *GormStore) SaveProfile(profile *models.ConfigProfile) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var existing models.ConfigProfile
+		err := tx.Where("name = ?", profile.Name).First(&existing).Error
+		switch {
+		case err == nil:
+			profile.ID = existing.ID
+			profile.CreatedAt = existing.CreatedAt
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+		return tx.Save(profile).Error
+	})
+}
+
+// ListProfiles 配置方案列表
+func (s *GormStore) ListProfiles() ([]models.ConfigProfile, error) {
+	var profiles []models.ConfigProfile
+	err := s.session().Order("name").Find(&profiles).Error
+	return profiles, err
+}
+
+// DeleteProfile 删除配置方案
+func (s *GormStore) DeleteProfile(name string) error {
+	result := s.session().Where("name = ?", name).Delete(&models.ConfigProfile{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// UpsertUserBets 在一个事务内按幂等键或（期号, 账号）写入用户派彩记录
+func (s *GormStore) UpsertUserBets(bets []*models.UserBet) ([]UpsertResult, error) {
+	results := make([]UpsertResult, 0, len(bets))
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for _, bet := range bets {
+			result, err := upsertUserBet(tx, bet)
+			if err != nil {
+				return err
+			}
+			results = append(results, result)
+		}
+		return nil
+	})
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		// 查询之后另一个请求抢先写入了同一期号和账号（或同一幂等键）
+		return nil, ErrDuplicate
+	}
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// upsertUserBet 在事务内写入一条用户派彩记录
+func upsertUserBet(tx *gorm.DB, bet *models.UserBet) (UpsertResult, error) {
+	var existing models.UserBet
+	if bet.IdempotencyKey != nil {
+		err := tx.Preload("Items").Where("idempotency_key = ?", *bet.IdempotencyKey).First(&existing).Error
+		if err == nil {
+			if existing.RoundID != bet.RoundID || existing.UserAccount != bet.UserAccount {
+				return "", ErrDuplicate
+			}
+			*bet = existing
+			return UpsertUnchanged, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", err
+		}
+	}
+
+	err := tx.Where("round_id = ? AND user_account = ?", bet.RoundID, bet.UserAccount).First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return UpsertCreated, tx.Create(bet).Error
+	}
+	if err != nil {
+		return "", err
+	}
+
+	bet.ID = existing.ID
+	bet.CreatedAt = existing.CreatedAt
+	if err := tx.Omit("Items").Save(bet).Error; err != nil {
+		return "", err
+	}
+	if err := tx.Where("user_bet_id = ?", bet.ID).Delete(&models.UserBetItem{}).Error; err != nil {
+		return "", err
+	}
+	for i := range bet.Items {
+		bet.Items[i].ID = 0
+		bet.Items[i].UserBetID = bet.ID
+	}
+	if len(bet.Items) > 0 {
+		if err := tx.Create(&bet.Items).Error; err != nil {
+			return "", err
+		}
+	}
+	return UpsertUpdated, nil
+}
+
+// UserBetsByRounds 批量查询多期的用户派彩记录
+func (s *GormStore) UserBetsByRounds(roundIDs []string) ([]models.UserBet, error) {
+	var bets []models.UserBet
+	if len(roundIDs) == 0 {
+		return bets, nil
+	}
+	err := s.session().Preload("Items").Where("round_id IN ?", roundIDs).Order("id").Find(&bets).Error
+	return bets, err
+}
+
+// ListUserBets 按账号和上传时间查询用户派彩记录
+func (s *GormStore) ListUserBets(query UserBetQuery) ([]models.UserBet, error) {
+	db := s.session().Model(&models.UserBet{})
+	if query.Account != "" {
+		db = db.Where("user_account = ?", query.Account)
+	}
+	if !query.From.IsZero() {
+		db = db.Where("created_at >= ?", query.From)
+	}
+	if !query.To.IsZero() {
+		db = db.Where("created_at < ?", query.To)
+	}
+	if query.Limit > 0 {
+		db = db.Offset(query.Offset).Limit(query.Limit)
+	}
+	var bets []models.UserBet
+	err := db.Preload("Items").Order("created_at, id").Find(&bets).Error
+	return bets, err
+}
+
+// CreateUser 创建用户
+func (s *GormStore) CreateUser(user *models.User) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.User{}).Where("username = ?", user.Username).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrDuplicate
+		}
+		return tx.Create(user).Error
+	})
+}
+
+// GetUser 按ID查询用户
+func (s *GormStore) GetUser(id uint) (*models.User, error) {
+	var user models.User
+	if err := s.session().First(&user, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &user, nil
+}
+
+// GetUserByName 按用户名查询
+func (s *GormStore) GetUserByName(username string) (*models.User, error) {
+	var user models.User
+	if err := s.session().Where("username = ?", username).First(&user).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &user, nil
+}
+
+// ListUsers 全部用户
+func (s *GormStore) ListUsers() ([]models.User, error) {
+	var users []models.User
+	err := s.session().Order("id").Find(&users).Error
+	return users, err
+}
+
+// CreateAPIKey 写入一个 API 密钥
+func (s *GormStore) CreateAPIKey(key *models.APIKey) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.APIKey{}).Where("key_hash = ?", key.KeyHash).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrDuplicate
+		}
+		return tx.Create(key).Error
+	})
+}
+
+// GetAPIKeyByHash 按哈希查询 API 密钥
+func (s *GormStore) GetAPIKeyByHash(hash string) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := s.session().Where("key_hash = ?", hash).First(&key).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &key, nil
+}
+
+// ListAPIKeys 全部 API 密钥
+func (s *GormStore) ListAPIKeys() ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := s.session().Order("id").Find(&keys).Error
+	return keys, err
+}
+
+// RevokeAPIKey 吊销 API 密钥
+func (s *GormStore) RevokeAPIKey(id uint, at time.Time) error {
+	result := s.session().Model(&models.APIKey{}).Where("id = ?", id).Update("revoked_at", at)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// TouchAPIKey 更新最近使用时间
+func (s *GormStore) TouchAPIKey(id uint, at time.Time) error {
+	return s.session().Model(&models.APIKey{}).Where("id = ?", id).Update("last_used_at", at).Error
+}
+
+// CreateSession 写入一个登录会话
+func (s *GormStore) CreateSession(session *models.UserSession) error {
+	return s.session().Create(session).Error
+}
+
+// GetSessionByHash 按令牌哈希查询登录会话
+func (s *GormStore) GetSessionByHash(hash string) (*models.UserSession, error) {
+	var session models.UserSession
+	if err := s.session().Where("token_hash = ?", hash).First(&session).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &session, nil
+}
+
+// DeleteSession 删除登录会话
+func (s *GormStore) DeleteSession(hash string) error {
+	return s.session().Where("token_hash = ?", hash).Delete(&models.UserSession{}).Error
+}
+
+// DeleteExpiredSessions 删除已过期的会话
+func (s *GormStore) DeleteExpiredSessions(before time.Time) error {
+	return s.session().Where("expires_at < ?", before).Delete(&models.UserSession{}).Error
+}
+
+// CreateAudit 写入一条审计日志
+func (s *GormStore) CreateAudit(entry *models.AuditLog) error {
+	return s.session().Create(entry).Error
+}
+
+// ListAudit 分页查询审计日志
+func (s *GormStore) ListAudit(query AuditQuery) ([]models.AuditLog, int64, error) {
+	scope := func() *gorm.DB {
+		db := s.session().Model(&models.AuditLog{})
+		if query.Actor != "" {
+			db = db.Where("actor = ?", query.Actor)
+		}
+		if query.Action != "" {
+			db = db.Where("action = ?", query.Action)
+		}
+		if !query.From.IsZero() {
+			db = db.Where("created_at >= ?", query.From)
+		}
+		if !query.To.IsZero() {
+			db = db.Where("created_at < ?", query.To)
+		}
+		return db
+	}
+
+	var total int64
+	if err := scope().Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var entries []models.AuditLog
+	err := scope().
+		Order("created_at DESC, id DESC").
+		Limit(query.Limit).
+		Offset(query.Offset).
+		Find(&entries).Error
+	return entries, total, err
+}
+
+// SaveReport 保存报表（同一类型和周期已存在时覆盖）
+func (s *GormStore) SaveReport(report *models.Report) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var existing models.Report
+		err := tx.Select("id").Where("kind = ? AND period = ?", report.Kind, report.Period).First(&existing).Error
+		switch {
+		case err == nil:
+			report.ID = existing.ID
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+		if report.CreatedAt == nil {
+			report.CreatedAt = now()
+		}
+		return tx.Save(report).Error
+	})
+}
+
+// GetReport 按ID查询报表
+func (s *GormStore) GetReport(id uint) (*models.Report, error) {
+	var report models.Report
+	if err := s.session().First(&report, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &report, nil
+}
+
+// FindReport 按类型和周期查询报表
+func (s *GormStore) FindReport(kind, period string) (*models.Report, error) {
+	var report models.Report
+	if err := s.session().Where("kind = ? AND period = ?", kind, period).First(&report).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &report, nil
+}
+
+// ListReports 报表列表（不读取正文）
+func (s *GormStore) ListReports(kind string, limit int) ([]models.Report, error) {
+	query := s.session().Omit("html", "csv").Order("period_start DESC").Order("id DESC")
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	var reports []models.Report
+	err := query.Find(&reports).Error
+	return reports, err
+}
+
+// UpdateReportDelivery 记录投递结果（MySQL 值未变化时影响行数为0，不据此判断记录是否存在）
+func (s *GormStore) UpdateReportDelivery(id uint, deliveredAt *time.Time, deliveryError string) error {
+	return s.session().Model(&models.Report{}).Where("id = ?", id).
+		Updates(map[string]any{"delivered_at": deliveredAt, "delivery_error": deliveryError}).Error
+}
+
+// CreateAlertRule 创建告警规则
+func (s *GormStore) CreateAlertRule(rule *models.AlertRule) error {
+	if rule.CreatedAt == nil {
+		rule.CreatedAt = now()
+	}
+	if rule.UpdatedAt == nil {
+		rule.UpdatedAt = rule.CreatedAt
+	}
+	return s.session().Create(rule).Error
+}
+
+// UpdateAlertRule 整条更新告警规则（先查询确认存在：MySQL 值未变化时影响行数为0）
+func (s *GormStore) UpdateAlertRule(rule *models.AlertRule) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var existing models.AlertRule
+		if err := tx.Select("id", "created_at", "created_by").First(&existing, rule.ID).Error; err != nil {
+			return notFound(err)
+		}
+		rule.CreatedAt, rule.CreatedBy = existing.CreatedAt, existing.CreatedBy
+		if rule.UpdatedAt == nil {
+			rule.UpdatedAt = now()
+		}
+		return tx.Save(rule).Error
+	})
+}
+
+// DeleteAlertRule 删除告警规则
+func (s *GormStore) DeleteAlertRule(id uint) error {
+	result := s.session().Delete(&models.AlertRule{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// GetAlertRule 按ID查询告警规则
+func (s *GormStore) GetAlertRule(id uint) (*models.AlertRule, error) {
+	var rule models.AlertRule
+	if err := s.session().First(&rule, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &rule, nil
+}
+
+// ListAlertRules 全部告警规则
+func (s *GormStore) ListAlertRules() ([]models.AlertRule, error) {
+	var rules []models.AlertRule
+	err := s.session().Order("id").Find(&rules).Error
+	return rules, err
+}
+
+// CreateAlert 写入告警记录
+func (s *GormStore) CreateAlert(alert *models.Alert) error {
+	if alert.CreatedAt == nil {
+		alert.CreatedAt = now()
+	}
+	return s.session().Create(alert).Error
+}
+
+// ListAlerts 分页查询告警记录
+func (s *GormStore) ListAlerts(query AlertQuery) ([]models.Alert, int64, error) {
+	scope := func() *gorm.DB {
+		db := s.session().Model(&models.Alert{})
+		if query.RuleID != 0 {
+			db = db.Where("rule_id = ?", query.RuleID)
+		}
+		if query.Severity != "" {
+			db = db.Where("severity = ?", query.Severity)
+		}
+		if query.Subject != "" {
+			db = db.Where("subject = ?", query.Subject)
+		}
+		if !query.From.IsZero() {
+			db = db.Where("created_at >= ?", query.From)
+		}
+		if !query.To.IsZero() {
+			db = db.Where("created_at < ?", query.To)
+		}
+		return db
+	}
+
+	var total int64
+	if err := scope().Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var alerts []models.Alert
+	err := scope().
+		Order("created_at DESC, id DESC").
+		Limit(query.Limit).
+		Offset(query.Offset).
+		Find(&alerts).Error
+	return alerts, total, err
+}
+
+// UpdateAlertDelivery 记录通知结果（不据影响行数判断记录是否存在）
+func (s *GormStore) UpdateAlertDelivery(id uint, deliveredAt *time.Time, deliveryError string) error {
+	return s.session().Model(&models.Alert{}).Where("id = ?", id).
+		Updates(map[string]any{"delivered_at": deliveredAt, "delivery_error": deliveryError}).Error
+}
@@ -0,0 +1,1275 @@
+package database
+
+import (
+	"benz-sniper/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryStore 内存存储实现（测试和临时运行使用，重启后数据丢失）
+type MemoryStore struct {
+	mu            sync.RWMutex
+	rounds        []models.GameRound
+	winners       []models.GameWinner
+	distributions []models.BetDistribution
+	history       []models.StrategyHistory
+	dailyStats    []models.DailyStrategyStat
+	sessions      []models.HistorySession
+	userBets      []models.UserBet
+	users         []models.User
+	apiKeys       []models.APIKey
+	userSessions  []models.UserSession
+	audit         []models.AuditLog
+	config        *models.SystemConfig
+	versions      []models.ConfigVersion
+	profiles      []models.ConfigProfile
+	reports       []models.Report
+	alertRules    []models.AlertRule
+	alerts        []models.Alert
+	nextID        uint
+}
+
+// NewMemoryStore 创建内存存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// Ping 内存存储始终可用
+func (s *MemoryStore) Ping() error {
+	return nil
+}
+
+// CheckSchema 内存存储没有表结构
+func (s *MemoryStore) CheckSchema() error {
+	return nil
+}
+
+// Close 内存存储无需关闭
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+// newID 生成自增ID（调用前需持有写锁）
+func (s *MemoryStore) newID() uint {
+	s.nextID++
+	return s.nextID
+}
+
+// now 当前时间指针
+func now() *time.Time {
+	t := time.Now().Local()
+	return &t
+}
+
+// LatestRound 最新一期
+func (s *MemoryStore) LatestRound() (*models.GameRound, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var latest *models.GameRound
+	for i := range s.rounds {
+		if latest == nil || s.rounds[i].RoundID > latest.RoundID {
+			latest = &s.rounds[i]
+		}
+	}
+	if latest == nil {
+		return nil, ErrNotFound
+	}
+	round := *latest
+	return &round, nil
+}
+
+// RecentRounds 最近 limit 期（按期号降序）
+func (s *MemoryStore) RecentRounds(limit int) ([]models.GameRound, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rounds := make([]models.GameRound, len(s.rounds))
+	copy(rounds, s.rounds)
+	sort.Slice(rounds, func(i, j int) bool {
+		return rounds[i].RoundID > rounds[j].RoundID
+	})
+	if limit > 0 && len(rounds) > limit {
+		rounds = rounds[:limit]
+	}
+	return rounds, nil
+}
+
+// GetRound 按期号查询
+func (s *MemoryStore) GetRound(roundID string) (*models.GameRound, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, r := range s.rounds {
+		if r.RoundID == roundID {
+			round := r
+			return &round, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// WinnersByRounds 批量查询多期的获胜项
+func (s *MemoryStore) WinnersByRounds(roundIDs []string) ([]models.GameWinner, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := toSet(roundIDs)
+	winners := make([]models.GameWinner, 0)
+	for _, w := range s.winners {
+		if ids[w.RoundID] {
+			winners = append(winners, w)
+		}
+	}
+	sort.SliceStable(winners, func(i, j int) bool {
+		if winners[i].RoundID != winners[j].RoundID {
+			return winners[i].RoundID < winners[j].RoundID
+		}
+		return winners[i].Position < winners[j].Position
+	})
+	return winners, nil
+}
+
+// ListRounds 按期号升序分批查询
+func (s *MemoryStore) ListRounds(query RoundQuery) ([]models.GameRound, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rounds := make([]models.GameRound, 0)
+	for _, r := range s.rounds {
+		if query.AfterRoundID != "" && r.RoundID <= query.AfterRoundID {
+			continue
+		}
+		if inRange(timeOf(r.CreatedAt), query.From, query.To) {
+			rounds = append(rounds, r)
+		}
+	}
+	sort.Slice(rounds, func(i, j int) bool { return rounds[i].RoundID < rounds[j].RoundID })
+	return paginate(rounds, 0, query.Limit), nil
+}
+
+// DistributionsByRound 查询单期投注分布
+func (s *MemoryStore) DistributionsByRound(roundID string) ([]models.BetDistribution, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	distributions := make([]models.BetDistribution, 0)
+	for _, d := range s.distributions {
+		if d.RoundID == roundID {
+			distributions = append(distributions, d)
+		}
+	}
+	sort.SliceStable(distributions, func(i, j int) bool {
+		return distributions[i].OptionID < distributions[j].OptionID
+	})
+	return distributions, nil
+}
+
+// SaveRound 写入一期完整数据
+func (s *MemoryStore) SaveRound(round *models.GameRound, winners []models.GameWinner, distributions []models.BetDistribution) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, r := range s.rounds {
+		if r.RoundID == round.RoundID {
+			return ErrDuplicate
+		}
+	}
+	for i := range winners {
+		winners[i].ID = s.newID()
+		if winners[i].CreatedAt == nil {
+			winners[i].CreatedAt = now()
+		}
+		s.winners = append(s.winners, winners[i])
+	}
+	for i := range distributions {
+		distributions[i].ID = s.newID()
+		if distributions[i].CreatedAt == nil {
+			distributions[i].CreatedAt = now()
+		}
+		s.distributions = append(s.distributions, distributions[i])
+	}
+	round.ID = s.newID()
+	if round.CreatedAt == nil {
+		round.CreatedAt = now()
+	}
+	s.rounds = append(s.rounds, *round)
+	return nil
+}
+
+// CreateHistory 写入一条历史记录
+func (s *MemoryStore) CreateHistory(history *models.StrategyHistory) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	history.ID = s.newID()
+	if history.CreatedAt == nil {
+		history.CreatedAt = now()
+	}
+	s.history = append(s.history, *history)
+	s.addDailyStat(dailyStatOf(history))
+	return nil
+}
+
+// addDailyStat 累加一行每日策略聚合（调用前需持有写锁）
+func (s *MemoryStore) addDailyStat(stat models.DailyStrategyStat) {
+	for i := range s.dailyStats {
+		row := &s.dailyStats[i]
+		if row.Day == stat.Day && row.Strategy == stat.Strategy && row.Status == stat.Status && row.SessionID == stat.SessionID {
+			row.Bets += stat.Bets
+			row.Wins += stat.Wins
+			row.Profit += stat.Profit
+			row.VirtualProfit += stat.VirtualProfit
+			row.BetAmount += stat.BetAmount
+			row.UpdatedAt = stat.UpdatedAt
+			return
+		}
+	}
+	stat.ID = s.newID()
+	s.dailyStats = append(s.dailyStats, stat)
+}
+
+// matchHistory 判断历史记录是否满足筛选条件
+func matchHistory(h models.StrategyHistory, filter HistoryFilter) bool {
+	if filter.Strategy != "" && h.Strategy != filter.Strategy {
+		return false
+	}
+	if filter.Status != nil && h.Status != *filter.Status {
+		return false
+	}
+	if !inRange(timeOf(h.CreatedAt), filter.From, filter.To) {
+		return false
+	}
+	if filter.Result != "" && h.Result != filter.Result {
+		return false
+	}
+	if filter.RoundFrom != "" && RoundIDLess(h.RoundID, filter.RoundFrom) {
+		return false
+	}
+	if filter.RoundTo != "" && RoundIDLess(filter.RoundTo, h.RoundID) {
+		return false
+	}
+	switch filter.SpecialReward {
+	case "":
+	case SpecialAny:
+		if h.SpecialReward == "" {
+			return false
+		}
+	default:
+		if h.SpecialReward != filter.SpecialReward {
+			return false
+		}
+	}
+	if filter.MinProfit != nil && h.Profit < *filter.MinProfit {
+		return false
+	}
+	return h.SessionID == filter.SessionID
+}
+
+// inRange 时间是否在 [from, to) 内（零值表示不限制）
+func inRange(t, from, to time.Time) bool {
+	if !from.IsZero() && t.Before(from) {
+		return false
+	}
+	return to.IsZero() || t.Before(to)
+}
+
+// filterHistory 按条件筛选历史记录（调用前需持有读锁）
+func (s *MemoryStore) filterHistory(filter HistoryFilter) []models.StrategyHistory {
+	records := make([]models.StrategyHistory, 0)
+	for _, h := range s.history {
+		if matchHistory(h, filter) {
+			records = append(records, h)
+		}
+	}
+	return records
+}
+
+// ListHistory 分页查询历史记录
+func (s *MemoryStore) ListHistory(query HistoryQuery) ([]models.StrategyHistory, int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	column := historyOrder(query.Sort)
+	if query.After != nil && column != HistorySortTime {
+		return nil, 0, ErrInvalidCursor
+	}
+
+	// less a 是否排在 b 之前（排序字段相同时按 id 同向排序）
+	less := func(a, b models.StrategyHistory) bool {
+		var ka, kb float64
+		switch column {
+		case HistorySortProfit:
+			ka, kb = a.Profit, b.Profit
+		case HistorySortBet:
+			ka, kb = a.BetAmount, b.BetAmount
+		default:
+			ta, tb := timeOf(a.CreatedAt), timeOf(b.CreatedAt)
+			if !ta.Equal(tb) {
+				return ta.Before(tb) == query.Asc
+			}
+		}
+		if ka != kb {
+			return (ka < kb) == query.Asc
+		}
+		if a.ID == b.ID {
+			return false
+		}
+		return (a.ID < b.ID) == query.Asc
+	}
+
+	records := s.filterHistory(query.HistoryFilter)
+	sort.Slice(records, func(i, j int) bool { return less(records[i], records[j]) })
+
+	total := int64(len(records))
+	if query.SkipCount {
+		total = -1
+	}
+	if query.After != nil {
+		after := models.StrategyHistory{ID: query.After.ID, CreatedAt: &query.After.CreatedAt}
+		start := sort.Search(len(records), func(i int) bool { return less(after, records[i]) })
+		return paginate(records[start:], 0, query.Limit), total, nil
+	}
+	return paginate(records, query.Offset, query.Limit), total, nil
+}
+
+// ArchiveHistory 把当前记录归档到新会话
+func (s *MemoryStore) ArchiveHistory(session *models.HistorySession) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var stat HistoryStat
+	session.RealProfit = 0
+	session.StartedAt = nil
+	session.ID = s.newID()
+	for i := range s.history {
+		h := &s.history[i]
+		if h.SessionID != 0 {
+			continue
+		}
+		addStat(&stat, *h)
+		if h.Status == 1 {
+			session.RealProfit += h.Profit
+		}
+		if session.StartedAt == nil || timeOf(h.CreatedAt).Before(*session.StartedAt) {
+			session.StartedAt = h.CreatedAt
+		}
+		h.SessionID = session.ID
+	}
+	for i := range s.dailyStats {
+		if s.dailyStats[i].SessionID == 0 {
+			s.dailyStats[i].SessionID = session.ID
+		}
+	}
+	session.Bets = stat.Bets
+	session.Wins = stat.Wins
+	session.Profit = stat.Profit
+	s.sessions = append(s.sessions, *session)
+	return nil
+}
+
+// ListSessions 全部归档会话
+func (s *MemoryStore) ListSessions() ([]models.HistorySession, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sessions := append([]models.HistorySession{}, s.sessions...)
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].ID > sessions[j].ID })
+	return sessions, nil
+}
+
+// GetSession 按ID查询归档会话
+func (s *MemoryStore) GetSession(id uint) (*models.HistorySession, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, sess := range s.sessions {
+		if sess.ID == id {
+			session := sess
+			return &session, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// RestoreSession 恢复归档会话的记录
+func (s *MemoryStore) RestoreSession(id uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.removeSession(id) {
+		return ErrNotFound
+	}
+	for i := range s.history {
+		if s.history[i].SessionID == id {
+			s.history[i].SessionID = 0
+		}
+	}
+
+	// 会话的聚合并入当前聚合
+	restored := s.removeDailyStats(id)
+	for _, stat := range restored {
+		stat.SessionID = 0
+		s.addDailyStat(stat)
+	}
+	return nil
+}
+
+// PurgeSession 彻底删除归档会话及其记录
+func (s *MemoryStore) PurgeSession(id uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.removeSession(id) {
+		return ErrNotFound
+	}
+	kept := s.history[:0]
+	for _, h := range s.history {
+		if h.SessionID != id {
+			kept = append(kept, h)
+		}
+	}
+	s.history = kept
+	s.removeDailyStats(id)
+	return nil
+}
+
+// removeDailyStats 删除归档会话的聚合并返回被删除的行（调用前需持有写锁）
+func (s *MemoryStore) removeDailyStats(sessionID uint) []models.DailyStrategyStat {
+	removed := make([]models.DailyStrategyStat, 0)
+	kept := s.dailyStats[:0]
+	for _, stat := range s.dailyStats {
+		if stat.SessionID == sessionID {
+			removed = append(removed, stat)
+		} else {
+			kept = append(kept, stat)
+		}
+	}
+	s.dailyStats = kept
+	return removed
+}
+
+// removeSession 删除归档会话（调用前需持有写锁）
+func (s *MemoryStore) removeSession(id uint) bool {
+	for i, sess := range s.sessions {
+		if sess.ID == id {
+			s.sessions = append(s.sessions[:i], s.sessions[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// SumProfit 累计盈亏
+func (s *MemoryStore) SumProfit(filter HistoryFilter) (float64, error) {
+	stat, err := s.HistoryStats(filter)
+	return stat.Profit, err
+}
+
+// HistoryStats 总体统计
+func (s *MemoryStore) HistoryStats(filter HistoryFilter) (HistoryStat, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var stat HistoryStat
+	for _, h := range s.filterHistory(filter) {
+		addStat(&stat, h)
+	}
+	return stat, nil
+}
+
+// HistoryStatsByStrategy 按策略分组统计
+func (s *MemoryStore) HistoryStatsByStrategy(filter HistoryFilter) ([]HistoryStat, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stats := groupStats(s.filterHistory(filter), func(h models.StrategyHistory) string {
+		return h.Strategy
+	})
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Key < stats[j].Key })
+	return stats, nil
+}
+
+// DailyStats 每日策略聚合
+func (s *MemoryStore) DailyStats(filter DailyStatFilter) ([]models.DailyStrategyStat, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stats := make([]models.DailyStrategyStat, 0)
+	for _, stat := range s.dailyStats {
+		if matchDailyStat(stat, filter) {
+			stats = append(stats, stat)
+		}
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Day != stats[j].Day {
+			return stats[i].Day > stats[j].Day
+		}
+		return stats[i].Strategy < stats[j].Strategy
+	})
+	return stats, nil
+}
+
+// VirtualProfitBackfillPending 内存存储每次启动都是空的，无需回填
+func (s *MemoryStore) VirtualProfitBackfillPending() bool {
+	return false
+}
+
+// SetVirtualProfits 批量更新纸面盈亏
+func (s *MemoryStore) SetVirtualProfits(profits map[uint]float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.history {
+		if profit, ok := profits[s.history[i].ID]; ok {
+			s.history[i].VirtualProfit = profit
+		}
+	}
+	return nil
+}
+
+// RebuildDailyStats 由历史记录重建每日策略聚合
+func (s *MemoryStore) RebuildDailyStats() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.dailyStats = nil
+	for i := range s.history {
+		s.addDailyStat(dailyStatOf(&s.history[i]))
+	}
+	return len(s.dailyStats), nil
+}
+
+// LoadConfig 读取配置
+func (s *MemoryStore) LoadConfig() (*models.SystemConfig, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.config == nil {
+		return nil, ErrNotFound
+	}
+	cfg := *s.config
+	return &cfg, nil
+}
+
+// SaveConfig 保存配置
+func (s *MemoryStore) SaveConfig(cfg *models.SystemConfig) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cfg.ID == 0 {
+		cfg.ID = 1
+	}
+	cfg.UpdatedAt = now()
+	saved := *cfg
+	s.config = &saved
+	return nil
+}
+
+// CreateConfigVersion 追加配置版本
+func (s *MemoryStore) CreateConfigVersion(version *models.ConfigVersion) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	version.ID = s.newID()
+	version.Version = len(s.versions) + 1
+	if version.CreatedAt == nil {
+		version.CreatedAt = now()
+	}
+	s.versions = append(s.versions, *version)
+	return nil
+}
+
+// ListConfigVersions 分页查询配置版本（按版本号降序）
+func (s *MemoryStore) ListConfigVersions(offset, limit int) ([]models.ConfigVersion, int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	versions := make([]models.ConfigVersion, 0, len(s.versions))
+	for i := len(s.versions) - 1; i >= 0; i-- {
+		versions = append(versions, s.versions[i])
+	}
+	return paginate(versions, offset, limit), int64(len(versions)), nil
+}
+
+// GetConfigVersion 按版本号查询
+func (s *MemoryStore) GetConfigVersion(version int) (*models.ConfigVersion, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if version < 1 || version > len(s.versions) {
+		return nil, ErrNotFound
+	}
+	v := s.versions[version-1]
+	return &v, nil
+}
+
+// SaveProfile 按名称创建或更新配置方案
+func (s *MemoryStore) SaveProfile(profile *models.ConfigProfile) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	profile.UpdatedAt = now()
+	for i := range s.profiles {
+		if s.profiles[i].Name == profile.Name {
+			profile.ID = s.profiles[i].ID
+			profile.CreatedAt = s.profiles[i].CreatedAt
+			s.profiles[i] = *profile
+			return nil
+		}
+	}
+	profile.ID = s.newID()
+	if profile.CreatedAt == nil {
+		profile.CreatedAt = profile.UpdatedAt
+	}
+	s.profiles = append(s.profiles, *profile)
+	return nil
+}
+
+// ListProfiles 配置方案列表（按名称排序）
+func (s *MemoryStore) ListProfiles() ([]models.ConfigProfile, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	profiles := append([]models.ConfigProfile(nil), s.profiles...)
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].Name < profiles[j].Name })
+	return profiles, nil
+}
+
+// DeleteProfile 删除配置方案
+func (s *MemoryStore) DeleteProfile(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.profiles {
+		if s.profiles[i].Name == name {
+			s.profiles = append(s.profiles[:i], s.profiles[i+1:]...)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// UpsertUserBets 按幂等键或（期号, 账号）写入用户派彩记录（任一条失败时恢复到写入前）
+func (s *MemoryStore) UpsertUserBets(bets []*models.UserBet) ([]UpsertResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	saved := append([]models.UserBet(nil), s.userBets...)
+	results := make([]UpsertResult, 0, len(bets))
+	for _, bet := range bets {
+		result, err := s.upsertUserBet(bet)
+		if err != nil {
+			s.userBets = saved
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// upsertUserBet 写入一条用户派彩记录（调用方持有锁）
+func (s *MemoryStore) upsertUserBet(bet *models.UserBet) (UpsertResult, error) {
+	if bet.IdempotencyKey != nil {
+		for _, b := range s.userBets {
+			if b.IdempotencyKey == nil || *b.IdempotencyKey != *bet.IdempotencyKey {
+				continue
+			}
+			if b.RoundID != bet.RoundID || b.UserAccount != bet.UserAccount {
+				return "", ErrDuplicate
+			}
+			*bet = copyUserBet(b)
+			return UpsertUnchanged, nil
+		}
+	}
+
+	for i := range bet.Items {
+		bet.Items[i].ID = s.newID()
+	}
+	for i, b := range s.userBets {
+		if b.RoundID == bet.RoundID && b.UserAccount == bet.UserAccount {
+			bet.ID = b.ID
+			bet.CreatedAt = b.CreatedAt
+			if bet.UpdatedAt == nil {
+				bet.UpdatedAt = now()
+			}
+			setItemOwner(bet)
+			s.userBets[i] = copyUserBet(*bet)
+			return UpsertUpdated, nil
+		}
+	}
+
+	bet.ID = s.newID()
+	if bet.CreatedAt == nil {
+		bet.CreatedAt = now()
+	}
+	if bet.UpdatedAt == nil {
+		bet.UpdatedAt = bet.CreatedAt
+	}
+	setItemOwner(bet)
+	s.userBets = append(s.userBets, copyUserBet(*bet))
+	return UpsertCreated, nil
+}
+
+// setItemOwner 明细关联到所属记录
+func setItemOwner(bet *models.UserBet) {
+	for i := range bet.Items {
+		bet.Items[i].UserBetID = bet.ID
+	}
+}
+
+// copyUserBet 复制记录（明细切片和幂等键不与调用方共享）
+func copyUserBet(b models.UserBet) models.UserBet {
+	if b.IdempotencyKey != nil {
+		key := *b.IdempotencyKey
+		b.IdempotencyKey = &key
+	}
+	if b.Items != nil {
+		b.Items = append([]models.UserBetItem(nil), b.Items...)
+	}
+	return b
+}
+
+// UserBetsByRounds 批量查询多期的用户派彩记录
+func (s *MemoryStore) UserBetsByRounds(roundIDs []string) ([]models.UserBet, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := toSet(roundIDs)
+	bets := make([]models.UserBet, 0)
+	for _, b := range s.userBets {
+		if ids[b.RoundID] {
+			bets = append(bets, copyUserBet(b))
+		}
+	}
+	return bets, nil
+}
+
+// ListUserBets 按账号和上传时间查询用户派彩记录
+func (s *MemoryStore) ListUserBets(query UserBetQuery) ([]models.UserBet, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	bets := make([]models.UserBet, 0)
+	for _, b := range s.userBets {
+		if query.Account != "" && b.UserAccount != query.Account {
+			continue
+		}
+		if !inRange(timeOf(b.CreatedAt), query.From, query.To) {
+			continue
+		}
+		bets = append(bets, copyUserBet(b))
+	}
+	sort.SliceStable(bets, func(i, j int) bool {
+		ti, tj := timeOf(bets[i].CreatedAt), timeOf(bets[j].CreatedAt)
+		if !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+		return bets[i].ID < bets[j].ID
+	})
+	return paginate(bets, query.Offset, query.Limit), nil
+}
+
+// CreateUser 创建用户
+func (s *MemoryStore) CreateUser(user *models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, u := range s.users {
+		if u.Username == user.Username {
+			return ErrDuplicate
+		}
+	}
+	user.ID = s.newID()
+	if user.CreatedAt == nil {
+		user.CreatedAt = now()
+	}
+	user.UpdatedAt = user.CreatedAt
+	s.users = append(s.users, *user)
+	return nil
+}
+
+// GetUser 按ID查询用户
+func (s *MemoryStore) GetUser(id uint) (*models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, u := range s.users {
+		if u.ID == id {
+			user := u
+			return &user, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// GetUserByName 按用户名查询
+func (s *MemoryStore) GetUserByName(username string) (*models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, u := range s.users {
+		if u.Username == username {
+			user := u
+			return &user, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// ListUsers 全部用户
+func (s *MemoryStore) ListUsers() ([]models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]models.User{}, s.users...), nil
+}
+
+// CreateAPIKey 写入一个 API 密钥
+func (s *MemoryStore) CreateAPIKey(key *models.APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, k := range s.apiKeys {
+		if k.KeyHash == key.KeyHash {
+			return ErrDuplicate
+		}
+	}
+	key.ID = s.newID()
+	if key.CreatedAt == nil {
+		key.CreatedAt = now()
+	}
+	s.apiKeys = append(s.apiKeys, *key)
+	return nil
+}
+
+// GetAPIKeyByHash 按哈希查询 API 密钥
+func (s *MemoryStore) GetAPIKeyByHash(hash string) (*models.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, k := range s.apiKeys {
+		if k.KeyHash == hash {
+			key := k
+			return &key, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// ListAPIKeys 全部 API 密钥
+func (s *MemoryStore) ListAPIKeys() ([]models.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]models.APIKey{}, s.apiKeys...), nil
+}
+
+// RevokeAPIKey 吊销 API 密钥
+func (s *MemoryStore) RevokeAPIKey(id uint, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.apiKeys {
+		if s.apiKeys[i].ID == id {
+			s.apiKeys[i].RevokedAt = &at
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// TouchAPIKey 更新最近使用时间
+func (s *MemoryStore) TouchAPIKey(id uint, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.apiKeys {
+		if s.apiKeys[i].ID == id {
+			s.apiKeys[i].LastUsedAt = &at
+		}
+	}
+	return nil
+}
+
+// CreateSession 写入一个登录会话
+func (s *MemoryStore) CreateSession(session *models.UserSession) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session.ID = s.newID()
+	if session.CreatedAt == nil {
+		session.CreatedAt = now()
+	}
+	s.userSessions = append(s.userSessions, *session)
+	return nil
+}
+
+// GetSessionByHash 按令牌哈希查询登录会话
+func (s *MemoryStore) GetSessionByHash(hash string) (*models.UserSession, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, sess := range s.userSessions {
+		if sess.TokenHash == hash {
+			session := sess
+			return &session, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// DeleteSession 删除登录会话
+func (s *MemoryStore) DeleteSession(hash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.userSessions = removeSessions(s.userSessions, func(sess models.UserSession) bool {
+		return sess.TokenHash == hash
+	})
+	return nil
+}
+
+// DeleteExpiredSessions 删除已过期的会话
+func (s *MemoryStore) DeleteExpiredSessions(before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.userSessions = removeSessions(s.userSessions, func(sess models.UserSession) bool {
+		return sess.ExpiresAt.Before(before)
+	})
+	return nil
+}
+
+// removeSessions 移除满足条件的会话
+func removeSessions(sessions []models.UserSession, match func(models.UserSession) bool) []models.UserSession {
+	kept := sessions[:0]
+	for _, sess := range sessions {
+		if !match(sess) {
+			kept = append(kept, sess)
+		}
+	}
+	return kept
+}
+
+// CreateAudit 写入一条审计日志
+func (s *MemoryStore) CreateAudit(entry *models.AuditLog) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry.ID = s.newID()
+	if entry.CreatedAt == nil {
+		entry.CreatedAt = now()
+	}
+	s.audit = append(s.audit, *entry)
+	return nil
+}
+
+// ListAudit 分页查询审计日志
+func (s *MemoryStore) ListAudit(query AuditQuery) ([]models.AuditLog, int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries := make([]models.AuditLog, 0)
+	for _, e := range s.audit {
+		at := timeOf(e.CreatedAt)
+		if query.Actor != "" && e.Actor != query.Actor {
+			continue
+		}
+		if query.Action != "" && e.Action != query.Action {
+			continue
+		}
+		if !query.From.IsZero() && at.Before(query.From) {
+			continue
+		}
+		if !query.To.IsZero() && !at.Before(query.To) {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		ti, tj := timeOf(entries[i].CreatedAt), timeOf(entries[j].CreatedAt)
+		if !ti.Equal(tj) {
+			return ti.After(tj)
+		}
+		return entries[i].ID > entries[j].ID
+	})
+
+	total := int64(len(entries))
+	return paginate(entries, query.Offset, query.Limit), total, nil
+}
+
+// toSet 字符串切片转集合
+func toSet(items []string) map[string]bool {
+	set := make(map[string]bool, len(items))
+	for _, item := range items {
+		set[item] = true
+	}
+	return set
+}
+
+// timeOf 安全取出时间指针的值
+func timeOf(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
+
+// paginate 对切片分页
+func paginate[T any](items []T, offset, limit int) []T {
+	if offset >= len(items) {
+		return []T{}
+	}
+	end := len(items)
+	if limit > 0 && offset+limit < end {
+		end = offset + limit
+	}
+	return items[offset:end]
+}
+
+// addStat 累加一条历史记录到统计
+func addStat(stat *HistoryStat, h models.StrategyHistory) {
+	stat.Bets++
+	if h.Result == "赢" {
+		stat.Wins++
+	}
+	stat.Profit += h.Profit
+	stat.VirtualProfit += h.VirtualProfit
+}
+
+// groupStats 按分组键统计
+func groupStats(records []models.StrategyHistory, keyOf func(models.StrategyHistory) string) []HistoryStat {
+	index := make(map[string]int)
+	stats := make([]HistoryStat, 0)
+	for _, h := range records {
+		key := keyOf(h)
+		i, ok := index[key]
+		if !ok {
+			i = len(stats)
+			index[key] = i
+			stats = append(stats, HistoryStat{Key: key})
+		}
+		addStat(&stats[i], h)
+	}
+	return stats
+}
+
+// SaveReport 保存报表（同一类型和周期已存在时覆盖）
+func (s *MemoryStore) SaveReport(report *models.Report) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if report.CreatedAt == nil {
+		report.CreatedAt = now()
+	}
+	for i := range s.reports {
+		if s.reports[i].Kind == report.Kind && s.reports[i].Period == report.Period {
+			report.ID = s.reports[i].ID
+			s.reports[i] = *report
+			return nil
+		}
+	}
+	report.ID = s.newID()
+	s.reports = append(s.reports, *report)
+	return nil
+}
+
+// GetReport 按ID查询报表
+func (s *MemoryStore) GetReport(id uint) (*models.Report, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, report := range s.reports {
+		if report.ID == id {
+			return &report, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// FindReport 按类型和周期查询报表
+func (s *MemoryStore) FindReport(kind, period string) (*models.Report, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, report := range s.reports {
+		if report.Kind == kind && report.Period == period {
+			return &report, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// ListReports 报表列表（不含正文）
+func (s *MemoryStore) ListReports(kind string, limit int) ([]models.Report, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	reports := make([]models.Report, 0)
+	for _, report := range s.reports {
+		if kind == "" || report.Kind == kind {
+			report.HTML, report.CSV = "", ""
+			reports = append(reports, report)
+		}
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		a, b := timeOf(reports[i].PeriodStart), timeOf(reports[j].PeriodStart)
+		if !a.Equal(b) {
+			return a.After(b)
+		}
+		return reports[i].ID > reports[j].ID
+	})
+	if limit > 0 && len(reports) > limit {
+		reports = reports[:limit]
+	}
+	return reports, nil
+}
+
+// UpdateReportDelivery 记录投递结果
+func (s *MemoryStore) UpdateReportDelivery(id uint, deliveredAt *time.Time, deliveryError string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.reports {
+		if s.reports[i].ID == id {
+			s.reports[i].DeliveredAt = deliveredAt
+			s.reports[i].DeliveryError = deliveryError
+		}
+	}
+	return nil
+}
+
+// CreateAlertRule 创建告警规则
+func (s *MemoryStore) CreateAlertRule(rule *models.AlertRule) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rule.CreatedAt == nil {
+		rule.CreatedAt = now()
+	}
+	if rule.UpdatedAt == nil {
+		rule.UpdatedAt = rule.CreatedAt
+	}
+	rule.ID = s.newID()
+	s.alertRules = append(s.alertRules, *rule)
+	return nil
+}
+
+// UpdateAlertRule 整条更新告警规则（保留创建人和创建时间）
+func (s *MemoryStore) UpdateAlertRule(rule *models.AlertRule) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.alertRules {
+		if s.alertRules[i].ID == rule.ID {
+			rule.CreatedAt, rule.CreatedBy = s.alertRules[i].CreatedAt, s.alertRules[i].CreatedBy
+			if rule.UpdatedAt == nil {
+				rule.UpdatedAt = now()
+			}
+			s.alertRules[i] = *rule
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// DeleteAlertRule 删除告警规则
+func (s *MemoryStore) DeleteAlertRule(id uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.alertRules {
+		if s.alertRules[i].ID == id {
+			s.alertRules = append(s.alertRules[:i], s.alertRules[i+1:]...)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// GetAlertRule 按ID查询告警规则
+func (s *MemoryStore) GetAlertRule(id uint) (*models.AlertRule, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, rule := range s.alertRules {
+		if rule.ID == id {
+			return &rule, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// ListAlertRules 全部告警规则（ID 递增分配，保存顺序即ID顺序）
+func (s *MemoryStore) ListAlertRules() ([]models.AlertRule, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]models.AlertRule{}, s.alertRules...), nil
+}
+
+// CreateAlert 写入告警记录
+func (s *MemoryStore) CreateAlert(alert *models.Alert) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if alert.CreatedAt == nil {
+		alert.CreatedAt = now()
+	}
+	alert.ID = s.newID()
+	s.alerts = append(s.alerts, *alert)
+	return nil
+}
+
+// ListAlerts 分页查询告警记录
+func (s *MemoryStore) ListAlerts(query AlertQuery) ([]models.Alert, int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	alerts := make([]models.Alert, 0)
+	for _, a := range s.alerts {
+		at := timeOf(a.CreatedAt)
+		if query.RuleID != 0 && a.RuleID != query.RuleID {
+			continue
+		}
+		if query.Severity != "" && a.Severity != query.Severity {
+			continue
+		}
+		if query.Subject != "" && a.Subject != query.Subject {
+			continue
+		}
+		if !query.From.IsZero() && at.Before(query.From) {
+			continue
+		}
+		if !query.To.IsZero() && !at.Before(query.To) {
+			continue
+		}
+		alerts = append(alerts, a)
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		ti, tj := timeOf(alerts[i].CreatedAt), timeOf(alerts[j].CreatedAt)
+		if !ti.Equal(tj) {
+			return ti.After(tj)
+		}
+		return alerts[i].ID > alerts[j].ID
+	})
+
+	total := int64(len(alerts))
+	return paginate(alerts, query.Offset, query.Limit), total, nil
+}
+
+// UpdateAlertDelivery 记录通知结果
+func (s *MemoryStore) UpdateAlertDelivery(id uint, deliveredAt *time.Time, deliveryError string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.alerts {
+		if s.alerts[i].ID == id {
+			s.alerts[i].DeliveredAt = deliveredAt
+			s.alerts[i].DeliveryError = deliveryError
+		}
+	}
+	return nil
+}
@@ -0,0 +1,346 @@
+package database
+
+import (
+	"benz-sniper/models"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNotFound 记录不存在
+	ErrNotFound = errors.New("记录不存在")
+	// ErrDuplicate 记录已存在
+	ErrDuplicate = errors.New("记录已存在")
+)
+
+// Store 存储接口（引擎和策略管理器只依赖此接口，不直接依赖具体数据库）
+type Store interface {
+	RoundStore
+	HistoryStore
+	ConfigStore
+	UserBetStore
+	AuthStore
+	AuditStore
+	ReportStore
+	AlertStore
+
+	// Ping 检查存储连接是否可用
+	Ping() error
+	// CheckSchema 检查表结构是否已迁移（缺少数据表时返回错误）
+	CheckSchema() error
+	// Close 关闭存储
+	Close() error
+}
+
+// RoundStore 开奖数据（game_rounds / game_winners / bet_distribution）
+type RoundStore interface {
+	// LatestRound 最新一期（无数据返回 ErrNotFound）
+	LatestRound() (*models.GameRound, error)
+	// RecentRounds 最近 limit 期（按期号降序）
+	RecentRounds(limit int) ([]models.GameRound, error)
+	// GetRound 按期号查询（不存在返回 ErrNotFound）
+	GetRound(roundID string) (*models.GameRound, error)
+	// WinnersByRounds 批量查询多期的获胜项
+	WinnersByRounds(roundIDs []string) ([]models.GameWinner, error)
+	// ListRounds 按期号升序分批查询（导出使用，From/To 按 created_at 筛选）
+	ListRounds(query RoundQuery) ([]models.GameRound, error)
+	// DistributionsByRound 查询单期投注分布
+	DistributionsByRound(roundID string) ([]models.BetDistribution, error)
+	// SaveRound 写入一期完整数据（获胜项、分布先于期数写入）
+	SaveRound(round *models.GameRound, winners []models.GameWinner, distributions []models.BetDistribution) error
+}
+
+// HistoryStore 策略下注历史（strategy_history）
+type HistoryStore interface {
+	// CreateHistory 写入一条历史记录（同一事务内累加 daily_strategy_stats）
+	CreateHistory(history *models.StrategyHistory) error
+	// ListHistory 分页查询（默认按 created_at DESC, id DESC），同时返回总数
+	ListHistory(query HistoryQuery) ([]models.StrategyHistory, int64, error)
+	// ArchiveHistory 把当前记录（session_id=0）归档到新会话，会话的统计字段由存储计算填充
+	ArchiveHistory(session *models.HistorySession) error
+	// ListSessions 全部归档会话（按ID降序）
+	ListSessions() ([]models.HistorySession, error)
+	// GetSession 按ID查询归档会话（不存在返回 ErrNotFound）
+	GetSession(id uint) (*models.HistorySession, error)
+	// RestoreSession 把归档会话的记录恢复为当前记录并删除会话（不存在返回 ErrNotFound）
+	RestoreSession(id uint) error
+	// PurgeSession 彻底删除归档会话及其记录（不存在返回 ErrNotFound）
+	PurgeSession(id uint) error
+	// SumProfit 累计盈亏
+	SumProfit(filter HistoryFilter) (float64, error)
+	// HistoryStats 总体统计
+	HistoryStats(filter HistoryFilter) (HistoryStat, error)
+	// HistoryStatsByStrategy 按策略分组统计
+	HistoryStatsByStrategy(filter HistoryFilter) ([]HistoryStat, error)
+	// DailyStats 每日策略聚合（按日期降序、策略升序），归档/恢复/删除会话时随记录一起迁移
+	DailyStats(filter DailyStatFilter) ([]models.DailyStrategyStat, error)
+	// RebuildDailyStats 清空并由全部历史记录重新汇总每日策略聚合，返回聚合行数
+	RebuildDailyStats() (int, error)
+	// SetVirtualProfits 按记录ID批量更新纸面盈亏（回填使用，不更新每日聚合）
+	SetVirtualProfits(profits map[uint]float64) error
+	// VirtualProfitBackfillPending 打开存储时迁移新增了 virtual_profit 列（已有记录需回填纸面盈亏）
+	VirtualProfitBackfillPending() bool
+}
+
+// ConfigStore 系统配置（system_config 单行为当前配置，config_versions 为修改历史）
+type ConfigStore interface {
+	// LoadConfig 读取配置（不存在返回 ErrNotFound）
+	LoadConfig() (*models.SystemConfig, error)
+	// SaveConfig 保存配置（存在则更新，不存在则创建）
+	SaveConfig(cfg *models.SystemConfig) error
+	// CreateConfigVersion 追加配置版本（自动分配递增的版本号）
+	CreateConfigVersion(version *models.ConfigVersion) error
+	// ListConfigVersions 分页查询配置版本（按版本号降序）
+	ListConfigVersions(offset, limit int) ([]models.ConfigVersion, int64, error)
+	// GetConfigVersion 按版本号查询（不存在返回 ErrNotFound）
+	GetConfigVersion(version int) (*models.ConfigVersion, error)
+	// SaveProfile 按名称创建或更新配置方案
+	SaveProfile(profile *models.ConfigProfile) error
+	// ListProfiles 配置方案列表（按名称排序）
+	ListProfiles() ([]models.ConfigProfile, error)
+	// DeleteProfile 删除配置方案（不存在返回 ErrNotFound）
+	DeleteProfile(name string) error
+}
+
+// UserBetStore 用户派彩记录（user_bets）
+type UserBetStore interface {
+	// UpsertUserBets 在一个事务内写入多条用户派彩记录（含明细），bets 回填为保存后的记录，任一条失败时全部不写入：
+	// 幂等键已存在时不做修改（键属于其他期号或账号时返回 ErrDuplicate），
+	// 同一期号和账号已有记录时整条覆盖（明细一并替换），否则新建；
+	// 并发写入同一期号和账号（或同一幂等键）触发唯一索引冲突时也返回 ErrDuplicate
+	UpsertUserBets(bets []*models.UserBet) ([]UpsertResult, error)
+	// UserBetsByRounds 批量查询多期的用户派彩记录（含明细）
+	UserBetsByRounds(roundIDs []string) ([]models.UserBet, error)
+	// ListUserBets 按账号和上传时间查询（含明细，按 created_at, id 升序，Limit<=0 表示不分页）
+	ListUserBets(query UserBetQuery) ([]models.UserBet, error)
+}
+
+// AuthStore 用户、API 密钥和登录会话（users / api_keys / user_sessions）
+type AuthStore interface {
+	// CreateUser 创建用户（用户名已存在返回 ErrDuplicate）
+	CreateUser(user *models.User) error
+	// GetUser 按ID查询用户（不存在返回 ErrNotFound）
+	GetUser(id uint) (*models.User, error)
+	// GetUserByName 按用户名查询（不存在返回 ErrNotFound）
+	GetUserByName(username string) (*models.User, error)
+	// ListUsers 全部用户（按ID升序）
+	ListUsers() ([]models.User, error)
+
+	// CreateAPIKey 写入一个 API 密钥（哈希已存在返回 ErrDuplicate）
+	CreateAPIKey(key *models.APIKey) error
+	// GetAPIKeyByHash 按哈希查询（包含已吊销的，不存在返回 ErrNotFound）
+	GetAPIKeyByHash(hash string) (*models.APIKey, error)
+	// ListAPIKeys 全部 API 密钥（按ID升序）
+	ListAPIKeys() ([]models.APIKey, error)
+	// RevokeAPIKey 吊销 API 密钥（不存在返回 ErrNotFound）
+	RevokeAPIKey(id uint, at time.Time) error
+	// TouchAPIKey 更新最近使用时间
+	TouchAPIKey(id uint, at time.Time) error
+
+	// CreateSession 写入一个登录会话
+	CreateSession(session *models.UserSession) error
+	// GetSessionByHash 按令牌哈希查询（不存在返回 ErrNotFound）
+	GetSessionByHash(hash string) (*models.UserSession, error)
+	// DeleteSession 删除登录会话（退出登录）
+	DeleteSession(hash string) error
+	// DeleteExpiredSessions 删除 before 之前过期的会话
+	DeleteExpiredSessions(before time.Time) error
+}
+
+// AuditStore 审计日志（audit_log）
+type AuditStore interface {
+	// CreateAudit 写入一条审计日志
+	CreateAudit(entry *models.AuditLog) error
+	// ListAudit 分页查询（按 created_at DESC, id DESC），同时返回总数
+	ListAudit(query AuditQuery) ([]models.AuditLog, int64, error)
+}
+
+// ReportStore 定时报表（reports）
+type ReportStore interface {
+	// SaveReport 保存报表（同一类型和周期已存在时覆盖正文并清空投递状态），report 回填ID
+	SaveReport(report *models.Report) error
+	// GetReport 按ID查询（含正文，不存在返回 ErrNotFound）
+	GetReport(id uint) (*models.Report, error)
+	// FindReport 按类型和周期查询（含正文，不存在返回 ErrNotFound）
+	FindReport(kind, period string) (*models.Report, error)
+	// ListReports 报表列表（不含正文，kind 为空表示全部类型，按周期开始降序）
+	ListReports(kind string, limit int) ([]models.Report, error)
+	// UpdateReportDelivery 记录投递结果（deliveredAt 为 nil 表示投递失败）
+	UpdateReportDelivery(id uint, deliveredAt *time.Time, deliveryError string) error
+}
+
+// AlertStore 告警规则和告警记录（alert_rules / alerts）
+type AlertStore interface {
+	// CreateAlertRule 创建告警规则，rule 回填ID
+	CreateAlertRule(rule *models.AlertRule) error
+	// UpdateAlertRule 按ID整条更新告警规则（不存在返回 ErrNotFound）
+	UpdateAlertRule(rule *models.AlertRule) error
+	// DeleteAlertRule 删除告警规则，已有的告警记录保留（不存在返回 ErrNotFound）
+	DeleteAlertRule(id uint) error
+	// GetAlertRule 按ID查询告警规则（不存在返回 ErrNotFound）
+	GetAlertRule(id uint) (*models.AlertRule, error)
+	// ListAlertRules 全部告警规则（按ID升序）
+	ListAlertRules() ([]models.AlertRule, error)
+	// CreateAlert 写入一条告警记录，alert 回填ID
+	CreateAlert(alert *models.Alert) error
+	// ListAlerts 分页查询告警记录（按 created_at DESC, id DESC），同时返回总数
+	ListAlerts(query AlertQuery) ([]models.Alert, int64, error)
+	// UpdateAlertDelivery 记录通知结果（deliveredAt 为 nil 表示发送失败）
+	UpdateAlertDelivery(id uint, deliveredAt *time.Time, deliveryError string) error
+}
+
+// HistoryFilter 历史记录筛选条件（零值表示不筛选；SessionID 零值表示当前记录）
+type HistoryFilter struct {
+	Strategy  string    // 策略名称
+	Status    *int      // 0=虚盘, 1=实盘
+	SessionID uint      // 归档会话ID（0=当前，未归档）
+	From      time.Time // 起始时间（含）
+	To        time.Time // 截止时间（不含）
+
+	Result        string   // 结果（赢/输）
+	RoundFrom     string   // 起始期号（含，按数值比较）
+	RoundTo       string   // 截止期号（含）
+	SpecialReward string   // 特殊奖项（SpecialAny 表示任意特殊奖项）
+	MinProfit     *float64 // 最低盈亏（含）
+}
+
+// SpecialAny 筛选任意特殊奖项
+const SpecialAny = "*"
+
+// 历史记录排序字段
+const (
+	HistorySortTime   = "created_at"
+	HistorySortProfit = "profit"
+	HistorySortBet    = "bet_amount"
+)
+
+// HistoryQuery 历史记录分页查询（Limit<0 表示不分页）
+//
+// 排序字段相同时按 id 同向排序；After 不为 nil 时按游标分页（只支持 created_at 排序），忽略 Offset。
+type HistoryQuery struct {
+	HistoryFilter
+	Sort      string         // 排序字段（默认 created_at）
+	Asc       bool           // 升序（默认降序）
+	After     *HistoryCursor // 游标：只返回排在该记录之后的记录
+	SkipCount bool           // 不统计总数（返回的总数为 -1）
+	Offset    int
+	Limit     int
+}
+
+// HistoryCursor 历史记录游标（按 created_at, id 定位一条记录）
+type HistoryCursor struct {
+	CreatedAt time.Time
+	ID        uint
+}
+
+// CursorOf 以一条记录构造游标
+func CursorOf(h models.StrategyHistory) HistoryCursor {
+	cursor := HistoryCursor{ID: h.ID}
+	if h.CreatedAt != nil {
+		cursor.CreatedAt = *h.CreatedAt
+	}
+	return cursor
+}
+
+// Encode 编码为不透明字符串（URL 安全）
+func (c HistoryCursor) Encode() string {
+	raw := strconv.FormatInt(c.CreatedAt.UnixNano(), 10) + ":" + strconv.FormatUint(uint64(c.ID), 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ErrInvalidCursor 游标格式错误
+var ErrInvalidCursor = errors.New("无效的游标")
+
+// ParseHistoryCursor 解析 Encode 生成的游标（时间转为本地时间，与入库时间一致）
+func ParseHistoryCursor(value string) (*HistoryCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	nanos, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	i, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &HistoryCursor{CreatedAt: time.Unix(0, n).Local(), ID: uint(i)}, nil
+}
+
+// RoundIDLess 期号比较（长度不同时按长度比较，数字期号等价于按数值比较）
+func RoundIDLess(a, b string) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	return a < b
+}
+
+// UserBetQuery 用户派彩记录查询（零值表示不筛选）
+type UserBetQuery struct {
+	Account string    // 用户账号
+	From    time.Time // 起始时间（含）
+	To      time.Time // 截止时间（不含）
+	Offset  int
+	Limit   int
+}
+
+// RoundQuery 开奖数据分批查询（零值表示不筛选）
+type RoundQuery struct {
+	From         time.Time // 起始时间（含）
+	To           time.Time // 截止时间（不含）
+	AfterRoundID string    // 只返回期号大于该值的记录（上一批最后一期）
+	Limit        int       // 每批条数（<=0 表示不限制）
+}
+
+// UpsertResult 写入用户派彩记录的结果
+type UpsertResult string
+
+const (
+	UpsertCreated   UpsertResult = "created"   // 新建
+	UpsertUpdated   UpsertResult = "updated"   // 覆盖已有记录
+	UpsertUnchanged UpsertResult = "unchanged" // 幂等键重放，未修改
+)
+
+// AuditQuery 审计日志分页查询（零值表示不筛选）
+type AuditQuery struct {
+	Actor  string    // 操作人
+	Action string    // 操作类型
+	From   time.Time // 起始时间（含）
+	To     time.Time // 截止时间（不含）
+	Offset int
+	Limit  int
+}
+
+// AlertQuery 告警记录分页查询（零值表示不筛选）
+type AlertQuery struct {
+	RuleID   uint      // 告警规则ID
+	Severity string    // 级别
+	Subject  string    // 触发对象
+	From     time.Time // 起始时间（含）
+	To       time.Time // 截止时间（不含）
+	Offset   int
+	Limit    int
+}
+
+// HistoryStat 历史统计结果
+type HistoryStat struct {
+	Key    string  `gorm:"column:stat_key"` // 分组键（策略名/日期，总体统计为空）
+	Bets   int64   `gorm:"column:bets"`     // 下单次数
+	Wins   int64   `gorm:"column:wins"`     // 命中次数（result='赢'）
+	Profit float64 `gorm:"column:profit"`   // 盈亏合计
+
+	VirtualProfit float64 `gorm:"column:virtual_profit"` // 纸面盈亏合计（每条记录都按下注计算）
+}
+
+// StatusFilter 构造状态筛选值
+func StatusFilter(status int) *int {
+	return &status
+}
@@ -0,0 +1,77 @@
+package database
+
+import (
+	"benz-sniper/config"
+	"benz-sniper/models"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestUserBetUniqueMigration(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "legacy.db")
+
+	// 旧表结构：（期号, 账号）和幂等键只有普通索引，空幂等键存为空字符串
+	legacy, err := gorm.Open(sqlite.Open(path), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range []string{
+		`CREATE TABLE user_bets (id integer PRIMARY KEY AUTOINCREMENT, round_id varchar(50), user_account varchar(100),
+			bet_amount real, payout_amount real, balance real, idempotency_key varchar(100), created_at datetime, updated_at datetime)`,
+		`CREATE INDEX idx_user_bets_round_account ON user_bets(round_id, user_account)`,
+		`CREATE INDEX idx_user_bets_idempotency_key ON user_bets(idempotency_key)`,
+		`CREATE TABLE user_bet_items (id integer PRIMARY KEY AUTOINCREMENT, user_bet_id integer, car varchar(20), stake real)`,
+		`INSERT INTO user_bets (id, round_id, user_account, bet_amount, idempotency_key) VALUES
+			(1, '1000', 'acc-1', 100, 'k0'), (2, '1000', 'acc-1', 200, ''), (3, '1001', 'acc-1', 300, 'k1'), (4, '1002', 'acc-2', 400, 'k1')`,
+		`INSERT INTO user_bet_items (user_bet_id, car, stake) VALUES (1, '红宝马', 100), (2, '黄大众', 200)`,
+	} {
+		if err := legacy.Exec(stmt).Error; err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+	if db, err := legacy.DB(); err == nil {
+		db.Close()
+	}
+
+	store, err := Open(&config.Config{DBDriver: DriverSQLite, SQLitePath: path})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer store.Close()
+
+	// 同一期号和账号只保留最后上传的一条（连同明细），重复的幂等键只保留在最早的记录上
+	bets, err := store.UserBetsByRounds([]string{"1000", "1001", "1002"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	byID := make(map[uint]models.UserBet)
+	for _, b := range bets {
+		byID[b.ID] = b
+	}
+	if len(bets) != 3 || byID[2].BetAmount != 200 || len(byID[2].Items) != 1 || byID[2].IdempotencyKey != nil ||
+		byID[3].IdempotencyKey == nil || *byID[3].IdempotencyKey != "k1" || byID[4].IdempotencyKey != nil {
+		t.Errorf("迁移后记录 = %+v", bets)
+	}
+
+	// 唯一索引生效：绕过查询直接插入重复记录（模拟并发上传）被拒绝
+	db := store.(*GormStore).DB()
+	var items int64
+	if err := db.Model(&models.UserBetItem{}).Count(&items).Error; err != nil || items != 1 {
+		t.Errorf("明细 = %d 条, want 只剩保留记录的 1 条 (%v)", items, err)
+	}
+	if err := db.Create(&models.UserBet{RoundID: "1000", UserAccount: "acc-1", BetAmount: 1}).Error; !errors.Is(err, gorm.ErrDuplicatedKey) {
+		t.Errorf("重复插入（期号, 账号）error = %v, want ErrDuplicatedKey", err)
+	}
+	key := "k2"
+	if _, err := store.UpsertUserBets([]*models.UserBet{{RoundID: "1003", UserAccount: "acc-1", BetAmount: 1, IdempotencyKey: &key}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&models.UserBet{RoundID: "1004", UserAccount: "acc-1", BetAmount: 1, IdempotencyKey: &key}).Error; !errors.Is(err, gorm.ErrDuplicatedKey) {
+		t.Errorf("重复插入幂等键 error = %v, want ErrDuplicatedKey", err)
+	}
+}
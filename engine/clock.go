@@ -0,0 +1,143 @@
+package engine
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock 时钟接口（测试和回放时可替换为手动时钟或加速时钟）
+type Clock interface {
+	// Now 当前时间
+	Now() time.Time
+	// After 经过 d 后向返回的通道发送当时的时间
+	After(d time.Duration) <-chan time.Time
+}
+
+// SystemClock 系统时钟
+type SystemClock struct{}
+
+// Now 当前系统时间
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// After 系统定时器
+func (SystemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// ScaledClock 加速时钟（回放时按 speed 倍速流逝，speed=10 表示 1 秒当 10 秒）
+type ScaledClock struct {
+	origin time.Time // 虚拟起点
+	start  time.Time // 真实起点
+	speed  float64
+}
+
+// NewScaledClock 创建从 origin 开始、按 speed 倍速流逝的时钟
+func NewScaledClock(origin time.Time, speed float64) *ScaledClock {
+	if speed <= 0 {
+		speed = 1
+	}
+	return &ScaledClock{origin: origin, start: time.Now(), speed: speed}
+}
+
+// Now 当前虚拟时间
+func (c *ScaledClock) Now() time.Time {
+	elapsed := time.Since(c.start)
+	return c.origin.Add(time.Duration(float64(elapsed) * c.speed))
+}
+
+// After 虚拟时间经过 d 后触发（真实等待 d/speed）
+func (c *ScaledClock) After(d time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	time.AfterFunc(time.Duration(float64(d)/c.speed), func() {
+		ch <- c.Now()
+	})
+	return ch
+}
+
+// ManualClock 手动时钟（只在调用 Set/Advance 时前进，到期的 After 随之触发）
+type ManualClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []manualWaiter
+}
+
+// manualWaiter 等待中的定时器
+type manualWaiter struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+// NewManualClock 创建手动时钟
+func NewManualClock(start time.Time) *ManualClock {
+	return &ManualClock{now: start}
+}
+
+// Now 当前时钟时间
+func (c *ManualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// After 时钟前进到 now+d 时触发
+func (c *ManualClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, manualWaiter{deadline: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// Waiters 等待中的定时器数量（测试用来确认调度方已进入等待）
+func (c *ManualClock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
+
+// Set 设置时钟时间
+func (c *ManualClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+	c.fire()
+}
+
+// Advance 时钟前进 d
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	c.fire()
+}
+
+// fire 触发所有到期的定时器（调用前需持有锁）
+func (c *ManualClock) fire() {
+	pending := c.waiters[:0]
+	for _, w := range c.waiters {
+		if !w.deadline.After(c.now) {
+			w.ch <- c.now
+		} else {
+			pending = append(pending, w)
+		}
+	}
+	c.waiters = pending
+}
+
+// TimestampToTime 将 game_rounds.timestamp 转为时间（兼容秒和毫秒）
+func TimestampToTime(ts int64) time.Time {
+	if ts <= 0 {
+		return time.Time{}
+	}
+	if ts > 1e12 {
+		return time.UnixMilli(ts)
+	}
+	return time.Unix(ts, 0)
+}
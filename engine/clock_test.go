@@ -0,0 +1,69 @@
+package engine
+
+import (
+	"testing"
+	"time"
+)
+
+func TestManualClockAfter(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewManualClock(start)
+
+	ch := c.After(5 * time.Second)
+	c.Advance(4 * time.Second)
+	select {
+	case <-ch:
+		t.Fatal("After 提前触发")
+	default:
+	}
+
+	c.Advance(time.Second)
+	select {
+	case got := <-ch:
+		if !got.Equal(start.Add(5 * time.Second)) {
+			t.Errorf("触发时间 = %v, want %v", got, start.Add(5*time.Second))
+		}
+	default:
+		t.Fatal("After 到期未触发")
+	}
+	if c.Waiters() != 0 {
+		t.Errorf("Waiters() = %d, want 0", c.Waiters())
+	}
+
+	select {
+	case <-c.After(0):
+	default:
+		t.Error("After(0) 应立即触发")
+	}
+}
+
+func TestScaledClock(t *testing.T) {
+	origin := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewScaledClock(origin, 1000)
+
+	select {
+	case <-c.After(10 * time.Second): // 真实等待 10ms
+	case <-time.After(time.Second):
+		t.Fatal("加速时钟 After 未按倍速触发")
+	}
+	if elapsed := c.Now().Sub(origin); elapsed < 10*time.Second {
+		t.Errorf("虚拟时间流逝 %v, want >= 10s", elapsed)
+	}
+}
+
+func TestTimestampToTime(t *testing.T) {
+	sec := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		ts   int64
+		want time.Time
+	}{
+		{sec.Unix(), sec},
+		{sec.UnixMilli() + 250, sec.Add(250 * time.Millisecond)},
+		{0, time.Time{}},
+	}
+	for _, tt := range tests {
+		if got := TimestampToTime(tt.ts); !got.Equal(tt.want) {
+			t.Errorf("TimestampToTime(%d) = %v, want %v", tt.ts, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package engine
+
+import (
+	"benz-sniper/database"
+	"benz-sniper/models"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// 配置变更来源
+const (
+	ConfigSourceInitial  = "initial"  // 首次启动时的配置
+	ConfigSourceAPI      = "api"      // 接口修改
+	ConfigSourceRollback = "rollback" // 回滚到历史版本
+	ConfigSourceProfile  = "profile"  // 手动启用方案
+	ConfigSourceSchedule = "schedule" // 按时段自动启用方案
+)
+
+var (
+	// ErrEmptyProfileName 方案名称为空
+	ErrEmptyProfileName = errors.New("方案名称不能为空")
+	// ErrInvalidSchedule 启用时段格式错误
+	ErrInvalidSchedule = errors.New("启用时段格式错误")
+)
+
+// ConfigChange 配置变更说明（写入配置版本）
+type ConfigChange struct {
+	Comment   string // 修改说明
+	CreatedBy string // 操作人
+	Source    string // 变更来源
+	Profile   string // 来源方案名称
+}
+
+// configFromVersion 配置版本转换为策略配置
+func configFromVersion(v models.ConfigVersion) StrategyConfig {
+	return StrategyConfig{
+		EntryCondition:     v.EntryCondition,
+		ExitCondition:      v.ExitCondition,
+		Hot3BetAmount:      v.Hot3BetAmount,
+		Balanced4BetAmount: v.Balanced4BetAmount,
+		Hot3Enabled:        v.Hot3Enabled,
+		Balanced4Enabled:   v.Balanced4Enabled,
+	}
+}
+
+// configFromProfile 配置方案转换为策略配置
+func configFromProfile(p models.ConfigProfile) StrategyConfig {
+	return StrategyConfig{
+		EntryCondition:     p.EntryCondition,
+		ExitCondition:      p.ExitCondition,
+		Hot3BetAmount:      p.Hot3BetAmount,
+		Balanced4BetAmount: p.Balanced4BetAmount,
+		Hot3Enabled:        p.Hot3Enabled,
+		Balanced4Enabled:   p.Balanced4Enabled,
+	}
+}
+
+// parseClock 解析 HH:MM，返回当天的分钟数
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q 不是 HH:MM", ErrInvalidSchedule, value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// validateSchedule 校验启用时段（都为空表示仅手动启用，结束早于开始表示跨零点）
+func validateSchedule(start, end string) error {
+	if start == "" && end == "" {
+		return nil
+	}
+	if start == "" || end == "" {
+		return fmt.Errorf("%w: 开始和结束时刻需同时设置", ErrInvalidSchedule)
+	}
+	s, err := parseClock(start)
+	if err != nil {
+		return err
+	}
+	e, err := parseClock(end)
+	if err != nil {
+		return err
+	}
+	if s == e {
+		return fmt.Errorf("%w: 开始和结束时刻不能相同", ErrInvalidSchedule)
+	}
+	return nil
+}
+
+// scheduleActive 方案的启用时段是否包含 now（按时钟所在时区）
+func scheduleActive(p models.ConfigProfile, now time.Time) bool {
+	if p.ScheduleStart == "" {
+		return false
+	}
+	start, err := parseClock(p.ScheduleStart)
+	if err != nil {
+		return false
+	}
+	end, err := parseClock(p.ScheduleEnd)
+	if err != nil {
+		return false
+	}
+	minute := now.Hour()*60 + now.Minute()
+	if start < end {
+		return minute >= start && minute < end
+	}
+	return minute >= start || minute < end // 跨零点
+}
+
+// ensureInitialVersion 没有任何版本时，把当前配置记为版本1（便于回滚到初始配置）
+func (m *StrategyManager) ensureInitialVersion() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, total, err := m.store.ListConfigVersions(0, 1)
+	if err != nil {
+		slog.Error("❌ 查询配置版本失败", "error", err)
+		return
+	}
+	if total == 0 {
+		m.recordVersion(ConfigChange{Comment: "初始配置", CreatedBy: "system", Source: ConfigSourceInitial})
+	}
+}
+
+// recordVersion 把当前配置追加为新版本（调用前需要持有锁）
+func (m *StrategyManager) recordVersion(change ConfigChange) *models.ConfigVersion {
+	now := m.clock.Now()
+	version := &models.ConfigVersion{
+		EntryCondition:     m.config.EntryCondition,
+		ExitCondition:      m.config.ExitCondition,
+		Hot3BetAmount:      m.config.Hot3BetAmount,
+		Balanced4BetAmount: m.config.Balanced4BetAmount,
+		Hot3Enabled:        m.config.Hot3Enabled,
+		Balanced4Enabled:   m.config.Balanced4Enabled,
+		Comment:            change.Comment,
+		Source:             change.Source,
+		Profile:            change.Profile,
+		CreatedBy:          change.CreatedBy,
+		CreatedAt:          &now,
+	}
+	if err := m.store.CreateConfigVersion(version); err != nil {
+		slog.Error("❌ 保存配置版本失败", "error", err)
+		return nil
+	}
+	slog.Info("🗂️ 配置版本", "version", version.Version, "source", version.Source, "comment", version.Comment)
+	return version
+}
+
+// setConfig 整体替换配置并记录版本（配置未变化时不记录，返回的版本为 nil）
+func (m *StrategyManager) setConfig(cfg StrategyConfig, change ConfigChange) (StrategyConfig, *models.ConfigVersion) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.activeProfile = change.Profile
+	if cfg == m.config {
+		return m.config, nil
+	}
+	m.config = cfg
+	slog.Info("📝 配置已切换", append([]any{"source", change.Source, "profile", change.Profile}, configAttrs(cfg)...)...)
+	m.saveConfigToDB()
+	return m.config, m.recordVersion(change)
+}
+
+// ListConfigVersions 分页查询配置版本（按版本号降序）
+func (m *StrategyManager) ListConfigVersions(offset, limit int) ([]models.ConfigVersion, int64, error) {
+	return m.store.ListConfigVersions(offset, limit)
+}
+
+// RollbackConfig 回滚到指定版本（回滚本身也会生成一个新版本）
+func (m *StrategyManager) RollbackConfig(version int, change ConfigChange) (StrategyConfig, *models.ConfigVersion, error) {
+	target, err := m.store.GetConfigVersion(version)
+	if err != nil {
+		return StrategyConfig{}, nil, err
+	}
+	change.Source = ConfigSourceRollback
+	change.Profile = ""
+	if change.Comment == "" {
+		change.Comment = fmt.Sprintf("回滚到版本 %d", version)
+	}
+	cfg, created := m.setConfig(configFromVersion(*target), change)
+	return cfg, created, nil
+}
+
+// loadProfiles 从数据库加载配置方案到缓存（定时检查只读缓存，不访问数据库）
+func (m *StrategyManager) loadProfiles() error {
+	profiles, err := m.store.ListProfiles()
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	m.profiles = profiles
+	m.mu.Unlock()
+	return nil
+}
+
+// ListProfiles 配置方案列表
+func (m *StrategyManager) ListProfiles() []models.ConfigProfile {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]models.ConfigProfile(nil), m.profiles...)
+}
+
+// ActiveProfile 当前生效的方案名称（配置被手动修改或回滚后为空）
+func (m *StrategyManager) ActiveProfile() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.activeProfile
+}
+
+// SaveProfile 创建或更新配置方案
+func (m *StrategyManager) SaveProfile(profile *models.ConfigProfile) error {
+	profile.Name = strings.TrimSpace(profile.Name)
+	if profile.Name == "" {
+		return ErrEmptyProfileName
+	}
+	if err := m.ConfigLimits().Validate(configFromProfile(*profile)); err != nil {
+		return err
+	}
+	if err := validateSchedule(profile.ScheduleStart, profile.ScheduleEnd); err != nil {
+		return err
+	}
+	now := m.clock.Now()
+	profile.UpdatedAt = &now
+	if err := m.store.SaveProfile(profile); err != nil {
+		return err
+	}
+	return m.loadProfiles()
+}
+
+// DeleteProfile 删除配置方案（不影响当前配置）
+func (m *StrategyManager) DeleteProfile(name string) error {
+	if err := m.store.DeleteProfile(name); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	if m.activeProfile == name {
+		m.activeProfile = ""
+	}
+	m.mu.Unlock()
+	return m.loadProfiles()
+}
+
+// ActivateProfile 手动启用配置方案
+func (m *StrategyManager) ActivateProfile(name string, change ConfigChange) (StrategyConfig, *models.ConfigVersion, error) {
+	profile, ok := m.findProfile(name)
+	if !ok {
+		return StrategyConfig{}, nil, database.ErrNotFound
+	}
+	change.Source = ConfigSourceProfile
+	change.Profile = profile.Name
+	if change.Comment == "" {
+		change.Comment = "启用方案 " + profile.Name
+	}
+	cfg, created := m.setConfig(configFromProfile(profile), change)
+	return cfg, created, nil
+}
+
+// findProfile 按名称查找缓存中的方案
+func (m *StrategyManager) findProfile(name string) (models.ConfigProfile, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, p := range m.profiles {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return models.ConfigProfile{}, false
+}
+
+// ApplyScheduledProfile 按时段自动启用方案（引擎每次轮询调用）
+// 只在进入某个方案的时段时切换一次，时段内的手动修改不会被覆盖；
+// 多个时段重叠时按名称取第一个
+func (m *StrategyManager) ApplyScheduledProfile() {
+	now := m.clock.Now()
+
+	m.mu.Lock()
+	var due models.ConfigProfile
+	for _, p := range m.profiles {
+		if scheduleActive(p, now) {
+			due = p
+			break
+		}
+	}
+	if due.Name == m.scheduledProfile {
+		m.mu.Unlock()
+		return
+	}
+	m.scheduledProfile = due.Name
+	m.mu.Unlock()
+
+	if due.Name == "" {
+		return
+	}
+	slog.Info("⏰ 进入方案启用时段", "profile", due.Name, "start", due.ScheduleStart, "end", due.ScheduleEnd)
+	m.setConfig(configFromProfile(due), ConfigChange{
+		Comment:   fmt.Sprintf("定时启用方案 %s (%s-%s)", due.Name, due.ScheduleStart, due.ScheduleEnd),
+		CreatedBy: "scheduler",
+		Source:    ConfigSourceSchedule,
+		Profile:   due.Name,
+	})
+}
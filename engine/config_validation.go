@@ -0,0 +1,173 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// ConfigLimits 配置取值范围
+type ConfigLimits struct {
+	MinCondition  int     `json:"min_condition"`   // 进场/离场条件下限
+	MaxCondition  int     `json:"max_condition"`   // 进场/离场条件上限
+	MinBetAmount  float64 `json:"min_bet_amount"`  // 下注金额下限
+	MaxBetAmount  float64 `json:"max_bet_amount"`  // 下注金额上限
+	BetAmountStep float64 `json:"bet_amount_step"` // 下注金额步长（金额须为其整数倍，0 表示不限制）
+}
+
+// DefaultConfigLimits 默认取值范围
+func DefaultConfigLimits() ConfigLimits {
+	return ConfigLimits{
+		MinCondition:  1,
+		MaxCondition:  10,
+		MinBetAmount:  10,
+		MaxBetAmount:  100000,
+		BetAmountStep: 10,
+	}
+}
+
+// ConfigPatch 配置部分更新（nil 字段保持不变）
+type ConfigPatch struct {
+	EntryCondition     *int     `json:"entry_condition,omitempty"`
+	ExitCondition      *int     `json:"exit_condition,omitempty"`
+	Hot3BetAmount      *float64 `json:"hot3_bet_amount,omitempty"`
+	Balanced4BetAmount *float64 `json:"balanced4_bet_amount,omitempty"`
+	Hot3Enabled        *bool    `json:"hot3_enabled,omitempty"`
+	Balanced4Enabled   *bool    `json:"balanced4_enabled,omitempty"`
+}
+
+// PatchFrom 把完整配置转换为覆盖全部字段的 patch
+func PatchFrom(cfg StrategyConfig) ConfigPatch {
+	return ConfigPatch{
+		EntryCondition:     &cfg.EntryCondition,
+		ExitCondition:      &cfg.ExitCondition,
+		Hot3BetAmount:      &cfg.Hot3BetAmount,
+		Balanced4BetAmount: &cfg.Balanced4BetAmount,
+		Hot3Enabled:        &cfg.Hot3Enabled,
+		Balanced4Enabled:   &cfg.Balanced4Enabled,
+	}
+}
+
+// Apply 在 cfg 上应用 patch，返回新配置（不修改 cfg）
+func (p ConfigPatch) Apply(cfg StrategyConfig) StrategyConfig {
+	if p.EntryCondition != nil {
+		cfg.EntryCondition = *p.EntryCondition
+	}
+	if p.ExitCondition != nil {
+		cfg.ExitCondition = *p.ExitCondition
+	}
+	if p.Hot3BetAmount != nil {
+		cfg.Hot3BetAmount = *p.Hot3BetAmount
+	}
+	if p.Balanced4BetAmount != nil {
+		cfg.Balanced4BetAmount = *p.Balanced4BetAmount
+	}
+	if p.Hot3Enabled != nil {
+		cfg.Hot3Enabled = *p.Hot3Enabled
+	}
+	if p.Balanced4Enabled != nil {
+		cfg.Balanced4Enabled = *p.Balanced4Enabled
+	}
+	return cfg
+}
+
+// FieldError 单个字段的校验错误（Field 为 JSON 字段名）
+type FieldError struct {
+	Field   string `json:"field"`
+	Value   any    `json:"value"`
+	Message string `json:"message"`
+}
+
+// ValidationError 校验失败（包含全部字段错误，配置和派彩上传共用）
+type ValidationError struct {
+	Errors []FieldError `json:"errors"`
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, 0, len(e.Errors))
+	for _, fe := range e.Errors {
+		parts = append(parts, fe.Field+": "+fe.Message)
+	}
+	return "校验失败: " + strings.Join(parts, "; ")
+}
+
+// ValidatePatch 校验 patch 中提供的字段（未提供的字段不校验）
+func (l ConfigLimits) ValidatePatch(p ConfigPatch) error {
+	var errs []FieldError
+	if p.EntryCondition != nil {
+		errs = append(errs, l.checkCondition("entry_condition", *p.EntryCondition)...)
+	}
+	if p.ExitCondition != nil {
+		errs = append(errs, l.checkCondition("exit_condition", *p.ExitCondition)...)
+	}
+	if p.Hot3BetAmount != nil {
+		errs = append(errs, l.checkBetAmount("hot3_bet_amount", *p.Hot3BetAmount)...)
+	}
+	if p.Balanced4BetAmount != nil {
+		errs = append(errs, l.checkBetAmount("balanced4_bet_amount", *p.Balanced4BetAmount)...)
+	}
+	if len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+	return nil
+}
+
+// Validate 校验完整配置
+func (l ConfigLimits) Validate(cfg StrategyConfig) error {
+	return l.ValidatePatch(PatchFrom(cfg))
+}
+
+// checkCondition 校验连赢/连输条件
+func (l ConfigLimits) checkCondition(field string, value int) []FieldError {
+	if value < l.MinCondition || value > l.MaxCondition {
+		return []FieldError{{
+			Field:   field,
+			Value:   value,
+			Message: fmt.Sprintf("须在 %d 到 %d 之间", l.MinCondition, l.MaxCondition),
+		}}
+	}
+	return nil
+}
+
+// checkBetAmount 校验下注金额（范围和步长）
+func (l ConfigLimits) checkBetAmount(field string, value float64) []FieldError {
+	if value < l.MinBetAmount || value > l.MaxBetAmount {
+		return []FieldError{{
+			Field:   field,
+			Value:   value,
+			Message: fmt.Sprintf("须在 %g 到 %g 之间", l.MinBetAmount, l.MaxBetAmount),
+		}}
+	}
+	if l.BetAmountStep > 0 && math.Abs(math.Remainder(value, l.BetAmountStep)) > 1e-9 {
+		return []FieldError{{
+			Field:   field,
+			Value:   value,
+			Message: fmt.Sprintf("须为 %g 的整数倍", l.BetAmountStep),
+		}}
+	}
+	return nil
+}
+
+// FieldChange 配置字段变化
+type FieldChange struct {
+	Field  string `json:"field"`
+	Before any    `json:"before"`
+	After  any    `json:"after"`
+}
+
+// DiffConfig 对比两份配置，按字段顺序返回变化
+func DiffConfig(before, after StrategyConfig) []FieldChange {
+	changes := make([]FieldChange, 0)
+	add := func(field string, b, a any) {
+		if b != a {
+			changes = append(changes, FieldChange{Field: field, Before: b, After: a})
+		}
+	}
+	add("entry_condition", before.EntryCondition, after.EntryCondition)
+	add("exit_condition", before.ExitCondition, after.ExitCondition)
+	add("hot3_bet_amount", before.Hot3BetAmount, after.Hot3BetAmount)
+	add("balanced4_bet_amount", before.Balanced4BetAmount, after.Balanced4BetAmount)
+	add("hot3_enabled", before.Hot3Enabled, after.Hot3Enabled)
+	add("balanced4_enabled", before.Balanced4Enabled, after.Balanced4Enabled)
+	return changes
+}
@@ -0,0 +1,80 @@
+package engine
+
+import (
+	"benz-sniper/database"
+	"errors"
+	"testing"
+)
+
+func TestConfigLimitsValidatePatch(t *testing.T) {
+	limits := DefaultConfigLimits()
+	intp := func(v int) *int { return &v }
+	floatp := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name   string
+		patch  ConfigPatch
+		fields []string
+	}{
+		{"空 patch", ConfigPatch{}, nil},
+		{"边界值", ConfigPatch{EntryCondition: intp(1), ExitCondition: intp(10), Hot3BetAmount: floatp(10), Balanced4BetAmount: floatp(100000)}, nil},
+		{"条件为零", ConfigPatch{EntryCondition: intp(0)}, []string{"entry_condition"}},
+		{"条件超上限", ConfigPatch{ExitCondition: intp(11)}, []string{"exit_condition"}},
+		{"金额不足", ConfigPatch{Hot3BetAmount: floatp(5)}, []string{"hot3_bet_amount"}},
+		{"金额步长", ConfigPatch{Balanced4BetAmount: floatp(105)}, []string{"balanced4_bet_amount"}},
+		{"多个字段", ConfigPatch{EntryCondition: intp(-1), Hot3BetAmount: floatp(1e9)}, []string{"entry_condition", "hot3_bet_amount"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := limits.ValidatePatch(tt.patch)
+			if tt.fields == nil {
+				if err != nil {
+					t.Fatalf("ValidatePatch() = %v, want nil", err)
+				}
+				return
+			}
+			var invalid *ValidationError
+			if !errors.As(err, &invalid) || len(invalid.Errors) != len(tt.fields) {
+				t.Fatalf("ValidatePatch() = %v, want 字段 %v", err, tt.fields)
+			}
+			for i, field := range tt.fields {
+				if invalid.Errors[i].Field != field {
+					t.Errorf("errors[%d].Field = %s, want %s", i, invalid.Errors[i].Field, field)
+				}
+			}
+		})
+	}
+}
+
+func TestPatchConfigPartialUpdate(t *testing.T) {
+	m := NewStrategyManager(database.NewMemoryStore())
+	disabled := false
+
+	// 只停用热门3码，均衡4码保持启用
+	result, err := m.PatchConfig(ConfigPatch{Hot3Enabled: &disabled}, ConfigChange{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.After.Hot3Enabled || !result.After.Balanced4Enabled || len(result.Changes) != 1 || result.Version == nil {
+		t.Errorf("PatchConfig() = %+v", result)
+	}
+
+	// 预览不保存
+	amount := 200.0
+	preview, err := m.PatchConfig(ConfigPatch{Hot3BetAmount: &amount}, ConfigChange{}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !preview.DryRun || preview.After.Hot3BetAmount != 200 || preview.Version != nil || m.GetConfig().Hot3BetAmount == 200 {
+		t.Errorf("预览 = %+v, 当前配置 = %+v", preview, m.GetConfig())
+	}
+
+	// 校验失败时配置不变
+	bad := 7.0
+	if _, err := m.PatchConfig(ConfigPatch{Hot3BetAmount: &bad}, ConfigChange{}, false); err == nil {
+		t.Error("PatchConfig(7) = nil, want 校验错误")
+	}
+	if got := m.GetConfig(); got.Hot3BetAmount != DefaultStrategyConfig().Hot3BetAmount || got.Hot3Enabled {
+		t.Errorf("校验失败后配置 = %+v", got)
+	}
+}
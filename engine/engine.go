@@ -1,51 +1,105 @@
 package engine
 
 import (
+	"benz-sniper/database"
+	"benz-sniper/metrics"
 	"benz-sniper/models"
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"strings"
 	"time"
-
-	"gorm.io/gorm"
 )
 
+// PollInterval 默认轮询间隔
+const PollInterval = 1 * time.Second
+
 // Engine 分析引擎
 type Engine struct {
-	db                *gorm.DB
+	store             database.Store
 	manager           *StrategyManager
-	pendingSettlement []string // 待结算的期号列表
+	clock             Clock                // 时钟（与策略管理器共用）
+	interval          time.Duration        // 轮询间隔
+	timer             *IntervalEstimator   // 开奖间隔估计
+	pendingSettlement []string             // 待结算的期号列表
+	pendingSince      map[string]time.Time // 期号加入待结算列表的时间
+	lastRound         *models.GameRound    // 最近一次查询到的最新期号
+	thresholds        HealthThresholds     // 健康检查阈值
+	health            healthTracker        // 轮询结果（供健康检查读取）
+	observers         []func(TickEvent)    // 轮询回调（告警等）
+	newRound          bool                 // 本次轮询是否发现新期号
+	settled           []SettledRound       // 本次轮询结算的期号
 }
 
-// New 创建引擎实例
-func New(db *gorm.DB, manager *StrategyManager) *Engine {
+// New 创建引擎实例（使用策略管理器的时钟）
+func New(store database.Store, manager *StrategyManager) *Engine {
 	return &Engine{
-		db:                db,
+		store:             store,
 		manager:           manager,
+		clock:             manager.Clock(),
+		interval:          PollInterval,
+		timer:             NewIntervalEstimator(),
 		pendingSettlement: make([]string, 0),
+		pendingSince:      make(map[string]time.Time),
+		thresholds:        DefaultHealthThresholds(),
+	}
+}
+
+// SetInterval 设置轮询间隔（需在 Run 之前调用）
+func (e *Engine) SetInterval(d time.Duration) {
+	if d > 0 {
+		e.interval = d
 	}
 }
 
 // Run 后台运行（单goroutine，无并发）
 func (e *Engine) Run() {
-	log.Println("🚀 策略引擎启动（虚实盘模式）")
+	e.RunContext(context.Background())
+}
+
+// RunContext 后台运行，直到 ctx 取消
+// 等待由时钟调度，测试和回放时可用手动时钟或加速时钟驱动
+func (e *Engine) RunContext(ctx context.Context) {
+	slog.Info("🚀 策略引擎启动（虚实盘模式）", "interval", e.interval)
 
 	for {
-		e.tick()
-		time.Sleep(1 * time.Second)
+		e.Tick()
+		select {
+		case <-ctx.Done():
+			slog.Info("🛑 策略引擎已停止")
+			return
+		case <-e.clock.After(e.interval):
+		}
 	}
 }
 
-// tick 单次轮询处理
-func (e *Engine) tick() {
+// Tick 单次轮询处理（Run 循环调用，模拟器也可直接驱动）
+func (e *Engine) Tick() {
+	start := time.Now()
+	at := e.clock.Now()
+	e.newRound, e.settled = false, nil
+	err := e.tick()
+	e.recordTick(at, err)
+	e.notifyObservers(at, err)
+	metrics.TickDuration.Observe(time.Since(start).Seconds())
+	metrics.LastTick.SetToCurrentTime()
+}
+
+// tick 单次轮询的处理逻辑（返回数据库错误，供健康检查判断轮询是否失败）
+func (e *Engine) tick() error {
+	// 0. 按时段启用配置方案
+	e.manager.ApplyScheduledProfile()
+
 	// 1. 查询最新期号
-	var latest models.GameRound
-	if err := e.db.Order("round_id DESC").First(&latest).Error; err != nil {
-		if err != gorm.ErrRecordNotFound {
-			log.Printf("查询最新期数失败: %v", err)
+	latest, err := e.store.LatestRound()
+	if err != nil {
+		if err != database.ErrNotFound {
+			slog.Error("❌ 查询最新期数失败", "error", err)
+			return fmt.Errorf("查询最新期数失败: %w", err)
 		}
-		return
+		return nil
 	}
+	e.lastRound = latest
 
 	// 2. 检查是否已处理
 	current := e.manager.GetState()
@@ -53,26 +107,41 @@ func (e *Engine) tick() {
 
 	// 3. 如果不是新期号，只处理待结算列表
 	if !isNewRound {
-		e.processPendingSettlements()
-		return
+		return e.processPendingSettlements()
 	}
 
-	log.Printf("💰 新期号: %s", latest.RoundID)
+	e.newRound = true
+	lg := slog.With("round_id", latest.RoundID)
+	lg.Info("💰 新期号")
+	metrics.RoundsProcessed.Inc()
 
 	// 4. 将【当前新期号】加入待结算列表
 	// 因为之前已经有对这一期的预测了（在上一期时生成的）
 	// 例如：检测到07开奖 → 将07加入待结算 → 用07的结果验证之前对07的预测
-	e.addPendingSettlement(latest.RoundID)
+	e.addPendingSettlement(lg, latest.RoundID)
 
 	// 5. 查询历史数据
-	var rounds []models.GameRound
-	e.db.Order("round_id DESC").Limit(50).Find(&rounds)
+	rounds, err := e.store.RecentRounds(50)
+	if err != nil {
+		lg.Error("❌ 查询历史期数失败", "error", err)
+		return fmt.Errorf("查询历史期数失败: %w", err)
+	}
 
 	// 反转顺序（从旧到新）
 	for i := 0; i < len(rounds)/2; i++ {
 		rounds[i], rounds[len(rounds)-1-i] = rounds[len(rounds)-1-i], rounds[i]
 	}
 
+	// 用相邻期的时间戳学习开奖间隔（已观察过的期号会被忽略）
+	// 倒计时以期数自身的时间戳为准，而不是发现新期号的时间
+	for _, r := range rounds {
+		e.timer.Observe(r.RoundID, TimestampToTime(r.Timestamp))
+	}
+	timing := e.timer.Estimate()
+	e.manager.UpdateRoundTiming(timing)
+	lg.Debug("⏱️ 开奖间隔", "interval", timing.Interval, "jitter", timing.Jitter,
+		"samples", timing.Samples, "confidence", timing.Confidence)
+
 	// 6. 计算热度
 	scores := e.calcHeatScores(rounds, 30)
 
@@ -83,7 +152,7 @@ func (e *Engine) tick() {
 	// 8. 计算下一期期号（预测的目标期号）
 	nextRoundID := calcNextRoundID(latest.RoundID)
 
-	log.Printf("  🎯 预测目标: %s | 热门3码: %v | 均衡4码: %v", nextRoundID, hot3, balanced4)
+	lg.Info("🎯 预测目标", "target_round_id", nextRoundID, "热门3码", hot3, "均衡4码", balanced4)
 
 	// 9. 更新策略预测
 	// currentRoundID=当前已开奖期号, targetRoundID=预测目标期号
@@ -91,7 +160,7 @@ func (e *Engine) tick() {
 	e.manager.UpdatePredictions(latest.RoundID, nextRoundID, "均衡4码", balanced4)
 
 	// 10. 处理所有待结算的期号
-	e.processPendingSettlements()
+	return e.processPendingSettlements()
 }
 
 // calcNextRoundID 计算下一期期号
@@ -110,7 +179,7 @@ func calcNextRoundID(currentRoundID string) string {
 }
 
 // addPendingSettlement 添加待结算期号
-func (e *Engine) addPendingSettlement(roundID string) {
+func (e *Engine) addPendingSettlement(lg *slog.Logger, roundID string) {
 	// 检查是否已存在
 	for _, pending := range e.pendingSettlement {
 		if pending == roundID {
@@ -118,22 +187,33 @@ func (e *Engine) addPendingSettlement(roundID string) {
 		}
 	}
 	e.pendingSettlement = append(e.pendingSettlement, roundID)
-	log.Printf("📋 添加待结算期号: %s", roundID)
+	e.pendingSince[roundID] = e.clock.Now()
+	metrics.PendingSettlements.Set(float64(len(e.pendingSettlement)))
+	lg.Debug("📋 添加待结算期号", "pending", len(e.pendingSettlement))
 }
 
-// processPendingSettlements 处理所有待结算的期号
-func (e *Engine) processPendingSettlements() {
+// processPendingSettlements 处理所有待结算的期号（返回第一个查询错误，其余期号照常处理）
+func (e *Engine) processPendingSettlements() error {
 	if len(e.pendingSettlement) == 0 {
-		return
+		return nil
 	}
 
 	toRemove := make([]string, 0)
+	var firstErr error
 
 	// 遍历所有待结算期号
 	for _, roundID := range e.pendingSettlement {
+		lg := slog.With("round_id", roundID)
+
 		// 查询该期的开奖结果
-		var winners []models.GameWinner
-		e.db.Where("round_id = ?", roundID).Find(&winners)
+		winners, err := e.store.WinnersByRounds([]string{roundID})
+		if err != nil {
+			lg.Error("❌ 查询开奖结果失败", "error", err)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("查询期号 %s 开奖结果失败: %w", roundID, err)
+			}
+			continue
+		}
 
 		// 如果没有开奖结果，跳过（等待数据写入）
 		if len(winners) == 0 {
@@ -143,14 +223,13 @@ func (e *Engine) processPendingSettlements() {
 		// 获取获胜车型名称
 		winnerNames := make([]string, 0, len(winners))
 		for _, w := range winners {
-			cleaned := cleanName(w.WinnerName)
+			cleaned := CleanName(w.WinnerName)
 			winnerNames = append(winnerNames, cleaned)
 		}
 
 		// 查询特殊奖项
 		specialReward := ""
-		var round models.GameRound
-		if err := e.db.Where("round_id = ?", roundID).First(&round).Error; err == nil {
+		if round, err := e.store.GetRound(roundID); err == nil {
 			for _, sr := range SPECIAL_REWARDS {
 				if strings.Contains(round.ResultName, sr) {
 					specialReward = sr
@@ -161,17 +240,21 @@ func (e *Engine) processPendingSettlements() {
 
 		// 执行结算
 		hasSettled := e.manager.SettleRound(roundID, winnerNames, specialReward)
-		
+
 		if hasSettled {
-			log.Printf("🏆 结算期号 %s: %v", roundID, winnerNames)
-			if specialReward != "" {
-				log.Printf("✨ 特殊奖项: %s", specialReward)
-			}
+			lg.Info("🏆 结算期号", "winners", winnerNames, "special_reward", specialReward)
 		} else {
 			// 没有预测可结算（比如系统刚启动的第一期），也要移除
-			log.Printf("⏭️ 跳过期号 %s（无预测）", roundID)
+			lg.Info("⏭️ 跳过期号（无预测）")
 		}
 
+		e.settled = append(e.settled, SettledRound{
+			RoundID:       roundID,
+			Winners:       winnerNames,
+			SpecialReward: specialReward,
+			HasBets:       hasSettled,
+		})
+
 		// 只要开奖结果存在，就从待结算列表中移除（无论是否有预测）
 		toRemove = append(toRemove, roundID)
 	}
@@ -189,13 +272,17 @@ func (e *Engine) processPendingSettlements() {
 			}
 			if !shouldRemove {
 				newPending = append(newPending, roundID)
+			} else {
+				delete(e.pendingSince, roundID)
 			}
 		}
 		e.pendingSettlement = newPending
+		metrics.PendingSettlements.Set(float64(len(newPending)))
 		if len(newPending) > 0 || len(toRemove) > 0 {
-			log.Printf("✅ 已处理 %d 个期号，剩余待结算: %d", len(toRemove), len(newPending))
+			slog.Debug("✅ 待结算期号已处理", "processed", len(toRemove), "pending", len(newPending))
 		}
 	}
+	return firstErr
 }
 
 // calcHeatScores 计算热度评分
@@ -224,13 +311,15 @@ func (e *Engine) calcHeatScores(rounds []models.GameRound, limit int) map[string
 		roundIDs[i] = round.RoundID
 	}
 
-	var allWinners []models.GameWinner
-	e.db.Where("round_id IN ?", roundIDs).Find(&allWinners)
+	allWinners, err := e.store.WinnersByRounds(roundIDs)
+	if err != nil {
+		slog.Error("❌ 查询获胜项失败", "error", err)
+	}
 
 	// 按round_id分组
 	winnersMap := make(map[string][]string)
 	for _, w := range allWinners {
-		cleaned := cleanName(w.WinnerName)
+		cleaned := CleanName(w.WinnerName)
 		winnersMap[w.RoundID] = append(winnersMap[w.RoundID], cleaned)
 	}
 
@@ -254,8 +343,8 @@ func (e *Engine) calcHeatScores(rounds []models.GameRound, limit int) map[string
 	return scores
 }
 
-// cleanName 清理车型名称
-func cleanName(name string) string {
+// CleanName 清理抓取的车型名称（去掉首尾空白）
+func CleanName(name string) string {
 	name = strings.TrimSpace(name)
 
 	// 检查是否是标准车型
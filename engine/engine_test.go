@@ -0,0 +1,205 @@
+package engine_test
+
+import (
+	"benz-sniper/database"
+	"benz-sniper/engine"
+	"benz-sniper/models"
+	"benz-sniper/testutil"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestEngineFirstRoundCreatesPredictions(t *testing.T) {
+	h := testutil.New(t, testutil.Options{})
+
+	roundID := h.Draw("红宝马")
+
+	state := h.Manager.GetState()
+	if state.RoundID != roundID {
+		t.Fatalf("RoundID = %s, want %s", state.RoundID, roundID)
+	}
+	if len(state.Strategies) != 2 {
+		t.Fatalf("len(Strategies) = %d, want 2", len(state.Strategies))
+	}
+
+	// 只有一期数据时，热度最高的是刚开出的车型，同分按 BET_LABELS 顺序
+	hot3 := h.Strategy("热门3码")
+	want := []string{"红宝马", "红奔驰", "绿奔驰"}
+	if !equal(hot3.Predictions, want) {
+		t.Errorf("热门3码 = %v, want %v", hot3.Predictions, want)
+	}
+	balanced4 := h.Strategy("均衡4码")
+	want = []string{"红宝马", "红奥迪", "绿奥迪", "黄奥迪"}
+	if !equal(balanced4.Predictions, want) {
+		t.Errorf("均衡4码 = %v, want %v", balanced4.Predictions, want)
+	}
+
+	// 第一期没有之前的预测，不应产生结算记录
+	if _, total, _ := h.Store.ListHistory(database.HistoryQuery{Limit: 10}); total != 0 {
+		t.Errorf("history total = %d, want 0", total)
+	}
+}
+
+func TestEngineVirtualToRealAndStopLoss(t *testing.T) {
+	h := testutil.New(t, testutil.Options{})
+	const name = "热门3码"
+
+	h.Draw("红宝马")
+
+	// 虚盘连赢2把 → 进入实盘
+	h.Draw(h.WinningCar(name))
+	if s := h.Strategy(name); s.Status != engine.StatusVirtual || s.VirtualStreak != 1 {
+		t.Fatalf("一次虚盘赢后 status=%d streak=%d, want 虚盘/1", s.Status, s.VirtualStreak)
+	}
+	h.Draw(h.WinningCar(name))
+	if s := h.Strategy(name); s.Status != engine.StatusReal {
+		t.Fatalf("两次虚盘赢后 status=%d, want 实盘", s.Status)
+	}
+
+	// 实盘赢：记录真实盈利
+	car := h.WinningCar(name)
+	preds := h.Strategy(name).Predictions
+	wantProfit := float64(engine.REAL_ODDS[car]-1)*100 - float64(len(preds)-1)*100
+	h.Draw(car)
+	s := h.Strategy(name)
+	if s.Status != engine.StatusReal {
+		t.Fatalf("实盘赢后 status=%d, want 实盘", s.Status)
+	}
+	if s.RealProfit != wantProfit {
+		t.Errorf("RealProfit = %.2f, want %.2f", s.RealProfit, wantProfit)
+	}
+
+	// 实盘输：止损退回虚盘
+	h.Draw(h.LosingCar())
+	s = h.Strategy(name)
+	if s.Status != engine.StatusVirtual || s.VirtualStreak != 0 {
+		t.Fatalf("实盘输后 status=%d streak=%d, want 虚盘/0", s.Status, s.VirtualStreak)
+	}
+	wantProfit -= 300
+	if s.RealProfit != wantProfit {
+		t.Errorf("RealProfit = %.2f, want %.2f", s.RealProfit, wantProfit)
+	}
+
+	// 历史：4 期结算 × 2 个策略，实盘记录只有 2 条（赢 + 输）
+	_, total, _ := h.Store.ListHistory(database.HistoryQuery{
+		HistoryFilter: database.HistoryFilter{Strategy: name},
+		Limit:         100,
+	})
+	if total != 4 {
+		t.Errorf("%s history total = %d, want 4", name, total)
+	}
+	realStat, _ := h.Store.HistoryStats(database.HistoryFilter{
+		Strategy: name,
+		Status:   database.StatusFilter(engine.StatusReal),
+	})
+	if realStat.Bets != 2 || realStat.Wins != 1 || realStat.Profit != wantProfit {
+		t.Errorf("实盘统计 = %+v, want bets=2 wins=1 profit=%.2f", realStat, wantProfit)
+	}
+}
+
+func TestEngineRecordsSpecialReward(t *testing.T) {
+	h := testutil.New(t, testutil.Options{})
+
+	h.Draw("红宝马")
+	roundID := h.DrawSpecial("大三元", "红奔驰", "绿奔驰", "黄奔驰")
+
+	records, total, err := h.Store.ListHistory(database.HistoryQuery{Limit: 10})
+	if err != nil || total != 2 {
+		t.Fatalf("ListHistory() total=%d err=%v, want 2", total, err)
+	}
+	for _, r := range records {
+		if r.RoundID != roundID {
+			t.Errorf("RoundID = %s, want %s", r.RoundID, roundID)
+		}
+		if r.SpecialReward != "大三元" {
+			t.Errorf("[%s] SpecialReward = %q, want 大三元", r.Strategy, r.SpecialReward)
+		}
+	}
+}
+
+func TestEngineSQLiteStore(t *testing.T) {
+	h := testutil.New(t, testutil.Options{Driver: database.DriverSQLite})
+
+	h.Draw("红宝马")
+	h.Draw(h.WinningCar("热门3码"))
+	h.Draw(h.WinningCar("热门3码"))
+	h.Draw(h.WinningCar("热门3码"))
+
+	if s := h.Strategy("热门3码"); s.Status != engine.StatusReal || s.RealProfit <= 0 {
+		t.Fatalf("热门3码 status=%d profit=%.2f, want 实盘且盈利", s.Status, s.RealProfit)
+	}
+	daily := h.Manager.GetDailyReport()
+	if len(daily) != 1 || daily[0].Date != "2026-01-01" {
+		t.Fatalf("GetDailyReport() = %+v, want one item for 2026-01-01", daily)
+	}
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestEngineRunContextScheduledByClock(t *testing.T) {
+	h := testutil.New(t, testutil.Options{})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		h.Engine.RunContext(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		h.Clock.Advance(engine.PollInterval)
+		<-done
+	}()
+
+	// 等待第一次 Tick 完成并进入等待
+	waitFor(t, func() bool { return h.Clock.Waiters() == 1 })
+
+	// 写入新一期但不手动 Tick，由时钟推进触发下一次轮询
+	round := &models.GameRound{RoundID: "5000", Timestamp: h.Clock.Now().Unix()}
+	if err := h.Store.SaveRound(round, []models.GameWinner{{RoundID: "5000", WinnerName: "红奔驰"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if h.Manager.GetState().RoundID == "5000" {
+		t.Fatal("时钟未推进前不应处理新期号")
+	}
+	h.Clock.Advance(engine.PollInterval)
+	waitFor(t, func() bool { return h.Manager.GetState().RoundID == "5000" })
+}
+
+// waitFor 等待条件成立（最多 2 秒）
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("等待条件超时")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCleanName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"红宝马", "红宝马"},
+		{" 黄大众\n", "黄大众"},
+		{"蓝奔驰", "蓝奔驰"}, // 未知车型原样返回
+	}
+	for _, tt := range tests {
+		if got := engine.CleanName(tt.name); got != tt.want {
+			t.Errorf("CleanName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package engine
+
+import (
+	"benz-sniper/database"
+	"benz-sniper/models"
+)
+
+// ExportBatchSize 导出时每批读取的记录数
+const ExportBatchSize = 500
+
+// EachHistory 分批读取历史记录（按 created_at DESC, id DESC，游标分页，导出过程中新写入的记录不会使分页错位）
+func (m *StrategyManager) EachHistory(filter database.HistoryFilter, fn func([]models.StrategyHistory) error) error {
+	return m.eachHistory(database.HistoryQuery{HistoryFilter: filter}, fn)
+}
+
+// eachHistory 按查询的排序方向分批读取历史记录（游标分页）
+func (m *StrategyManager) eachHistory(query database.HistoryQuery, fn func([]models.StrategyHistory) error) error {
+	query.SkipCount = true
+	query.Limit = ExportBatchSize
+	for {
+		records, _, err := m.store.ListHistory(query)
+		if err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			return nil
+		}
+		if err := fn(records); err != nil {
+			return err
+		}
+		if len(records) < ExportBatchSize {
+			return nil
+		}
+		cursor := database.CursorOf(records[len(records)-1])
+		query.After = &cursor
+	}
+}
+
+// EachUserBet 分批读取用户派彩记录（含明细，按 created_at, id 升序）
+func (m *StrategyManager) EachUserBet(query database.UserBetQuery, fn func([]models.UserBet) error) error {
+	query.Limit = ExportBatchSize
+	for query.Offset = 0; ; query.Offset += ExportBatchSize {
+		bets, err := m.store.ListUserBets(query)
+		if err != nil {
+			return err
+		}
+		if len(bets) > 0 {
+			if err := fn(bets); err != nil {
+				return err
+			}
+		}
+		if len(bets) < ExportBatchSize {
+			return nil
+		}
+	}
+}
+
+// EachRound 分批读取开奖数据及获胜项（按期号升序，winners 按期号分组、按名次排序）
+func (m *StrategyManager) EachRound(query database.RoundQuery, fn func([]models.GameRound, map[string][]models.GameWinner) error) error {
+	query.Limit = ExportBatchSize
+	for {
+		rounds, err := m.store.ListRounds(query)
+		if err != nil {
+			return err
+		}
+		if len(rounds) == 0 {
+			return nil
+		}
+		roundIDs := make([]string, 0, len(rounds))
+		for _, r := range rounds {
+			roundIDs = append(roundIDs, r.RoundID)
+		}
+		rows, err := m.store.WinnersByRounds(roundIDs)
+		if err != nil {
+			return err
+		}
+		winners := make(map[string][]models.GameWinner)
+		for _, w := range rows {
+			winners[w.RoundID] = append(winners[w.RoundID], w)
+		}
+		if err := fn(rounds, winners); err != nil {
+			return err
+		}
+		if len(rounds) < ExportBatchSize {
+			return nil
+		}
+		query.AfterRoundID = rounds[len(rounds)-1].RoundID
+	}
+}
@@ -0,0 +1,213 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 健康检查状态
+const (
+	HealthOK        = "ok"
+	HealthUnhealthy = "unhealthy"
+)
+
+// HealthThresholds 健康检查阈值
+type HealthThresholds struct {
+	TickStaleAfter   time.Duration // 超过该时长没有成功轮询视为引擎停摆
+	FreshnessFactor  float64       // 最新一期超过 开奖间隔×倍数 未更新视为数据过期
+	SettlementMaxAge time.Duration // 待结算期号等待超过该时长视为结算积压
+}
+
+// DefaultHealthThresholds 默认健康检查阈值
+func DefaultHealthThresholds() HealthThresholds {
+	return HealthThresholds{
+		TickStaleAfter:   30 * time.Second,
+		FreshnessFactor:  3,
+		SettlementMaxAge: 2 * time.Minute,
+	}
+}
+
+// PendingSettlement 待结算期号
+type PendingSettlement struct {
+	RoundID string    `json:"round_id"`
+	Since   time.Time `json:"since"` // 加入待结算列表的时间
+}
+
+// EngineHealth 引擎运行状况（每次轮询结束时更新）
+type EngineHealth struct {
+	LastTickAt          time.Time           `json:"last_tick_at"`         // 最近一次轮询
+	LastSuccessAt       time.Time           `json:"last_success_at"`      // 最近一次成功轮询
+	LastError           string              `json:"last_error,omitempty"` // 最近一次轮询错误
+	LastErrorAt         time.Time           `json:"last_error_at"`        // 最近一次出错时间
+	ConsecutiveFailures int                 `json:"consecutive_failures"` // 连续失败次数
+	LastRoundID         string              `json:"last_round_id"`        // 最近看到的期号
+	LastRoundAt         time.Time           `json:"last_round_at"`        // 该期开奖时间
+	RoundInterval       time.Duration       `json:"-"`                    // 开奖间隔估计
+	Pending             []PendingSettlement `json:"pending_settlements"`  // 待结算期号
+}
+
+// HealthCheck 单项检查结果
+type HealthCheck struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// HealthReport 健康检查报告（任一检查失败时 Status 为 unhealthy）
+type HealthReport struct {
+	Status    string        `json:"status"`
+	CheckedAt time.Time     `json:"checked_at"`
+	Checks    []HealthCheck `json:"checks"`
+	Engine    *EngineHealth `json:"engine,omitempty"`
+}
+
+// Healthy 是否所有检查都通过
+func (r HealthReport) Healthy() bool {
+	return r.Status == HealthOK
+}
+
+// healthTracker 引擎健康状态（引擎 goroutine 写，HTTP 读）
+type healthTracker struct {
+	mu     sync.Mutex
+	health EngineHealth
+}
+
+// SetHealthThresholds 设置健康检查阈值（零值字段保持默认）
+func (e *Engine) SetHealthThresholds(t HealthThresholds) {
+	if t.TickStaleAfter > 0 {
+		e.thresholds.TickStaleAfter = t.TickStaleAfter
+	}
+	if t.FreshnessFactor > 0 {
+		e.thresholds.FreshnessFactor = t.FreshnessFactor
+	}
+	if t.SettlementMaxAge > 0 {
+		e.thresholds.SettlementMaxAge = t.SettlementMaxAge
+	}
+}
+
+// recordTick 记录一次轮询结果（在引擎 goroutine 中调用）
+func (e *Engine) recordTick(at time.Time, err error) {
+	pending := make([]PendingSettlement, 0, len(e.pendingSettlement))
+	for _, roundID := range e.pendingSettlement {
+		pending = append(pending, PendingSettlement{RoundID: roundID, Since: e.pendingSince[roundID]})
+	}
+
+	e.health.mu.Lock()
+	defer e.health.mu.Unlock()
+	h := &e.health.health
+	h.LastTickAt = at
+	if err != nil {
+		h.LastError = err.Error()
+		h.LastErrorAt = at
+		h.ConsecutiveFailures++
+	} else {
+		h.LastSuccessAt = at
+		h.ConsecutiveFailures = 0
+	}
+	if e.lastRound != nil {
+		h.LastRoundID = e.lastRound.RoundID
+		h.LastRoundAt = roundTime(e.lastRound)
+	}
+	h.RoundInterval = e.timer.Estimate().Interval
+	h.Pending = pending
+}
+
+// Health 引擎存活检查：数据库可达、轮询未停摆、开奖数据新鲜、没有积压的待结算期号
+func (e *Engine) Health() HealthReport {
+	now := e.clock.Now()
+	e.health.mu.Lock()
+	h := e.health.health
+	e.health.mu.Unlock()
+	t := e.thresholds
+
+	checks := []HealthCheck{checkError("database", e.store.Ping())}
+
+	switch {
+	case h.LastSuccessAt.IsZero():
+		checks = append(checks, failed("tick", "引擎尚未成功轮询"+lastError(h)))
+	case now.Sub(h.LastSuccessAt) > t.TickStaleAfter:
+		checks = append(checks, failed("tick", fmt.Sprintf("已 %s 没有成功轮询%s",
+			now.Sub(h.LastSuccessAt).Round(time.Second), lastError(h))))
+	default:
+		checks = append(checks, passed("tick"))
+	}
+
+	interval := h.RoundInterval
+	if interval <= 0 {
+		interval = DefaultRoundInterval
+	}
+	maxAge := time.Duration(float64(interval) * t.FreshnessFactor)
+	switch {
+	case h.LastRoundAt.IsZero():
+		checks = append(checks, failed("data_freshness", "尚无开奖数据"))
+	case now.Sub(h.LastRoundAt) > maxAge:
+		checks = append(checks, failed("data_freshness", fmt.Sprintf("最新期号 %s 已 %s 未更新（上限 %s）",
+			h.LastRoundID, now.Sub(h.LastRoundAt).Round(time.Second), maxAge.Round(time.Second))))
+	default:
+		checks = append(checks, passed("data_freshness"))
+	}
+
+	stale := make([]string, 0)
+	for _, p := range h.Pending {
+		if now.Sub(p.Since) > t.SettlementMaxAge {
+			stale = append(stale, p.RoundID)
+		}
+	}
+	if len(stale) > 0 {
+		sort.Strings(stale)
+		checks = append(checks, failed("pending_settlements", fmt.Sprintf("%d 个期号等待结算超过 %s: %s",
+			len(stale), t.SettlementMaxAge, strings.Join(stale, ", "))))
+	} else {
+		checks = append(checks, passed("pending_settlements"))
+	}
+
+	return newHealthReport(now, checks, &h)
+}
+
+// Readiness 就绪检查：数据库可达、表结构已迁移、配置已加载
+func (e *Engine) Readiness() HealthReport {
+	checks := []HealthCheck{checkError("database", e.store.Ping())}
+	if checks[0].Status == HealthOK {
+		checks = append(checks, checkError("schema", e.store.CheckSchema()))
+	}
+	checks = append(checks, checkError("config", e.manager.EnsureConfigLoaded()))
+	return newHealthReport(e.clock.Now(), checks, nil)
+}
+
+// newHealthReport 汇总各项检查
+func newHealthReport(now time.Time, checks []HealthCheck, h *EngineHealth) HealthReport {
+	status := HealthOK
+	for _, c := range checks {
+		if c.Status != HealthOK {
+			status = HealthUnhealthy
+		}
+	}
+	return HealthReport{Status: status, CheckedAt: now, Checks: checks, Engine: h}
+}
+
+// checkError 以错误构造检查结果
+func checkError(name string, err error) HealthCheck {
+	if err != nil {
+		return failed(name, err.Error())
+	}
+	return passed(name)
+}
+
+func passed(name string) HealthCheck {
+	return HealthCheck{Name: name, Status: HealthOK}
+}
+
+func failed(name, message string) HealthCheck {
+	return HealthCheck{Name: name, Status: HealthUnhealthy, Message: message}
+}
+
+// lastError 最近一次轮询错误的说明后缀
+func lastError(h EngineHealth) string {
+	if h.LastError == "" {
+		return ""
+	}
+	return "，最近错误: " + h.LastError
+}
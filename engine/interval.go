@@ -0,0 +1,142 @@
+package engine
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	DefaultRoundInterval = 34 * time.Second // 默认开奖间隔（样本不足时使用）
+	BettingLockLead      = 10 * time.Second // 开奖前多久停止下注（开奖动画时间）
+	BettingWarnThreshold = 5 * time.Second  // 距停止下注不足该时间时告警
+
+	maxIntervalSamples = 50              // 最多保留的间隔样本数
+	maxRoundGap        = 5               // 期号跳跃超过该值时不计入样本（停机、断档）
+	minIntervalSample  = 5 * time.Second // 合理间隔下限
+	maxIntervalSample  = 5 * time.Minute // 合理间隔上限
+	fullConfidenceN    = 10              // 样本数达到该值时样本置信度为 1
+)
+
+// RoundTiming 开奖时间预测（不可变快照）
+type RoundTiming struct {
+	LastDrawAt time.Time     `json:"last_draw_at"` // 最新一期开奖时间
+	NextDrawAt time.Time     `json:"next_draw_at"` // 预测下一期开奖时间
+	Interval   time.Duration `json:"-"`            // 间隔估计（中位数）
+	Jitter     time.Duration `json:"-"`            // 抖动（中位数绝对偏差）
+	Samples    int           `json:"samples"`      // 样本数
+	Confidence float64       `json:"confidence"`   // 置信度（0~1）
+}
+
+// BettingClosesAt 预测的停止下注时间
+func (t RoundTiming) BettingClosesAt() time.Time {
+	return t.NextDrawAt.Add(-BettingLockLead)
+}
+
+// Countdown 距停止下注的剩余秒数（向上取整，不小于0）
+func (t RoundTiming) Countdown(now time.Time) int {
+	remaining := t.BettingClosesAt().Sub(now)
+	if remaining <= 0 {
+		return 0
+	}
+	return int(math.Ceil(remaining.Seconds()))
+}
+
+// Closing 下注窗口是否即将关闭（或已关闭）
+func (t RoundTiming) Closing(now time.Time) bool {
+	return t.BettingClosesAt().Sub(now) <= BettingWarnThreshold
+}
+
+// IntervalEstimator 根据相邻期的时间戳学习真实开奖间隔（非并发安全，由引擎单goroutine使用）
+type IntervalEstimator struct {
+	samples []time.Duration
+	lastID  int64
+	lastAt  time.Time
+	hasLast bool
+}
+
+// NewIntervalEstimator 创建间隔估计器
+func NewIntervalEstimator() *IntervalEstimator {
+	return &IntervalEstimator{}
+}
+
+// Observe 记录一期的开奖时间（按期号递增调用，重复或乱序的期号会被忽略）
+func (e *IntervalEstimator) Observe(roundID string, drawAt time.Time) {
+	if drawAt.IsZero() {
+		return
+	}
+	id, err := strconv.ParseInt(roundID, 10, 64)
+	if err != nil {
+		// 非数字期号：视为连续期
+		id = e.lastID + 1
+	}
+
+	if e.hasLast {
+		if id <= e.lastID || !drawAt.After(e.lastAt) {
+			return
+		}
+		step := id - e.lastID
+		if step <= maxRoundGap {
+			interval := drawAt.Sub(e.lastAt) / time.Duration(step)
+			if interval >= minIntervalSample && interval <= maxIntervalSample {
+				e.samples = append(e.samples, interval)
+				if len(e.samples) > maxIntervalSamples {
+					e.samples = e.samples[len(e.samples)-maxIntervalSamples:]
+				}
+			}
+		}
+	}
+
+	e.lastID = id
+	e.lastAt = drawAt
+	e.hasLast = true
+}
+
+// Estimate 当前开奖时间预测
+func (e *IntervalEstimator) Estimate() RoundTiming {
+	timing := RoundTiming{
+		LastDrawAt: e.lastAt,
+		Interval:   DefaultRoundInterval,
+		Samples:    len(e.samples),
+	}
+
+	if len(e.samples) > 0 {
+		timing.Interval = median(e.samples)
+		deviations := make([]time.Duration, len(e.samples))
+		for i, s := range e.samples {
+			deviations[i] = absDuration(s - timing.Interval)
+		}
+		timing.Jitter = median(deviations)
+
+		// 置信度 = 样本充足度 × 稳定度（抖动达到间隔的 25% 时稳定度为 0）
+		sampleFactor := math.Min(1, float64(len(e.samples))/fullConfidenceN)
+		stability := 1 - math.Min(1, timing.Jitter.Seconds()/(timing.Interval.Seconds()*0.25))
+		timing.Confidence = math.Round(sampleFactor*stability*100) / 100
+	}
+
+	if e.hasLast {
+		timing.NextDrawAt = e.lastAt.Add(timing.Interval)
+	}
+	return timing
+}
+
+// median 中位数（不修改入参）
+func median(values []time.Duration) time.Duration {
+	sorted := make([]time.Duration, len(values))
+	copy(sorted, values)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// absDuration 绝对值
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
@@ -0,0 +1,91 @@
+package engine
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestIntervalEstimatorDefaultsWithoutSamples(t *testing.T) {
+	e := NewIntervalEstimator()
+	if timing := e.Estimate(); timing.Interval != DefaultRoundInterval || !timing.NextDrawAt.IsZero() {
+		t.Fatalf("空估计 = %+v, want 默认间隔且无下一期时间", timing)
+	}
+
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.Local)
+	e.Observe("1000", start)
+	timing := e.Estimate()
+	if timing.Samples != 0 || timing.Confidence != 0 || !timing.NextDrawAt.Equal(start.Add(DefaultRoundInterval)) {
+		t.Errorf("单期估计 = %+v", timing)
+	}
+}
+
+func TestIntervalEstimatorMedianAndJitter(t *testing.T) {
+	e := NewIntervalEstimator()
+	at := time.Date(2026, 1, 1, 12, 0, 0, 0, time.Local)
+	deltas := []time.Duration{40, 41, 39, 40, 42, 40, 38, 40, 41, 40}
+	e.Observe("1000", at)
+	for i, d := range deltas {
+		at = at.Add(d * time.Second)
+		e.Observe(strconv.Itoa(1001+i), at)
+	}
+
+	timing := e.Estimate()
+	if timing.Interval != 40*time.Second {
+		t.Errorf("Interval = %v, want 40s", timing.Interval)
+	}
+	if timing.Jitter != time.Second/2 {
+		t.Errorf("Jitter = %v, want 500ms", timing.Jitter)
+	}
+	if timing.Samples != len(deltas) || timing.Confidence != 0.95 {
+		t.Errorf("Samples = %d Confidence = %.2f, want %d/0.95", timing.Samples, timing.Confidence, len(deltas))
+	}
+	if !timing.NextDrawAt.Equal(at.Add(40 * time.Second)) {
+		t.Errorf("NextDrawAt = %v, want %v", timing.NextDrawAt, at.Add(40*time.Second))
+	}
+}
+
+func TestIntervalEstimatorIgnoresGapsAndDisorder(t *testing.T) {
+	e := NewIntervalEstimator()
+	at := time.Date(2026, 1, 1, 12, 0, 0, 0, time.Local)
+	e.Observe("1000", at)
+	e.Observe("1002", at.Add(60*time.Second)) // 缺一期：按 2 个间隔平分
+	e.Observe("1001", at.Add(90*time.Second)) // 乱序：忽略
+	e.Observe("1002", at.Add(95*time.Second)) // 重复：忽略
+	e.Observe("1100", at.Add(time.Hour))      // 停机断档：不计样本，但更新最新期
+
+	timing := e.Estimate()
+	if timing.Samples != 1 || timing.Interval != 30*time.Second {
+		t.Errorf("估计 = %+v, want 1 个 30s 样本", timing)
+	}
+	if !timing.LastDrawAt.Equal(at.Add(time.Hour)) {
+		t.Errorf("LastDrawAt = %v, want %v", timing.LastDrawAt, at.Add(time.Hour))
+	}
+}
+
+func TestRoundTimingCountdownAndClosing(t *testing.T) {
+	next := time.Date(2026, 1, 1, 12, 0, 34, 0, time.Local)
+	timing := RoundTiming{NextDrawAt: next}
+
+	tests := []struct {
+		name      string
+		now       time.Time
+		countdown int
+		closing   bool
+	}{
+		{"窗口充足", next.Add(-30 * time.Second), 20, false},
+		{"向上取整", next.Add(-20*time.Second - 500*time.Millisecond), 11, false},
+		{"即将关闭", next.Add(-14 * time.Second), 4, true},
+		{"已关闭", next.Add(-5 * time.Second), 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := timing.Countdown(tt.now); got != tt.countdown {
+				t.Errorf("Countdown() = %d, want %d", got, tt.countdown)
+			}
+			if got := timing.Closing(tt.now); got != tt.closing {
+				t.Errorf("Closing() = %v, want %v", got, tt.closing)
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package engine
+
+import (
+	"benz-sniper/database"
+	"benz-sniper/models"
+	"math"
+	"sort"
+	"time"
+)
+
+// 余额异动类型
+const (
+	BalanceDeposit    = "deposit"    // 余额增加多于派彩（充值）
+	BalanceWithdrawal = "withdrawal" // 余额减少多于下注（提现）
+)
+
+// LedgerEntry 账号流水中的一期（余额 <= 0 视为未上报，不参与余额轨迹和异动检测）
+type LedgerEntry struct {
+	RoundID         string    `json:"round_id"`
+	At              time.Time `json:"at"`                         // 上传时间
+	Stake           float64   `json:"stake"`                      // 下注
+	Payout          float64   `json:"payout"`                     // 派彩
+	Net             float64   `json:"net"`                        // 派彩 - 下注
+	Balance         float64   `json:"balance"`                    // 上报的余额
+	ExpectedBalance *float64  `json:"expected_balance,omitempty"` // 按上一期余额和本期输赢推算的余额
+	Adjustment      float64   `json:"adjustment"`                 // 无法由下注解释的余额变动
+	CumStake        float64   `json:"cum_stake"`                  // 累计下注（从时间范围开始）
+	CumPayout       float64   `json:"cum_payout"`                 // 累计派彩
+	CumNet          float64   `json:"cum_net"`                    // 累计净盈亏
+}
+
+// BalanceJump 无法由下注解释的余额变动（充值/提现）
+type BalanceJump struct {
+	RoundID         string    `json:"round_id"`
+	At              time.Time `json:"at"`
+	Kind            string    `json:"kind"`             // deposit / withdrawal
+	PreviousBalance float64   `json:"previous_balance"` // 上一期余额
+	ExpectedBalance float64   `json:"expected_balance"` // 推算余额
+	Balance         float64   `json:"balance"`          // 上报余额
+	Amount          float64   `json:"amount"`           // 上报 - 推算
+}
+
+// LedgerDay 账号每日汇总（按上传时间所属的营业日）
+type LedgerDay struct {
+	Date           string  `json:"date"`
+	Bets           int     `json:"bets"`
+	Stake          float64 `json:"stake"`
+	Payout         float64 `json:"payout"`
+	Net            float64 `json:"net"`
+	Deposits       float64 `json:"deposits"`        // 当日充值合计
+	Withdrawals    float64 `json:"withdrawals"`     // 当日提现合计（正数）
+	OpeningBalance float64 `json:"opening_balance"` // 当日第一条上报余额
+	ClosingBalance float64 `json:"closing_balance"` // 当日最后一条上报余额
+}
+
+// AccountSummary 账号汇总
+type AccountSummary struct {
+	Account     string     `json:"account"`
+	Bets        int        `json:"bets"`         // 上传的期数
+	TotalStake  float64    `json:"total_stake"`  // 累计下注
+	TotalPayout float64    `json:"total_payout"` // 累计派彩
+	Net         float64    `json:"net"`          // 累计净盈亏
+	Deposits    float64    `json:"deposits"`     // 检测到的充值合计
+	Withdrawals float64    `json:"withdrawals"`  // 检测到的提现合计（正数）
+	Balance     float64    `json:"balance"`      // 最近一次上报的余额
+	FirstBetAt  *time.Time `json:"first_bet_at"`
+	LastBetAt   *time.Time `json:"last_bet_at"`
+}
+
+// AccountLedger 账号流水
+type AccountLedger struct {
+	AccountSummary
+	Entries []LedgerEntry `json:"entries"` // 流水（按期号降序）
+	Jumps   []BalanceJump `json:"jumps"`   // 余额异动（按期号降序）
+	Daily   []LedgerDay   `json:"daily"`   // 每日汇总（按日期降序）
+}
+
+// ListAccounts 所有上传过派彩的账号汇总（按账号排序，只包含时间范围内有上传的账号）
+func (m *StrategyManager) ListAccounts(from, to time.Time) ([]AccountSummary, error) {
+	bets, err := m.store.ListUserBets(database.UserBetQuery{})
+	if err != nil {
+		return nil, err
+	}
+	byAccount := make(map[string][]models.UserBet)
+	for _, b := range bets {
+		byAccount[b.UserAccount] = append(byAccount[b.UserAccount], b)
+	}
+
+	summaries := make([]AccountSummary, 0, len(byAccount))
+	for account, list := range byAccount {
+		ledger := buildLedger(account, list, from, to)
+		if ledger.Bets > 0 {
+			summaries = append(summaries, ledger.AccountSummary)
+		}
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Account < summaries[j].Account })
+	return summaries, nil
+}
+
+// AccountLedger 单个账号的流水、余额轨迹、余额异动和每日汇总（账号没有任何上传返回 ErrNotFound）
+//
+// 流水按期号排序；时间范围按上传时间筛选，但余额推算会用到范围之前的最后一期。
+func (m *StrategyManager) AccountLedger(account string, from, to time.Time) (*AccountLedger, error) {
+	bets, err := m.store.ListUserBets(database.UserBetQuery{Account: account})
+	if err != nil {
+		return nil, err
+	}
+	if len(bets) == 0 {
+		return nil, database.ErrNotFound
+	}
+	return buildLedger(account, bets, from, to), nil
+}
+
+// buildLedger 按期号顺序计算累计值和余额异动
+func buildLedger(account string, bets []models.UserBet, from, to time.Time) *AccountLedger {
+	sort.SliceStable(bets, func(i, j int) bool { return database.RoundIDLess(bets[i].RoundID, bets[j].RoundID) })
+
+	ledger := &AccountLedger{
+		AccountSummary: AccountSummary{Account: account},
+		Entries:        make([]LedgerEntry, 0),
+		Jumps:          make([]BalanceJump, 0),
+		Daily:          make([]LedgerDay, 0),
+	}
+	daily := make(map[string]*LedgerDay)
+	prevBalance := 0.0
+	for _, b := range bets {
+		at := timeOrZero(b.CreatedAt)
+		entry := LedgerEntry{
+			RoundID: b.RoundID,
+			At:      at,
+			Stake:   b.BetAmount,
+			Payout:  b.PayoutAmount,
+			Net:     roundMoney(b.PayoutAmount - b.BetAmount),
+			Balance: b.Balance,
+		}
+		var jump *BalanceJump
+		if b.Balance > 0 && prevBalance > 0 {
+			expected := roundMoney(prevBalance - b.BetAmount + b.PayoutAmount)
+			entry.ExpectedBalance = &expected
+			if diff := roundMoney(b.Balance - expected); math.Abs(diff) > ReconcileTolerance {
+				entry.Adjustment = diff
+				jump = &BalanceJump{
+					RoundID:         b.RoundID,
+					At:              at,
+					Kind:            BalanceDeposit,
+					PreviousBalance: prevBalance,
+					ExpectedBalance: expected,
+					Balance:         b.Balance,
+					Amount:          diff,
+				}
+				if diff < 0 {
+					jump.Kind = BalanceWithdrawal
+				}
+			}
+		}
+		if b.Balance > 0 {
+			prevBalance = b.Balance
+		}
+		if !inWindow(at, from, to) {
+			continue
+		}
+
+		s := &ledger.AccountSummary
+		s.Bets++
+		s.TotalStake = roundMoney(s.TotalStake + b.BetAmount)
+		s.TotalPayout = roundMoney(s.TotalPayout + b.PayoutAmount)
+		s.Net = roundMoney(s.TotalPayout - s.TotalStake)
+		if b.Balance > 0 {
+			s.Balance = b.Balance
+		}
+		if s.FirstBetAt == nil || at.Before(*s.FirstBetAt) {
+			s.FirstBetAt = &at
+		}
+		if s.LastBetAt == nil || at.After(*s.LastBetAt) {
+			s.LastBetAt = &at
+		}
+		entry.CumStake, entry.CumPayout, entry.CumNet = s.TotalStake, s.TotalPayout, s.Net
+
+		date := database.GetCalendar().Day(at)
+		day, ok := daily[date]
+		if !ok {
+			day = &LedgerDay{Date: date}
+			daily[date] = day
+		}
+		day.Bets++
+		day.Stake = roundMoney(day.Stake + b.BetAmount)
+		day.Payout = roundMoney(day.Payout + b.PayoutAmount)
+		day.Net = roundMoney(day.Payout - day.Stake)
+		if b.Balance > 0 {
+			if day.OpeningBalance == 0 {
+				day.OpeningBalance = b.Balance
+			}
+			day.ClosingBalance = b.Balance
+		}
+		if jump != nil {
+			if jump.Amount > 0 {
+				s.Deposits = roundMoney(s.Deposits + jump.Amount)
+				day.Deposits = roundMoney(day.Deposits + jump.Amount)
+			} else {
+				s.Withdrawals = roundMoney(s.Withdrawals - jump.Amount)
+				day.Withdrawals = roundMoney(day.Withdrawals - jump.Amount)
+			}
+			ledger.Jumps = append(ledger.Jumps, *jump)
+		}
+		ledger.Entries = append(ledger.Entries, entry)
+	}
+
+	for _, day := range daily {
+		ledger.Daily = append(ledger.Daily, *day)
+	}
+	sort.Slice(ledger.Daily, func(i, j int) bool { return ledger.Daily[i].Date > ledger.Daily[j].Date })
+	reverse(ledger.Entries)
+	reverse(ledger.Jumps)
+	return ledger
+}
+
+// inWindow 时间是否在 [from, to) 内（零值表示不限制）
+func inWindow(t, from, to time.Time) bool {
+	return (from.IsZero() || !t.Before(from)) && (to.IsZero() || t.Before(to))
+}
+
+// reverse 原地反转切片
+func reverse[T any](s []T) {
+	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+		s[i], s[j] = s[j], s[i]
+	}
+}
@@ -0,0 +1,116 @@
+package engine
+
+import (
+	"benz-sniper/database"
+	"benz-sniper/metrics"
+	"log/slog"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	roundLagDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metrics.Namespace, "engine", "round_lag"),
+		"game_rounds 最新期号领先引擎状态的期数", nil, nil)
+	roundLagSecondsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metrics.Namespace, "engine", "round_lag_seconds"),
+		"game_rounds 最新一期开奖时间领先引擎状态的秒数", nil, nil)
+	strategyStatusDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metrics.Namespace, "strategy", "status"),
+		"策略状态（0=虚盘, 1=实盘）", []string{"strategy"}, nil)
+	strategyRealProfitDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metrics.Namespace, "strategy", "real_profit"),
+		"策略实盘累计盈亏", []string{"strategy"}, nil)
+	realProfitDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metrics.Namespace, "", "real_profit"),
+		"全部策略实盘累计盈亏", nil, nil)
+	strategyVirtualProfitDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metrics.Namespace, "strategy", "virtual_profit"),
+		"策略纸面累计盈亏（每期都按下注计算）", []string{"strategy"}, nil)
+	virtualProfitDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metrics.Namespace, "", "virtual_profit"),
+		"全部策略纸面累计盈亏", nil, nil)
+)
+
+// Collector 抓取时读取的引擎指标：落后期数、策略状态、实盘和纸面盈亏
+// 引擎停摆时这些值仍会如实反映，不依赖引擎主动上报
+type Collector struct {
+	store   database.Store
+	manager *StrategyManager
+}
+
+// NewCollector 创建引擎指标采集器（注册到 metrics.Registry 后由 /metrics 输出）
+func NewCollector(e *Engine) *Collector {
+	return &Collector{store: e.store, manager: e.manager}
+}
+
+// Describe 实现 prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- roundLagDesc
+	ch <- roundLagSecondsDesc
+	ch <- strategyStatusDesc
+	ch <- strategyRealProfitDesc
+	ch <- realProfitDesc
+	ch <- strategyVirtualProfitDesc
+	ch <- virtualProfitDesc
+}
+
+// Collect 实现 prometheus.Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	m := c.manager
+	state := m.GetState()
+	lastDrawAt := state.Timing.LastDrawAt
+
+	if latest, err := c.store.LatestRound(); err == nil {
+		latestAt := TimestampToTime(latest.Timestamp)
+		if lastDrawAt.IsZero() {
+			lastDrawAt = m.clock.Now()
+		}
+		lagSeconds := 0.0
+		if !latestAt.IsZero() && latestAt.After(lastDrawAt) {
+			lagSeconds = latestAt.Sub(lastDrawAt).Seconds()
+		}
+		ch <- prometheus.MustNewConstMetric(roundLagDesc, prometheus.GaugeValue, float64(roundLag(latest.RoundID, state.RoundID)))
+		ch <- prometheus.MustNewConstMetric(roundLagSecondsDesc, prometheus.GaugeValue, lagSeconds)
+	} else if err != database.ErrNotFound {
+		slog.Error("❌ 指标查询最新期号失败", "error", err)
+	}
+
+	for _, strategy := range state.Strategies {
+		ch <- prometheus.MustNewConstMetric(strategyStatusDesc, prometheus.GaugeValue, float64(strategy.Status), strategy.Name)
+		ch <- prometheus.MustNewConstMetric(strategyRealProfitDesc, prometheus.GaugeValue, strategy.RealProfit, strategy.Name)
+		ch <- prometheus.MustNewConstMetric(strategyVirtualProfitDesc, prometheus.GaugeValue, strategy.VirtualProfit, strategy.Name)
+	}
+	ch <- prometheus.MustNewConstMetric(realProfitDesc, prometheus.GaugeValue, state.TotalRealProfit)
+	ch <- prometheus.MustNewConstMetric(virtualProfitDesc, prometheus.GaugeValue, state.TotalVirtualProfit)
+}
+
+// roundLag 最新期号领先引擎期号的期数（非数字期号只区分是否一致）
+func roundLag(latest, current string) int64 {
+	if latest == current {
+		return 0
+	}
+	l, errL := strconv.ParseInt(latest, 10, 64)
+	c, errC := strconv.ParseInt(current, 10, 64)
+	if errL != nil || errC != nil || l < c {
+		return 1
+	}
+	return l - c
+}
+
+// statusLabel 策略状态的指标标签
+func statusLabel(status int) string {
+	if status == StatusReal {
+		return "real"
+	}
+	return "virtual"
+}
+
+// resultLabel 结算结果的指标标签
+func resultLabel(won bool) string {
+	if won {
+		return "win"
+	}
+	return "loss"
+}
@@ -0,0 +1,79 @@
+package engine_test
+
+import (
+	"benz-sniper/engine"
+	"benz-sniper/metrics"
+	"benz-sniper/models"
+	"benz-sniper/testutil"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetricsCollector(t *testing.T) {
+	h := testutil.New(t, testutil.Options{})
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(engine.NewCollector(h.Engine))
+
+	realWins := promtest.ToFloat64(metrics.Settlements.WithLabelValues("热门3码", "real", "win"))
+	rounds := promtest.ToFloat64(metrics.RoundsProcessed)
+
+	h.PromoteToReal("热门3码")
+	h.Draw(h.WinningCar("热门3码"))
+	profit := h.Strategy("热门3码").RealProfit
+
+	if got := promtest.ToFloat64(metrics.Settlements.WithLabelValues("热门3码", "real", "win")) - realWins; got != 1 {
+		t.Errorf("实盘赢结算次数 = %v, want 1", got)
+	}
+	if got := promtest.ToFloat64(metrics.RoundsProcessed) - rounds; got < 3 {
+		t.Errorf("处理期数 = %v, want >= 3", got)
+	}
+	if got := promtest.ToFloat64(metrics.PendingSettlements); got != 0 {
+		t.Errorf("待结算队列 = %v, want 0", got)
+	}
+
+	balanced := strconv.Itoa(h.Strategy("均衡4码").Status)
+	want := func(lag string) string {
+		return `
+# HELP benz_engine_round_lag game_rounds 最新期号领先引擎状态的期数
+# TYPE benz_engine_round_lag gauge
+benz_engine_round_lag ` + lag + `
+# HELP benz_strategy_status 策略状态（0=虚盘, 1=实盘）
+# TYPE benz_strategy_status gauge
+benz_strategy_status{strategy="均衡4码"} ` + balanced + `
+benz_strategy_status{strategy="热门3码"} 1
+`
+	}
+	if err := promtest.GatherAndCompare(registry, strings.NewReader(want("0")),
+		"benz_engine_round_lag", "benz_strategy_status"); err != nil {
+		t.Error(err)
+	}
+	if n, err := promtest.GatherAndCount(registry, "benz_strategy_real_profit"); err != nil || n != 2 {
+		t.Errorf("策略盈亏序列 = %d (%v), want 2", n, err)
+	}
+	if profit <= 0 {
+		t.Fatalf("实盘盈利 = %.2f, want > 0", profit)
+	}
+
+	// 引擎未处理的新期号计入落后期数和落后秒数
+	current, _ := strconv.ParseInt(h.Manager.GetState().RoundID, 10, 64)
+	for i := int64(1); i <= 2; i++ {
+		at := h.Clock.Now().Add(time.Duration(i) * testutil.RoundInterval)
+		round := &models.GameRound{RoundID: strconv.FormatInt(current+i, 10), Timestamp: at.Unix(), ResultName: "红宝马", CreatedAt: &at}
+		if err := h.Store.SaveRound(round, nil, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lagSeconds := strconv.Itoa(int(2 * testutil.RoundInterval.Seconds()))
+	if err := promtest.GatherAndCompare(registry, strings.NewReader(want("2")+`
+# HELP benz_engine_round_lag_seconds game_rounds 最新一期开奖时间领先引擎状态的秒数
+# TYPE benz_engine_round_lag_seconds gauge
+benz_engine_round_lag_seconds `+lagSeconds+`
+`), "benz_engine_round_lag", "benz_engine_round_lag_seconds", "benz_strategy_status"); err != nil {
+		t.Error(err)
+	}
+}
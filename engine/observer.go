@@ -0,0 +1,53 @@
+package engine
+
+import (
+	"benz-sniper/models"
+	"time"
+)
+
+// TickEvent 一次轮询的结果（轮询结束后传给 OnTick 注册的回调）
+type TickEvent struct {
+	At       time.Time      // 轮询时间
+	Err      error          // 轮询错误（数据库不可用等）
+	RoundID  string         // 最近看到的期号（尚无数据时为空）
+	RoundAt  time.Time      // 该期开奖时间
+	NewRound bool           // 本次轮询发现了新期号
+	Settled  []SettledRound // 本次轮询结算的期号（含没有预测的期号）
+}
+
+// SettledRound 一期开奖结果
+type SettledRound struct {
+	RoundID       string   `json:"round_id"`
+	Winners       []string `json:"winners"`
+	SpecialReward string   `json:"special_reward"` // 特殊奖项（没有时为空）
+	HasBets       bool     `json:"has_bets"`       // 是否有策略参与结算
+}
+
+// OnTick 注册轮询回调（需在 Run 之前调用；回调在引擎 goroutine 中同步执行，耗时操作应自行异步）
+func (e *Engine) OnTick(fn func(TickEvent)) {
+	e.observers = append(e.observers, fn)
+}
+
+// notifyObservers 把本次轮询的结果传给回调
+func (e *Engine) notifyObservers(at time.Time, err error) {
+	if len(e.observers) == 0 {
+		return
+	}
+	event := TickEvent{At: at, Err: err, NewRound: e.newRound, Settled: e.settled}
+	if e.lastRound != nil {
+		event.RoundID = e.lastRound.RoundID
+		event.RoundAt = roundTime(e.lastRound)
+	}
+	for _, fn := range e.observers {
+		fn(event)
+	}
+}
+
+// roundTime 一期的开奖时间（时间戳缺失时取写入时间）
+func roundTime(r *models.GameRound) time.Time {
+	at := TimestampToTime(r.Timestamp)
+	if at.IsZero() && r.CreatedAt != nil {
+		at = *r.CreatedAt
+	}
+	return at
+}
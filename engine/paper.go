@@ -0,0 +1,69 @@
+package engine
+
+import (
+	"benz-sniper/database"
+	"benz-sniper/models"
+	"encoding/json"
+	"log/slog"
+)
+
+// paperProfit 按预测和开奖结果计算记录的纸面盈亏（与 SettleRound 的盈亏规则一致）
+func (m *StrategyManager) paperProfit(h models.StrategyHistory) float64 {
+	var predictions, winners []string
+	if json.Unmarshal([]byte(h.Predictions), &predictions) != nil || len(predictions) == 0 {
+		return 0
+	}
+	_ = json.Unmarshal([]byte(h.Winners), &winners)
+	if !m.checkWin(predictions, winners) {
+		return -h.BetAmount
+	}
+	return m.calculateProfit(predictions, winners, h.BetAmount/float64(len(predictions)))
+}
+
+// BackfillVirtualProfit 重算全部记录（含归档会话）的纸面盈亏，返回更新的记录数
+// 只更新与计算结果不一致的记录（升级前写入的记录该列为0），完成后重建每日聚合
+func (m *StrategyManager) BackfillVirtualProfit() (int, error) {
+	sessions, err := m.store.ListSessions()
+	if err != nil {
+		return 0, err
+	}
+	sessionIDs := []uint{0}
+	for _, session := range sessions {
+		sessionIDs = append(sessionIDs, session.ID)
+	}
+
+	updated := 0
+	for _, sessionID := range sessionIDs {
+		err := m.EachHistory(database.HistoryFilter{SessionID: sessionID}, func(records []models.StrategyHistory) error {
+			profits := make(map[uint]float64)
+			for _, h := range records {
+				if profit := m.paperProfit(h); profit != h.VirtualProfit {
+					profits[h.ID] = profit
+				}
+			}
+			if len(profits) == 0 {
+				return nil
+			}
+			updated += len(profits)
+			return m.store.SetVirtualProfits(profits)
+		})
+		if err != nil {
+			return updated, err
+		}
+	}
+	if updated == 0 {
+		return 0, nil
+	}
+
+	if _, err := m.store.RebuildDailyStats(); err != nil {
+		return updated, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.loadProfits(); err != nil {
+		return updated, err
+	}
+	m.publish()
+	slog.Info("✅ 纸面盈亏已回填", "records", updated)
+	return updated, nil
+}
@@ -0,0 +1,67 @@
+package engine
+
+import (
+	"benz-sniper/config"
+	"benz-sniper/database"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestVirtualProfitBackfillOnUpgrade(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "legacy.db")
+
+	// 升级前的表结构：strategy_history 没有 virtual_profit 列
+	legacy, err := gorm.Open(sqlite.Open(path), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range []string{
+		`CREATE TABLE strategy_history (id integer PRIMARY KEY AUTOINCREMENT, round_id varchar(50), strategy varchar(50),
+			status integer, predictions text, winners text, special_reward varchar(50), result varchar(10),
+			bet_amount real, profit real, total_profit real, session_id integer DEFAULT 0, created_at datetime)`,
+		`INSERT INTO strategy_history (round_id, strategy, status, predictions, winners, result, bet_amount, profit, total_profit, created_at) VALUES
+			('1000', '热门3码', 0, '["红宝马","绿奔驰","黄大众"]', '["红宝马"]', '赢', 30, 0, 0, '2026-01-01 12:00:00'),
+			('1001', '热门3码', 0, '["红宝马","绿奔驰","黄大众"]', '["红奥迪"]', '输', 30, 0, 0, '2026-01-01 12:00:34')`,
+	} {
+		if err := legacy.Exec(stmt).Error; err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+	if db, err := legacy.DB(); err == nil {
+		db.Close()
+	}
+
+	store, err := database.Open(&config.Config{DBDriver: database.DriverSQLite, SQLitePath: path})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer store.Close()
+	if !store.VirtualProfitBackfillPending() {
+		t.Fatal("新增 virtual_profit 列后 VirtualProfitBackfillPending() = false")
+	}
+
+	// 创建策略管理器时自动回填：命中 (22-1)×10 - 2×10 = 190，未命中 -30
+	m := NewStrategyManagerWithClock(store, NewManualClock(time.Date(2026, 1, 2, 0, 0, 0, 0, time.Local)))
+	history := m.GetHistory(HistoryQueryParams{Page: 1, PageSize: 10, Asc: true})
+	if len(history.Records) != 2 || history.Records[0].VirtualProfit != 190 || history.Records[1].VirtualProfit != -30 {
+		t.Fatalf("回填后的纸面盈亏 = %+v", history.Records)
+	}
+	if got := m.GetState().TotalVirtualProfit; got != 160 {
+		t.Errorf("TotalVirtualProfit = %.2f, want 160", got)
+	}
+
+	// 再次打开时列已存在，不再回填
+	reopened, err := database.Open(&config.Config{DBDriver: database.DriverSQLite, SQLitePath: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	if reopened.VirtualProfitBackfillPending() {
+		t.Error("列已存在时 VirtualProfitBackfillPending() = true")
+	}
+}
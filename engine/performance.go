@@ -0,0 +1,313 @@
+package engine
+
+import (
+	"benz-sniper/database"
+	"benz-sniper/models"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// 绩效报表模式
+const (
+	PerformanceModeReal    = "real"    // 实盘记录的实际盈亏
+	PerformanceModeVirtual = "virtual" // 虚盘记录的纸面盈亏（按下注计算）
+)
+
+// 收益曲线点数
+const (
+	DefaultEquityPoints = 200
+	MaxEquityPoints     = 2000
+)
+
+// PerformanceStats 绩效统计（单个策略按注单计算；全部策略合计时同一期的注单合并为一期）
+type PerformanceStats struct {
+	Rounds            int64         `json:"rounds"`              // 期数
+	Bets              int64         `json:"bets"`                // 下单次数
+	Wins              int64         `json:"wins"`                // 命中次数
+	WinRate           float64       `json:"win_rate"`            // 命中率（%）
+	TotalProfit       float64       `json:"total_profit"`        // 总盈亏
+	Turnover          float64       `json:"turnover"`            // 下注流水
+	ROI               float64       `json:"roi"`                 // 流水回报率（%）
+	AvgProfit         float64       `json:"avg_profit"`          // 平均每注盈亏
+	ProfitFactor      *float64      `json:"profit_factor"`       // 盈利因子（盈利合计/亏损合计，没有亏损时为 null）
+	Sharpe            float64       `json:"sharpe"`              // 每期收益率（盈亏/下注）的均值/标准差，不年化
+	MaxDrawdown       float64       `json:"max_drawdown"`        // 最大回撤（从累计盈亏峰值回落的金额）
+	MaxDrawdownRounds int64         `json:"max_drawdown_rounds"` // 最大回撤持续期数（从峰值到恢复，未恢复时到区间末尾）
+	DrawdownStart     *time.Time    `json:"drawdown_start"`      // 最大回撤开始时间
+	DrawdownEnd       *time.Time    `json:"drawdown_end"`        // 最大回撤恢复时间（未恢复为 null）
+	LongestWinStreak  int64         `json:"longest_win_streak"`  // 最长连赢期数
+	LongestLossStreak int64         `json:"longest_loss_streak"` // 最长连输期数
+	Equity            []EquityPoint `json:"equity"`              // 收益曲线（按期抽样，包含最后一期）
+}
+
+// EquityPoint 收益曲线上的一点
+type EquityPoint struct {
+	RoundID  string    `json:"round_id"`
+	Time     time.Time `json:"time"`
+	Equity   float64   `json:"equity"`   // 累计盈亏
+	Drawdown float64   `json:"drawdown"` // 距峰值的回撤
+}
+
+// StrategyPerformance 单个策略的绩效
+type StrategyPerformance struct {
+	Name string `json:"name"`
+	PerformanceStats
+}
+
+// PerformanceReport 绩效报表
+type PerformanceReport struct {
+	Mode       string                `json:"mode"`
+	Overall    PerformanceStats      `json:"overall"`
+	Strategies []StrategyPerformance `json:"strategies"`
+}
+
+// GetPerformanceReport 按筛选条件统计绩效（mode 决定统计实盘还是虚盘记录，points 为收益曲线最多点数）
+// 按时间升序流式读取明细，内存占用与记录数无关
+func (m *StrategyManager) GetPerformanceReport(filter database.HistoryFilter, mode string, points int) (PerformanceReport, error) {
+	report := PerformanceReport{Mode: mode, Strategies: []StrategyPerformance{}}
+	switch mode {
+	case PerformanceModeReal:
+		filter.Status = database.StatusFilter(StatusReal)
+	case PerformanceModeVirtual:
+		filter.Status = database.StatusFilter(StatusVirtual)
+	default:
+		return report, fmt.Errorf("mode 只能是 %s 或 %s: %s", PerformanceModeReal, PerformanceModeVirtual, mode)
+	}
+	if points <= 0 {
+		points = DefaultEquityPoints
+	}
+	points = min(points, MaxEquityPoints)
+
+	overall := newPerformanceTracker(points)
+	strategies := make(map[string]*performanceTracker)
+	var round roundResult
+	flush := func() {
+		if round.bets > 0 {
+			overall.add(round)
+		}
+		round = roundResult{}
+	}
+
+	query := database.HistoryQuery{HistoryFilter: filter, Asc: true}
+	err := m.eachHistory(query, func(records []models.StrategyHistory) error {
+		for _, h := range records {
+			profit := h.Profit
+			if mode == PerformanceModeVirtual {
+				profit = h.VirtualProfit
+			}
+			at := timeOrZero(h.CreatedAt)
+			won := h.Result == "赢"
+
+			tracker, ok := strategies[h.Strategy]
+			if !ok {
+				tracker = newPerformanceTracker(points)
+				strategies[h.Strategy] = tracker
+			}
+			tracker.add(roundResult{roundID: h.RoundID, at: at, bets: 1, wins: boolCount(won), profit: profit, turnover: h.BetAmount})
+
+			// 同一期的注单连续写入，期号变化时合并为一期
+			if round.bets > 0 && round.roundID != h.RoundID {
+				flush()
+			}
+			round.roundID, round.at = h.RoundID, at
+			round.bets++
+			round.wins += boolCount(won)
+			round.profit += profit
+			round.turnover += h.BetAmount
+		}
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+	flush()
+
+	report.Overall = overall.stats()
+	for name, tracker := range strategies {
+		report.Strategies = append(report.Strategies, StrategyPerformance{Name: name, PerformanceStats: tracker.stats()})
+	}
+	sort.Slice(report.Strategies, func(i, j int) bool { return report.Strategies[i].Name < report.Strategies[j].Name })
+	return report, nil
+}
+
+// roundResult 一期的结算结果
+type roundResult struct {
+	roundID  string
+	at       time.Time
+	bets     int64
+	wins     int64
+	profit   float64
+	turnover float64
+}
+
+// performanceTracker 按期累加绩效指标
+type performanceTracker struct {
+	result PerformanceStats
+
+	grossWin, grossLoss float64
+
+	// 收益率均值和方差（Welford 算法）
+	returns     int64
+	mean, m2    float64
+	streak      int64 // 当前连赢（正数）或连输（负数）期数
+	equity      float64
+	peak        float64
+	peakRounds  int64     // 峰值时的期数
+	peakAt      time.Time // 峰值所在期的时间（初始峰值为零值）
+	episodeLow  float64   // 当前回撤的最大深度
+	episodeFrom time.Time // 当前回撤的开始时间
+
+	curve equityCurve
+}
+
+func newPerformanceTracker(points int) *performanceTracker {
+	return &performanceTracker{curve: equityCurve{max: points, stride: 1}}
+}
+
+// add 累加一期
+func (t *performanceTracker) add(r roundResult) {
+	s := &t.result
+	s.Rounds++
+	s.Bets += r.bets
+	s.Wins += r.wins
+	s.TotalProfit += r.profit
+	s.Turnover += r.turnover
+	if r.profit > 0 {
+		t.grossWin += r.profit
+	} else {
+		t.grossLoss -= r.profit
+	}
+
+	if r.turnover > 0 {
+		x := r.profit / r.turnover
+		t.returns++
+		delta := x - t.mean
+		t.mean += delta / float64(t.returns)
+		t.m2 += delta * (x - t.mean)
+	}
+
+	// 连赢连输按本期盈亏判断（打平算输，与结算规则一致）
+	if r.profit > 0 {
+		t.streak = max(t.streak, 0) + 1
+		s.LongestWinStreak = max(s.LongestWinStreak, t.streak)
+	} else {
+		t.streak = min(t.streak, 0) - 1
+		s.LongestLossStreak = max(s.LongestLossStreak, -t.streak)
+	}
+
+	// 回撤：以累计盈亏峰值为基准（初始峰值为0）
+	t.equity += r.profit
+	if t.equity >= t.peak {
+		if t.peak-t.episodeLow > 0 {
+			t.closeEpisode(s.Rounds, &r.at)
+		}
+		t.peak, t.peakRounds, t.peakAt, t.episodeLow = t.equity, s.Rounds, r.at, t.equity
+	} else {
+		if t.peak-t.episodeLow <= 0 {
+			t.episodeFrom = t.peakAt
+			if t.episodeFrom.IsZero() {
+				t.episodeFrom = r.at
+			}
+		}
+		t.episodeLow = min(t.episodeLow, t.equity)
+	}
+
+	t.curve.add(EquityPoint{RoundID: r.roundID, Time: r.at, Equity: t.equity, Drawdown: t.peak - t.equity})
+}
+
+// closeEpisode 结束一段回撤（end 为 nil 表示未恢复），深度超过记录时更新最大回撤
+func (t *performanceTracker) closeEpisode(rounds int64, end *time.Time) {
+	depth := t.peak - t.episodeLow
+	if depth <= t.result.MaxDrawdown {
+		return
+	}
+	from := t.episodeFrom
+	t.result.MaxDrawdown = depth
+	t.result.MaxDrawdownRounds = rounds - t.peakRounds
+	t.result.DrawdownStart = &from
+	t.result.DrawdownEnd = nil
+	if end != nil {
+		recovered := *end
+		t.result.DrawdownEnd = &recovered
+	}
+}
+
+// stats 汇总结果
+func (t *performanceTracker) stats() PerformanceStats {
+	s := t.result
+	if t.peak-t.episodeLow > 0 {
+		t.closeEpisode(s.Rounds, nil)
+		s = t.result
+	}
+	if s.Bets > 0 {
+		s.WinRate = float64(s.Wins) / float64(s.Bets) * 100
+		s.AvgProfit = s.TotalProfit / float64(s.Bets)
+	}
+	if s.Turnover > 0 {
+		s.ROI = s.TotalProfit / s.Turnover * 100
+	}
+	if t.grossLoss > 0 {
+		factor := t.grossWin / t.grossLoss
+		s.ProfitFactor = &factor
+	}
+	if t.returns > 1 {
+		if std := math.Sqrt(t.m2 / float64(t.returns-1)); std > 0 {
+			s.Sharpe = t.mean / std
+		}
+	}
+	s.Equity = t.curve.points()
+	return s
+}
+
+// equityCurve 流式抽样的收益曲线：点数超过上限的两倍时隔点删除并加大步长
+type equityCurve struct {
+	max    int
+	stride int64
+	count  int64
+	kept   []EquityPoint
+	last   EquityPoint
+}
+
+func (c *equityCurve) add(p EquityPoint) {
+	if c.count%c.stride == 0 {
+		c.kept = append(c.kept, p)
+		if len(c.kept) > 2*c.max {
+			for i := 0; i < len(c.kept)/2; i++ {
+				c.kept[i] = c.kept[2*i]
+			}
+			c.kept = c.kept[:len(c.kept)/2]
+			c.stride *= 2
+		}
+	}
+	c.count++
+	c.last = p
+}
+
+// points 最终曲线（不超过上限，始终包含最后一期）
+func (c *equityCurve) points() []EquityPoint {
+	points := c.kept
+	if step := (len(points) + c.max - 1) / c.max; step > 1 {
+		sampled := make([]EquityPoint, 0, c.max+1)
+		for i := 0; i < len(points); i += step {
+			sampled = append(sampled, points[i])
+		}
+		points = sampled
+	}
+	if c.count > 0 && (len(points) == 0 || points[len(points)-1] != c.last) {
+		if len(points) >= c.max {
+			points = points[:c.max-1]
+		}
+		points = append(points, c.last)
+	}
+	return append([]EquityPoint{}, points...)
+}
+
+// boolCount 命中计数
+func boolCount(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,89 @@
+package engine
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestPerformanceTracker(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.Local)
+	profits := []float64{100, -50, -100, 200, -30}
+	tracker := newPerformanceTracker(DefaultEquityPoints)
+	for i, p := range profits {
+		tracker.add(roundResult{
+			roundID:  string(rune('1' + i)),
+			at:       start.Add(time.Duration(i) * time.Minute),
+			bets:     1,
+			wins:     boolCount(p > 0),
+			profit:   p,
+			turnover: 100,
+		})
+	}
+	s := tracker.stats()
+
+	if s.TotalProfit != 120 || s.Turnover != 500 || s.ROI != 24 || s.AvgProfit != 24 {
+		t.Errorf("profit=%.2f turnover=%.2f roi=%.2f avg=%.2f, want 120/500/24/24", s.TotalProfit, s.Turnover, s.ROI, s.AvgProfit)
+	}
+	if s.LongestWinStreak != 1 || s.LongestLossStreak != 2 {
+		t.Errorf("streaks = %d/%d, want 1/2", s.LongestWinStreak, s.LongestLossStreak)
+	}
+	if s.ProfitFactor == nil || math.Abs(*s.ProfitFactor-300.0/180.0) > 1e-9 {
+		t.Errorf("ProfitFactor = %v, want %.4f", s.ProfitFactor, 300.0/180.0)
+	}
+
+	// 峰值 100（第1期）回落到 -50，第4期恢复：回撤 150，持续 3 期
+	if s.MaxDrawdown != 150 || s.MaxDrawdownRounds != 3 {
+		t.Errorf("MaxDrawdown = %.2f / %d 期, want 150 / 3", s.MaxDrawdown, s.MaxDrawdownRounds)
+	}
+	if s.DrawdownStart == nil || !s.DrawdownStart.Equal(start) || s.DrawdownEnd == nil || !s.DrawdownEnd.Equal(start.Add(3*time.Minute)) {
+		t.Errorf("回撤区间 = %v ~ %v", s.DrawdownStart, s.DrawdownEnd)
+	}
+
+	returns := []float64{1, -0.5, -1, 2, -0.3}
+	mean, ss := 0.24, 0.0
+	for _, r := range returns {
+		ss += (r - mean) * (r - mean)
+	}
+	if want := mean / math.Sqrt(ss/4); math.Abs(s.Sharpe-want) > 1e-9 {
+		t.Errorf("Sharpe = %.6f, want %.6f", s.Sharpe, want)
+	}
+
+	if len(s.Equity) != len(profits) || s.Equity[4].Equity != 120 || s.Equity[4].Drawdown != 30 {
+		t.Errorf("Equity = %+v", s.Equity)
+	}
+}
+
+func TestPerformanceTrackerOpenDrawdown(t *testing.T) {
+	tracker := newPerformanceTracker(DefaultEquityPoints)
+	at := time.Date(2026, 1, 1, 12, 0, 0, 0, time.Local)
+	for _, p := range []float64{-100, -100, 50} {
+		tracker.add(roundResult{at: at, bets: 1, profit: p, turnover: 100})
+	}
+	s := tracker.stats()
+	if s.MaxDrawdown != 200 || s.MaxDrawdownRounds != 3 || s.DrawdownEnd != nil {
+		t.Errorf("未恢复回撤 = %.2f / %d 期 / end=%v, want 200 / 3 / nil", s.MaxDrawdown, s.MaxDrawdownRounds, s.DrawdownEnd)
+	}
+	if s.ProfitFactor == nil || *s.ProfitFactor != 0.25 {
+		t.Errorf("ProfitFactor = %v, want 0.25", s.ProfitFactor)
+	}
+}
+
+func TestEquityCurveSampling(t *testing.T) {
+	curve := equityCurve{max: 100, stride: 1}
+	for i := 0; i < 10007; i++ {
+		curve.add(EquityPoint{Equity: float64(i)})
+	}
+	points := curve.points()
+	if len(points) > 100 {
+		t.Errorf("len(points) = %d, want <= 100", len(points))
+	}
+	if points[0].Equity != 0 || points[len(points)-1].Equity != 10006 {
+		t.Errorf("首尾 = %.0f / %.0f, want 0 / 10006", points[0].Equity, points[len(points)-1].Equity)
+	}
+	for i := 1; i < len(points); i++ {
+		if points[i].Equity <= points[i-1].Equity {
+			t.Fatalf("曲线未按顺序抽样: %v", points)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package engine
+
+import (
+	"benz-sniper/database"
+	"benz-sniper/models"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// PeriodReportItem 统计周期报表项（下单/命中/盈亏只统计实盘，纸面盈亏统计全部注单）
+type PeriodReportItem struct {
+	Period      string    `json:"period"`   // 周期标识（小时 2006-01-02 15:00，日 2006-01-02，周 2006-W01，月 2006-01）
+	Start       time.Time `json:"start"`    // 周期开始（含）
+	End         time.Time `json:"end"`      // 周期结束（不含）
+	TotalBets   int64     `json:"bets"`     // 下单次数
+	TotalWins   int64     `json:"wins"`     // 命中次数
+	WinRate     float64   `json:"win_rate"` // 命中率
+	TotalProfit float64   `json:"profit"`   // 盈利
+
+	VirtualProfit float64 `json:"virtual_profit"` // 纸面盈亏（周期内全部注单）
+}
+
+// PeriodReport 按小时/日/周/月分组的报表
+type PeriodReport struct {
+	Unit      string             `json:"unit"`       // 统计周期: hour / day / week / month
+	Timezone  string             `json:"timezone"`   // 营业时区
+	DayCutoff int                `json:"day_cutoff"` // 日切时间（点）
+	Summary   ReportSummary      `json:"summary"`    // 范围内合计
+	Items     []PeriodReportItem `json:"items"`      // 有记录的周期（按时间降序）
+}
+
+// GetPeriodReport 按营业日历分组统计（filter 的状态条件被忽略，实盘和纸面分别统计）
+// 日/周/月且时间范围在营业日边界上时由每日聚合汇总，否则流式读取明细在 Go 中分组
+func (m *StrategyManager) GetPeriodReport(filter database.HistoryFilter, unit string) (PeriodReport, error) {
+	cal := database.GetCalendar()
+	report := PeriodReport{Unit: unit, Timezone: cal.Location.String(), DayCutoff: cal.CutoffHour, Items: []PeriodReportItem{}}
+	if !database.ValidPeriod(unit) {
+		return report, fmt.Errorf("unit 只能是 %s / %s / %s / %s: %s",
+			database.PeriodHour, database.PeriodDay, database.PeriodWeek, database.PeriodMonth, unit)
+	}
+	items, err := m.periodItems(filter, unit)
+	if err != nil {
+		return report, err
+	}
+	report.Items = items
+	for _, item := range items {
+		report.Summary.TotalBets += item.TotalBets
+		report.Summary.TotalWins += item.TotalWins
+		report.Summary.TotalProfit += item.TotalProfit
+		report.Summary.VirtualProfit += item.VirtualProfit
+	}
+	if report.Summary.TotalBets > 0 {
+		report.Summary.WinRate = float64(report.Summary.TotalWins) / float64(report.Summary.TotalBets) * 100
+	}
+	return report, nil
+}
+
+// periodItems 按统计周期分组（按时间降序）
+func (m *StrategyManager) periodItems(filter database.HistoryFilter, unit string) ([]PeriodReportItem, error) {
+	cal := database.GetCalendar()
+	filter = paperFilter(filter)
+	buckets := make(map[int64]*PeriodReportItem)
+	add := func(at time.Time, status int, bets, wins int64, profit, virtualProfit float64) {
+		start := cal.PeriodStart(at, unit)
+		item, ok := buckets[start.Unix()]
+		if !ok {
+			item = &PeriodReportItem{
+				Period: cal.PeriodKey(start, unit),
+				Start:  start,
+				End:    cal.NextPeriod(start, unit),
+			}
+			buckets[start.Unix()] = item
+		}
+		item.VirtualProfit += virtualProfit
+		if status == StatusReal {
+			item.TotalBets += bets
+			item.TotalWins += wins
+			item.TotalProfit += profit
+		}
+	}
+
+	var rows []models.DailyStrategyStat
+	ok := false
+	if unit != database.PeriodHour {
+		var err error
+		if rows, ok, err = m.dailyStats(filter); err != nil {
+			return nil, err
+		}
+	}
+	if ok {
+		for _, row := range rows {
+			start, err := cal.DayStart(row.Day)
+			if err != nil {
+				return nil, fmt.Errorf("每日聚合日期无效 %s: %w", row.Day, err)
+			}
+			add(start, row.Status, row.Bets, row.Wins, row.Profit, row.VirtualProfit)
+		}
+	} else {
+		err := m.eachHistory(database.HistoryQuery{HistoryFilter: filter}, func(records []models.StrategyHistory) error {
+			for _, h := range records {
+				add(timeOrZero(h.CreatedAt), h.Status, 1, boolCount(h.Result == "赢"), h.Profit, h.VirtualProfit)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	items := make([]PeriodReportItem, 0, len(buckets))
+	for _, item := range buckets {
+		if item.TotalBets > 0 {
+			item.WinRate = float64(item.TotalWins) / float64(item.TotalBets) * 100
+		}
+		items = append(items, *item)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Start.After(items[j].Start) })
+	return items, nil
+}
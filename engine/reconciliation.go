@@ -0,0 +1,290 @@
+package engine
+
+import (
+	"benz-sniper/database"
+	"math"
+	"sort"
+	"time"
+)
+
+// ReconcileTolerance 金额比较容差
+const ReconcileTolerance = 0.01
+
+// reconcileLookback 向前多查的历史范围：窗口开始前结算、开始后才上传的派彩不会被误判为多余下注
+const reconcileLookback = time.Hour
+
+// 对账差异类型
+const (
+	DiscrepancyMissingBet     = "missing_bet"     // 实盘期号没有上传下注
+	DiscrepancyOverBet        = "over_bet"        // 下注金额大于策略应下注额
+	DiscrepancyUnderBet       = "under_bet"       // 下注金额小于策略应下注额
+	DiscrepancyUnexpectedBet  = "unexpected_bet"  // 策略没有实盘下注的期号出现了下注
+	DiscrepancyPayoutMismatch = "payout_mismatch" // 派彩与按赔率计算的应派彩不一致
+)
+
+// Discrepancy 对账差异
+type Discrepancy struct {
+	RoundID  string  `json:"round_id"`
+	Date     string  `json:"date"`     // 营业日（YYYY-MM-DD）
+	Account  string  `json:"account"`  // 用户账号（为空表示该期没有任何账号上传）
+	Kind     string  `json:"kind"`     // 差异类型
+	Expected float64 `json:"expected"` // 应下注/应派彩
+	Actual   float64 `json:"actual"`   // 实际下注/派彩
+	Diff     float64 `json:"diff"`     // 实际 - 应有
+}
+
+// ReconciliationSummary 对账汇总（Date 为空时表示整个时间范围）
+type ReconciliationSummary struct {
+	Date           string         `json:"date,omitempty"`
+	Rounds         int            `json:"rounds"`          // 参与对账的期数
+	MatchedRounds  int            `json:"matched_rounds"`  // 无差异的期数
+	ExpectedStake  float64        `json:"expected_stake"`  // 策略应下注合计
+	ActualStake    float64        `json:"actual_stake"`    // 实际下注合计
+	ExpectedPayout float64        `json:"expected_payout"` // 应派彩合计（按单账号计）
+	ActualPayout   float64        `json:"actual_payout"`   // 实际派彩合计
+	Discrepancies  int            `json:"discrepancies"`   // 差异条数
+	ByKind         map[string]int `json:"by_kind"`         // 按类型统计
+}
+
+// ReconciliationReport 对账结果
+type ReconciliationReport struct {
+	From          time.Time               `json:"from"`
+	To            time.Time               `json:"to"`
+	Accounts      []string                `json:"accounts"`      // 参与对账的账号
+	Summary       ReconciliationSummary   `json:"summary"`       // 总计
+	Daily         []ReconciliationSummary `json:"daily"`         // 每日汇总（按日期降序）
+	Discrepancies []Discrepancy           `json:"discrepancies"` // 差异明细（按期号降序）
+}
+
+// roundExpectation 一期策略的应下注额和应派彩（来自实盘历史记录）
+type roundExpectation struct {
+	at     time.Time // 结算时间
+	stake  float64   // 应下注额
+	payout float64   // 应派彩 = 下注额 + calculateProfit 盈亏
+}
+
+// accountBet 单个账号在一期的下注和派彩（同一期多次上传时累加）
+type accountBet struct {
+	stake  float64
+	payout float64
+	at     time.Time
+}
+
+// Reconcile 对账：逐期比较策略的实盘下注（即 /api/next-prediction 给出的注额）和各账号上传的下注、派彩
+//
+// 应派彩按 calculateProfit 计算（下注额 + 盈亏）；账号下注额与应下注额不同时按比例折算。
+// 账号只从第一次上传的期号开始检查漏单。时间范围按结算时间计，没有实盘记录的期号按上传时间计。
+func (m *StrategyManager) Reconcile(from, to time.Time) (*ReconciliationReport, error) {
+	historyFrom := from
+	if !from.IsZero() {
+		historyFrom = from.Add(-reconcileLookback)
+	}
+	records, _, err := m.store.ListHistory(database.HistoryQuery{
+		HistoryFilter: database.HistoryFilter{Status: database.StatusFilter(StatusReal), From: historyFrom, To: to},
+		Limit:         -1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	expected := make(map[string]*roundExpectation)
+	for _, h := range records {
+		e, ok := expected[h.RoundID]
+		if !ok {
+			e = &roundExpectation{at: timeOrZero(h.CreatedAt)}
+			expected[h.RoundID] = e
+		}
+		e.stake += h.BetAmount
+		e.payout += h.BetAmount + h.Profit
+	}
+
+	uploaded, err := m.store.ListUserBets(database.UserBetQuery{From: from, To: to})
+	if err != nil {
+		return nil, err
+	}
+	roundIDs := make([]string, 0, len(expected))
+	seen := make(map[string]bool)
+	for id := range expected {
+		roundIDs = append(roundIDs, id)
+		seen[id] = true
+	}
+	for _, b := range uploaded {
+		if !seen[b.RoundID] {
+			roundIDs = append(roundIDs, b.RoundID)
+			seen[b.RoundID] = true
+		}
+	}
+	bets, err := m.store.UserBetsByRounds(roundIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// 按期号、账号汇总实际下注
+	actual := make(map[string]map[string]*accountBet)
+	for _, b := range bets {
+		if actual[b.RoundID] == nil {
+			actual[b.RoundID] = make(map[string]*accountBet)
+		}
+		a, ok := actual[b.RoundID][b.UserAccount]
+		if !ok {
+			a = &accountBet{at: timeOrZero(b.CreatedAt)}
+			actual[b.RoundID][b.UserAccount] = a
+		}
+		a.stake += b.BetAmount
+		a.payout += b.PayoutAmount
+	}
+
+	// 期号时间：有实盘记录按结算时间，否则按最早的上传时间；窗口外结算的期号不参与
+	roundAt := make(map[string]time.Time)
+	for _, id := range roundIDs {
+		if e, ok := expected[id]; ok {
+			roundAt[id] = e.at
+			continue
+		}
+		var at time.Time
+		for _, a := range actual[id] {
+			if at.IsZero() || a.at.Before(at) {
+				at = a.at
+			}
+		}
+		roundAt[id] = at
+	}
+	inWindow := func(id string) bool {
+		at := roundAt[id]
+		return (from.IsZero() || !at.Before(from)) && (to.IsZero() || at.Before(to))
+	}
+
+	// 每个账号从第一次上传的期号开始检查漏单
+	firstSeen := make(map[string]time.Time)
+	for id, byAccount := range actual {
+		for account := range byAccount {
+			if at, ok := firstSeen[account]; !ok || roundAt[id].Before(at) {
+				firstSeen[account] = roundAt[id]
+			}
+		}
+	}
+	accounts := make([]string, 0, len(firstSeen))
+	for account := range firstSeen {
+		accounts = append(accounts, account)
+	}
+	sort.Strings(accounts)
+
+	report := &ReconciliationReport{
+		From:          from,
+		To:            to,
+		Accounts:      accounts,
+		Summary:       ReconciliationSummary{ByKind: make(map[string]int)},
+		Daily:         make([]ReconciliationSummary, 0),
+		Discrepancies: make([]Discrepancy, 0),
+	}
+	daily := make(map[string]*ReconciliationSummary)
+	for _, id := range roundIDs {
+		if !inWindow(id) {
+			continue
+		}
+		date := database.GetCalendar().Day(roundAt[id])
+		found := reconcileRound(id, date, expected[id], actual[id], accounts, firstSeen, roundAt[id])
+
+		day, ok := daily[date]
+		if !ok {
+			day = &ReconciliationSummary{Date: date, ByKind: make(map[string]int)}
+			daily[date] = day
+		}
+		for _, s := range []*ReconciliationSummary{day, &report.Summary} {
+			s.Rounds++
+			if len(found) == 0 {
+				s.MatchedRounds++
+			}
+			if e := expected[id]; e != nil {
+				s.ExpectedStake += e.stake
+				s.ExpectedPayout += e.payout
+			}
+			for _, a := range actual[id] {
+				s.ActualStake += a.stake
+				s.ActualPayout += a.payout
+			}
+			s.Discrepancies += len(found)
+			for _, d := range found {
+				s.ByKind[d.Kind]++
+			}
+		}
+		report.Discrepancies = append(report.Discrepancies, found...)
+	}
+
+	for _, day := range daily {
+		report.Daily = append(report.Daily, *day)
+	}
+	sort.Slice(report.Daily, func(i, j int) bool { return report.Daily[i].Date > report.Daily[j].Date })
+	sort.SliceStable(report.Discrepancies, func(i, j int) bool {
+		a, b := report.Discrepancies[i], report.Discrepancies[j]
+		if a.RoundID != b.RoundID {
+			return a.RoundID > b.RoundID
+		}
+		return a.Account < b.Account
+	})
+	return report, nil
+}
+
+// reconcileRound 对比一期的应下注和各账号实际下注
+func reconcileRound(roundID, date string, e *roundExpectation, bets map[string]*accountBet,
+	accounts []string, firstSeen map[string]time.Time, at time.Time) []Discrepancy {
+	found := make([]Discrepancy, 0)
+	add := func(account, kind string, expected, actual float64) {
+		found = append(found, Discrepancy{
+			RoundID:  roundID,
+			Date:     date,
+			Account:  account,
+			Kind:     kind,
+			Expected: roundMoney(expected),
+			Actual:   roundMoney(actual),
+			Diff:     roundMoney(actual - expected),
+		})
+	}
+
+	if e == nil || e.stake == 0 {
+		for _, account := range accounts {
+			if a, ok := bets[account]; ok && a.stake > ReconcileTolerance {
+				add(account, DiscrepancyUnexpectedBet, 0, a.stake)
+			}
+		}
+		return found
+	}
+
+	if len(accounts) == 0 {
+		add("", DiscrepancyMissingBet, e.stake, 0)
+		return found
+	}
+	for _, account := range accounts {
+		a, ok := bets[account]
+		if !ok {
+			if !at.Before(firstSeen[account]) {
+				add(account, DiscrepancyMissingBet, e.stake, 0)
+			}
+			continue
+		}
+		switch {
+		case a.stake > e.stake+ReconcileTolerance:
+			add(account, DiscrepancyOverBet, e.stake, a.stake)
+		case a.stake < e.stake-ReconcileTolerance:
+			add(account, DiscrepancyUnderBet, e.stake, a.stake)
+		}
+		// 应派彩按实际下注额与应下注额的比例折算
+		wantPayout := e.payout * a.stake / e.stake
+		if math.Abs(a.payout-wantPayout) > ReconcileTolerance {
+			add(account, DiscrepancyPayoutMismatch, wantPayout, a.payout)
+		}
+	}
+	return found
+}
+
+// roundMoney 金额保留两位小数
+func roundMoney(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// timeOrZero 解引用时间指针（nil 返回零值）
+func timeOrZero(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
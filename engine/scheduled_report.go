@@ -0,0 +1,162 @@
+package engine
+
+import (
+	"benz-sniper/database"
+	"benz-sniper/models"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// 定时报表类型
+const (
+	ReportKindDaily  = "eod" // 日报（营业日）
+	ReportKindWeekly = "eow" // 周报（周一开始）
+)
+
+// reportMaxRoundGap 估算实盘时长时单期最多计入的间隔（更长的间隔视为停机）
+const reportMaxRoundGap = 5 * time.Minute
+
+// ReportPeriodUnit 定时报表类型对应的统计周期
+func ReportPeriodUnit(kind string) (string, bool) {
+	switch kind {
+	case ReportKindDaily:
+		return database.PeriodDay, true
+	case ReportKindWeekly:
+		return database.PeriodWeek, true
+	}
+	return "", false
+}
+
+// ScheduledReport 日报/周报内容
+type ScheduledReport struct {
+	Kind           string                  `json:"kind"`
+	Period         string                  `json:"period"`   // 周期标识（营业日 2006-01-02 / 周 2006-W01）
+	Start          time.Time               `json:"start"`    // 周期开始（含）
+	End            time.Time               `json:"end"`      // 周期结束（不含）
+	Timezone       string                  `json:"timezone"` // 营业时区
+	GeneratedAt    time.Time               `json:"generated_at"`
+	Summary        ReportSummary           `json:"summary"`         // 实盘合计和纸面盈亏
+	Strategies     []ScheduledStrategyItem `json:"strategies"`      // 按策略统计（按名称排序）
+	SpecialRewards []SpecialRewardItem     `json:"special_rewards"` // 特殊奖项（按期数降序）
+	Reconciliation ReconciliationSummary   `json:"reconciliation"`  // 下注对账汇总
+	Discrepancies  []Discrepancy           `json:"discrepancies"`   // 对账差异明细
+}
+
+// ScheduledStrategyItem 报表中单个策略的统计（下单/命中/盈亏只统计实盘）
+type ScheduledStrategyItem struct {
+	Name          string  `json:"name"`
+	Bets          int64   `json:"bets"`
+	Wins          int64   `json:"wins"`
+	WinRate       float64 `json:"win_rate"`
+	Profit        float64 `json:"profit"`
+	VirtualProfit float64 `json:"virtual_profit"` // 纸面盈亏（全部注单）
+	Rounds        int64   `json:"rounds"`         // 参与期数（含虚盘）
+	RealSeconds   int64   `json:"real_seconds"`   // 实盘时长（按实盘注单与上一期的间隔估算）
+}
+
+// SpecialRewardItem 周期内开出的特殊奖项
+type SpecialRewardItem struct {
+	Reward string  `json:"reward"`
+	Rounds int     `json:"rounds"` // 期数
+	Profit float64 `json:"profit"` // 这些期的实盘盈亏
+}
+
+// BuildScheduledReport 统计 at 所在周期的日报/周报（周期按营业日历划分）
+func (m *StrategyManager) BuildScheduledReport(kind string, at time.Time) (*ScheduledReport, error) {
+	unit, ok := ReportPeriodUnit(kind)
+	if !ok {
+		return nil, fmt.Errorf("报表类型只能是 %s 或 %s: %s", ReportKindDaily, ReportKindWeekly, kind)
+	}
+	cal := database.GetCalendar()
+	start := cal.PeriodStart(at, unit)
+	report := &ScheduledReport{
+		Kind:           kind,
+		Period:         cal.PeriodKey(start, unit),
+		Start:          start,
+		End:            cal.NextPeriod(start, unit),
+		Timezone:       cal.Location.String(),
+		GeneratedAt:    m.clock.Now(),
+		Strategies:     []ScheduledStrategyItem{},
+		SpecialRewards: []SpecialRewardItem{},
+	}
+
+	strategies := make(map[string]*ScheduledStrategyItem)
+	lastAt := make(map[string]time.Time)
+	specials := make(map[string]*SpecialRewardItem)
+	specialRounds := make(map[string]bool)
+	query := database.HistoryQuery{HistoryFilter: database.HistoryFilter{From: report.Start.Local(), To: report.End.Local()}, Asc: true}
+	err := m.eachHistory(query, func(records []models.StrategyHistory) error {
+		for _, h := range records {
+			at := timeOrZero(h.CreatedAt)
+			item, ok := strategies[h.Strategy]
+			if !ok {
+				item = &ScheduledStrategyItem{Name: h.Strategy}
+				strategies[h.Strategy] = item
+				lastAt[h.Strategy] = report.Start
+			}
+			item.Rounds++
+			item.VirtualProfit += h.VirtualProfit
+			if h.Status == StatusReal {
+				item.Bets++
+				item.Wins += boolCount(h.Result == "赢")
+				item.Profit += h.Profit
+				item.RealSeconds += int64(min(at.Sub(lastAt[h.Strategy]), reportMaxRoundGap).Seconds())
+			}
+			lastAt[h.Strategy] = at
+
+			if h.SpecialReward == "" {
+				continue
+			}
+			special, ok := specials[h.SpecialReward]
+			if !ok {
+				special = &SpecialRewardItem{Reward: h.SpecialReward}
+				specials[h.SpecialReward] = special
+			}
+			if !specialRounds[h.RoundID] {
+				specialRounds[h.RoundID] = true
+				special.Rounds++
+			}
+			if h.Status == StatusReal {
+				special.Profit += h.Profit
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range strategies {
+		if item.Bets > 0 {
+			item.WinRate = float64(item.Wins) / float64(item.Bets) * 100
+		}
+		report.Summary.TotalBets += item.Bets
+		report.Summary.TotalWins += item.Wins
+		report.Summary.TotalProfit += item.Profit
+		report.Summary.VirtualProfit += item.VirtualProfit
+		report.Strategies = append(report.Strategies, *item)
+	}
+	if report.Summary.TotalBets > 0 {
+		report.Summary.WinRate = float64(report.Summary.TotalWins) / float64(report.Summary.TotalBets) * 100
+	}
+	sort.Slice(report.Strategies, func(i, j int) bool { return report.Strategies[i].Name < report.Strategies[j].Name })
+	for _, special := range specials {
+		report.SpecialRewards = append(report.SpecialRewards, *special)
+	}
+	sort.Slice(report.SpecialRewards, func(i, j int) bool {
+		a, b := report.SpecialRewards[i], report.SpecialRewards[j]
+		if a.Rounds != b.Rounds {
+			return a.Rounds > b.Rounds
+		}
+		return a.Reward < b.Reward
+	})
+
+	reconciliation, err := m.Reconcile(report.Start.Local(), report.End.Local())
+	if err != nil {
+		return nil, fmt.Errorf("对账失败: %w", err)
+	}
+	report.Reconciliation = reconciliation.Summary
+	report.Discrepancies = reconciliation.Discrepancies
+	return report, nil
+}
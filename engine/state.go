@@ -7,20 +7,25 @@ import (
 
 // State 状态快照（不可变）
 type State struct {
-	RoundID       string           `json:"round_id"`
-	UpdatedAt     time.Time        `json:"updated_at"`
-	SystemUptime  int              `json:"system_uptime"` // 系统运行时长（秒）
-	Strategies    []StrategyResult `json:"strategies"`
+	RoundID            string           `json:"round_id"`
+	RoundTime          time.Time        `json:"round_time"` // 开奖时间（来自 game_rounds.timestamp，未知时为零值）
+	Timing             RoundTiming      `json:"timing"`     // 开奖时间预测
+	UpdatedAt          time.Time        `json:"updated_at"`
+	SystemUptime       int              `json:"system_uptime"` // 系统运行时长（秒）
+	Strategies         []StrategyResult `json:"strategies"`
+	TotalRealProfit    float64          `json:"total_real_profit"`    // 所有实盘注单总盈利（结算时累加）
+	TotalVirtualProfit float64          `json:"total_virtual_profit"` // 所有注单纸面总盈亏（结算时累加）
 }
 
 // StrategyResult 策略结果
 type StrategyResult struct {
 	Name          string   `json:"name"`
 	Predictions   []string `json:"predictions"`
-	Status        int      `json:"status"`          // 0=虚盘, 1=实盘
-	StatusText    string   `json:"status_text"`     // 状态文字
-	VirtualStreak int      `json:"virtual_streak"`  // 虚盘连赢次数
-	RealProfit    float64  `json:"real_profit"`     // 实盘累计盈利
+	Status        int      `json:"status"`         // 0=虚盘, 1=实盘
+	StatusText    string   `json:"status_text"`    // 状态文字
+	VirtualStreak int      `json:"virtual_streak"` // 虚盘连赢次数
+	RealProfit    float64  `json:"real_profit"`    // 实盘累计盈利
+	VirtualProfit float64  `json:"virtual_profit"` // 纸面累计盈亏（每期都按下注计算）
 }
 
 // AtomicState 原子状态容器
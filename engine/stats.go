@@ -0,0 +1,80 @@
+package engine
+
+import (
+	"benz-sniper/database"
+	"benz-sniper/models"
+	"sort"
+	"time"
+)
+
+// dailyStatFilter 把报表筛选条件转换为每日聚合筛选条件
+// 只有策略/状态/会话和整天时间范围能由聚合表表达，其他条件返回 false（回退到明细统计）
+func dailyStatFilter(filter database.HistoryFilter) (database.DailyStatFilter, bool) {
+	if filter.Result != "" || filter.RoundFrom != "" || filter.RoundTo != "" ||
+		filter.SpecialReward != "" || filter.MinProfit != nil {
+		return database.DailyStatFilter{}, false
+	}
+	fromDay, okFrom := statDayBound(filter.From)
+	toDay, okTo := statDayBound(filter.To)
+	if !okFrom || !okTo {
+		return database.DailyStatFilter{}, false
+	}
+	return database.DailyStatFilter{
+		Strategy:  filter.Strategy,
+		Status:    filter.Status,
+		SessionID: filter.SessionID,
+		FromDay:   fromDay,
+		ToDay:     toDay,
+	}, true
+}
+
+// statDayBound 时间范围边界对应的统计日期（零值不限制，不在营业日开始时刻时返回 false）
+func statDayBound(t time.Time) (string, bool) {
+	if t.IsZero() {
+		return "", true
+	}
+	cal := database.GetCalendar()
+	if !cal.IsDayBoundary(t) {
+		return "", false
+	}
+	return cal.Day(t), true
+}
+
+// dailyStats 读取每日聚合（筛选条件不能由聚合表表达时 ok=false）
+func (m *StrategyManager) dailyStats(filter database.HistoryFilter) (rows []models.DailyStrategyStat, ok bool, err error) {
+	statFilter, ok := dailyStatFilter(filter)
+	if !ok {
+		return nil, false, nil
+	}
+	rows, err = m.store.DailyStats(statFilter)
+	return rows, true, err
+}
+
+// historyStats 总体统计（优先读取每日聚合）
+func (m *StrategyManager) historyStats(filter database.HistoryFilter) (database.HistoryStat, error) {
+	rows, ok, err := m.dailyStats(filter)
+	if !ok {
+		return m.store.HistoryStats(filter)
+	}
+	var total database.HistoryStat
+	for _, stat := range database.SumDailyStats(rows, func(models.DailyStrategyStat) string { return "" }) {
+		total = stat
+	}
+	return total, err
+}
+
+// historyStatsByStrategy 按策略分组统计（优先读取每日聚合，按策略名排序）
+func (m *StrategyManager) historyStatsByStrategy(filter database.HistoryFilter) ([]database.HistoryStat, error) {
+	rows, ok, err := m.dailyStats(filter)
+	if !ok {
+		return m.store.HistoryStatsByStrategy(filter)
+	}
+	stats := database.SumDailyStats(rows, func(row models.DailyStrategyStat) string { return row.Strategy })
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Key < stats[j].Key })
+	return stats, err
+}
+
+// RebuildDailyStats 由全部历史记录重建每日策略聚合（回填或修复使用），返回聚合行数
+func (m *StrategyManager) RebuildDailyStats() (int, error) {
+	return m.store.RebuildDailyStats()
+}
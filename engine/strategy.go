@@ -57,9 +57,12 @@ func topN(scores map[string]float64, n int) []string {
 		sorted = append(sorted, kv{k, v})
 	}
 
-	// 按分数降序排序
+	// 按分数降序排序（同分按 BET_LABELS 顺序，保证结果确定）
 	sort.Slice(sorted, func(i, j int) bool {
-		return sorted[i].Value > sorted[j].Value
+		if sorted[i].Value != sorted[j].Value {
+			return sorted[i].Value > sorted[j].Value
+		}
+		return labelOrder(sorted[i].Key) < labelOrder(sorted[j].Key)
 	})
 
 	// 取前N个
@@ -85,9 +88,12 @@ func topNFromList(scores map[string]float64, list []string, n int) []string {
 		}
 	}
 
-	// 按分数降序排序
+	// 按分数降序排序（同分按 BET_LABELS 顺序，保证结果确定）
 	sort.Slice(sorted, func(i, j int) bool {
-		return sorted[i].Value > sorted[j].Value
+		if sorted[i].Value != sorted[j].Value {
+			return sorted[i].Value > sorted[j].Value
+		}
+		return labelOrder(sorted[i].Key) < labelOrder(sorted[j].Key)
 	})
 
 	// 取前N个
@@ -98,3 +104,13 @@ func topNFromList(scores map[string]float64, list []string, n int) []string {
 
 	return result
 }
+
+// labelOrder 车型在 BET_LABELS 中的位置（未知车型排在最后）
+func labelOrder(label string) int {
+	for i, l := range BET_LABELS {
+		if l == label {
+			return i
+		}
+	}
+	return len(BET_LABELS)
+}
@@ -1,14 +1,15 @@
 package engine
 
 import (
+	"benz-sniper/database"
+	"benz-sniper/metrics"
 	"benz-sniper/models"
 	"encoding/json"
-	"log"
+	"log/slog"
+	"sort"
 	"strconv"
 	"sync"
 	"time"
-
-	"gorm.io/gorm"
 )
 
 // 状态常量
@@ -41,21 +42,24 @@ func DefaultStrategyConfig() StrategyConfig {
 
 // StrategyState 策略状态
 type StrategyState struct {
-	Name              string              // 策略名称
-	Status            int                 // 0=虚盘, 1=实盘
-	Predictions       []string            // 当前预测
-	VirtualStreak     int                 // 虚盘连赢次数
-	RealProfit        float64             // 实盘累计盈利
-	RoundPredictions  map[string][]string // 每期的预测（期号 -> 预测列表）
+	Name             string              // 策略名称
+	Status           int                 // 0=虚盘, 1=实盘
+	Predictions      []string            // 当前预测
+	VirtualStreak    int                 // 虚盘连赢次数
+	RealProfit       float64             // 实盘累计盈利
+	VirtualProfit    float64             // 纸面累计盈亏（每期都按下注计算，不受虚实切换影响）
+	RoundPredictions map[string][]string // 每期的预测（期号 -> 预测列表）
 }
 
 // UserBetRecord 用户派彩记录（API 使用）
 type UserBetRecord struct {
-	RoundID      string  `json:"round_id"`      // 期号
-	UserAccount  string  `json:"user_account"`  // 用户账号
-	BetAmount    float64 `json:"bet_amount"`    // 下注金额
-	PayoutAmount float64 `json:"payout_amount"` // 派彩金额
-	Balance      float64 `json:"balance"`       // 剩余余额
+	RoundID        string        `json:"round_id"`                  // 期号
+	UserAccount    string        `json:"user_account"`              // 用户账号
+	BetAmount      float64       `json:"bet_amount"`                // 下注金额
+	PayoutAmount   float64       `json:"payout_amount"`             // 派彩金额
+	Balance        float64       `json:"balance"`                   // 剩余余额
+	Items          []UserBetItem `json:"items,omitempty"`           // 按车型的下注明细
+	IdempotencyKey string        `json:"idempotency_key,omitempty"` // 幂等键
 }
 
 // HistoryRecord 历史记录
@@ -70,6 +74,7 @@ type HistoryRecord struct {
 	Result        string          `json:"result"`         // 结果：赢/输
 	BetAmount     float64         `json:"bet_amount"`     // 下注金额
 	Profit        float64         `json:"profit"`         // 本期盈亏
+	VirtualProfit float64         `json:"virtual_profit"` // 本期纸面盈亏（无论虚实都按下注计算）
 	TotalProfit   float64         `json:"total_profit"`   // 累计盈利
 	Timestamp     time.Time       `json:"timestamp"`      // 时间戳
 	UserBets      []UserBetRecord `json:"user_bets"`      // 用户派彩记录
@@ -78,40 +83,99 @@ type HistoryRecord struct {
 // StrategyManager 策略管理器（带读写锁）
 type StrategyManager struct {
 	mu         sync.RWMutex
-	db         *gorm.DB // 数据库连接
+	store      database.Store // 存储
 	strategies map[string]*StrategyState
 	roundID    string
+	timing     RoundTiming // 开奖时间预测（来自 game_rounds.timestamp）
 	updatedAt  time.Time
 	startTime  time.Time      // 系统启动时间
 	config     StrategyConfig // 策略配置
+	limits     ConfigLimits   // 配置取值范围
+	clock      Clock          // 时钟（测试时可注入手动时钟）
+
+	configLoaded bool // 配置是否已从数据库加载
+
+	profiles         []models.ConfigProfile // 配置方案缓存
+	activeProfile    string                 // 当前生效的方案
+	scheduledProfile string                 // 最近一次按时段进入的方案（避免重复切换）
+
+	seedProfits map[string]profitTotals // 数据库中各策略的累计盈亏（启动或恢复归档时加载，用于尚未创建的策略）
+	snapshot    AtomicState             // 最新状态快照（每次写操作后发布，读取不加锁、不查数据库）
 }
 
 // NewStrategyManager 创建策略管理器实例
-func NewStrategyManager(db *gorm.DB) *StrategyManager {
-	now := time.Now()
+func NewStrategyManager(store database.Store) *StrategyManager {
+	return NewStrategyManagerWithClock(store, SystemClock{})
+}
+
+// NewStrategyManagerWithClock 使用指定时钟创建策略管理器实例
+func NewStrategyManagerWithClock(store database.Store, clock Clock) *StrategyManager {
+	now := clock.Now()
 	m := &StrategyManager{
-		db:         db,
+		store:      store,
+		clock:      clock,
 		strategies: make(map[string]*StrategyState),
 		updatedAt:  now,
 		startTime:  now,                    // 记录启动时间
 		config:     DefaultStrategyConfig(), // 使用默认配置
+		limits:     DefaultConfigLimits(),
 	}
 	
 	// 从数据库加载配置
 	m.loadConfigFromDB()
+	m.ensureInitialVersion()
+	if err := m.loadProfiles(); err != nil {
+		slog.Error("❌ 加载配置方案失败", "error", err)
+	}
+	if err := m.loadProfits(); err != nil {
+		slog.Error("❌ 加载累计盈亏失败", "error", err)
+	}
+	m.publish()
+
+	// 升级后首次启动：回填已有记录的纸面盈亏（失败时可用 rebuild-stats -virtual-profit 重试）
+	if store.VirtualProfitBackfillPending() {
+		if _, err := m.BackfillVirtualProfit(); err != nil {
+			slog.Error("❌ 回填纸面盈亏失败", "error", err)
+		}
+	}
 	
 	return m
 }
 
-// loadConfigFromDB 从数据库加载配置
-func (m *StrategyManager) loadConfigFromDB() {
-	var dbConfig models.SystemConfig
-	result := m.db.First(&dbConfig)
+// Clock 策略管理器使用的时钟
+func (m *StrategyManager) Clock() Clock {
+	return m.clock
+}
+
+// UpdateRoundTiming 更新开奖时间预测（写锁）
+func (m *StrategyManager) UpdateRoundTiming(timing RoundTiming) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.timing = timing
+	m.publish()
+}
+
+// currentTiming 当前开奖时间预测（调用前需持有锁）
+// 没有时间戳时以发现新期号的时间为准，使用默认间隔
+func (m *StrategyManager) currentTiming() RoundTiming {
+	if !m.timing.LastDrawAt.IsZero() || m.roundID == "" {
+		return m.timing
+	}
+	return RoundTiming{
+		LastDrawAt: m.updatedAt,
+		NextDrawAt: m.updatedAt.Add(DefaultRoundInterval),
+		Interval:   DefaultRoundInterval,
+	}
+}
+
+// loadConfigFromDB 从数据库加载配置（成功后 configLoaded 为 true）
+func (m *StrategyManager) loadConfigFromDB() error {
+	dbConfig, err := m.store.LoadConfig()
 	
-	if result.Error != nil {
-		if result.Error == gorm.ErrRecordNotFound {
+	if err != nil {
+		if err == database.ErrNotFound {
 			// 数据库中没有配置记录，创建默认配置
-			dbConfig = models.SystemConfig{
+			dbConfig = &models.SystemConfig{
 				EntryCondition:     m.config.EntryCondition,
 				ExitCondition:      m.config.ExitCondition,
 				Hot3BetAmount:      m.config.Hot3BetAmount,
@@ -119,15 +183,16 @@ func (m *StrategyManager) loadConfigFromDB() {
 				Hot3Enabled:        m.config.Hot3Enabled,
 				Balanced4Enabled:   m.config.Balanced4Enabled,
 			}
-			if err := m.db.Create(&dbConfig).Error; err != nil {
-				log.Printf("❌ 创建默认配置失败: %v", err)
-			} else {
-				log.Println("✅ 已创建并加载默认配置")
+			if err := m.store.SaveConfig(dbConfig); err != nil {
+				slog.Error("❌ 创建默认配置失败", "error", err)
+				return err
 			}
-		} else {
-			log.Printf("❌ 加载配置失败: %v", result.Error)
+			slog.Info("✅ 已创建并加载默认配置")
+			m.configLoaded = true
+			return nil
 		}
-		return
+		slog.Error("❌ 加载配置失败", "error", err)
+		return err
 	}
 	
 	// 从数据库加载配置
@@ -139,8 +204,20 @@ func (m *StrategyManager) loadConfigFromDB() {
 		Hot3Enabled:        dbConfig.Hot3Enabled,
 		Balanced4Enabled:   dbConfig.Balanced4Enabled,
 	}
+	m.configLoaded = true
 	
-	log.Println("✅ 已从数据库加载配置")
+	slog.Info("✅ 已从数据库加载配置")
+	return nil
+}
+
+// EnsureConfigLoaded 确认配置已从数据库加载（启动时加载失败的，在这里重试）
+func (m *StrategyManager) EnsureConfigLoaded() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.configLoaded {
+		return nil
+	}
+	return m.loadConfigFromDB()
 }
 
 // getStrategyBetAmount 根据策略名称获取下注金额
@@ -158,38 +235,97 @@ func (m *StrategyManager) GetConfig() StrategyConfig {
 	return m.config
 }
 
-// UpdateConfig 更新配置（写锁，支持部分更新）
-func (m *StrategyManager) UpdateConfig(newConfig StrategyConfig) StrategyConfig {
+// ConfigUpdate 配置更新结果（DryRun 时只计算差异，不保存）
+type ConfigUpdate struct {
+	Before  StrategyConfig        `json:"before"`            // 更新前
+	After   StrategyConfig        `json:"after"`             // 更新后（生效配置）
+	Changes []FieldChange         `json:"changes"`           // 字段变化
+	Version *models.ConfigVersion `json:"version,omitempty"` // 新版本（无变化或预览时为空）
+	DryRun  bool                  `json:"dry_run"`           // 是否为预览
+}
+
+// ConfigLimits 当前配置取值范围
+func (m *StrategyManager) ConfigLimits() ConfigLimits {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.limits
+}
+
+// SetConfigLimits 设置配置取值范围（启动时调用）
+func (m *StrategyManager) SetConfigLimits(limits ConfigLimits) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	m.limits = limits
+}
 
-	// 只更新非零值字段（支持部分更新）
+// UpdateConfig 更新配置（兼容旧调用：数值字段只更新正数，布尔值总是更新）
+// 需要部分更新布尔值或获取校验错误时使用 PatchConfig
+func (m *StrategyManager) UpdateConfig(newConfig StrategyConfig) StrategyConfig {
+	patch := ConfigPatch{
+		Hot3Enabled:      &newConfig.Hot3Enabled,
+		Balanced4Enabled: &newConfig.Balanced4Enabled,
+	}
 	if newConfig.EntryCondition > 0 {
-		m.config.EntryCondition = newConfig.EntryCondition
+		patch.EntryCondition = &newConfig.EntryCondition
 	}
 	if newConfig.ExitCondition > 0 {
-		m.config.ExitCondition = newConfig.ExitCondition
+		patch.ExitCondition = &newConfig.ExitCondition
 	}
 	if newConfig.Hot3BetAmount > 0 {
-		m.config.Hot3BetAmount = newConfig.Hot3BetAmount
+		patch.Hot3BetAmount = &newConfig.Hot3BetAmount
 	}
 	if newConfig.Balanced4BetAmount > 0 {
-		m.config.Balanced4BetAmount = newConfig.Balanced4BetAmount
+		patch.Balanced4BetAmount = &newConfig.Balanced4BetAmount
 	}
 
-	// 布尔值需要特殊处理（总是更新）
-	m.config.Hot3Enabled = newConfig.Hot3Enabled
-	m.config.Balanced4Enabled = newConfig.Balanced4Enabled
+	result, err := m.PatchConfig(patch, ConfigChange{}, false)
+	if err != nil {
+		slog.Error("❌ 更新配置失败", "error", err)
+		return m.GetConfig()
+	}
+	return result.After
+}
+
+// PatchConfig 校验并部分更新配置（写锁）
+// 只有提供的字段会被校验和修改；校验失败返回 *ValidationError，配置保持不变
+func (m *StrategyManager) PatchConfig(patch ConfigPatch, change ConfigChange, dryRun bool) (ConfigUpdate, error) {
+	if change.Source == "" {
+		change.Source = ConfigSourceAPI
+	}
 
-	log.Printf("📝 配置已更新: 进场条件=%d, 离场条件=%d, 热门3码金额=%.2f, 均衡4码金额=%.2f, 热门3码启用=%v, 均衡4码启用=%v",
-		m.config.EntryCondition, m.config.ExitCondition,
-		m.config.Hot3BetAmount, m.config.Balanced4BetAmount,
-		m.config.Hot3Enabled, m.config.Balanced4Enabled)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := ConfigUpdate{Before: m.config, After: patch.Apply(m.config), DryRun: dryRun}
+	if err := m.limits.ValidatePatch(patch); err != nil {
+		return result, err
+	}
+	result.Changes = DiffConfig(result.Before, result.After)
+	if dryRun || len(result.Changes) == 0 {
+		return result, nil
+	}
+
+	m.config = result.After
+	slog.Info("📝 配置已更新", configAttrs(m.config)...)
 
 	// 保存配置到数据库
 	m.saveConfigToDB()
+	m.activeProfile = ""
+	result.Version = m.recordVersion(change)
 
-	return m.config
+	return result, nil
+}
+
+// configAttrs 配置的日志字段
+func configAttrs(c StrategyConfig) []any {
+	return []any{
+		"entry_condition", c.EntryCondition,
+		"exit_condition", c.ExitCondition,
+		"hot3_bet_amount", c.Hot3BetAmount,
+		"balanced4_bet_amount", c.Balanced4BetAmount,
+		"hot3_enabled", c.Hot3Enabled,
+		"balanced4_enabled", c.Balanced4Enabled,
+	}
 }
 
 // saveConfigToDB 保存配置到数据库（调用前需要持有锁）
@@ -206,10 +342,10 @@ func (m *StrategyManager) saveConfigToDB() {
 	}
 	
 	// 使用 Save 方法（存在则更新，不存在则创建）
-	if err := m.db.Save(&dbConfig).Error; err != nil {
-		log.Printf("❌ 保存配置到数据库失败: %v", err)
+	if err := m.store.SaveConfig(&dbConfig); err != nil {
+		slog.Error("❌ 保存配置到数据库失败", "error", err)
 	} else {
-		log.Println("✅ 配置已保存到数据库")
+		slog.Debug("✅ 配置已保存到数据库")
 	}
 }
 
@@ -228,11 +364,12 @@ func (m *StrategyManager) UpdatePredictions(currentRoundID string, targetRoundID
 			Name:             name,
 			Status:           StatusVirtual, // 初始为虚盘
 			VirtualStreak:    0,
-			RealProfit:       0.0,
+			RealProfit:       m.seedProfits[name].Real, // 延续数据库中的累计盈亏
+			VirtualProfit:    m.seedProfits[name].Virtual,
 			RoundPredictions: make(map[string][]string),
 		}
 		m.strategies[name] = state
-		log.Printf("🎯 初始化策略: %s (虚盘模式)", name)
+		slog.Info("🎯 初始化策略（虚盘模式）", "strategy", name)
 	}
 
 	// 将预测保存到【目标期号】的 key 中
@@ -243,7 +380,8 @@ func (m *StrategyManager) UpdatePredictions(currentRoundID string, targetRoundID
 
 	// 更新全局期号（显示的是当前已开奖的期号）
 	m.roundID = currentRoundID
-	m.updatedAt = time.Now()
+	m.updatedAt = m.clock.Now()
+	m.publish()
 }
 
 // SettleRound 结算上一期盈亏（写锁）
@@ -253,9 +391,12 @@ func (m *StrategyManager) SettleRound(roundID string, winners []string, specialR
 	defer m.mu.Unlock()
 
 	settled := false
+	roundLog := slog.With("round_id", roundID)
 
 	// 遍历所有策略进行结算
 	for _, state := range m.strategies {
+		lg := roundLog.With("strategy", state.Name)
+
 		// 从 map 中获取该期号的预测
 		predictions, exists := state.RoundPredictions[roundID]
 		if !exists || len(predictions) == 0 {
@@ -289,14 +430,21 @@ func (m *StrategyManager) SettleRound(roundID string, winners []string, specialR
 			profit = -betAmount
 		}
 
+		// 纸面盈亏：无论虚实都按下注计算，用于评估进出场规则
+		virtualProfit := profit
+		state.VirtualProfit += virtualProfit
+
 		// 实盘状态需要记录实际盈亏
 		if state.Status == StatusVirtual {
 			// 虚盘不记录盈亏，但需要判定胜负
 			profit = 0.0
 		}
 
+		lg.Debug("💵 盈利计算", "predictions", predictions, "winners", winners, "bet_amount", betAmount, "profit", profit)
+
 		// 根据当前状态执行流转逻辑
-		m.updateStatus(state, won, profit)
+		m.updateStatus(lg, state, won, profit)
+		metrics.Settlements.WithLabelValues(state.Name, statusLabel(statusBeforeUpdate), resultLabel(won)).Inc()
 
 		// 保存历史记录到数据库
 		result := "输"
@@ -308,6 +456,7 @@ func (m *StrategyManager) SettleRound(roundID string, winners []string, specialR
 		predictionsJSON, _ := json.Marshal(predictions)
 		winnersJSON, _ := json.Marshal(winners)
 
+		settledAt := m.clock.Now()
 		history := models.StrategyHistory{
 			RoundID:       roundID,
 			Strategy:      state.Name,
@@ -318,18 +467,23 @@ func (m *StrategyManager) SettleRound(roundID string, winners []string, specialR
 			Result:        result,
 			BetAmount:     betAmount,
 			Profit:        profit,
+			VirtualProfit: virtualProfit,
 			TotalProfit:   state.RealProfit,
+			CreatedAt:     &settledAt,
 		}
 
 		// 写入数据库
-		if err := m.db.Create(&history).Error; err != nil {
-			log.Printf("❌ 保存历史记录失败: %v", err)
+		if err := m.store.CreateHistory(&history); err != nil {
+			lg.Error("❌ 保存历史记录失败", "error", err)
 		}
 
 		// 从 map 中删除已结算的期号预测
 		delete(state.RoundPredictions, roundID)
 	}
 
+	if settled {
+		m.publish()
+	}
 	return settled
 }
 
@@ -365,7 +519,7 @@ func (m *StrategyManager) calculateProfit(predictions []string, winners []string
 		// 获取赔率
 		odds, exists := REAL_ODDS[hitCar]
 		if !exists {
-			log.Printf("⚠️ 未找到车型 %s 的赔率，使用默认赔率10", hitCar)
+			slog.Warn("⚠️ 未找到车型赔率，使用默认赔率10", "car", hitCar)
 			odds = 10
 		}
 		// 每个命中车型的盈利 = (赔率 - 1) * 单注金额
@@ -378,9 +532,6 @@ func (m *StrategyManager) calculateProfit(predictions []string, winners []string
 	// 总盈利 = 所有命中车型的盈利之和 - 未命中车型的损失
 	profit := totalWinAmount - loseAmount
 
-	log.Printf("💵 盈利计算: 命中 %d 个 %v, 未命中 %d 个, 盈利=%.2f-%.2f=%.2f", 
-		len(hitCars), hitCars, missCount, totalWinAmount, loseAmount, profit)
-
 	return profit
 }
 
@@ -401,23 +552,23 @@ func (m *StrategyManager) checkWin(predictions []string, winners []string) bool
 }
 
 // updateStatus 状态流转核心逻辑（内部方法，调用者需持有锁）
-func (m *StrategyManager) updateStatus(state *StrategyState, won bool, profit float64) {
+func (m *StrategyManager) updateStatus(lg *slog.Logger, state *StrategyState, won bool, profit float64) {
 	if state.Status == StatusVirtual {
 		// 场景 A：虚盘状态
 		if won {
 			// 赢了：连赢次数加1
 			state.VirtualStreak++
-			log.Printf("🎉 [%s] 虚盘赢 | 连赢: %d/%d", state.Name, state.VirtualStreak, m.config.EntryCondition)
+			lg.Info("🎉 虚盘赢", "virtual_streak", state.VirtualStreak, "entry_condition", m.config.EntryCondition)
 
 			// 判断进场：达到进场条件
 			if state.VirtualStreak >= m.config.EntryCondition {
 				state.Status = StatusReal
-				log.Printf("🚀 [%s] 表现优异，切换至实盘模式！", state.Name)
+				lg.Info("🚀 表现优异，切换至实盘模式")
 			}
 		} else {
 			// 输了：连赢次数归零
 			if state.VirtualStreak > 0 {
-				log.Printf("😔 [%s] 虚盘输 | 连赢归零: %d -> 0", state.Name, state.VirtualStreak)
+				lg.Info("😔 虚盘输，连赢归零", "virtual_streak", state.VirtualStreak)
 			}
 			state.VirtualStreak = 0
 		}
@@ -425,81 +576,112 @@ func (m *StrategyManager) updateStatus(state *StrategyState, won bool, profit fl
 		// 场景 B：实盘状态
 		if won {
 			state.RealProfit += profit
-			log.Printf("💰 [%s] 实盘赢 +%.2f | 累计盈利: %.2f", state.Name, profit, state.RealProfit)
+			lg.Info("💰 实盘赢", "profit", profit, "real_profit", state.RealProfit)
 		} else {
 			state.RealProfit += profit
-			log.Printf("⚠️ [%s] 实盘输 %.2f | 累计盈利: %.2f", state.Name, profit, state.RealProfit)
+			lg.Info("⚠️ 实盘输", "profit", profit, "real_profit", state.RealProfit)
 
 			// 触发止损：切换回虚盘（连输达到离场条件）
 			// 注：当前实现是连输1把即离场，可根据 ExitCondition 配置扩展
 			state.Status = StatusVirtual
 			state.VirtualStreak = 0
-			log.Printf("🛑 [%s] 实盘止损，退回观望模式", state.Name)
+			lg.Info("🛑 实盘止损，退回观望模式")
 		}
 	}
 }
 
-// GetState 获取状态快照（读锁）
+// GetState 获取状态快照（无锁，不查数据库）
+// 返回最近一次发布的快照副本，只重新计算系统运行时长
 func (m *StrategyManager) GetState() *State {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+	state := *m.snapshot.Get()
+	state.SystemUptime = int(m.clock.Now().Sub(m.startTime).Seconds())
+	return &state
+}
+
+// GetRealPredictions 只返回实盘策略（无锁，不查数据库）
+func (m *StrategyManager) GetRealPredictions() []StrategyResult {
+	results := make([]StrategyResult, 0)
+	for _, strategy := range m.snapshot.Get().Strategies {
+		// 只返回实盘状态的策略
+		if strategy.Status == StatusReal {
+			results = append(results, strategy)
+		}
+	}
+	return results
+}
 
+// publish 发布新的状态快照（调用者需持有写锁）
+// 策略按名称排序；实盘累计盈利取内存中随结算累加的值，总盈利还包括数据库中尚未创建的策略
+func (m *StrategyManager) publish() {
 	results := make([]StrategyResult, 0, len(m.strategies))
+	var total profitTotals
 	for _, state := range m.strategies {
 		statusText := "虚盘观望"
 		if state.Status == StatusReal {
 			statusText = "实盘下注"
 		}
-
-		// 从数据库计算该策略的实盘总盈利
-		realProfit := m.GetStrategyRealProfit(state.Name)
-
 		results = append(results, StrategyResult{
 			Name:          state.Name,
-			Predictions:   state.Predictions,
+			Predictions:   append([]string(nil), state.Predictions...),
 			Status:        state.Status,
 			StatusText:    statusText,
 			VirtualStreak: state.VirtualStreak,
-			RealProfit:    realProfit, // 使用从数据库计算的值
+			RealProfit:    state.RealProfit,
+			VirtualProfit: state.VirtualProfit,
 		})
+		total.Real += state.RealProfit
+		total.Virtual += state.VirtualProfit
 	}
-
-	// 计算系统运行时长（从启动到现在）
-	systemUptime := int(time.Since(m.startTime).Seconds())
-
-	return &State{
-		RoundID:      m.roundID,
-		UpdatedAt:    m.updatedAt,
-		SystemUptime: systemUptime,
-		Strategies:   results,
+	for name, seed := range m.seedProfits {
+		if _, exists := m.strategies[name]; !exists {
+			total.Real += seed.Real
+			total.Virtual += seed.Virtual
+		}
 	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+
+	m.snapshot.Set(&State{
+		RoundID:            m.roundID,
+		RoundTime:          m.timing.LastDrawAt,
+		Timing:             m.currentTiming(),
+		UpdatedAt:          m.updatedAt,
+		Strategies:         results,
+		TotalRealProfit:    total.Real,
+		TotalVirtualProfit: total.Virtual,
+	})
 }
 
-// GetRealPredictions 只返回实盘策略（读锁）
-func (m *StrategyManager) GetRealPredictions() []StrategyResult {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+// profitTotals 累计盈亏
+type profitTotals struct {
+	Real    float64 // 实盘累计盈亏
+	Virtual float64 // 纸面累计盈亏
+}
 
-	results := make([]StrategyResult, 0)
+// loadProfits 从数据库加载各策略当前记录的实盘和纸面累计盈亏（调用者需持有写锁或处于构造阶段）
+func (m *StrategyManager) loadProfits() error {
+	realStats, err := m.historyStatsByStrategy(realFilter(0))
+	if err != nil {
+		return err
+	}
+	all, err := m.historyStatsByStrategy(database.HistoryFilter{})
+	if err != nil {
+		return err
+	}
+	profits := make(map[string]profitTotals, len(all))
+	for _, stat := range all {
+		profits[stat.Key] = profitTotals{Virtual: stat.VirtualProfit}
+	}
+	for _, stat := range realStats {
+		seed := profits[stat.Key]
+		seed.Real = stat.Profit
+		profits[stat.Key] = seed
+	}
+	m.seedProfits = profits
 	for _, state := range m.strategies {
-		// 只返回实盘状态的策略
-		if state.Status == StatusReal {
-			statusText := "实盘下注"
-			// 从数据库计算该策略的实盘总盈利
-			realProfit := m.GetStrategyRealProfit(state.Name)
-			
-			results = append(results, StrategyResult{
-				Name:          state.Name,
-				Predictions:   state.Predictions,
-				Status:        state.Status,
-				StatusText:    statusText,
-				VirtualStreak: state.VirtualStreak,
-				RealProfit:    realProfit, // 使用从数据库计算的值
-			})
-		}
+		state.RealProfit = profits[state.Name].Real
+		state.VirtualProfit = profits[state.Name].Virtual
 	}
-
-	return results
+	return nil
 }
 
 // NextPredictionItem 下一期预测项
@@ -511,8 +693,12 @@ type NextPredictionItem struct {
 
 // NextPredictionResult 下一期预测结果
 type NextPredictionResult struct {
-	Round      string               `json:"round"`      // 下一期期号
-	Strategies []NextPredictionItem `json:"strategies"` // 启用的策略列表
+	Round           string               `json:"round"`             // 下一期期号
+	Strategies      []NextPredictionItem `json:"strategies"`        // 启用的策略列表
+	NextDrawAt      string               `json:"next_draw_at"`      // 预测开奖时间（RFC3339）
+	BettingClosesAt string               `json:"betting_closes_at"` // 预测停止下注时间（RFC3339）
+	Countdown       int                  `json:"countdown"`         // 距停止下注秒数
+	Closing         bool                 `json:"closing"`           // 下注窗口即将关闭
 }
 
 // GetNextPrediction 获取下一期预测（只返回启用的策略）
@@ -554,26 +740,51 @@ func (m *StrategyManager) GetNextPrediction() NextPredictionResult {
 		}
 	}
 
-	return NextPredictionResult{
+	result := NextPredictionResult{
 		Round:      nextRound,
 		Strategies: strategies,
 	}
+
+	// 下注时间窗口
+	timing := m.currentTiming()
+	if !timing.NextDrawAt.IsZero() {
+		now := m.clock.Now()
+		result.NextDrawAt = timing.NextDrawAt.Format(time.RFC3339)
+		result.BettingClosesAt = timing.BettingClosesAt().Format(time.RFC3339)
+		result.Countdown = timing.Countdown(now)
+		result.Closing = timing.Closing(now)
+	}
+
+	return result
 }
 
 // HistoryQueryParams 历史记录查询参数
 type HistoryQueryParams struct {
-	Page     int  // 页码（从1开始）
-	PageSize int  // 每页大小
-	RealOnly bool // 是否只查询实盘记录
+	Page      int  // 页码（从1开始）
+	PageSize  int  // 每页大小
+	RealOnly  bool // 是否只查询实盘记录
+	SessionID uint // 归档会话ID（0=当前记录）
+
+	OmitUserBets bool // 不查询用户派彩记录（UserBets 为 nil）
+
+	Filter database.HistoryFilter // 其他筛选条件（Status / SessionID 以 RealOnly / SessionID 为准）
+	Sort   string                 // 排序字段（database.HistorySort*，默认 created_at）
+	Asc    bool                   // 升序（默认降序）
+
+	// 游标分页：Cursor 为 true 时忽略 Page，从 After 之后开始（After 为 nil 表示第一页），不统计总数
+	Cursor bool
+	After  *database.HistoryCursor
 }
 
 // HistoryResult 历史记录查询结果
 type HistoryResult struct {
 	Records    []HistoryRecord // 记录列表
-	Total      int64           // 总记录数
-	TotalPages int             // 总页数
+	Total      int64           // 总记录数（游标分页时为 -1）
+	TotalPages int             // 总页数（游标分页时为 0）
 	Page       int             // 当前页码
 	PageSize   int             // 每页大小
+	NextCursor string          // 下一页游标（按 created_at 排序且还有更多记录时）
+	HasMore    bool            // 是否还有更多记录
 }
 
 // GetHistory 获取历史记录（从数据库，支持分页和筛选）
@@ -589,17 +800,30 @@ func (m *StrategyManager) GetHistory(params HistoryQueryParams) HistoryResult {
 		params.PageSize = 100
 	}
 
-	// 获取新的数据库会话（避免任何缓存）
-	db := m.db.Session(&gorm.Session{NewDB: true})
-
-	// 查询总数
-	var total int64
-	countQuery := db.Table("strategy_history")
+	// 查询条件
+	filter := params.Filter
+	filter.SessionID = params.SessionID
 	if params.RealOnly {
-		countQuery = countQuery.Where("status = ?", StatusReal)
+		filter.Status = database.StatusFilter(StatusReal)
 	}
-	if err := countQuery.Count(&total).Error; err != nil {
-		log.Printf("❌ 查询历史记录总数失败: %v", err)
+
+	// 分页查询（偏移分页同时返回总数；游标分页多取一条判断是否还有下一页）
+	query := database.HistoryQuery{
+		HistoryFilter: filter,
+		Sort:          params.Sort,
+		Asc:           params.Asc,
+		Offset:        (params.Page - 1) * params.PageSize,
+		Limit:         params.PageSize,
+	}
+	if params.Cursor {
+		query.After = params.After
+		query.SkipCount = true
+		query.Offset = 0
+		query.Limit = params.PageSize + 1
+	}
+	dbRecords, total, err := m.store.ListHistory(query)
+	if err != nil {
+		slog.Error("❌ 查询历史记录失败", "error", err)
 		return HistoryResult{
 			Records:    []HistoryRecord{},
 			Total:      0,
@@ -609,35 +833,20 @@ func (m *StrategyManager) GetHistory(params HistoryQueryParams) HistoryResult {
 		}
 	}
 
-	// 计算总页数
-	totalPages := int((total + int64(params.PageSize) - 1) / int64(params.PageSize))
-
-	// 分页查询（使用新的会话）
-	offset := (params.Page - 1) * params.PageSize
-	var dbRecords []models.StrategyHistory
-	
-	dataQuery := db.Table("strategy_history")
-	if params.RealOnly {
-		dataQuery = dataQuery.Where("status = ?", StatusReal)
-	}
-	
-	if err := dataQuery.Order("created_at DESC, id DESC").
-		Limit(params.PageSize).
-		Offset(offset).
-		Find(&dbRecords).Error; err != nil {
-		log.Printf("❌ 查询历史记录失败: %v", err)
-		return HistoryResult{
-			Records:    []HistoryRecord{},
-			Total:      total,
-			TotalPages: totalPages,
-			Page:       params.Page,
-			PageSize:   params.PageSize,
+	// 计算总页数和下一页游标
+	totalPages := 0
+	hasMore := false
+	if params.Cursor {
+		if hasMore = len(dbRecords) > params.PageSize; hasMore {
+			dbRecords = dbRecords[:params.PageSize]
 		}
+	} else {
+		totalPages = int((total + int64(params.PageSize) - 1) / int64(params.PageSize))
+		hasMore = params.Page < totalPages
 	}
-	
-	log.Printf("📋 历史记录查询: 总数=%d, 本页=%d条, 页码=%d", total, len(dbRecords), params.Page)
-	if len(dbRecords) > 0 {
-		log.Printf("📋 最新记录ID=%d, 期号=%s", dbRecords[0].ID, dbRecords[0].RoundID)
+	nextCursor := ""
+	if hasMore && len(dbRecords) > 0 && (params.Sort == "" || params.Sort == database.HistorySortTime) {
+		nextCursor = database.CursorOf(dbRecords[len(dbRecords)-1]).Encode()
 	}
 
 	// 收集所有期号，用于查询用户派彩记录（去重）
@@ -650,23 +859,15 @@ func (m *StrategyManager) GetHistory(params HistoryQueryParams) HistoryResult {
 		roundIDs = append(roundIDs, rid)
 	}
 
-	// 查询用户派彩记录（使用新的会话）
+	// 查询用户派彩记录
 	userBetsMap := make(map[string][]UserBetRecord)
-	if len(roundIDs) > 0 {
-		var userBets []models.UserBet
-		if err := db.Table("user_bets").Where("round_id IN ?", roundIDs).Find(&userBets).Error; err != nil {
-			log.Printf("⚠️ 查询用户派彩记录失败: %v", err)
+	if len(roundIDs) > 0 && !params.OmitUserBets {
+		if userBets, err := m.store.UserBetsByRounds(roundIDs); err != nil {
+			slog.Warn("⚠️ 查询用户派彩记录失败", "error", err)
 		} else {
-			log.Printf("📊 查询到 %d 条用户派彩记录，期号: %v", len(userBets), roundIDs)
 			// 按期号分组
 			for _, ub := range userBets {
-				userBetsMap[ub.RoundID] = append(userBetsMap[ub.RoundID], UserBetRecord{
-					RoundID:      ub.RoundID,
-					UserAccount:  ub.UserAccount,
-					BetAmount:    ub.BetAmount,
-					PayoutAmount: ub.PayoutAmount,
-					Balance:      ub.Balance,
-				})
+				userBetsMap[ub.RoundID] = append(userBetsMap[ub.RoundID], userBetRecordFrom(ub))
 			}
 		}
 	}
@@ -684,14 +885,14 @@ func (m *StrategyManager) GetHistory(params HistoryQueryParams) HistoryResult {
 			statusText = "实盘下注"
 		}
 
-		timestamp := time.Now()
+		timestamp := m.clock.Now()
 		if dbRecord.CreatedAt != nil {
 			timestamp = *dbRecord.CreatedAt
 		}
 
 		// 获取该期号的用户派彩记录
 		userBets := userBetsMap[dbRecord.RoundID]
-		if userBets == nil {
+		if userBets == nil && !params.OmitUserBets {
 			userBets = []UserBetRecord{}
 		}
 
@@ -706,6 +907,7 @@ func (m *StrategyManager) GetHistory(params HistoryQueryParams) HistoryResult {
 			Result:        dbRecord.Result,
 			BetAmount:     dbRecord.BetAmount,
 			Profit:        dbRecord.Profit,
+			VirtualProfit: dbRecord.VirtualProfit,
 			TotalProfit:   dbRecord.TotalProfit,
 			Timestamp:     timestamp,
 			UserBets:      userBets,
@@ -718,73 +920,83 @@ func (m *StrategyManager) GetHistory(params HistoryQueryParams) HistoryResult {
 		TotalPages: totalPages,
 		Page:       params.Page,
 		PageSize:   params.PageSize,
+		NextCursor: nextCursor,
+		HasMore:    hasMore,
 	}
 }
 
-// ClearHistory 清空历史记录（从数据库）
-func (m *StrategyManager) ClearHistory() {
-	err := m.db.Where("1 = 1").Delete(&models.StrategyHistory{}).Error
-	if err != nil {
-		log.Printf("❌ 清空历史记录失败: %v", err)
-	} else {
-		log.Println("📝 历史记录已清空")
-	}
-}
+// ArchiveHistory 清空当前历史：把记录归档到新会话（name 为空时按归档时间命名），实盘累计盈利重新开始
+func (m *StrategyManager) ArchiveHistory(name, createdBy string) (*models.HistorySession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
-// SaveUserBet 保存用户派彩记录
-func (m *StrategyManager) SaveUserBet(record UserBetRecord) error {
-	userBet := models.UserBet{
-		RoundID:      record.RoundID,
-		UserAccount:  record.UserAccount,
-		BetAmount:    record.BetAmount,
-		PayoutAmount: record.PayoutAmount,
-		Balance:      record.Balance,
+	archivedAt := m.clock.Now()
+	if name == "" {
+		name = "归档 " + archivedAt.Format("2006-01-02 15:04")
+	}
+	session := &models.HistorySession{
+		Name:       name,
+		ArchivedAt: &archivedAt,
+		CreatedBy:  createdBy,
+	}
+	if err := m.store.ArchiveHistory(session); err != nil {
+		slog.Error("❌ 归档历史记录失败", "error", err)
+		return nil, err
 	}
 
-	if err := m.db.Create(&userBet).Error; err != nil {
-		log.Printf("❌ 保存用户派彩记录失败: %v", err)
-		return err
+	// 当前记录已归档，累计盈利从零开始（与数据库中的当前记录保持一致）
+	for _, state := range m.strategies {
+		state.RealProfit = 0
+		state.VirtualProfit = 0
 	}
+	m.seedProfits = make(map[string]profitTotals)
+	m.publish()
 
-	log.Printf("✅ 用户派彩记录已保存: 期号=%s, 用户=%s, 下注=%.2f, 派彩=%.2f",
-		record.RoundID, record.UserAccount, record.BetAmount, record.PayoutAmount)
-	return nil
+	slog.Info("📦 历史记录已归档", "session_id", session.ID, "session", session.Name,
+		"bets", session.Bets, "real_profit", session.RealProfit)
+	return session, nil
 }
 
-// GetTotalRealProfit 计算所有实盘注单的总盈利（从数据库）
-func (m *StrategyManager) GetTotalRealProfit() float64 {
-	var totalProfit float64
-	
-	// 查询所有实盘状态的历史记录，累计盈利
-	err := m.db.Model(&models.StrategyHistory{}).
-		Where("status = ?", StatusReal).
-		Select("COALESCE(SUM(profit), 0)").
-		Scan(&totalProfit).Error
-	
+// ListSessions 全部归档会话
+func (m *StrategyManager) ListSessions() ([]models.HistorySession, error) {
+	return m.store.ListSessions()
+}
+
+// RestoreSession 恢复归档会话（记录并入当前历史）
+func (m *StrategyManager) RestoreSession(id uint) (*models.HistorySession, error) {
+	session, err := m.store.GetSession(id)
 	if err != nil {
-		log.Printf("❌ 计算实盘总盈利失败: %v", err)
-		return 0.0
+		return nil, err
 	}
-	
-	return totalProfit
+	if err := m.store.RestoreSession(id); err != nil {
+		slog.Error("❌ 恢复归档会话失败", "session_id", id, "error", err)
+		return nil, err
+	}
+
+	// 恢复的实盘盈亏并入累计盈利
+	m.mu.Lock()
+	if err := m.loadProfits(); err != nil {
+		slog.Error("❌ 重新加载累计盈亏失败", "session_id", id, "error", err)
+	}
+	m.publish()
+	m.mu.Unlock()
+
+	slog.Info("📦 归档会话已恢复", "session_id", session.ID, "session", session.Name, "bets", session.Bets)
+	return session, nil
 }
 
-// GetStrategyRealProfit 计算单个策略的实盘总盈利（从数据库）
-func (m *StrategyManager) GetStrategyRealProfit(strategyName string) float64 {
-	var totalProfit float64
-	
-	// 查询指定策略的所有实盘状态的历史记录，累计盈利
-	err := m.db.Model(&models.StrategyHistory{}).
-		Where("strategy = ? AND status = ?", strategyName, StatusReal).
-		Select("COALESCE(SUM(profit), 0)").
-		Scan(&totalProfit).Error
-	
+// PurgeSession 彻底删除归档会话及其记录（不可恢复）
+func (m *StrategyManager) PurgeSession(id uint) (*models.HistorySession, error) {
+	session, err := m.store.GetSession(id)
 	if err != nil {
-		log.Printf("❌ 计算策略 %s 实盘总盈利失败: %v", strategyName, err)
-		return 0.0
+		return nil, err
 	}
-	
-	return totalProfit
+	if err := m.store.PurgeSession(id); err != nil {
+		slog.Error("❌ 删除归档会话失败", "session_id", id, "error", err)
+		return nil, err
+	}
+	slog.Info("🗑️ 归档会话已彻底删除", "session_id", session.ID, "session", session.Name, "bets", session.Bets)
+	return session, nil
 }
 
 // ReportSummary 总体报表统计
@@ -793,15 +1005,18 @@ type ReportSummary struct {
 	TotalWins   int64   `json:"total_wins"`   // 总命中次数
 	WinRate     float64 `json:"win_rate"`     // 命中率
 	TotalProfit float64 `json:"total_profit"` // 总盈利
+
+	VirtualProfit float64 `json:"virtual_profit"` // 纸面总盈亏（全部注单都按下注计算，与实盘对比进出场规则的效果）
 }
 
 // DailyReportItem 每日报表统计
 type DailyReportItem struct {
-	Date        string  `json:"date"`         // 日期
-	TotalBets   int64   `json:"bets"`         // 下单次数
-	TotalWins   int64   `json:"wins"`         // 命中次数
-	WinRate     float64 `json:"win_rate"`     // 命中率
-	TotalProfit float64 `json:"profit"`       // 盈利
+	Date          string  `json:"date"`           // 日期
+	TotalBets     int64   `json:"bets"`           // 下单次数
+	TotalWins     int64   `json:"wins"`           // 命中次数
+	WinRate       float64 `json:"win_rate"`       // 命中率
+	TotalProfit   float64 `json:"profit"`         // 盈利
+	VirtualProfit float64 `json:"virtual_profit"` // 纸面盈亏（当天全部注单）
 }
 
 // StrategyReportItem 策略报表统计
@@ -811,27 +1026,70 @@ type StrategyReportItem struct {
 	TotalWins          int64    `json:"total_wins"`          // 实盘命中次数
 	WinRate            float64  `json:"win_rate"`            // 实盘命中率
 	TotalProfit        float64  `json:"total_profit"`        // 实盘总盈利
+	VirtualProfit      float64  `json:"virtual_profit"`      // 纸面总盈亏（全部注单）
 	Status             int      `json:"status"`              // 当前状态
 	StatusText         string   `json:"status_text"`         // 状态描述
 	CurrentPredictions []string `json:"current_predictions"` // 当前推荐
 }
 
+// SessionReport 单个归档会话的报表
+type SessionReport struct {
+	Session    *models.HistorySession `json:"session"` // 会话信息（当前记录为 nil）
+	Summary    ReportSummary          `json:"summary"`
+	Daily      []DailyReportItem      `json:"daily"`
+	Strategies []StrategyReportItem   `json:"strategies"`
+}
+
+// GetSessionReport 获取归档会话的报表（sessionID=0 为当前记录）
+func (m *StrategyManager) GetSessionReport(sessionID uint) (SessionReport, error) {
+	var report SessionReport
+	if sessionID != 0 {
+		session, err := m.store.GetSession(sessionID)
+		if err != nil {
+			return report, err
+		}
+		report.Session = session
+	}
+	report.Summary = m.reportSummary(realFilter(sessionID))
+	report.Daily = m.dailyReport(realFilter(sessionID))
+	report.Strategies = m.strategyReport(realFilter(sessionID))
+	return report, nil
+}
+
+// GetRangeReport 当前记录在 [from, to) 内的报表（零值表示不限制）
+func (m *StrategyManager) GetRangeReport(from, to time.Time) SessionReport {
+	filter := realFilter(0)
+	filter.From, filter.To = from, to
+	return SessionReport{
+		Summary:    m.reportSummary(filter),
+		Daily:      m.dailyReport(filter),
+		Strategies: m.strategyReport(filter),
+	}
+}
+
+// realFilter 实盘记录筛选条件
+func realFilter(sessionID uint) database.HistoryFilter {
+	return database.HistoryFilter{
+		Status:    database.StatusFilter(StatusReal),
+		SessionID: sessionID,
+	}
+}
+
 // GetReportSummary 获取总体统计报表（只统计实盘）
 func (m *StrategyManager) GetReportSummary() ReportSummary {
+	return m.reportSummary(realFilter(0))
+}
+
+// reportSummary 总体统计（指定筛选条件）
+func (m *StrategyManager) reportSummary(filter database.HistoryFilter) ReportSummary {
 	var result ReportSummary
-	type Result struct {
-		Bets   int64
-		Wins   int64
-		Profit float64
-	}
-	var dbResult Result
 
 	// 统计实盘记录
 	// 命中次数定义：result='赢'
-	m.db.Model(&models.StrategyHistory{}).
-		Where("status = ?", StatusReal).
-		Select("COUNT(*) as bets, SUM(CASE WHEN result='赢' THEN 1 ELSE 0 END) as wins, COALESCE(SUM(profit), 0) as profit").
-		Scan(&dbResult)
+	dbResult, err := m.historyStats(filter)
+	if err != nil {
+		slog.Error("❌ 查询总体报表失败", "error", err)
+	}
 
 	result.TotalBets = dbResult.Bets
 	result.TotalWins = dbResult.Wins
@@ -839,46 +1097,50 @@ func (m *StrategyManager) GetReportSummary() ReportSummary {
 	if result.TotalBets > 0 {
 		result.WinRate = float64(result.TotalWins) / float64(result.TotalBets) * 100
 	}
+
+	// 纸面盈亏统计全部注单
+	all, err := m.historyStats(paperFilter(filter))
+	if err != nil {
+		slog.Error("❌ 查询纸面盈亏失败", "error", err)
+	}
+	result.VirtualProfit = all.VirtualProfit
 	return result
 }
 
+// paperFilter 纸面盈亏的筛选条件（不限虚实状态）
+func paperFilter(filter database.HistoryFilter) database.HistoryFilter {
+	filter.Status = nil
+	return filter
+}
+
 // GetDailyReport 获取每日统计报表（只统计实盘）
 func (m *StrategyManager) GetDailyReport() []DailyReportItem {
+	return m.dailyReport(realFilter(0))
+}
+
+// dailyReport 每日统计（指定筛选条件）
+func (m *StrategyManager) dailyReport(filter database.HistoryFilter) []DailyReportItem {
 	var results []DailyReportItem
-	
-	type DailyStat struct {
-		DateStr string  `gorm:"column:date"`
-		Bets    int64   `gorm:"column:bets"`
-		Wins    int64   `gorm:"column:wins"`
-		Profit  float64 `gorm:"column:profit"`
-	}
-	var stats []DailyStat
-
-	// 按日期分组统计实盘数据
-	// 使用 DATE_FORMAT 确保日期格式一致
-	err := m.db.Model(&models.StrategyHistory{}).
-		Where("status = ?", StatusReal).
-		Select("DATE_FORMAT(created_at, '%Y-%m-%d') as date, COUNT(*) as bets, SUM(CASE WHEN result='赢' THEN 1 ELSE 0 END) as wins, COALESCE(SUM(profit), 0) as profit").
-		Group("DATE_FORMAT(created_at, '%Y-%m-%d')").
-		Order("date DESC").
-		Scan(&stats).Error
 
+	// 按营业日分组（整天范围读取每日聚合表）；纸面盈亏按全部注单统计，但只列出有实盘注单的日期
+	items, err := m.periodItems(filter, database.PeriodDay)
 	if err != nil {
-		log.Printf("❌ 查询每日报表失败: %v", err)
+		slog.Error("❌ 查询每日报表失败", "error", err)
 		return []DailyReportItem{}
 	}
 
-	for _, stat := range stats {
-		item := DailyReportItem{
-			Date:        stat.DateStr,
-			TotalBets:   stat.Bets,
-			TotalWins:   stat.Wins,
-			TotalProfit: stat.Profit,
-		}
-		if item.TotalBets > 0 {
-			item.WinRate = float64(item.TotalWins) / float64(item.TotalBets) * 100
+	for _, period := range items {
+		if period.TotalBets == 0 {
+			continue
 		}
-		results = append(results, item)
+		results = append(results, DailyReportItem{
+			Date:          period.Period,
+			TotalBets:     period.TotalBets,
+			TotalWins:     period.TotalWins,
+			WinRate:       period.WinRate,
+			TotalProfit:   period.TotalProfit,
+			VirtualProfit: period.VirtualProfit,
+		})
 	}
 
 	return results
@@ -886,24 +1148,28 @@ func (m *StrategyManager) GetDailyReport() []DailyReportItem {
 
 // GetStrategyReport 获取策略统计报表
 func (m *StrategyManager) GetStrategyReport() []StrategyReportItem {
-	// 1. 获取数据库统计数据（只统计实盘）
-	type StatResult struct {
-		Strategy string  `gorm:"column:strategy"`
-		Bets     int64   `gorm:"column:bets"`
-		Wins     int64   `gorm:"column:wins"`
-		Profit   float64 `gorm:"column:profit"`
-	}
-	var stats []StatResult
-
-	m.db.Model(&models.StrategyHistory{}).
-		Where("status = ?", StatusReal).
-		Select("strategy, COUNT(*) as bets, SUM(CASE WHEN result='赢' THEN 1 ELSE 0 END) as wins, COALESCE(SUM(profit), 0) as profit").
-		Group("strategy").
-		Scan(&stats)
-
-	statsMap := make(map[string]StatResult)
+	return m.strategyReport(realFilter(0))
+}
+
+// strategyReport 策略统计（指定筛选条件）
+func (m *StrategyManager) strategyReport(filter database.HistoryFilter) []StrategyReportItem {
+	// 1. 获取数据库统计数据（只统计实盘，整天范围读取每日聚合表）
+	stats, err := m.historyStatsByStrategy(filter)
+	if err != nil {
+		slog.Error("❌ 查询策略报表失败", "error", err)
+	}
+
+	statsMap := make(map[string]database.HistoryStat)
 	for _, s := range stats {
-		statsMap[s.Strategy] = s
+		statsMap[s.Key] = s
+	}
+	paper, err := m.historyStatsByStrategy(paperFilter(filter))
+	if err != nil {
+		slog.Error("❌ 查询策略纸面盈亏失败", "error", err)
+	}
+	virtualMap := make(map[string]float64, len(paper))
+	for _, p := range paper {
+		virtualMap[p.Key] = p.VirtualProfit
 	}
 
 	// 2. 结合内存中的当前状态
@@ -921,6 +1187,7 @@ func (m *StrategyManager) GetStrategyReport() []StrategyReportItem {
 			TotalBets:          stat.Bets,
 			TotalWins:          stat.Wins,
 			TotalProfit:        stat.Profit,
+			VirtualProfit:      virtualMap[name],
 			Status:             state.Status,
 			CurrentPredictions: state.Predictions,
 		}
@@ -0,0 +1,286 @@
+package engine
+
+import (
+	"benz-sniper/database"
+	"benz-sniper/models"
+	"flag"
+	"io"
+	"log"
+	"log/slog"
+	"math"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	// 屏蔽结算日志，-v 时保留
+	flag.Parse()
+	if !testing.Verbose() {
+		log.SetOutput(io.Discard)
+	}
+	os.Exit(m.Run())
+}
+
+func TestCalculateProfit(t *testing.T) {
+	m := &StrategyManager{}
+
+	tests := []struct {
+		name        string
+		predictions []string
+		winners     []string
+		unit        float64
+		want        float64
+	}{
+		{
+			name:        "单个命中（热门3码）",
+			predictions: []string{"红奔驰", "绿宝马", "黄奥迪"},
+			winners:     []string{"红奔驰"},
+			unit:        100,
+			want:        (45-1)*100 - 2*100,
+		},
+		{
+			name:        "单个命中低赔率（均衡4码打平）",
+			predictions: []string{"红宝马", "红大众", "绿大众", "黄大众"},
+			winners:     []string{"黄大众"},
+			unit:        100,
+			want:        (4-1)*100 - 3*100,
+		},
+		{
+			name:        "特殊奖项多个命中（大三元）",
+			predictions: []string{"红奔驰", "绿奔驰", "黄奔驰"},
+			winners:     []string{"红奔驰", "绿奔驰", "黄奔驰"},
+			unit:        100,
+			want:        (44 + 37 + 26) * 100,
+		},
+		{
+			name:        "特殊奖项部分命中（大四喜）",
+			predictions: []string{"红奔驰", "红大众", "绿大众", "黄大众"},
+			winners:     []string{"红奔驰", "红宝马", "红奥迪", "红大众"},
+			unit:        50,
+			want:        (44+6)*50 - 2*50,
+		},
+		{
+			name:        "未命中",
+			predictions: []string{"红奔驰", "绿宝马", "黄奥迪"},
+			winners:     []string{"黄大众"},
+			unit:        100,
+			want:        -300,
+		},
+		{
+			name:        "未知车型使用默认赔率10",
+			predictions: []string{"紫奔驰", "红大众"},
+			winners:     []string{"紫奔驰"},
+			unit:        10,
+			want:        (10-1)*10 - 10,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := m.calculateProfit(tt.predictions, tt.winners, tt.unit)
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("calculateProfit() = %.2f, want %.2f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		start      StrategyState
+		won        bool
+		profit     float64
+		wantStatus int
+		wantStreak int
+		wantProfit float64
+	}{
+		{
+			name:       "虚盘赢累计连赢",
+			start:      StrategyState{Status: StatusVirtual, VirtualStreak: 0},
+			won:        true,
+			wantStatus: StatusVirtual,
+			wantStreak: 1,
+		},
+		{
+			name:       "虚盘连赢达标进场",
+			start:      StrategyState{Status: StatusVirtual, VirtualStreak: 1},
+			won:        true,
+			wantStatus: StatusReal,
+			wantStreak: 2,
+		},
+		{
+			name:       "虚盘输连赢归零",
+			start:      StrategyState{Status: StatusVirtual, VirtualStreak: 1},
+			won:        false,
+			wantStatus: StatusVirtual,
+			wantStreak: 0,
+		},
+		{
+			name:       "实盘赢累计盈利",
+			start:      StrategyState{Status: StatusReal, VirtualStreak: 2, RealProfit: 100},
+			won:        true,
+			profit:     4200,
+			wantStatus: StatusReal,
+			wantStreak: 2,
+			wantProfit: 4300,
+		},
+		{
+			name:       "实盘输止损离场",
+			start:      StrategyState{Status: StatusReal, VirtualStreak: 2, RealProfit: 100},
+			won:        false,
+			profit:     -300,
+			wantStatus: StatusVirtual,
+			wantStreak: 0,
+			wantProfit: -200,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &StrategyManager{config: DefaultStrategyConfig()}
+			state := tt.start
+			m.updateStatus(slog.Default(), &state, tt.won, tt.profit)
+			if state.Status != tt.wantStatus {
+				t.Errorf("Status = %d, want %d", state.Status, tt.wantStatus)
+			}
+			if state.VirtualStreak != tt.wantStreak {
+				t.Errorf("VirtualStreak = %d, want %d", state.VirtualStreak, tt.wantStreak)
+			}
+			if state.RealProfit != tt.wantProfit {
+				t.Errorf("RealProfit = %.2f, want %.2f", state.RealProfit, tt.wantProfit)
+			}
+		})
+	}
+}
+
+func TestSettleRoundProfitAndResult(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		predictions []string
+		winners     []string
+		wantResult  string
+		wantProfit  float64
+	}{
+		{"实盘命中盈利", StatusReal, []string{"红奔驰", "绿宝马", "黄奥迪"}, []string{"绿宝马"}, "赢", 1300},
+		{"实盘打平算输", StatusReal, []string{"红宝马", "红大众", "绿大众", "黄大众"}, []string{"黄大众"}, "输", 0},
+		{"实盘多个命中", StatusReal, []string{"红奔驰", "绿奔驰", "黄奔驰"}, []string{"红奔驰", "绿奔驰", "黄奔驰"}, "赢", 10700},
+		{"实盘未命中", StatusReal, []string{"红奔驰", "绿宝马", "黄奥迪"}, []string{"黄大众"}, "输", -300},
+		{"虚盘不记盈亏", StatusVirtual, []string{"红奔驰", "绿宝马", "黄奥迪"}, []string{"红奔驰"}, "赢", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := database.NewMemoryStore()
+			m := NewStrategyManagerWithClock(store, NewManualClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.Local)))
+			m.UpdatePredictions("1", "2", "热门3码", tt.predictions)
+			m.strategies["热门3码"].Status = tt.status
+
+			if !m.SettleRound("2", tt.winners, "") {
+				t.Fatal("SettleRound() = false, want true")
+			}
+
+			records, total, err := store.ListHistory(database.HistoryQuery{Limit: 10})
+			if err != nil || total != 1 {
+				t.Fatalf("ListHistory() total=%d err=%v, want 1 record", total, err)
+			}
+			h := records[0]
+			if h.Result != tt.wantResult {
+				t.Errorf("Result = %s, want %s", h.Result, tt.wantResult)
+			}
+			if h.Profit != tt.wantProfit {
+				t.Errorf("Profit = %.2f, want %.2f", h.Profit, tt.wantProfit)
+			}
+			// 纸面盈亏无论虚实都按下注计算
+			if wantVirtual := m.paperProfit(h); h.VirtualProfit != wantVirtual || (tt.status == StatusReal && h.VirtualProfit != h.Profit) {
+				t.Errorf("VirtualProfit = %.2f, want %.2f", h.VirtualProfit, wantVirtual)
+			}
+			if tt.status == StatusVirtual && h.VirtualProfit == 0 {
+				t.Error("虚盘命中的纸面盈亏为0")
+			}
+			if state := m.GetState().Strategies[0]; state.VirtualProfit != h.VirtualProfit {
+				t.Errorf("策略纸面累计 = %.2f, want %.2f", state.VirtualProfit, h.VirtualProfit)
+			}
+			if h.Status != tt.status {
+				t.Errorf("Status = %d, want %d（应记录结算前状态）", h.Status, tt.status)
+			}
+			if h.BetAmount != float64(len(tt.predictions))*100 {
+				t.Errorf("BetAmount = %.2f, want %.2f", h.BetAmount, float64(len(tt.predictions))*100)
+			}
+		})
+	}
+}
+
+func TestSettleRoundWithoutPrediction(t *testing.T) {
+	m := NewStrategyManager(database.NewMemoryStore())
+	m.UpdatePredictions("1", "2", "热门3码", []string{"红奔驰", "绿宝马", "黄奥迪"})
+
+	if m.SettleRound("1", []string{"红奔驰"}, "") {
+		t.Error("SettleRound() 对没有预测的期号返回 true")
+	}
+}
+
+// countingStore 统计历史汇总和每日聚合查询次数
+type countingStore struct {
+	database.Store
+	queries int
+}
+
+func (s *countingStore) SumProfit(filter database.HistoryFilter) (float64, error) {
+	s.queries++
+	return s.Store.SumProfit(filter)
+}
+
+func (s *countingStore) DailyStats(filter database.DailyStatFilter) ([]models.DailyStrategyStat, error) {
+	s.queries++
+	return s.Store.DailyStats(filter)
+}
+
+func (s *countingStore) HistoryStatsByStrategy(filter database.HistoryFilter) ([]database.HistoryStat, error) {
+	s.queries++
+	return s.Store.HistoryStatsByStrategy(filter)
+}
+
+func TestStateSnapshotRealProfit(t *testing.T) {
+	store := &countingStore{Store: database.NewMemoryStore()}
+	settledAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.Local)
+	if err := store.CreateHistory(&models.StrategyHistory{
+		RoundID: "1", Strategy: "热门3码", Status: StatusReal, Result: "赢", Profit: 500, CreatedAt: &settledAt,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重启后尚未生成预测，总盈利仍来自数据库
+	m := NewStrategyManagerWithClock(store, NewManualClock(settledAt))
+	if got := m.GetState().TotalRealProfit; got != 500 {
+		t.Errorf("启动后 TotalRealProfit = %.2f, want 500", got)
+	}
+
+	m.UpdatePredictions("1", "2", "热门3码", []string{"红奔驰", "绿宝马", "黄奥迪"})
+	m.strategies["热门3码"].Status = StatusReal
+	if !m.SettleRound("2", []string{"绿宝马"}, "") {
+		t.Fatal("SettleRound() = false, want true")
+	}
+
+	queries := store.queries
+	for i := 0; i < 3; i++ {
+		state := m.GetState()
+		if state.TotalRealProfit != 1800 || state.Strategies[0].RealProfit != 1800 {
+			t.Errorf("TotalRealProfit = %.2f, RealProfit = %.2f, want 1800",
+				state.TotalRealProfit, state.Strategies[0].RealProfit)
+		}
+		if real := m.GetRealPredictions(); len(real) != 1 || real[0].RealProfit != 1800 {
+			t.Errorf("GetRealPredictions() = %+v", real)
+		}
+	}
+	if store.queries != queries {
+		t.Errorf("读取状态查询了数据库 %d 次", store.queries-queries)
+	}
+
+	dbProfit, err := store.Store.SumProfit(database.HistoryFilter{Status: database.StatusFilter(StatusReal)})
+	if err != nil || dbProfit != 1800 {
+		t.Errorf("数据库实盘盈利 = %.2f, err=%v, want 1800", dbProfit, err)
+	}
+}
@@ -0,0 +1,22 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStrategiesBreakTiesByLabelOrder(t *testing.T) {
+	// 同分时按 BET_LABELS 顺序：结果不随 map 遍历顺序变化
+	scores := map[string]float64{
+		"黄大众": 2, "绿奥迪": 2, "红宝马": 2, "黄奔驰": 2,
+		"绿奔驰": 1, "红大众": 1, "红奥迪": 1, "黄宝马": 1,
+	}
+	for i := 0; i < 20; i++ {
+		if got, want := StratHot3(scores), []string{"黄奔驰", "红宝马", "绿奥迪"}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("StratHot3 = %v, want %v", got, want)
+		}
+		if got, want := StratBalanced4(scores), []string{"黄奔驰", "绿奥迪", "黄大众", "红奥迪"}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("StratBalanced4 = %v, want %v", got, want)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package engine
+
+import (
+	"benz-sniper/database"
+	"benz-sniper/models"
+	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+)
+
+// MaxUserBetBatch 批量上传单次最多的记录数
+const MaxUserBetBatch = 500
+
+// UserBetItem 按车型的下注明细
+type UserBetItem struct {
+	Car   string  `json:"car"`   // 车型
+	Stake float64 `json:"stake"` // 下注金额
+}
+
+// UserBetResult 单条派彩记录的写入结果
+type UserBetResult struct {
+	ID          uint                  `json:"id"`
+	RoundID     string                `json:"round_id"`
+	UserAccount string                `json:"user_account"`
+	Status      database.UpsertResult `json:"status"` // created / updated / unchanged
+}
+
+// userBetRecordFrom 数据库记录转换为 API 记录
+func userBetRecordFrom(ub models.UserBet) UserBetRecord {
+	record := UserBetRecord{
+		RoundID:      ub.RoundID,
+		UserAccount:  ub.UserAccount,
+		BetAmount:    ub.BetAmount,
+		PayoutAmount: ub.PayoutAmount,
+		Balance:      ub.Balance,
+	}
+	if ub.IdempotencyKey != nil {
+		record.IdempotencyKey = *ub.IdempotencyKey
+	}
+	for _, item := range ub.Items {
+		record.Items = append(record.Items, UserBetItem{Car: item.Car, Stake: item.Stake})
+	}
+	return record
+}
+
+// SaveUserBet 保存用户派彩记录（校验失败返回 *ValidationError）
+func (m *StrategyManager) SaveUserBet(record UserBetRecord) (UserBetResult, error) {
+	results, err := m.saveUserBets([]UserBetRecord{record}, func(int) string { return "" })
+	if err != nil {
+		return UserBetResult{}, err
+	}
+	return results[0], nil
+}
+
+// SaveUserBets 批量保存用户派彩记录：先校验全部记录（字段名带 bets[i]. 前缀），有任何错误则一条都不写入；
+// 校验通过后整批在一个事务内写入，写入失败时同样一条都不保存
+func (m *StrategyManager) SaveUserBets(records []UserBetRecord) ([]UserBetResult, error) {
+	if len(records) > MaxUserBetBatch {
+		return nil, &ValidationError{Errors: []FieldError{{
+			Field:   "bets",
+			Value:   len(records),
+			Message: fmt.Sprintf("单次最多上传 %d 条", MaxUserBetBatch),
+		}}}
+	}
+	return m.saveUserBets(records, func(i int) string { return fmt.Sprintf("bets[%d].", i) })
+}
+
+func (m *StrategyManager) saveUserBets(records []UserBetRecord, prefix func(int) string) ([]UserBetResult, error) {
+	if err := m.validateUserBets(records, prefix); err != nil {
+		return nil, err
+	}
+
+	uploadedAt := m.clock.Now()
+	bets := make([]*models.UserBet, 0, len(records))
+	for _, record := range records {
+		userBet := &models.UserBet{
+			RoundID:      record.RoundID,
+			UserAccount:  record.UserAccount,
+			BetAmount:    record.BetAmount,
+			PayoutAmount: record.PayoutAmount,
+			Balance:      record.Balance,
+			CreatedAt:    &uploadedAt,
+			UpdatedAt:    &uploadedAt,
+		}
+		if record.IdempotencyKey != "" {
+			key := record.IdempotencyKey
+			userBet.IdempotencyKey = &key
+		}
+		for _, item := range record.Items {
+			userBet.Items = append(userBet.Items, models.UserBetItem{Car: item.Car, Stake: item.Stake})
+		}
+		bets = append(bets, userBet)
+	}
+
+	// 整批在一个事务内写入，任一条失败时一条都不保存
+	statuses, err := m.store.UpsertUserBets(bets)
+	if err != nil {
+		slog.Error("❌ 保存用户派彩记录失败", "records", len(records), "error", err)
+		return nil, err
+	}
+	results := make([]UserBetResult, 0, len(bets))
+	for i, userBet := range bets {
+		results = append(results, UserBetResult{
+			ID:          userBet.ID,
+			RoundID:     userBet.RoundID,
+			UserAccount: userBet.UserAccount,
+			Status:      statuses[i],
+		})
+		slog.Info("✅ 用户派彩记录已保存", "round_id", userBet.RoundID, "account", userBet.UserAccount, "result", statuses[i],
+			"bet_amount", records[i].BetAmount, "payout_amount", records[i].PayoutAmount, "items", len(records[i].Items))
+	}
+	return results, nil
+}
+
+// validateUserBets 校验派彩记录：明细车型合法且不重复、合计等于 bet_amount，
+// 带明细时派彩须等于按开奖结果和赔率计算的金额（Σ 命中车型赔率 × 下注额）
+func (m *StrategyManager) validateUserBets(records []UserBetRecord, prefix func(int) string) error {
+	roundIDs := make([]string, 0, len(records))
+	for _, r := range records {
+		if len(r.Items) > 0 {
+			roundIDs = append(roundIDs, r.RoundID)
+		}
+	}
+	winners := make(map[string][]string)
+	if len(roundIDs) > 0 {
+		rows, err := m.store.WinnersByRounds(roundIDs)
+		if err != nil {
+			return err
+		}
+		for _, w := range rows {
+			// 与结算一致：抓取的车型名称先清理再比较
+			winners[w.RoundID] = append(winners[w.RoundID], CleanName(w.WinnerName))
+		}
+	}
+
+	var errs []FieldError
+	seen := make(map[string]int)
+	for i, r := range records {
+		p := prefix(i)
+		add := func(field string, value any, format string, args ...any) {
+			errs = append(errs, FieldError{Field: p + field, Value: value, Message: fmt.Sprintf(format, args...)})
+		}
+
+		if r.RoundID == "" {
+			add("round_id", r.RoundID, "期号不能为空")
+		}
+		if r.UserAccount == "" {
+			add("user_account", r.UserAccount, "用户账号不能为空")
+		}
+		if r.BetAmount <= 0 {
+			add("bet_amount", r.BetAmount, "下注金额必须大于 0")
+		}
+		if r.PayoutAmount < 0 {
+			add("payout_amount", r.PayoutAmount, "派彩金额不能为负数")
+		}
+		key := r.RoundID + "/" + r.UserAccount
+		if j, ok := seen[key]; ok {
+			add("round_id", r.RoundID, "与 %s 的期号和账号重复", strings.TrimSuffix(prefix(j), "."))
+		}
+		seen[key] = i
+
+		if len(r.Items) == 0 {
+			continue
+		}
+		itemsOK := true
+		cars := make(map[string]bool)
+		sum := 0.0
+		for k, item := range r.Items {
+			field := fmt.Sprintf("items[%d].", k)
+			if _, ok := REAL_ODDS[item.Car]; !ok {
+				add(field+"car", item.Car, "未知车型")
+				itemsOK = false
+			} else if cars[item.Car] {
+				add(field+"car", item.Car, "车型重复")
+				itemsOK = false
+			}
+			cars[item.Car] = true
+			if item.Stake <= 0 {
+				add(field+"stake", item.Stake, "下注金额必须大于 0")
+				itemsOK = false
+			}
+			sum += item.Stake
+		}
+		if !itemsOK {
+			continue
+		}
+		if math.Abs(sum-r.BetAmount) > ReconcileTolerance {
+			add("items", roundMoney(sum), "明细合计 %.2f 与 bet_amount %.2f 不一致", sum, r.BetAmount)
+		}
+
+		won, drawn := winners[r.RoundID]
+		if !drawn {
+			add("round_id", r.RoundID, "期号未开奖，无法校验派彩")
+			continue
+		}
+		if want := expectedPayout(r.Items, won); math.Abs(r.PayoutAmount-want) > ReconcileTolerance {
+			add("payout_amount", r.PayoutAmount, "派彩应为 %.2f（按开奖结果 %v 和赔率计算）", want, won)
+		}
+	}
+	if len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+	return nil
+}
+
+// expectedPayout 按开奖结果计算明细的应派彩（与 calculateProfit 一致：命中车型返还 赔率 × 下注额）
+func expectedPayout(items []UserBetItem, winners []string) float64 {
+	winnerSet := make(map[string]bool)
+	for _, w := range winners {
+		winnerSet[w] = true
+	}
+	payout := 0.0
+	for _, item := range items {
+		if winnerSet[item.Car] {
+			payout += float64(REAL_ODDS[item.Car]) * item.Stake
+		}
+	}
+	return roundMoney(payout)
+}
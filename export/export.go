@@ -0,0 +1,244 @@
+// Package export 表格数据导出（CSV / XLSX / NDJSON），按行写入，调用方分批读取数据即可流式输出
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Format 导出格式
+type Format string
+
+const (
+	FormatCSV    Format = "csv"
+	FormatXLSX   Format = "xlsx"
+	FormatNDJSON Format = "ndjson"
+)
+
+// ErrUnknownFormat 不支持的导出格式
+var ErrUnknownFormat = errors.New("不支持的导出格式（可选 csv / xlsx / ndjson）")
+
+// timeLayout CSV / XLSX 中的时间格式（本地时间）
+const timeLayout = "2006-01-02 15:04:05"
+
+// ParseFormat 解析导出格式（空值为 csv）
+func ParseFormat(value string) (Format, error) {
+	switch Format(value) {
+	case "", FormatCSV:
+		return FormatCSV, nil
+	case FormatXLSX, FormatNDJSON:
+		return Format(value), nil
+	}
+	return "", ErrUnknownFormat
+}
+
+// ContentType 响应的 Content-Type
+func (f Format) ContentType() string {
+	switch f {
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case FormatNDJSON:
+		return "application/x-ndjson; charset=utf-8"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Writer 按行写入表格（WriteHeader 须在 WriteRow 之前调用一次）
+type Writer interface {
+	// WriteHeader 写入列名
+	WriteHeader(columns []string) error
+	// WriteRow 写入一行（值与列一一对应；支持 string、数值、bool、time.Time、*time.Time）
+	WriteRow(values ...any) error
+	// Flush 把缓冲的数据写到底层 io.Writer（XLSX 只能在 Close 时整体输出）
+	Flush() error
+	// Close 结束写入
+	Close() error
+}
+
+// NewWriter 创建指定格式的 Writer（sheet 为 XLSX 工作表名称）
+func NewWriter(format Format, w io.Writer, sheet string) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w)
+	case FormatXLSX:
+		return newXLSXWriter(w, sheet)
+	case FormatNDJSON:
+		return &ndjsonWriter{w: w}, nil
+	}
+	return nil, ErrUnknownFormat
+}
+
+// cellText 单元格文本（CSV 使用）
+func cellText(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case time.Time:
+		if val.IsZero() {
+			return ""
+		}
+		return val.Local().Format(timeLayout)
+	case *time.Time:
+		if val == nil {
+			return ""
+		}
+		return cellText(*val)
+	}
+	return fmt.Sprint(v)
+}
+
+// csvWriter CSV（带 UTF-8 BOM，Excel 直接打开中文不乱码）
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer) (*csvWriter, error) {
+	if _, err := io.WriteString(w, "\uFEFF"); err != nil {
+		return nil, err
+	}
+	return &csvWriter{w: csv.NewWriter(w)}, nil
+}
+
+func (c *csvWriter) WriteHeader(columns []string) error {
+	return c.w.Write(columns)
+}
+
+func (c *csvWriter) WriteRow(values ...any) error {
+	record := make([]string, len(values))
+	for i, v := range values {
+		record[i] = cellText(v)
+	}
+	return c.w.Write(record)
+}
+
+func (c *csvWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvWriter) Close() error {
+	return c.Flush()
+}
+
+// ndjsonWriter 每行一个 JSON 对象（键按列顺序输出）
+type ndjsonWriter struct {
+	w       io.Writer
+	columns [][]byte
+}
+
+func (n *ndjsonWriter) WriteHeader(columns []string) error {
+	n.columns = make([][]byte, len(columns))
+	for i, col := range columns {
+		key, err := json.Marshal(col)
+		if err != nil {
+			return err
+		}
+		n.columns[i] = key
+	}
+	return nil
+}
+
+func (n *ndjsonWriter) WriteRow(values ...any) error {
+	if len(values) != len(n.columns) {
+		return fmt.Errorf("列数不一致: %d != %d", len(values), len(n.columns))
+	}
+	line := []byte{'{'}
+	for i, v := range values {
+		if t, ok := v.(*time.Time); ok && t == nil {
+			v = nil
+		}
+		value, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			line = append(line, ',')
+		}
+		line = append(line, n.columns[i]...)
+		line = append(line, ':')
+		line = append(line, value...)
+	}
+	line = append(line, '}', '\n')
+	_, err := n.w.Write(line)
+	return err
+}
+
+func (n *ndjsonWriter) Flush() error { return nil }
+
+func (n *ndjsonWriter) Close() error { return nil }
+
+// xlsxWriter XLSX（excelize 流式写入，行数据超过内存阈值时暂存到临时文件）
+type xlsxWriter struct {
+	w      io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	row    int
+}
+
+func newXLSXWriter(w io.Writer, sheet string) (*xlsxWriter, error) {
+	file := excelize.NewFile()
+	if sheet == "" {
+		sheet = "Sheet1"
+	}
+	if err := file.SetSheetName("Sheet1", sheet); err != nil {
+		file.Close()
+		return nil, err
+	}
+	stream, err := file.NewStreamWriter(sheet)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &xlsxWriter{w: w, file: file, stream: stream}, nil
+}
+
+func (x *xlsxWriter) WriteHeader(columns []string) error {
+	values := make([]any, len(columns))
+	for i, col := range columns {
+		values[i] = col
+	}
+	return x.writeRow(values)
+}
+
+func (x *xlsxWriter) WriteRow(values ...any) error {
+	cells := make([]any, len(values))
+	for i, v := range values {
+		switch val := v.(type) {
+		case time.Time, *time.Time:
+			cells[i] = cellText(val)
+		default:
+			cells[i] = val
+		}
+	}
+	return x.writeRow(cells)
+}
+
+func (x *xlsxWriter) writeRow(values []any) error {
+	x.row++
+	cell, err := excelize.CoordinatesToCellName(1, x.row)
+	if err != nil {
+		return err
+	}
+	return x.stream.SetRow(cell, values)
+}
+
+func (x *xlsxWriter) Flush() error { return nil }
+
+func (x *xlsxWriter) Close() error {
+	defer x.file.Close()
+	if err := x.stream.Flush(); err != nil {
+		return err
+	}
+	return x.file.Write(x.w)
+}
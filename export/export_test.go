@@ -0,0 +1,72 @@
+package export
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func writeAll(t *testing.T, format Format) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewWriter(format, &buf, "数据")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.Local)
+	var missing *time.Time
+	if err := w.WriteHeader([]string{"round_id", "amount", "note", "at", "missing"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteRow("1001", 12.5, "红宝马,绿奔驰", &at, missing); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteRow("1002", 0, "", at, missing); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return &buf
+}
+
+func TestWriters(t *testing.T) {
+	csv := writeAll(t, FormatCSV).String()
+	want := "\uFEFFround_id,amount,note,at,missing\n1001,12.5,\"红宝马,绿奔驰\",2026-01-02 03:04:05,\n1002,0,,2026-01-02 03:04:05,\n"
+	if csv != want {
+		t.Errorf("csv = %q, want %q", csv, want)
+	}
+
+	lines := strings.Split(strings.TrimSpace(writeAll(t, FormatNDJSON).String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], `{"round_id":"1001","amount":12.5,"note":"红宝马,绿奔驰","at":"2026-01-02T03:04:05`) ||
+		!strings.HasSuffix(lines[0], `,"missing":null}`) {
+		t.Errorf("ndjson = %q", lines)
+	}
+
+	f, err := excelize.OpenReader(writeAll(t, FormatXLSX))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rows, err := f.GetRows("数据")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 || rows[1][0] != "1001" || rows[1][1] != "12.5" || rows[2][3] != "2026-01-02 03:04:05" {
+		t.Errorf("xlsx rows = %q", rows)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for value, want := range map[string]Format{"": FormatCSV, "csv": FormatCSV, "xlsx": FormatXLSX, "ndjson": FormatNDJSON} {
+		if got, err := ParseFormat(value); err != nil || got != want {
+			t.Errorf("ParseFormat(%q) = %q, %v", value, got, err)
+		}
+	}
+	if _, err := ParseFormat("pdf"); err != ErrUnknownFormat {
+		t.Errorf("ParseFormat(pdf) err = %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+// Package logging 结构化分级日志（基于 log/slog，级别可在运行时修改）
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// 日志格式
+const (
+	FormatText = "text" // logfmt（默认）
+	FormatJSON = "json"
+)
+
+// level 全局日志级别（所有 Setup/New 创建的 logger 共用）
+var level = new(slog.LevelVar)
+
+// ParseLevel 解析日志级别（debug / info / warn / error，不区分大小写）
+func ParseLevel(s string) (slog.Level, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(strings.TrimSpace(s))); err != nil {
+		return 0, fmt.Errorf("未知日志级别: %s", s)
+	}
+	return l, nil
+}
+
+// SetLevel 运行时修改日志级别
+func SetLevel(s string) error {
+	l, err := ParseLevel(s)
+	if err != nil {
+		return err
+	}
+	level.Set(l)
+	return nil
+}
+
+// Level 当前日志级别（小写）
+func Level() string {
+	return strings.ToLower(level.Level().String())
+}
+
+// New 创建写入 w 的 logger（format 为空时使用 logfmt）
+func New(w io.Writer, format string) (*slog.Logger, error) {
+	opts := &slog.HandlerOptions{Level: level}
+	switch format {
+	case FormatText, "":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case FormatJSON:
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("未知日志格式: %s", format)
+	}
+}
+
+// Setup 按配置初始化默认 logger（标准库 log 的输出也会转入 slog）
+func Setup(format, lvl string) error {
+	if err := SetLevel(lvl); err != nil {
+		return err
+	}
+	logger, err := New(os.Stderr, format)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+	return nil
+}
+
+// loggerKey context 中 logger 的键
+type loggerKey struct{}
+
+// WithLogger 将 logger 放入 context
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext 取出 context 中的 logger（没有时返回默认 logger）
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
@@ -0,0 +1,112 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// capture 将默认 logger 临时替换为写入缓冲区的 JSON logger
+func capture(t *testing.T, lvl string) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	logger, err := New(&buf, FormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prevLogger, prevLevel := slog.Default(), Level()
+	slog.SetDefault(logger)
+	if err := SetLevel(lvl); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		slog.SetDefault(prevLogger)
+		SetLevel(prevLevel)
+	})
+	return &buf
+}
+
+// entries 解析缓冲区中的 JSON 日志
+func entries(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	out := make([]map[string]any, 0)
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("解析日志失败: %v\n%s", err, line)
+		}
+		out = append(out, entry)
+	}
+	return out
+}
+
+func TestSetLevel(t *testing.T) {
+	buf := capture(t, "warn")
+	slog.Info("被过滤")
+	slog.Warn("保留")
+	if err := SetLevel("DEBUG"); err != nil || Level() != "debug" {
+		t.Fatalf("SetLevel(DEBUG) = %v, level = %s", err, Level())
+	}
+	slog.Debug("调试")
+	if err := SetLevel("verbose"); err == nil {
+		t.Error("未知级别应返回错误")
+	}
+
+	got := entries(t, buf)
+	if len(got) != 2 || got[0]["msg"] != "保留" || got[1]["msg"] != "调试" {
+		t.Errorf("日志 = %v", got)
+	}
+	if _, err := New(buf, "xml"); err == nil {
+		t.Error("未知格式应返回错误")
+	}
+}
+
+func TestMiddlewareRequestID(t *testing.T) {
+	buf := capture(t, "debug")
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware())
+	router.GET("/items/:id", func(c *gin.Context) {
+		FromContext(c.Request.Context()).Info("处理中", "item", c.Param("id"))
+		c.Status(http.StatusNotFound)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/items/7", nil)
+	req.Header.Set(RequestIDHeader, "abc-123")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Header().Get(RequestIDHeader) != "abc-123" {
+		t.Errorf("响应头 %s = %q, want abc-123", RequestIDHeader, rec.Header().Get(RequestIDHeader))
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/items/8", nil))
+	generated := rec.Header().Get(RequestIDHeader)
+	if len(generated) != 16 {
+		t.Errorf("生成的请求 ID = %q", generated)
+	}
+
+	got := entries(t, buf)
+	if len(got) != 4 {
+		t.Fatalf("日志 = %v, want 4 条", got)
+	}
+	handler, access := got[0], got[1]
+	if handler["request_id"] != "abc-123" || handler["item"] != "7" {
+		t.Errorf("处理日志 = %v", handler)
+	}
+	if access["request_id"] != "abc-123" || access["level"] != "WARN" || access["route"] != "/items/:id" || access["status"] != float64(404) {
+		t.Errorf("访问日志 = %v", access)
+	}
+	if got[3]["request_id"] != generated {
+		t.Errorf("第二个请求的访问日志 = %v, want request_id %s", got[3], generated)
+	}
+}
@@ -0,0 +1,77 @@
+package logging
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader 请求 ID 的请求/响应头
+const RequestIDHeader = "X-Request-ID"
+
+// SlowRequest 超过该耗时的请求按 warn 级别记录
+const SlowRequest = 500 * time.Millisecond
+
+// requestIDKey gin 上下文中请求 ID 的键
+const requestIDKey = "request_id"
+
+// Middleware 请求 ID 和访问日志中间件
+//
+// 沿用请求头中的 X-Request-ID（没有时生成），写回响应头，并把带 request_id 的 logger 放入请求 context。
+// 5xx 记为 error，4xx 和慢请求记为 warn，其余记为 debug。
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		id := c.GetHeader(RequestIDHeader)
+		if id == "" || len(id) > 64 {
+			id = newRequestID()
+		}
+		c.Set(requestIDKey, id)
+		c.Header(RequestIDHeader, id)
+
+		logger := slog.Default().With("request_id", id)
+		c.Request = c.Request.WithContext(WithLogger(c.Request.Context(), logger))
+
+		c.Next()
+
+		latency := time.Since(start)
+		status := c.Writer.Status()
+		lvl := slog.LevelDebug
+		switch {
+		case status >= 500:
+			lvl = slog.LevelError
+		case status >= 400 || latency > SlowRequest:
+			lvl = slog.LevelWarn
+		}
+		attrs := []any{
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"route", c.FullPath(),
+			"status", status,
+			"latency_ms", float64(latency.Microseconds()) / 1000,
+			"bytes", c.Writer.Size(),
+			"client_ip", c.ClientIP(),
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, "errors", c.Errors.String())
+		}
+		logger.Log(c.Request.Context(), lvl, "HTTP 请求", attrs...)
+	}
+}
+
+// RequestID 当前请求的 ID（未经过 Middleware 时为空）
+func RequestID(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
+
+// newRequestID 生成 16 位十六进制请求 ID
+func newRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return time.Now().Format("150405.000000")
+	}
+	return hex.EncodeToString(b)
+}
@@ -1,14 +1,20 @@
 package main
 
 import (
+	"benz-sniper/alerts"
 	"benz-sniper/api"
+	"benz-sniper/auth"
 	"benz-sniper/config"
 	"benz-sniper/database"
 	"benz-sniper/engine"
+	"benz-sniper/logging"
+	"benz-sniper/metrics"
+	"benz-sniper/notify"
+	"benz-sniper/reports"
 	"context"
 	"embed"
 	"io/fs"
-	"log"
+	"log/slog"
 	"net"
 	"net/http"
 	"os"
@@ -28,41 +34,116 @@ var assetsFS embed.FS
 func main() {
 	// 加载配置
 	cfg := config.Load()
+
+	// 结构化日志（级别可通过 PUT /api/admin/log-level 运行时修改）
+	if err := logging.Setup(cfg.LogFormat, cfg.LogLevel); err != nil {
+		fatal("❌ 日志初始化失败", "error", err)
+	}
 	
 	// 初始化数据库
 	if err := database.Init(cfg); err != nil {
-		log.Fatalf("❌ 数据库初始化失败: %v", err)
+		fatal("❌ 数据库初始化失败", "error", err)
 	}
 	defer database.Close()
+
+	// 根上下文：收到 SIGINT / SIGTERM 时取消，后台任务随之停止
+	rootCtx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 	
+	// 时钟（CLOCK_SPEED>1 时按倍速流逝，用于回放模拟数据）
+	var clock engine.Clock = engine.SystemClock{}
+	if cfg.ClockSpeed != 1 {
+		clock = engine.NewScaledClock(time.Now(), cfg.ClockSpeed)
+		slog.Info("⏩ 时钟倍速", "speed", cfg.ClockSpeed)
+	}
+
 	// 创建策略管理器（虚实盘系统，使用默认配置）
-	manager := engine.NewStrategyManager(database.GetDB())
+	manager := engine.NewStrategyManagerWithClock(database.GetStore(), clock)
+	limits := engine.DefaultConfigLimits()
+	limits.MinBetAmount = cfg.BetAmountMin
+	limits.MaxBetAmount = cfg.BetAmountMax
+	limits.BetAmountStep = cfg.BetAmountStep
+	manager.SetConfigLimits(limits)
 	
 	// 创建并启动分析引擎（后台单goroutine）
-	eng := engine.New(database.GetDB(), manager)
-	go eng.Run()
+	eng := engine.New(database.GetStore(), manager)
+	eng.SetHealthThresholds(engine.HealthThresholds{
+		TickStaleAfter:   cfg.HealthTickStale,
+		FreshnessFactor:  cfg.HealthFreshnessFactor,
+		SettlementMaxAge: cfg.HealthSettlementMaxAge,
+	})
+
+	// 告警规则（每次轮询后检查，告警记录保存到 alerts 表，按配置通过邮件/Webhook 通知）
+	alertService := alerts.NewService(manager, database.GetStore(), alerts.Options{
+		Channels: notifyChannels(cfg, cfg.AlertEmailTo, cfg.AlertWebhookURL),
+	})
+	eng.OnTick(alertService.Observe)
+	engineDone := make(chan struct{})
+	go func() {
+		defer close(engineDone)
+		eng.RunContext(rootCtx)
+	}()
+
+	// 定时报表（日报/周报保存到 reports 表，按配置通过邮件/Webhook 投递）
+	for _, kind := range cfg.ReportSchedule {
+		if _, ok := engine.ReportPeriodUnit(kind); !ok {
+			fatal("❌ REPORT_SCHEDULE 只能包含 eod / eow", "kind", kind)
+		}
+	}
+	reportService := reports.NewService(manager, database.GetStore(), reports.Options{
+		Kinds:    cfg.ReportSchedule,
+		Delay:    cfg.ReportDelay,
+		Channels: notifyChannels(cfg, cfg.ReportEmailTo, cfg.ReportWebhookURL),
+		BaseURL:  cfg.ReportBaseURL,
+	})
+	reportsDone := make(chan struct{})
+	go func() {
+		defer close(reportsDone)
+		reportService.Run(rootCtx)
+	}()
 	
 	// 设置 Gin 模式
 	gin.SetMode(gin.ReleaseMode)
 	
-	// 创建路由（自定义日志，只记录错误和慢请求）
+	// 创建路由（请求 ID + 访问日志：错误和慢请求为 warn/error，其余为 debug）
 	router := gin.New()
-	router.Use(customLogger())
+	// 只采信可信代理转发的客户端 IP（默认不信任任何代理，审计日志记录连接地址）
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		fatal("❌ TRUSTED_PROXIES 无效", "error", err)
+	}
+	router.Use(logging.Middleware())
 	router.Use(gin.Recovery())
+	router.Use(metrics.Middleware())
+	
+	// 启用 CORS（仅允许 CORS_ORIGINS 中的来源）
+	router.Use(corsMiddleware(cfg.CORSOrigins))
 	
-	// 启用 CORS
-	router.Use(corsMiddleware())
+	// 认证服务（启动时按环境变量创建管理员）
+	authService := auth.NewService(database.GetStore(), clock, auth.Options{
+		SessionTTL:    cfg.SessionTTL,
+		AnonymousRole: anonymousRole(cfg.AnonymousRole),
+	})
+	if err := authService.Bootstrap(cfg.AdminUsername, cfg.AdminPassword, cfg.AdminAPIKey); err != nil {
+		fatal("❌ 初始化管理员失败", "error", err)
+	}
 	
-	// 设置 API 路由（读写锁保护）
-	apiHandler := api.New(manager)
+	// 设置 API 路由（读写锁保护，按角色鉴权）
+	apiHandler := api.New(manager, authService, database.GetStore())
+	apiHandler.SetEngine(eng)
+	apiHandler.SetReports(reportService)
+	apiHandler.SetAlerts(alertService)
 	apiHandler.SetupRoutes(router)
+
+	// Prometheus 指标（引擎状态在抓取时读取）
+	metrics.Registry.MustRegister(engine.NewCollector(eng))
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
 	
 	// 使用嵌入的静态文件（支持 CI/CD 部署）
 	// 首页
 	router.GET("/", func(c *gin.Context) {
 		data, err := indexHTML.ReadFile("index.html")
 		if err != nil {
-			log.Printf("❌ 读取 index.html 失败: %v", err)
+			logging.FromContext(c.Request.Context()).Error("❌ 读取 index.html 失败", "error", err)
 			c.String(http.StatusInternalServerError, "页面加载失败")
 			return
 		}
@@ -72,7 +153,7 @@ func main() {
 	// 静态资源目录
 	assetsSubFS, err := fs.Sub(assetsFS, "assets")
 	if err != nil {
-		log.Fatalf("❌ 加载静态资源失败: %v", err)
+		fatal("❌ 加载静态资源失败", "error", err)
 	}
 	router.StaticFS("/assets", http.FS(assetsSubFS))
 	
@@ -85,85 +166,114 @@ func main() {
 		Addr:           "0.0.0.0:" + port,
 		Handler:        router,
 		ReadTimeout:    10 * time.Second,
-		WriteTimeout:   10 * time.Second,
+		WriteTimeout:   10 * time.Second, // 导出接口单独延长（api.ExportWriteTimeout）
 		MaxHeaderBytes: 1 << 20,
 	}
 	
 	// 启动服务器
 	go func() {
-		log.Printf("📱 狙击手地址: http://%s:%s", ip, port)
-		log.Printf("🚀 服务器启动在端口: %s (虚实盘模式)", port)
+		slog.Info("📱 狙击手地址", "url", "http://"+ip+":"+port)
+		slog.Info("🚀 服务器启动（虚实盘模式）", "port", port)
 		
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("❌ 服务器启动失败: %v", err)
+			fatal("❌ 服务器启动失败", "error", err)
 		}
 	}()
 	
-	// 优雅关闭
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	// 优雅关闭：先停止后台任务，再关闭 HTTP 服务器，等后台任务退出后才关闭数据库
+	<-rootCtx.Done()
+	stop()
 	
-	log.Println("🛑 正在关闭服务器...")
+	slog.Info("🛑 正在关闭服务器...")
 	
 	// 关闭 HTTP 服务器
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Printf("❌ 服务器强制关闭: %v", err)
+		slog.Error("❌ 服务器强制关闭", "error", err)
 	}
+
+	// 等待引擎完成当前轮询、定时报表完成当前投递，以及已触发告警的通知发送完毕
+	<-engineDone
+	<-reportsDone
+	alertService.Wait()
 	
-	log.Println("✅ 服务器已关闭")
+	slog.Info("✅ 服务器已关闭")
 }
 
-// customLogger 自定义日志中间件（只记录慢请求和错误）
-func customLogger() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		start := time.Now()
-		path := c.Request.URL.Path
-		
-		c.Next()
-		
-		// 跳过静态资源
-		if path == "/" || 
-		   c.Request.URL.Path == "/assets/css/style.css" ||
-		   c.Request.URL.Path == "/assets/js/app.js" {
-			return
-		}
-		
-		latency := time.Since(start)
-		statusCode := c.Writer.Status()
-		
-		// 只记录错误请求或慢请求（>500ms）
-		if statusCode >= 400 || latency > 500*time.Millisecond {
-			log.Printf("[GIN] %d | %13v | %s | %s",
-				statusCode,
-				latency,
-				c.Request.Method,
-				path,
-			)
-		}
+// notifyChannels 按配置创建报表/告警的通知渠道（SMTP 需同时配置服务器和收件人）
+func notifyChannels(cfg *config.Config, emailTo []string, webhookURL string) []notify.Channel {
+	var channels []notify.Channel
+	if cfg.SMTPAddr != "" && len(emailTo) > 0 {
+		channels = append(channels, notify.NewSMTP(notify.SMTPConfig{
+			Addr:     cfg.SMTPAddr,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.SMTPFrom,
+			To:       emailTo,
+		}))
+	}
+	if webhookURL != "" {
+		channels = append(channels, notify.NewWebhook(webhookURL))
 	}
+	return channels
 }
 
-// corsMiddleware CORS 中间件
-func corsMiddleware() gin.HandlerFunc {
+// corsMiddleware CORS 中间件（来源白名单；* 表示任意来源，但此时不允许携带凭证）
+func corsMiddleware(origins []string) gin.HandlerFunc {
+	allowAll := false
+	allowed := make(map[string]bool, len(origins))
+	for _, origin := range origins {
+		if origin == "*" {
+			allowAll = true
+		}
+		allowed[origin] = true
+	}
+
 	return func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
-		
+		// 响应随 Origin 变化（包括不允许的来源），共享缓存不能把一个来源的响应复用给另一个来源
+		header := c.Writer.Header()
+		header.Add("Vary", "Origin")
+		origin := c.GetHeader("Origin")
+		if origin != "" && (allowAll || allowed[origin]) {
+			if allowed[origin] {
+				header.Set("Access-Control-Allow-Origin", origin)
+				header.Set("Access-Control-Allow-Credentials", "true")
+			} else {
+				header.Set("Access-Control-Allow-Origin", "*")
+			}
+			header.Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-API-Key, accept, origin, Cache-Control, X-Requested-With")
+			header.Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
+		}
+
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
 			return
 		}
-		
+
 		c.Next()
 	}
 }
 
+// fatal 记录错误并退出
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// anonymousRole 解析未登录访问的角色（none 表示必须登录）
+func anonymousRole(value string) auth.Role {
+	if value == "" || value == "none" {
+		return ""
+	}
+	role, err := auth.ParseRole(value)
+	if err != nil {
+		fatal("❌ AUTH_ANONYMOUS_ROLE 无效", "value", value)
+	}
+	return role
+}
+
 // getLocalIP 获取本机IP地址
 func getLocalIP() string {
 	conn, err := net.Dial("udp", "8.8.8.8:80")
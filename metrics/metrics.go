@@ -0,0 +1,122 @@
+// Package metrics Prometheus 指标：引擎轮询、结算、告警、数据库查询和 HTTP 请求
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Namespace 指标名前缀
+const Namespace = "benz"
+
+// Registry 进程内唯一的指标注册表（/metrics 输出的内容）
+var Registry = prometheus.NewRegistry()
+
+var (
+	// TickDuration 引擎单次轮询耗时
+	TickDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "engine",
+		Name:      "tick_duration_seconds",
+		Help:      "引擎单次轮询耗时",
+		Buckets:   []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	})
+
+	// LastTick 最近一次轮询完成的时间（Unix 秒，用于发现引擎停摆）
+	LastTick = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "engine",
+		Name:      "last_tick_timestamp_seconds",
+		Help:      "最近一次轮询完成的时间",
+	})
+
+	// RoundsProcessed 引擎处理的新期号数
+	RoundsProcessed = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "engine",
+		Name:      "rounds_processed_total",
+		Help:      "引擎处理的新期号数",
+	})
+
+	// PendingSettlements 待结算队列长度
+	PendingSettlements = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "engine",
+		Name:      "pending_settlements",
+		Help:      "待结算队列长度",
+	})
+
+	// Settlements 结算次数（按策略、结算时状态 virtual/real、结果 win/loss）
+	Settlements = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "settlements_total",
+		Help:      "策略结算次数",
+	}, []string{"strategy", "status", "result"})
+
+	// AlertsFired 触发的告警数（按规则类型和级别）
+	AlertsFired = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "alerts_fired_total",
+		Help:      "触发的告警数",
+	}, []string{"kind", "severity"})
+
+	// DBQueryDuration 数据库操作耗时（按操作类型和表名）
+	DBQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "db",
+		Name:      "query_duration_seconds",
+		Help:      "数据库操作耗时",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"operation", "table"})
+
+	// HTTPRequestDuration HTTP 请求耗时（按方法、路由模板和状态码）
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP 请求耗时",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		TickDuration,
+		LastTick,
+		RoundsProcessed,
+		PendingSettlements,
+		Settlements,
+		AlertsFired,
+		DBQueryDuration,
+		HTTPRequestDuration,
+	)
+}
+
+// Handler /metrics 处理器
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// Middleware 记录 HTTP 请求耗时（路由取 gin 的路由模板，未匹配的请求记为 unmatched，避免标签爆炸）
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		HTTPRequestDuration.
+			WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).
+			Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,40 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMiddlewareUsesRouteTemplate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware())
+	router.GET("/api/sessions/:id/report", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	router.GET("/metrics", gin.WrapH(Handler()))
+
+	for _, path := range []string{"/api/sessions/1/report", "/api/sessions/2/report", "/missing"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if n := testutil.CollectAndCount(HTTPRequestDuration); n != 2 {
+		t.Errorf("HTTP 指标序列 = %d, want 2（路由模板 + unmatched）", n)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		`benz_http_request_duration_seconds_count{method="GET",route="/api/sessions/:id/report",status="204"} 2`,
+		`benz_http_request_duration_seconds_count{method="GET",route="unmatched",status="404"} 1`,
+		"benz_engine_tick_duration_seconds",
+		"go_goroutines",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("/metrics 缺少 %s", want)
+		}
+	}
+}
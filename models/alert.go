@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// AlertRule 告警规则表（每次轮询后检查，满足条件时生成告警记录并通知）
+type AlertRule struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	Name      string     `gorm:"column:name;type:varchar(100)" json:"name"`             // 规则名称
+	Kind      string     `gorm:"column:kind;type:varchar(30)" json:"kind"`              // 规则类型（real_loss_streak / daily_real_pnl / round_stale / car_absent / special_reward）
+	Target    string     `gorm:"column:target;type:varchar(50)" json:"target"`          // 检查对象：策略/车型/特殊奖项（为空表示全部）
+	Operator  string     `gorm:"column:operator;type:varchar(2)" json:"operator"`       // 比较方式：>= / > / <= / <
+	Threshold float64    `gorm:"column:threshold" json:"threshold"`                     // 阈值（期数/金额/秒）
+	Severity  string     `gorm:"column:severity;type:varchar(10)" json:"severity"`      // 级别：info / warning / critical
+	Cooldown  int        `gorm:"column:cooldown" json:"cooldown"`                       // 冷却时间（秒，同一对象在冷却期内只告警一次）
+	Channels  string     `gorm:"column:channels;type:varchar(200)" json:"channels"`     // 通知渠道（逗号分隔，为空表示全部已配置的渠道）
+	Enabled   bool       `gorm:"column:enabled" json:"enabled"`                         // 是否启用
+	CreatedBy string     `gorm:"column:created_by;type:varchar(100)" json:"created_by"` // 创建人
+	CreatedAt *time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt *time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (AlertRule) TableName() string {
+	return "alert_rules"
+}
+
+// Alert 告警记录表（规则被触发一次记一条，规则删除后保留）
+type Alert struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	RuleID        uint       `gorm:"column:rule_id;index" json:"rule_id"`
+	RuleName      string     `gorm:"column:rule_name;type:varchar(100)" json:"rule_name"`
+	Kind          string     `gorm:"column:kind;type:varchar(30)" json:"kind"`
+	Severity      string     `gorm:"column:severity;type:varchar(10);index" json:"severity"`
+	Subject       string     `gorm:"column:subject;type:varchar(50)" json:"subject"`   // 触发对象（策略/车型/特殊奖项，没有对象时为空）
+	Value         float64    `gorm:"column:value" json:"value"`                        // 触发时的取值
+	Threshold     float64    `gorm:"column:threshold" json:"threshold"`                // 触发时的阈值
+	Message       string     `gorm:"column:message;type:varchar(500)" json:"message"`  // 告警内容
+	RoundID       string     `gorm:"column:round_id;type:varchar(50)" json:"round_id"` // 相关期号
+	DeliveredAt   *time.Time `gorm:"column:delivered_at" json:"delivered_at"`          // 全部渠道发送成功的时间
+	DeliveryError string     `gorm:"column:delivery_error;type:varchar(500)" json:"delivery_error"`
+	CreatedAt     *time.Time `gorm:"column:created_at;index" json:"created_at"`
+}
+
+func (Alert) TableName() string {
+	return "alerts"
+}
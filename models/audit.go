@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// AuditLog 审计日志表（配置修改、清空历史、策略启停、用户派彩上传等操作）
+type AuditLog struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	Actor     string     `gorm:"column:actor;type:varchar(100);index" json:"actor"`    // 操作人（用户名/密钥名称）
+	ActorKind string     `gorm:"column:actor_kind;type:varchar(20)" json:"actor_kind"` // anonymous / user / api_key
+	Role      string     `gorm:"column:role;type:varchar(20)" json:"role"`             // 操作时的角色
+	Action    string     `gorm:"column:action;type:varchar(50);index" json:"action"`   // 操作类型
+	Before    string     `gorm:"column:before_data;type:text" json:"before"`           // 操作前（JSON）
+	After     string     `gorm:"column:after_data;type:text" json:"after"`             // 操作后（JSON）
+	SourceIP  string     `gorm:"column:source_ip;type:varchar(64)" json:"source_ip"`   // 来源IP
+	CreatedAt *time.Time `gorm:"column:created_at;index" json:"created_at"`
+}
+
+func (AuditLog) TableName() string {
+	return "audit_log"
+}
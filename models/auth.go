@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// User 登录用户表
+type User struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	Username     string     `gorm:"column:username;type:varchar(50);uniqueIndex" json:"username"`
+	PasswordHash string     `gorm:"column:password_hash;type:varchar(100)" json:"-"` // bcrypt 哈希
+	Role         string     `gorm:"column:role;type:varchar(20)" json:"role"`        // viewer / operator / admin
+	Disabled     bool       `gorm:"column:disabled" json:"disabled"`                 // 是否禁用
+	CreatedAt    *time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt    *time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (User) TableName() string {
+	return "users"
+}
+
+// APIKey API 密钥表（只保存哈希，明文仅在创建时返回一次）
+type APIKey struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	Name       string     `gorm:"column:name;type:varchar(100)" json:"name"`             // 用途说明
+	Prefix     string     `gorm:"column:prefix;type:varchar(20)" json:"prefix"`          // 明文前缀（用于识别）
+	KeyHash    string     `gorm:"column:key_hash;type:varchar(64);uniqueIndex" json:"-"` // SHA-256 哈希
+	Role       string     `gorm:"column:role;type:varchar(20)" json:"role"`              // viewer / operator / admin
+	CreatedBy  string     `gorm:"column:created_by;type:varchar(50)" json:"created_by"`  // 创建人
+	LastUsedAt *time.Time `gorm:"column:last_used_at" json:"last_used_at"`               // 最近使用时间
+	RevokedAt  *time.Time `gorm:"column:revoked_at" json:"revoked_at"`                   // 吊销时间（为空表示有效）
+	CreatedAt  *time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// UserSession 登录会话表（只保存令牌哈希）
+type UserSession struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	TokenHash string     `gorm:"column:token_hash;type:varchar(64);uniqueIndex" json:"-"` // SHA-256 哈希
+	UserID    uint       `gorm:"column:user_id;index" json:"user_id"`
+	ExpiresAt time.Time  `gorm:"column:expires_at;index" json:"expires_at"`
+	CreatedAt *time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+func (UserSession) TableName() string {
+	return "user_sessions"
+}
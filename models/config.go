@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// ConfigVersion 配置版本表（每次修改追加一条，可按版本号回滚）
+type ConfigVersion struct {
+	ID                 uint       `gorm:"primaryKey" json:"id"`
+	Version            int        `gorm:"column:version;uniqueIndex" json:"version"`                // 版本号（从1递增）
+	EntryCondition     int        `gorm:"column:entry_condition" json:"entry_condition"`            // 连赢几把进场
+	ExitCondition      int        `gorm:"column:exit_condition" json:"exit_condition"`              // 连输几把离场
+	Hot3BetAmount      float64    `gorm:"column:hot3_bet_amount" json:"hot3_bet_amount"`            // 热门3码下注金额
+	Balanced4BetAmount float64    `gorm:"column:balanced4_bet_amount" json:"balanced4_bet_amount"`  // 均衡4码下注金额
+	Hot3Enabled        bool       `gorm:"column:hot3_enabled" json:"hot3_enabled"`                  // 热门3码启用
+	Balanced4Enabled   bool       `gorm:"column:balanced4_enabled" json:"balanced4_enabled"`        // 均衡4码启用
+	Comment            string     `gorm:"column:comment;type:varchar(255)" json:"comment"`          // 修改说明
+	Source             string     `gorm:"column:source;type:varchar(20)" json:"source"`             // initial / api / rollback / profile / schedule
+	Profile            string     `gorm:"column:profile;type:varchar(50)" json:"profile,omitempty"` // 来源方案名称
+	CreatedBy          string     `gorm:"column:created_by;type:varchar(100)" json:"created_by"`    // 操作人
+	CreatedAt          *time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+func (ConfigVersion) TableName() string {
+	return "config_versions"
+}
+
+// ConfigProfile 命名配置方案表（如 conservative / aggressive，可手动或按时段启用）
+type ConfigProfile struct {
+	ID                 uint       `gorm:"primaryKey" json:"id"`
+	Name               string     `gorm:"column:name;type:varchar(50);uniqueIndex" json:"name"`        // 方案名称
+	EntryCondition     int        `gorm:"column:entry_condition" json:"entry_condition"`               // 连赢几把进场
+	ExitCondition      int        `gorm:"column:exit_condition" json:"exit_condition"`                 // 连输几把离场
+	Hot3BetAmount      float64    `gorm:"column:hot3_bet_amount" json:"hot3_bet_amount"`               // 热门3码下注金额
+	Balanced4BetAmount float64    `gorm:"column:balanced4_bet_amount" json:"balanced4_bet_amount"`     // 均衡4码下注金额
+	Hot3Enabled        bool       `gorm:"column:hot3_enabled" json:"hot3_enabled"`                     // 热门3码启用
+	Balanced4Enabled   bool       `gorm:"column:balanced4_enabled" json:"balanced4_enabled"`           // 均衡4码启用
+	ScheduleStart      string     `gorm:"column:schedule_start;type:varchar(5)" json:"schedule_start"` // 每日启用时刻 HH:MM（为空表示仅手动启用）
+	ScheduleEnd        string     `gorm:"column:schedule_end;type:varchar(5)" json:"schedule_end"`     // 每日结束时刻 HH:MM（可跨零点）
+	CreatedAt          *time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt          *time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (ConfigProfile) TableName() string {
+	return "config_profiles"
+}
@@ -54,14 +54,16 @@ type StrategyHistory struct {
 	ID            uint       `gorm:"primaryKey" json:"id"`
 	RoundID       string     `gorm:"column:round_id;type:varchar(50);index" json:"round_id"`
 	Strategy      string     `gorm:"column:strategy;type:varchar(50)" json:"strategy"`
-	Status        int        `gorm:"column:status" json:"status"`                            // 0=虚盘, 1=实盘
-	Predictions   string     `gorm:"column:predictions;type:text" json:"predictions"`        // JSON 格式
-	Winners       string     `gorm:"column:winners;type:text" json:"winners"`                // JSON 格式
+	Status        int        `gorm:"column:status" json:"status"`                                  // 0=虚盘, 1=实盘
+	Predictions   string     `gorm:"column:predictions;type:text" json:"predictions"`              // JSON 格式
+	Winners       string     `gorm:"column:winners;type:text" json:"winners"`                      // JSON 格式
 	SpecialReward string     `gorm:"column:special_reward;type:varchar(50)" json:"special_reward"` // 特殊奖项
-	Result        string     `gorm:"column:result;type:varchar(10)" json:"result"`           // 赢/输
-	BetAmount     float64    `gorm:"column:bet_amount" json:"bet_amount"`                    // 下注金额
-	Profit        float64    `gorm:"column:profit" json:"profit"`                            // 本期盈亏
-	TotalProfit   float64    `gorm:"column:total_profit" json:"total_profit"`                // 累计盈利
+	Result        string     `gorm:"column:result;type:varchar(10)" json:"result"`                 // 赢/输
+	BetAmount     float64    `gorm:"column:bet_amount" json:"bet_amount"`                          // 下注金额
+	Profit        float64    `gorm:"column:profit" json:"profit"`                                  // 本期盈亏
+	VirtualProfit float64    `gorm:"column:virtual_profit" json:"virtual_profit"`                  // 本期纸面盈亏（无论虚实都按下注计算）
+	TotalProfit   float64    `gorm:"column:total_profit" json:"total_profit"`                      // 累计盈利
+	SessionID     uint       `gorm:"column:session_id;index;default:0" json:"session_id"`          // 所属归档会话（0=当前）
 	CreatedAt     *time.Time `gorm:"column:created_at" json:"created_at"`
 }
 
@@ -69,21 +71,53 @@ func (StrategyHistory) TableName() string {
 	return "strategy_history"
 }
 
-// UserBet 用户派彩记录表
+// HistorySession 历史归档会话（清空历史时把当前记录归档到一个会话，可查询、恢复或彻底删除）
+type HistorySession struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	Name       string     `gorm:"column:name;type:varchar(100)" json:"name"`             // 会话名称
+	Bets       int64      `gorm:"column:bets" json:"bets"`                               // 记录数
+	Wins       int64      `gorm:"column:wins" json:"wins"`                               // 命中次数
+	Profit     float64    `gorm:"column:profit" json:"profit"`                           // 盈亏合计（含虚盘）
+	RealProfit float64    `gorm:"column:real_profit" json:"real_profit"`                 // 实盘盈亏
+	StartedAt  *time.Time `gorm:"column:started_at" json:"started_at"`                   // 第一条记录时间
+	ArchivedAt *time.Time `gorm:"column:archived_at" json:"archived_at"`                 // 归档时间
+	CreatedBy  string     `gorm:"column:created_by;type:varchar(100)" json:"created_by"` // 归档人
+}
+
+func (HistorySession) TableName() string {
+	return "history_sessions"
+}
+
+// UserBet 用户派彩记录表（同一期号和账号只保留一条，重复上传时覆盖）
 type UserBet struct {
-	ID           uint       `gorm:"primaryKey" json:"id"`
-	RoundID      string     `gorm:"column:round_id;type:varchar(50);index" json:"round_id"`     // 期号
-	UserAccount  string     `gorm:"column:user_account;type:varchar(100)" json:"user_account"` // 用户账号
-	BetAmount    float64    `gorm:"column:bet_amount" json:"bet_amount"`                       // 下注金额
-	PayoutAmount float64    `gorm:"column:payout_amount" json:"payout_amount"`                 // 派彩金额
-	Balance      float64    `gorm:"column:balance" json:"balance"`                             // 剩余余额
-	CreatedAt    *time.Time `gorm:"column:created_at" json:"created_at"`
+	ID             uint          `gorm:"primaryKey" json:"id"`
+	RoundID        string        `gorm:"column:round_id;type:varchar(50);index;uniqueIndex:uniq_user_bets_round_account" json:"round_id"`                      // 期号
+	UserAccount    string        `gorm:"column:user_account;type:varchar(100);uniqueIndex:uniq_user_bets_round_account" json:"user_account"`                   // 用户账号
+	BetAmount      float64       `gorm:"column:bet_amount" json:"bet_amount"`                                                                                  // 下注金额
+	PayoutAmount   float64       `gorm:"column:payout_amount" json:"payout_amount"`                                                                            // 派彩金额
+	Balance        float64       `gorm:"column:balance" json:"balance"`                                                                                        // 剩余余额
+	IdempotencyKey *string       `gorm:"column:idempotency_key;type:varchar(100);uniqueIndex:uniq_user_bets_idempotency_key" json:"idempotency_key,omitempty"` // 幂等键（未提供时为 NULL）
+	Items          []UserBetItem `gorm:"foreignKey:UserBetID" json:"items,omitempty"`                                                                          // 按车型的下注明细
+	CreatedAt      *time.Time    `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt      *time.Time    `gorm:"column:updated_at" json:"updated_at"`
 }
 
 func (UserBet) TableName() string {
 	return "user_bets"
 }
 
+// UserBetItem 用户下注明细（每个车型一行）
+type UserBetItem struct {
+	ID        uint    `gorm:"primaryKey" json:"-"`
+	UserBetID uint    `gorm:"column:user_bet_id;index" json:"-"`
+	Car       string  `gorm:"column:car;type:varchar(20)" json:"car"` // 车型
+	Stake     float64 `gorm:"column:stake" json:"stake"`              // 下注金额
+}
+
+func (UserBetItem) TableName() string {
+	return "user_bet_items"
+}
+
 // SystemConfig 系统配置表（单行存储）
 type SystemConfig struct {
 	ID                 uint       `gorm:"primaryKey" json:"id"`
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Report 定时报表表（日报/周报，同一类型和周期只保留最新一份）
+type Report struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	Kind          string     `gorm:"column:kind;type:varchar(10);uniqueIndex:idx_report_period" json:"kind"`     // eod=日报 / eow=周报
+	Period        string     `gorm:"column:period;type:varchar(20);uniqueIndex:idx_report_period" json:"period"` // 周期标识（营业日 2006-01-02 / 周 2006-W01）
+	PeriodStart   *time.Time `gorm:"column:period_start;index" json:"period_start"`                              // 周期开始（含）
+	PeriodEnd     *time.Time `gorm:"column:period_end" json:"period_end"`                                        // 周期结束（不含）
+	Bets          int64      `gorm:"column:bets" json:"bets"`                                                    // 实盘下单次数
+	Profit        float64    `gorm:"column:profit" json:"profit"`                                                // 实盘盈亏
+	VirtualProfit float64    `gorm:"column:virtual_profit" json:"virtual_profit"`                                // 纸面盈亏
+	Discrepancies int        `gorm:"column:discrepancies" json:"discrepancies"`                                  // 对账差异条数
+	HTML          string     `gorm:"column:html;type:mediumtext" json:"-"`                                       // HTML 正文
+	CSV           string     `gorm:"column:csv;type:mediumtext" json:"-"`                                        // CSV 正文
+	DeliveredAt   *time.Time `gorm:"column:delivered_at" json:"delivered_at"`                                    // 最近一次全部投递成功的时间
+	DeliveryError string     `gorm:"column:delivery_error;type:varchar(500)" json:"delivery_error"`              // 最近一次投递失败的原因
+	CreatedAt     *time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+func (Report) TableName() string {
+	return "reports"
+}
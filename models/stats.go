@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// DailyStrategyStat 每日策略聚合表（按日期、策略、状态和归档会话汇总 strategy_history，结算时同事务累加）
+type DailyStrategyStat struct {
+	ID            uint       `gorm:"primaryKey" json:"-"`
+	Day           string     `gorm:"column:day;type:varchar(10);uniqueIndex:idx_daily_stat" json:"day"`           // 营业日（YYYY-MM-DD，按报表营业日历）
+	Strategy      string     `gorm:"column:strategy;type:varchar(50);uniqueIndex:idx_daily_stat" json:"strategy"` // 策略名称
+	Status        int        `gorm:"column:status;uniqueIndex:idx_daily_stat" json:"status"`                      // 0=虚盘, 1=实盘
+	SessionID     uint       `gorm:"column:session_id;uniqueIndex:idx_daily_stat" json:"session_id"`              // 所属归档会话（0=当前）
+	Bets          int64      `gorm:"column:bets" json:"bets"`                                                     // 下单次数
+	Wins          int64      `gorm:"column:wins" json:"wins"`                                                     // 命中次数（result='赢'）
+	Profit        float64    `gorm:"column:profit" json:"profit"`                                                 // 盈亏合计
+	VirtualProfit float64    `gorm:"column:virtual_profit" json:"virtual_profit"`                                 // 纸面盈亏合计
+	BetAmount     float64    `gorm:"column:bet_amount" json:"bet_amount"`                                         // 下注金额合计
+	UpdatedAt     *time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (DailyStrategyStat) TableName() string {
+	return "daily_strategy_stats"
+}
+
+// StatsCalendar 每日聚合所用的营业日历（单行表，启动时与当前 REPORT_TIMEZONE / REPORT_DAY_CUTOFF 不一致则重建聚合）
+type StatsCalendar struct {
+	ID        uint       `gorm:"primaryKey" json:"-"`
+	Signature string     `gorm:"column:signature;type:varchar(100)" json:"signature"` // 时区@日切时间
+	UpdatedAt *time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (StatsCalendar) TableName() string {
+	return "stats_calendar"
+}
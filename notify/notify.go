@@ -0,0 +1,56 @@
+// Package notify 通知渠道：定时报表和告警通过同一组渠道投递（SMTP 邮件、Webhook）
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Message 一条通知
+type Message struct {
+	Subject     string       // 标题
+	Text        string       // 纯文本正文
+	HTML        string       // HTML 正文（邮件优先使用）
+	Attachments []Attachment // 附件（邮件使用）
+	Payload     any          // Webhook 的 JSON 正文（为 nil 时发送标题和纯文本正文）
+}
+
+// Attachment 邮件附件
+type Attachment struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// Channel 通知渠道
+type Channel interface {
+	// Name 渠道名称（日志和投递结果使用）
+	Name() string
+	// Send 投递一条通知
+	Send(ctx context.Context, msg Message) error
+}
+
+// SendAll 依次投递到全部渠道，返回合并后的错误（某个渠道失败不影响其他渠道）
+func SendAll(ctx context.Context, channels []Channel, msg Message) error {
+	var errs []error
+	for _, ch := range channels {
+		if err := ch.Send(ctx, msg); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", ch.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Summary 错误的单行摘要（写入数据库时使用，超过 limit 个字符截断）
+func Summary(err error, limit int) string {
+	if err == nil {
+		return ""
+	}
+	msg := strings.ReplaceAll(err.Error(), "\n", "; ")
+	if runes := []rune(msg); len(runes) > limit {
+		msg = string(runes[:limit])
+	}
+	return msg
+}
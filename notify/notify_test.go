@@ -0,0 +1,119 @@
+package notify_test
+
+import (
+	"benz-sniper/notify"
+	"benz-sniper/testutil"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+func TestSMTPSend(t *testing.T) {
+	server := testutil.NewSMTPServer(t)
+	ch := notify.NewSMTP(notify.SMTPConfig{
+		Addr: server.Addr,
+		From: "sniper@example.com",
+		To:   []string{"ops@example.com", "boss@example.com"},
+	})
+	msg := notify.Message{
+		Subject:     "奔驰宝马日报 2026-01-01",
+		HTML:        "<h1>日报</h1>",
+		Attachments: []notify.Attachment{{Name: "report.csv", ContentType: "text/csv; charset=utf-8", Data: []byte("策略,盈亏\n热门3码,100\n")}},
+	}
+	if err := ch.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	got := server.Messages()
+	if len(got) != 1 || got[0].From != "sniper@example.com" || len(got[0].To) != 2 {
+		t.Fatalf("messages = %+v", got)
+	}
+	parsed, err := mail.ReadMessage(strings.NewReader(got[0].Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil || subject != msg.Subject {
+		t.Errorf("Subject = %q, %v", subject, err)
+	}
+	_, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := multipart.NewReader(parsed.Body, params["boundary"])
+	var bodies []string
+	var filename string
+	for {
+		part, err := parts.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(base64.NewDecoder(base64.StdEncoding, part))
+		bodies = append(bodies, string(data))
+		if part.FileName() != "" {
+			filename = part.FileName()
+		}
+	}
+	if len(bodies) != 2 || bodies[0] != msg.HTML || bodies[1] != string(msg.Attachments[0].Data) || filename != "report.csv" {
+		t.Errorf("parts = %q, filename = %q", bodies, filename)
+	}
+
+	// 服务器拒收和不支持认证都返回错误
+	server.Reject("mailbox unavailable")
+	if err := ch.Send(context.Background(), msg); err == nil || !strings.Contains(err.Error(), "mailbox unavailable") {
+		t.Errorf("拒收时 Send() error = %v", err)
+	}
+	server.Reject("")
+	auth := notify.NewSMTP(notify.SMTPConfig{Addr: server.Addr, Username: "u", Password: "p", From: "a@b", To: []string{"c@d"}})
+	if err := auth.Send(context.Background(), msg); err == nil {
+		t.Error("服务器不支持认证时应返回错误")
+	}
+}
+
+func TestWebhookAndSendAll(t *testing.T) {
+	var received map[string]any
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Content-Type = %s", r.Header.Get("Content-Type"))
+		}
+		_ = json.NewDecoder(r.Body).Decode(&received)
+	}))
+	defer ok.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+
+	msg := notify.Message{Subject: "告警", Text: "连输 5 期"}
+	if err := notify.NewWebhook(ok.URL).Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if received["subject"] != "告警" || received["text"] != "连输 5 期" {
+		t.Errorf("payload = %v", received)
+	}
+
+	// 一个渠道失败不影响其他渠道，错误中带渠道名称
+	received = nil
+	msg.Payload = map[string]int{"bets": 3}
+	err := notify.SendAll(context.Background(), []notify.Channel{notify.NewWebhook(failing.URL), notify.NewWebhook(ok.URL)}, msg)
+	if err == nil || !strings.Contains(err.Error(), "webhook: HTTP 500") {
+		t.Errorf("SendAll() error = %v", err)
+	}
+	if received["bets"] != float64(3) {
+		t.Errorf("第二个渠道 payload = %v", received)
+	}
+	if got := notify.Summary(err, 12); len([]rune(got)) != 12 {
+		t.Errorf("Summary() = %q, want 12 个字符", got)
+	}
+}
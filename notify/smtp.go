@@ -0,0 +1,163 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// smtpTimeout 未设置截止时间时单次投递的超时
+const smtpTimeout = 30 * time.Second
+
+// SMTPConfig SMTP 邮件渠道配置
+type SMTPConfig struct {
+	Addr     string   // 服务器地址（host:port）
+	Username string   // 认证用户名（为空时不认证）
+	Password string   // 认证密码
+	From     string   // 发件人
+	To       []string // 收件人
+}
+
+// SMTP 邮件渠道（服务器支持 STARTTLS 时自动加密，认证只在加密连接或本机上进行）
+type SMTP struct {
+	cfg SMTPConfig
+}
+
+// NewSMTP 创建邮件渠道
+func NewSMTP(cfg SMTPConfig) *SMTP {
+	return &SMTP{cfg: cfg}
+}
+
+// Name 渠道名称
+func (s *SMTP) Name() string {
+	return "smtp"
+}
+
+// Send 发送邮件（HTML 正文，附件以 base64 编码）
+func (s *SMTP) Send(ctx context.Context, msg Message) error {
+	if len(s.cfg.To) == 0 {
+		return errors.New("没有配置收件人")
+	}
+	body, err := s.compose(msg)
+	if err != nil {
+		return err
+	}
+
+	host, _, err := net.SplitHostPort(s.cfg.Addr)
+	if err != nil {
+		return fmt.Errorf("SMTP 地址格式错误: %w", err)
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", s.cfg.Addr)
+	if err != nil {
+		return err
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(smtpTimeout)
+	}
+	_ = conn.SetDeadline(deadline)
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if s.cfg.Username != "" {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return errors.New("SMTP 服务器不支持认证")
+		}
+		if err := c.Auth(smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(s.cfg.From); err != nil {
+		return err
+	}
+	for _, to := range s.cfg.To {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(body); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// compose 生成 MIME 邮件（multipart/mixed：正文 + 附件）
+func (s *SMTP) compose(msg Message) ([]byte, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	header := []string{
+		"From: " + s.cfg.From,
+		"To: " + strings.Join(s.cfg.To, ", "),
+		"Subject: " + mime.BEncoding.Encode("UTF-8", msg.Subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/mixed; boundary=" + mw.Boundary(),
+	}
+	buf.WriteString(strings.Join(header, "\r\n") + "\r\n\r\n")
+
+	contentType, content := "text/plain; charset=UTF-8", msg.Text
+	if msg.HTML != "" {
+		contentType, content = "text/html; charset=UTF-8", msg.HTML
+	}
+	if err := writePart(mw, textproto.MIMEHeader{"Content-Type": {contentType}}, []byte(content)); err != nil {
+		return nil, err
+	}
+	for _, a := range msg.Attachments {
+		h := textproto.MIMEHeader{
+			"Content-Type":        {a.ContentType},
+			"Content-Disposition": {mime.FormatMediaType("attachment", map[string]string{"filename": a.Name})},
+		}
+		if err := writePart(mw, h, a.Data); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writePart 写入一个 base64 编码的 MIME 分段（每行 76 个字符）
+func writePart(mw *multipart.Writer, h textproto.MIMEHeader, data []byte) error {
+	h.Set("Content-Transfer-Encoding", "base64")
+	w, err := mw.CreatePart(h)
+	if err != nil {
+		return err
+	}
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		if _, err := w.Write([]byte(encoded[:76] + "\r\n")); err != nil {
+			return err
+		}
+		encoded = encoded[76:]
+	}
+	_, err = w.Write([]byte(encoded + "\r\n"))
+	return err
+}
@@ -0,0 +1,57 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// webhookTimeout Webhook 请求超时
+const webhookTimeout = 10 * time.Second
+
+// Webhook 以 JSON POST 投递的渠道
+type Webhook struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhook 创建 Webhook 渠道
+func NewWebhook(url string) *Webhook {
+	return &Webhook{url: url, client: &http.Client{Timeout: webhookTimeout}}
+}
+
+// Name 渠道名称
+func (w *Webhook) Name() string {
+	return "webhook"
+}
+
+// Send 发送 JSON 请求（非 2xx 响应视为失败）
+func (w *Webhook) Send(ctx context.Context, msg Message) error {
+	payload := msg.Payload
+	if payload == nil {
+		payload = map[string]string{"subject": msg.Subject, "text": msg.Text}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, bytes.TrimSpace(detail))
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package reports
+
+import (
+	"benz-sniper/engine"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"strconv"
+	"time"
+)
+
+// htmlDiscrepancyLimit HTML 中最多列出的对账差异条数（完整明细见 CSV）
+const htmlDiscrepancyLimit = 100
+
+// kindNames 报表类型名称
+var kindNames = map[string]string{
+	engine.ReportKindDaily:  "日报",
+	engine.ReportKindWeekly: "周报",
+}
+
+// Title 报表标题
+func Title(r *engine.ScheduledReport) string {
+	return title(r.Kind, r.Period)
+}
+
+func title(kind, period string) string {
+	return fmt.Sprintf("奔驰宝马%s %s", kindNames[kind], period)
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"money":    func(v float64) string { return strconv.FormatFloat(v, 'f', 2, 64) },
+	"percent":  func(v float64) string { return strconv.FormatFloat(v, 'f', 1, 64) + "%" },
+	"duration": formatSeconds,
+	"time":     func(t time.Time) string { return t.Format("2006-01-02 15:04") },
+	"signed": func(v float64) template.CSS {
+		if v < 0 {
+			return "color:#c0392b"
+		}
+		return "color:#27ae60"
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="UTF-8">
+<title>{{.Title}}</title>
+<style>
+body{font-family:-apple-system,"PingFang SC","Microsoft YaHei",sans-serif;color:#333;margin:24px}
+table{border-collapse:collapse;margin:8px 0 24px}
+th,td{border:1px solid #ddd;padding:6px 10px;text-align:right}
+th{background:#f5f5f5}
+td:first-child,th:first-child{text-align:left}
+.muted{color:#888}
+</style>
+</head>
+<body>
+{{with .Report}}
+<h1>{{$.Title}}</h1>
+<p class="muted">{{time .Start}} ~ {{time .End}}（{{.Timezone}}），生成于 {{time .GeneratedAt}}</p>
+
+<h2>汇总</h2>
+<table>
+<tr><th>实盘下单</th><th>命中</th><th>命中率</th><th>实盘盈亏</th><th>纸面盈亏</th></tr>
+<tr><td>{{.Summary.TotalBets}}</td><td>{{.Summary.TotalWins}}</td><td>{{percent .Summary.WinRate}}</td>
+<td style="{{signed .Summary.TotalProfit}}">{{money .Summary.TotalProfit}}</td>
+<td style="{{signed .Summary.VirtualProfit}}">{{money .Summary.VirtualProfit}}</td></tr>
+</table>
+
+<h2>策略</h2>
+{{if .Strategies}}
+<table>
+<tr><th>策略</th><th>期数</th><th>实盘下单</th><th>命中</th><th>命中率</th><th>实盘盈亏</th><th>纸面盈亏</th><th>实盘时长</th></tr>
+{{range .Strategies}}
+<tr><td>{{.Name}}</td><td>{{.Rounds}}</td><td>{{.Bets}}</td><td>{{.Wins}}</td><td>{{percent .WinRate}}</td>
+<td style="{{signed .Profit}}">{{money .Profit}}</td><td style="{{signed .VirtualProfit}}">{{money .VirtualProfit}}</td>
+<td>{{duration .RealSeconds}}</td></tr>
+{{end}}
+</table>
+{{else}}<p class="muted">本周期没有下注记录</p>{{end}}
+
+<h2>特殊奖项</h2>
+{{if .SpecialRewards}}
+<table>
+<tr><th>奖项</th><th>期数</th><th>实盘盈亏</th></tr>
+{{range .SpecialRewards}}<tr><td>{{.Reward}}</td><td>{{.Rounds}}</td><td style="{{signed .Profit}}">{{money .Profit}}</td></tr>
+{{end}}
+</table>
+{{else}}<p class="muted">本周期没有开出特殊奖项</p>{{end}}
+
+<h2>下注对账</h2>
+<table>
+<tr><th>对账期数</th><th>无差异</th><th>应下注</th><th>实际下注</th><th>应派彩</th><th>实际派彩</th><th>差异</th></tr>
+{{with .Reconciliation}}<tr><td>{{.Rounds}}</td><td>{{.MatchedRounds}}</td><td>{{money .ExpectedStake}}</td><td>{{money .ActualStake}}</td>
+<td>{{money .ExpectedPayout}}</td><td>{{money .ActualPayout}}</td><td>{{.Discrepancies}}</td></tr>{{end}}
+</table>
+{{if $.Discrepancies}}
+<table>
+<tr><th>期号</th><th>账号</th><th>类型</th><th>应有</th><th>实际</th><th>差额</th></tr>
+{{range $.Discrepancies}}<tr><td>{{.RoundID}}</td><td>{{.Account}}</td><td>{{.Kind}}</td><td>{{money .Expected}}</td><td>{{money .Actual}}</td><td>{{money .Diff}}</td></tr>
+{{end}}
+</table>
+{{if $.Truncated}}<p class="muted">仅列出前 {{len $.Discrepancies}} 条差异，完整明细见 CSV</p>{{end}}
+{{end}}
+{{end}}
+</body>
+</html>
+`))
+
+// RenderHTML 生成 HTML 报表
+func RenderHTML(r *engine.ScheduledReport) (string, error) {
+	discrepancies := r.Discrepancies
+	if len(discrepancies) > htmlDiscrepancyLimit {
+		discrepancies = discrepancies[:htmlDiscrepancyLimit]
+	}
+	var buf bytes.Buffer
+	err := htmlTemplate.Execute(&buf, map[string]any{
+		"Title":         Title(r),
+		"Report":        r,
+		"Discrepancies": discrepancies,
+		"Truncated":     len(discrepancies) < len(r.Discrepancies),
+	})
+	return buf.String(), err
+}
+
+// RenderCSV 生成 CSV 报表（带 UTF-8 BOM；汇总、策略、特殊奖项、对账差异分块，块之间空一行）
+func RenderCSV(r *engine.ScheduledReport) (string, error) {
+	var buf bytes.Buffer
+	buf.WriteString("\uFEFF")
+	w := csv.NewWriter(&buf)
+	money := func(v float64) string { return strconv.FormatFloat(v, 'f', 2, 64) }
+	itoa := func(v int64) string { return strconv.FormatInt(v, 10) }
+
+	rows := [][]string{
+		{"报表", Title(r)},
+		{"开始", r.Start.Format(time.RFC3339)},
+		{"结束", r.End.Format(time.RFC3339)},
+		{"实盘下单", itoa(r.Summary.TotalBets)},
+		{"命中", itoa(r.Summary.TotalWins)},
+		{"命中率", strconv.FormatFloat(r.Summary.WinRate, 'f', 2, 64)},
+		{"实盘盈亏", money(r.Summary.TotalProfit)},
+		{"纸面盈亏", money(r.Summary.VirtualProfit)},
+		{},
+		{"策略", "期数", "实盘下单", "命中", "命中率", "实盘盈亏", "纸面盈亏", "实盘时长(秒)"},
+	}
+	for _, s := range r.Strategies {
+		rows = append(rows, []string{s.Name, itoa(s.Rounds), itoa(s.Bets), itoa(s.Wins),
+			strconv.FormatFloat(s.WinRate, 'f', 2, 64), money(s.Profit), money(s.VirtualProfit), itoa(s.RealSeconds)})
+	}
+	rows = append(rows, []string{}, []string{"特殊奖项", "期数", "实盘盈亏"})
+	for _, s := range r.SpecialRewards {
+		rows = append(rows, []string{s.Reward, strconv.Itoa(s.Rounds), money(s.Profit)})
+	}
+	rc := r.Reconciliation
+	rows = append(rows, []string{},
+		[]string{"对账期数", "无差异", "应下注", "实际下注", "应派彩", "实际派彩", "差异"},
+		[]string{strconv.Itoa(rc.Rounds), strconv.Itoa(rc.MatchedRounds), money(rc.ExpectedStake), money(rc.ActualStake),
+			money(rc.ExpectedPayout), money(rc.ActualPayout), strconv.Itoa(rc.Discrepancies)},
+		[]string{},
+		[]string{"期号", "日期", "账号", "差异类型", "应有", "实际", "差额"})
+	for _, d := range r.Discrepancies {
+		rows = append(rows, []string{d.RoundID, d.Date, d.Account, d.Kind, money(d.Expected), money(d.Actual), money(d.Diff)})
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// formatSeconds 时长（时:分）
+func formatSeconds(seconds int64) string {
+	return fmt.Sprintf("%d:%02d", seconds/3600, seconds%3600/60)
+}
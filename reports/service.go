@@ -0,0 +1,221 @@
+// Package reports 定时报表：日报/周报渲染为 HTML 和 CSV，保存到 reports 表并通过通知渠道投递
+package reports
+
+import (
+	"benz-sniper/database"
+	"benz-sniper/engine"
+	"benz-sniper/models"
+	"benz-sniper/notify"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+)
+
+// 定时生成参数
+const (
+	DefaultDelay  = 5 * time.Minute // 周期结束后等待的时间（让最后几期完成结算）
+	retryInterval = time.Minute     // 生成失败后的重试间隔
+)
+
+// Options 定时报表参数
+type Options struct {
+	Kinds    []string         // 定时生成的报表类型（eod / eow，为空时不定时生成）
+	Delay    time.Duration    // 周期结束后多久生成（默认 DefaultDelay）
+	Channels []notify.Channel // 投递渠道（为空时只保存不投递）
+	BaseURL  string           // 下载链接前缀（如 http://host:8001，为空时只给出路径）
+}
+
+// Service 报表生成和投递
+type Service struct {
+	manager *engine.StrategyManager
+	store   database.ReportStore
+	clock   engine.Clock
+	opts    Options
+}
+
+// NewService 创建报表服务（使用策略管理器的时钟）
+func NewService(manager *engine.StrategyManager, store database.ReportStore, opts Options) *Service {
+	if opts.Delay <= 0 {
+		opts.Delay = DefaultDelay
+	}
+	return &Service{manager: manager, store: store, clock: manager.Clock(), opts: opts}
+}
+
+// Generate 生成 at 所在周期的报表并保存（同一周期重复生成时覆盖），deliver 为 true 时投递
+// 投递失败只记录在报表上，不作为生成失败返回
+func (s *Service) Generate(ctx context.Context, kind string, at time.Time, deliver bool) (*models.Report, error) {
+	data, err := s.manager.BuildScheduledReport(kind, at)
+	if err != nil {
+		return nil, err
+	}
+	html, err := RenderHTML(data)
+	if err != nil {
+		return nil, fmt.Errorf("生成 HTML 失败: %w", err)
+	}
+	csv, err := RenderCSV(data)
+	if err != nil {
+		return nil, fmt.Errorf("生成 CSV 失败: %w", err)
+	}
+
+	// 时间统一按本地时间入库（与其他表一致，SQLite 按文本比较时间）
+	start, end, generatedAt := data.Start.Local(), data.End.Local(), data.GeneratedAt.Local()
+	report := &models.Report{
+		Kind:          data.Kind,
+		Period:        data.Period,
+		PeriodStart:   &start,
+		PeriodEnd:     &end,
+		Bets:          data.Summary.TotalBets,
+		Profit:        data.Summary.TotalProfit,
+		VirtualProfit: data.Summary.VirtualProfit,
+		Discrepancies: len(data.Discrepancies),
+		HTML:          html,
+		CSV:           csv,
+		CreatedAt:     &generatedAt,
+	}
+	if err := s.store.SaveReport(report); err != nil {
+		return nil, err
+	}
+	slog.Info("📊 报表已生成", "report_id", report.ID, "kind", kind, "period", report.Period,
+		"bets", report.Bets, "profit", report.Profit)
+
+	if deliver {
+		_ = s.Deliver(ctx, report)
+	}
+	return report, nil
+}
+
+// Deliver 投递已保存的报表到全部渠道并记录结果（没有配置渠道时不做任何事）
+func (s *Service) Deliver(ctx context.Context, report *models.Report) error {
+	if len(s.opts.Channels) == 0 {
+		return nil
+	}
+	err := notify.SendAll(ctx, s.opts.Channels, s.message(report))
+	var deliveredAt *time.Time
+	if err == nil {
+		now := s.clock.Now().Local()
+		deliveredAt = &now
+		slog.Info("📨 报表已投递", "report_id", report.ID, "period", report.Period, "channels", len(s.opts.Channels))
+	} else {
+		slog.Error("❌ 报表投递失败", "report_id", report.ID, "period", report.Period, "error", err)
+	}
+	report.DeliveredAt, report.DeliveryError = deliveredAt, notify.Summary(err, 500)
+	if updateErr := s.store.UpdateReportDelivery(report.ID, deliveredAt, report.DeliveryError); updateErr != nil {
+		slog.Error("❌ 记录投递结果失败", "report_id", report.ID, "error", updateErr)
+	}
+	return err
+}
+
+// message 报表通知：邮件正文为 HTML、附带 CSV；Webhook 发送摘要和下载链接
+func (s *Service) message(report *models.Report) notify.Message {
+	title := title(report.Kind, report.Period)
+	url := s.opts.BaseURL + "/api/reports/" + strconv.FormatUint(uint64(report.ID), 10)
+	return notify.Message{
+		Subject: title,
+		Text: fmt.Sprintf("%s：实盘下单 %d，实盘盈亏 %.2f，纸面盈亏 %.2f，对账差异 %d 条\n%s",
+			title, report.Bets, report.Profit, report.VirtualProfit, report.Discrepancies, url),
+		HTML: report.HTML,
+		Attachments: []notify.Attachment{{
+			Name:        fmt.Sprintf("report-%s-%s.csv", report.Kind, report.Period),
+			ContentType: "text/csv; charset=utf-8",
+			Data:        []byte(report.CSV),
+		}},
+		Payload: map[string]any{
+			"event":          "report",
+			"id":             report.ID,
+			"kind":           report.Kind,
+			"period":         report.Period,
+			"title":          title,
+			"start":          report.PeriodStart,
+			"end":            report.PeriodEnd,
+			"bets":           report.Bets,
+			"profit":         report.Profit,
+			"virtual_profit": report.VirtualProfit,
+			"discrepancies":  report.Discrepancies,
+			"html_url":       url + "?format=html",
+			"csv_url":        url + "?format=csv",
+		},
+	}
+}
+
+// Run 定时生成并投递（每个周期结束 Delay 后），直到 ctx 取消
+// 启动时补生成最近一个已结束且还没有报表的周期（例如服务在生成时刻停机）
+func (s *Service) Run(ctx context.Context) {
+	if len(s.opts.Kinds) == 0 {
+		return
+	}
+	slog.Info("📅 定时报表已启动", "kinds", s.opts.Kinds, "delay", s.opts.Delay, "channels", len(s.opts.Channels))
+	for {
+		now := s.clock.Now()
+		wait := s.nextDue(now).Sub(now)
+		if err := s.RunDue(ctx, now); err != nil {
+			wait = min(wait, retryInterval)
+		}
+		select {
+		case <-ctx.Done():
+			slog.Info("🛑 定时报表已停止")
+			return
+		case <-s.clock.After(wait):
+		}
+	}
+}
+
+// RunDue 生成 now 时最近一个已结束周期中还没有生成的报表（Run 循环调用）
+func (s *Service) RunDue(ctx context.Context, now time.Time) error {
+	cal := database.GetCalendar()
+	var errs []error
+	for _, kind := range s.opts.Kinds {
+		unit, ok := engine.ReportPeriodUnit(kind)
+		if !ok {
+			errs = append(errs, fmt.Errorf("未知报表类型: %s", kind))
+			continue
+		}
+		current := cal.PeriodStart(now.Add(-s.opts.Delay), unit)
+		previous := cal.PeriodStart(current.Add(-time.Nanosecond), unit)
+		_, err := s.store.FindReport(kind, cal.PeriodKey(previous, unit))
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, database.ErrNotFound) {
+			errs = append(errs, err)
+			continue
+		}
+		if _, err := s.Generate(ctx, kind, previous, true); err != nil {
+			slog.Error("❌ 生成定时报表失败", "kind", kind, "error", err)
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// nextDue 下一个周期结束 Delay 后的时刻
+func (s *Service) nextDue(now time.Time) time.Time {
+	cal := database.GetCalendar()
+	var next time.Time
+	for _, kind := range s.opts.Kinds {
+		unit, ok := engine.ReportPeriodUnit(kind)
+		if !ok {
+			continue
+		}
+		due := cal.NextPeriod(cal.PeriodStart(now.Add(-s.opts.Delay), unit), unit).Add(s.opts.Delay)
+		if next.IsZero() || due.Before(next) {
+			next = due
+		}
+	}
+	if next.IsZero() {
+		next = now.Add(retryInterval)
+	}
+	return next
+}
+
+// Get 按ID查询报表（含正文）
+func (s *Service) Get(id uint) (*models.Report, error) {
+	return s.store.GetReport(id)
+}
+
+// List 报表列表（不含正文）
+func (s *Service) List(kind string, limit int) ([]models.Report, error) {
+	return s.store.ListReports(kind, limit)
+}
@@ -0,0 +1,290 @@
+package simulator
+
+import (
+	"benz-sniper/engine"
+	"benz-sniper/models"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config 模拟器配置
+type Config struct {
+	Seed          int64              // 随机种子（相同种子生成相同序列）
+	StartRoundID  int64              // 起始期号
+	StartTime     time.Time          // 第一期开奖时间
+	Interval      time.Duration      // 每期间隔
+	Probabilities map[string]float64 // 车型开奖概率（覆盖按 REAL_ODDS 反推的值，未列出的车型保留反推值，合并后归一化）
+	SpecialRate   float64            // 特殊奖项出现概率（0~1）
+	Specials      []string           // 可注入的特殊奖项（为空时使用 SPECIAL_REWARDS）
+	PoolSize      float64            // 每期投注池规模（用于生成投注分布）
+}
+
+// DefaultConfig 返回默认模拟配置
+func DefaultConfig() Config {
+	return Config{
+		Seed:         1,
+		StartRoundID: 7935000,
+		StartTime:    time.Now().Truncate(time.Second),
+		Interval:     34 * time.Second, // 24秒倒计时 + 10秒开奖动画
+		SpecialRate:  0.02,
+		PoolSize:     50000,
+	}
+}
+
+// Round 模拟生成的一期完整数据
+type Round struct {
+	Round        models.GameRound
+	Winners      []models.GameWinner
+	Distribution []models.BetDistribution
+}
+
+// WinnerNames 获胜车型名称列表
+func (r Round) WinnerNames() []string {
+	names := make([]string, 0, len(r.Winners))
+	for _, w := range r.Winners {
+		names = append(names, w.WinnerName)
+	}
+	return names
+}
+
+// Simulator 确定性开奖模拟器（非并发安全，单goroutine使用）
+type Simulator struct {
+	cfg     Config
+	rng     *rand.Rand
+	labels  []string  // 车型顺序（固定为 BET_LABELS 顺序，保证确定性）
+	cumProb []float64 // 累积概率
+	nextID  int64
+	nextAt  time.Time
+}
+
+// New 创建模拟器实例
+func New(cfg Config) *Simulator {
+	def := DefaultConfig()
+	if cfg.Interval <= 0 {
+		cfg.Interval = def.Interval
+	}
+	if cfg.StartRoundID <= 0 {
+		cfg.StartRoundID = def.StartRoundID
+	}
+	if cfg.StartTime.IsZero() {
+		cfg.StartTime = def.StartTime
+	}
+	if cfg.PoolSize <= 0 {
+		cfg.PoolSize = def.PoolSize
+	}
+	if len(cfg.Specials) == 0 {
+		cfg.Specials = engine.SPECIAL_REWARDS
+	}
+	// 只列出部分车型时，其余车型沿用按赔率反推的概率
+	probs := ImpliedProbabilities()
+	for label, p := range cfg.Probabilities {
+		probs[label] = p
+	}
+	cfg.Probabilities = probs
+
+	s := &Simulator{
+		cfg:    cfg,
+		rng:    rand.New(rand.NewSource(cfg.Seed)),
+		labels: engine.BET_LABELS,
+		nextID: cfg.StartRoundID,
+		nextAt: cfg.StartTime,
+	}
+
+	// 按固定顺序归一化概率并计算累积分布
+	total := 0.0
+	for _, label := range s.labels {
+		total += cfg.Probabilities[label]
+	}
+	normalized := make(map[string]float64, len(s.labels))
+	acc := 0.0
+	s.cumProb = make([]float64, len(s.labels))
+	for i, label := range s.labels {
+		if total > 0 {
+			normalized[label] = cfg.Probabilities[label] / total
+			acc += normalized[label]
+		}
+		s.cumProb[i] = acc
+	}
+	s.cfg.Probabilities = normalized
+
+	return s
+}
+
+// ImpliedProbabilities 按真实赔率反推的开奖概率（1/赔率 归一化）
+func ImpliedProbabilities() map[string]float64 {
+	probs := make(map[string]float64, len(engine.BET_LABELS))
+	total := 0.0
+	for _, label := range engine.BET_LABELS {
+		p := 1.0 / float64(engine.REAL_ODDS[label])
+		probs[label] = p
+		total += p
+	}
+	for label := range probs {
+		probs[label] /= total
+	}
+	return probs
+}
+
+// ParseProbabilities 解析概率配置，格式："红奔驰=0.02,绿奔驰=0.03"
+func ParseProbabilities(s string) (map[string]float64, error) {
+	probs := make(map[string]float64)
+	if strings.TrimSpace(s) == "" {
+		return probs, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		parts := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("概率格式错误: %q", pair)
+		}
+		name := strings.TrimSpace(parts[0])
+		if _, ok := engine.REAL_ODDS[name]; !ok {
+			return nil, fmt.Errorf("未知车型: %q", name)
+		}
+		p, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		if err != nil || p < 0 {
+			return nil, fmt.Errorf("概率值错误: %q", pair)
+		}
+		probs[name] = p
+	}
+	return probs, nil
+}
+
+// Next 生成下一期数据
+func (s *Simulator) Next() Round {
+	roundID := strconv.FormatInt(s.nextID, 10)
+	drawAt := s.nextAt
+	s.nextID++
+	s.nextAt = s.nextAt.Add(s.cfg.Interval)
+
+	// 1. 决定开奖结果（普通开奖或特殊奖项）
+	special := ""
+	var winners []string
+	if s.cfg.SpecialRate > 0 && s.rng.Float64() < s.cfg.SpecialRate {
+		special = s.cfg.Specials[s.rng.Intn(len(s.cfg.Specials))]
+		winners = s.specialWinners(special)
+	} else {
+		winners = []string{s.pick()}
+	}
+
+	// 2. 生成投注分布
+	createdAt := drawAt
+	distribution := make([]models.BetDistribution, 0, len(s.labels))
+	amounts := make(map[string]float64, len(s.labels))
+	totalInput := 0.0
+	for i, label := range s.labels {
+		share := s.cfg.Probabilities[label]
+		// 在理论份额基础上加入 ±50% 的随机波动，取整到 10
+		amount := s.cfg.PoolSize * share * (0.5 + s.rng.Float64())
+		amount = float64(int64(amount/10) * 10)
+		amounts[label] = amount
+		totalInput += amount
+		distribution = append(distribution, models.BetDistribution{
+			RoundID:    roundID,
+			OptionID:   i + 1,
+			OptionName: label,
+			Odds:       float64(engine.REAL_ODDS[label]),
+			Amount:     amount,
+			CreatedAt:  &createdAt,
+		})
+	}
+
+	// 3. 生成获胜项
+	gameWinners := make([]models.GameWinner, 0, len(winners))
+	totalOutput := 0.0
+	for i, w := range winners {
+		totalOutput += amounts[w] * float64(engine.REAL_ODDS[w])
+		gameWinners = append(gameWinners, models.GameWinner{
+			RoundID:    roundID,
+			WinnerID:   s.labelIndex(w) + 1,
+			WinnerName: w,
+			Position:   i + 1,
+			CreatedAt:  &createdAt,
+		})
+	}
+
+	// 4. 生成期数记录
+	resultType := s.labelIndex(winners[0]) + 1
+	resultName := winners[0]
+	if special != "" {
+		resultType = 100 + s.specialIndex(special) + 1
+		resultName = special + "(" + strings.Join(winners, ",") + ")"
+	}
+
+	return Round{
+		Round: models.GameRound{
+			Timestamp:   drawAt.Unix(),
+			RoundID:     roundID,
+			ResultType:  resultType,
+			ResultName:  resultName,
+			TotalInput:  totalInput,
+			TotalOutput: totalOutput,
+			HouseNet:    totalInput - totalOutput,
+			CreatedAt:   &createdAt,
+			UpdatedAt:   &createdAt,
+		},
+		Winners:      gameWinners,
+		Distribution: distribution,
+	}
+}
+
+// pick 按概率抽取一个车型
+func (s *Simulator) pick() string {
+	r := s.rng.Float64()
+	for i, c := range s.cumProb {
+		if r < c {
+			return s.labels[i]
+		}
+	}
+	return s.labels[len(s.labels)-1]
+}
+
+// specialWinners 生成特殊奖项的获胜车型
+// 大三元：同品牌三色全中；大四喜：同颜色四品牌全中；其它：随机多个车型
+func (s *Simulator) specialWinners(special string) []string {
+	switch special {
+	case "大三元":
+		brands := []string{"奔驰", "宝马", "奥迪", "大众"}
+		brand := brands[s.rng.Intn(len(brands))]
+		return []string{"红" + brand, "绿" + brand, "黄" + brand}
+	case "大四喜":
+		colors := []string{"红", "绿", "黄"}
+		color := colors[s.rng.Intn(len(colors))]
+		return []string{color + "奔驰", color + "宝马", color + "奥迪", color + "大众"}
+	}
+
+	count := 2 + s.rng.Intn(3) // 2~4 个
+	seen := make(map[string]bool)
+	winners := make([]string, 0, count)
+	// 限制抽取次数，避免可选车型不足时死循环
+	for attempt := 0; len(winners) < count && attempt < 100; attempt++ {
+		w := s.pick()
+		if !seen[w] {
+			seen[w] = true
+			winners = append(winners, w)
+		}
+	}
+	return winners
+}
+
+// labelIndex 车型在 BET_LABELS 中的位置
+func (s *Simulator) labelIndex(label string) int {
+	for i, l := range s.labels {
+		if l == label {
+			return i
+		}
+	}
+	return -1
+}
+
+// specialIndex 特殊奖项在配置中的位置
+func (s *Simulator) specialIndex(special string) int {
+	for i, sr := range s.cfg.Specials {
+		if sr == special {
+			return i
+		}
+	}
+	return 0
+}
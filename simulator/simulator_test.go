@@ -0,0 +1,96 @@
+package simulator
+
+import (
+	"benz-sniper/database"
+	"benz-sniper/engine"
+	"context"
+	"io"
+	"log"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSimulatorDeterministic(t *testing.T) {
+	cfg := Config{Seed: 42, StartTime: time.Date(2026, 1, 1, 0, 0, 0, 0, time.Local), SpecialRate: 0.1}
+	a, b := New(cfg), New(cfg)
+
+	for i := 0; i < 200; i++ {
+		ra, rb := a.Next(), b.Next()
+		if ra.Round.RoundID != rb.Round.RoundID || ra.Round.ResultName != rb.Round.ResultName {
+			t.Fatalf("第 %d 期不一致: %s/%s vs %s/%s", i,
+				ra.Round.RoundID, ra.Round.ResultName, rb.Round.RoundID, rb.Round.ResultName)
+		}
+		if ra.Round.Timestamp != cfg.StartTime.Add(time.Duration(i)*DefaultConfig().Interval).Unix() {
+			t.Fatalf("第 %d 期时间戳 = %d", i, ra.Round.Timestamp)
+		}
+	}
+}
+
+func TestImpliedProbabilities(t *testing.T) {
+	probs := ImpliedProbabilities()
+	total := 0.0
+	for _, p := range probs {
+		total += p
+	}
+	if math.Abs(total-1) > 1e-9 {
+		t.Errorf("概率和 = %f, want 1", total)
+	}
+	if probs["黄大众"] <= probs["红奔驰"] {
+		t.Errorf("低赔率车型概率应更高: 黄大众=%f 红奔驰=%f", probs["黄大众"], probs["红奔驰"])
+	}
+}
+
+func TestParseProbabilities(t *testing.T) {
+	probs, err := ParseProbabilities("红奔驰=0.5, 黄大众=0.5")
+	if err != nil || probs["红奔驰"] != 0.5 || probs["黄大众"] != 0.5 {
+		t.Fatalf("ParseProbabilities() = %v, %v", probs, err)
+	}
+	for _, bad := range []string{"红奔驰", "紫奔驰=0.1", "红奔驰=-1"} {
+		if _, err := ParseProbabilities(bad); err == nil {
+			t.Errorf("ParseProbabilities(%q) 应返回错误", bad)
+		}
+	}
+}
+
+func TestPartialProbabilitiesKeepOtherCars(t *testing.T) {
+	probs, err := ParseProbabilities("红奔驰=0.5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := New(Config{Seed: 1, Probabilities: probs}).cfg.Probabilities
+
+	// 红奔驰覆盖为 0.5，其余车型保留反推值，合并后归一化
+	implied := ImpliedProbabilities()
+	total := 0.5 + 1 - implied["红奔驰"]
+	for _, label := range engine.BET_LABELS {
+		want := implied[label] / total
+		if label == "红奔驰" {
+			want = 0.5 / total
+		}
+		if math.Abs(got[label]-want) > 1e-9 {
+			t.Errorf("%s 概率 = %f, want %f", label, got[label], want)
+		}
+	}
+}
+
+func TestRunFeedsEngine(t *testing.T) {
+	out := log.Writer()
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(out)
+
+	store := database.NewMemoryStore()
+	manager := engine.NewStrategyManager(store)
+	sink := NewEngineSink(NewStoreSink(store), engine.New(store, manager))
+
+	written, err := Run(context.Background(), New(Config{Seed: 7, SpecialRate: 0.05}), sink, RunOptions{Rounds: 100})
+	if err != nil || written != 100 {
+		t.Fatalf("Run() = %d, %v, want 100 rounds", written, err)
+	}
+
+	// 第一期没有预测，其余 99 期每期两个策略各结算一次
+	_, total, _ := store.ListHistory(database.HistoryQuery{Limit: 1})
+	if total != 99*2 {
+		t.Errorf("history total = %d, want %d", total, 99*2)
+	}
+}
@@ -0,0 +1,88 @@
+package simulator
+
+import (
+	"benz-sniper/database"
+	"benz-sniper/engine"
+	"context"
+	"log/slog"
+)
+
+// Sink 模拟数据输出目标
+type Sink interface {
+	Write(r Round) error
+}
+
+// StoreSink 写入存储（与外部抓取程序写入的表结构一致）
+type StoreSink struct {
+	store database.RoundStore
+}
+
+// NewStoreSink 创建存储输出
+func NewStoreSink(store database.RoundStore) *StoreSink {
+	return &StoreSink{store: store}
+}
+
+// Write 写入期数、获胜项和投注分布
+func (s *StoreSink) Write(r Round) error {
+	return s.store.SaveRound(&r.Round, r.Winners, r.Distribution)
+}
+
+// EngineSink 写入数据库后立即驱动引擎处理（无需等待轮询）
+type EngineSink struct {
+	Sink
+	eng *engine.Engine
+}
+
+// NewEngineSink 创建直接驱动引擎的输出
+func NewEngineSink(sink Sink, eng *engine.Engine) *EngineSink {
+	return &EngineSink{Sink: sink, eng: eng}
+}
+
+// Write 写入数据后执行一次引擎轮询
+func (s *EngineSink) Write(r Round) error {
+	if err := s.Sink.Write(r); err != nil {
+		return err
+	}
+	s.eng.Tick()
+	return nil
+}
+
+// RunOptions 运行参数
+type RunOptions struct {
+	Rounds   int          // 生成期数（0=无限）
+	Realtime bool         // 是否按间隔等待（false=尽快生成）
+	Clock    engine.Clock // 等待使用的时钟（默认系统时钟，回放时可用加速时钟）
+}
+
+// Run 按计划持续生成数据，直到达到期数或 ctx 取消
+// 返回成功写入的期数
+func Run(ctx context.Context, sim *Simulator, sink Sink, opts RunOptions) (int, error) {
+	if opts.Clock == nil {
+		opts.Clock = engine.SystemClock{}
+	}
+	written := 0
+	for opts.Rounds == 0 || written < opts.Rounds {
+		select {
+		case <-ctx.Done():
+			return written, nil
+		default:
+		}
+
+		r := sim.Next()
+		if err := sink.Write(r); err != nil {
+			slog.Error("❌ 写入模拟期号失败", "round_id", r.Round.RoundID, "error", err)
+			return written, err
+		}
+		written++
+		slog.Info("🎲 模拟期号", "round_id", r.Round.RoundID, "result", r.Round.ResultName)
+
+		if opts.Realtime {
+			select {
+			case <-ctx.Done():
+				return written, nil
+			case <-opts.Clock.After(sim.cfg.Interval):
+			}
+		}
+	}
+	return written, nil
+}
@@ -0,0 +1,296 @@
+package testutil
+
+import (
+	"benz-sniper/alerts"
+	"benz-sniper/api"
+	"benz-sniper/auth"
+	"benz-sniper/config"
+	"benz-sniper/database"
+	"benz-sniper/engine"
+	"benz-sniper/logging"
+	"benz-sniper/models"
+	"benz-sniper/reports"
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RoundInterval 脚本开奖的默认间隔
+const RoundInterval = 34 * time.Second
+
+// Options 测试环境参数
+type Options struct {
+	Driver        string    // 存储类型：memory（默认）/ sqlite
+	Start         time.Time // 手动时钟起点（默认 2026-01-01 12:00 本地时间）
+	StartRoundID  int64     // 起始期号（默认 1000）
+	AnonymousRole auth.Role // 未登录访问的角色（默认为空，只能访问看板接口）
+
+	Reports reports.Options // 定时报表参数（投递渠道等）
+	Alerts  alerts.Options  // 告警参数（通知渠道）
+}
+
+// Harness 端到端测试环境：存储 + 手动时钟 + 策略管理器 + 引擎 + API 路由
+type Harness struct {
+	T       testing.TB
+	Store   database.Store
+	Clock   *engine.ManualClock
+	Manager *engine.StrategyManager
+	Engine  *engine.Engine
+	Router  *gin.Engine
+	Auth    *auth.Service
+	Reports *reports.Service
+	Alerts  *alerts.Service
+	APIKey  string // 管理员 API 密钥（Do 默认携带）
+
+	nextRound int64
+}
+
+// New 创建测试环境（测试结束时自动关闭存储）
+func New(t testing.TB, opts Options) *Harness {
+	t.Helper()
+
+	if opts.Driver == "" {
+		opts.Driver = database.DriverMemory
+	}
+	if opts.Start.IsZero() {
+		opts.Start = time.Date(2026, 1, 1, 12, 0, 0, 0, time.Local)
+	}
+	if opts.StartRoundID == 0 {
+		opts.StartRoundID = 1000
+	}
+
+	// 非 -v 模式下屏蔽引擎日志
+	if !testing.Verbose() {
+		log.SetOutput(io.Discard)
+		t.Cleanup(func() { log.SetOutput(logWriter) })
+	}
+
+	store, err := database.Open(&config.Config{
+		DBDriver:   opts.Driver,
+		SQLitePath: filepath.Join(t.TempDir(), "test.db"),
+	})
+	if err != nil {
+		t.Fatalf("打开存储失败: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	clock := engine.NewManualClock(opts.Start)
+	manager := engine.NewStrategyManagerWithClock(store, clock)
+	eng := engine.New(store, manager)
+	alertService := alerts.NewService(manager, store, opts.Alerts)
+	eng.OnTick(alertService.Observe)
+
+	authService := auth.NewService(store, clock, auth.Options{AnonymousRole: opts.AnonymousRole})
+	apiKey, _, err := authService.CreateAPIKey("harness", auth.RoleAdmin, "test")
+	if err != nil {
+		t.Fatalf("创建测试密钥失败: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	_ = router.SetTrustedProxies(nil) // 与默认配置一致：不信任任何代理
+	router.Use(logging.Middleware())
+	handler := api.New(manager, authService, store)
+	handler.SetEngine(eng)
+	reportService := reports.NewService(manager, store, opts.Reports)
+	handler.SetReports(reportService)
+	handler.SetAlerts(alertService)
+	handler.SetupRoutes(router)
+
+	return &Harness{
+		T:         t,
+		Store:     store,
+		Clock:     clock,
+		Manager:   manager,
+		Engine:    eng,
+		Router:    router,
+		Auth:      authService,
+		Reports:   reportService,
+		Alerts:    alertService,
+		APIKey:    apiKey,
+		nextRound: opts.StartRoundID,
+	}
+}
+
+// Drivers 端到端测试覆盖的存储类型
+var Drivers = []string{database.DriverMemory, database.DriverSQLite}
+
+// EachDriver 对每种存储类型各创建一个测试环境并运行 fn（子测试名为存储类型）
+func EachDriver(t *testing.T, fn func(t *testing.T, h *Harness)) {
+	EachDriverWith(t, Options{}, fn)
+}
+
+// EachDriverWith 同 EachDriver，按 opts 创建测试环境（opts.Driver 会被覆盖）
+func EachDriverWith(t *testing.T, opts Options, fn func(t *testing.T, h *Harness)) {
+	t.Helper()
+	for _, driver := range Drivers {
+		t.Run(driver, func(t *testing.T) {
+			opts := opts
+			opts.Driver = driver
+			fn(t, New(t, opts))
+		})
+	}
+}
+
+// Draw 写入下一期开奖（普通或多车型命中）并驱动引擎处理，返回期号
+func (h *Harness) Draw(winners ...string) string {
+	h.T.Helper()
+	return h.draw(strings.Join(winners, ","), winners)
+}
+
+// DrawSpecial 写入带特殊奖项的一期并驱动引擎处理，返回期号
+func (h *Harness) DrawSpecial(special string, winners ...string) string {
+	h.T.Helper()
+	return h.draw(special+"("+strings.Join(winners, ",")+")", winners)
+}
+
+// draw 写入一期数据并执行一次引擎轮询
+func (h *Harness) draw(resultName string, winners []string) string {
+	h.T.Helper()
+
+	h.Clock.Advance(RoundInterval)
+	drawAt := h.Clock.Now()
+	roundID := strconv.FormatInt(h.nextRound, 10)
+	h.nextRound++
+
+	gameWinners := make([]models.GameWinner, 0, len(winners))
+	for i, w := range winners {
+		gameWinners = append(gameWinners, models.GameWinner{
+			RoundID:    roundID,
+			WinnerName: w,
+			Position:   i + 1,
+			CreatedAt:  &drawAt,
+		})
+	}
+	round := &models.GameRound{
+		Timestamp:  drawAt.Unix(),
+		RoundID:    roundID,
+		ResultName: resultName,
+		CreatedAt:  &drawAt,
+	}
+	if err := h.Store.SaveRound(round, gameWinners, nil); err != nil {
+		h.T.Fatalf("写入期号 %s 失败: %v", roundID, err)
+	}
+
+	h.Engine.Tick()
+	return roundID
+}
+
+// Strategy 当前状态中指定策略的结果
+func (h *Harness) Strategy(name string) engine.StrategyResult {
+	h.T.Helper()
+	state := h.Manager.GetState()
+	for _, s := range state.Strategies {
+		if s.Name == name {
+			return s
+		}
+	}
+	h.T.Fatalf("策略 %s 不存在", name)
+	return engine.StrategyResult{}
+}
+
+// Do 以管理员身份发送 HTTP 请求（body 为 nil 时不带请求体）
+func (h *Harness) Do(method, path string, body any) *httptest.ResponseRecorder {
+	h.T.Helper()
+	return h.DoWith(method, path, body, http.Header{api.APIKeyHeader: {h.APIKey}})
+}
+
+// DoWith 携带指定请求头发送 HTTP 请求（header 为空时以匿名身份访问）
+func (h *Harness) DoWith(method, path string, body any, header http.Header) *httptest.ResponseRecorder {
+	h.T.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			h.T.Fatalf("序列化请求体失败: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, values := range header {
+		for _, v := range values {
+			req.Header.Add(k, v)
+		}
+	}
+	rec := httptest.NewRecorder()
+	h.Router.ServeHTTP(rec, req)
+	return rec
+}
+
+// JSON 发送请求，断言 200 并解析响应
+func (h *Harness) JSON(method, path string, body any, out any) {
+	h.T.Helper()
+
+	rec := h.Do(method, path, body)
+	if rec.Code != http.StatusOK {
+		h.T.Fatalf("%s %s 返回 %d: %s", method, path, rec.Code, rec.Body.String())
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+		h.T.Fatalf("解析 %s %s 响应失败: %v\n%s", method, path, err, rec.Body.String())
+	}
+}
+
+// logWriter 标准日志的默认输出
+var logWriter = log.Writer()
+
+// WinningCar 返回能让指定策略盈利的车型（当前预测中赔率最高的）
+func (h *Harness) WinningCar(name string) string {
+	h.T.Helper()
+	best := ""
+	for _, p := range h.Strategy(name).Predictions {
+		if best == "" || engine.REAL_ODDS[p] > engine.REAL_ODDS[best] {
+			best = p
+		}
+	}
+	if best == "" {
+		h.T.Fatalf("策略 %s 没有预测", name)
+	}
+	return best
+}
+
+// LosingCar 返回不在任何策略当前预测中的车型
+func (h *Harness) LosingCar() string {
+	h.T.Helper()
+	predicted := make(map[string]bool)
+	for _, s := range h.Manager.GetState().Strategies {
+		for _, p := range s.Predictions {
+			predicted[p] = true
+		}
+	}
+	for _, label := range engine.BET_LABELS {
+		if !predicted[label] {
+			return label
+		}
+	}
+	h.T.Fatalf("所有车型都在预测中")
+	return ""
+}
+
+// PromoteToReal 连续开出让指定策略盈利的车型，直到其进入实盘
+func (h *Harness) PromoteToReal(name string) {
+	h.T.Helper()
+	if len(h.Manager.GetState().Strategies) == 0 {
+		h.Draw(engine.BET_LABELS[0])
+	}
+	for i := 0; i < 10; i++ {
+		if h.Strategy(name).Status == engine.StatusReal {
+			return
+		}
+		h.Draw(h.WinningCar(name))
+	}
+	h.T.Fatalf("策略 %s 未能进入实盘", name)
+}
@@ -0,0 +1,137 @@
+package testutil
+
+import (
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// SMTPMessage SMTP 替身收到的一封邮件
+type SMTPMessage struct {
+	From string
+	To   []string
+	Data string // 原始邮件（头部 + 正文）
+}
+
+// SMTPServer 本地 SMTP 替身：监听 127.0.0.1 的随机端口，把收到的邮件保存在内存中（不支持 TLS 和认证）
+type SMTPServer struct {
+	Addr string
+
+	ln       net.Listener
+	mu       sync.Mutex
+	messages []SMTPMessage
+	reject   string // 非空时拒绝 MAIL 命令（模拟投递失败）
+}
+
+// NewSMTPServer 启动 SMTP 替身（测试结束时自动关闭）
+func NewSMTPServer(t testing.TB) *SMTPServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("启动 SMTP 替身失败: %v", err)
+	}
+	s := &SMTPServer{Addr: ln.Addr().String(), ln: ln}
+	go s.accept()
+	t.Cleanup(func() { ln.Close() })
+	return s
+}
+
+// Messages 已收到的邮件
+func (s *SMTPServer) Messages() []SMTPMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]SMTPMessage{}, s.messages...)
+}
+
+// Reset 清空已收到的邮件并恢复接收
+func (s *SMTPServer) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = nil
+	s.reject = ""
+}
+
+// Reject 之后的邮件以 reason 拒收（为空时恢复接收）
+func (s *SMTPServer) Reject(reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reject = reason
+}
+
+func (s *SMTPServer) accept() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.serve(conn)
+	}
+}
+
+// serve 处理一个连接（只实现 net/smtp 客户端用到的命令）
+func (s *SMTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	reply := func(line string) { _ = tp.PrintfLine("%s", line) }
+	reply("220 localhost ESMTP test")
+
+	var msg SMTPMessage
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(cmd) {
+		case "EHLO":
+			reply("250-localhost")
+			reply("250 8BITMIME")
+		case "HELO", "NOOP":
+			reply("250 OK")
+		case "RSET":
+			msg = SMTPMessage{}
+			reply("250 OK")
+		case "MAIL":
+			s.mu.Lock()
+			reject := s.reject
+			s.mu.Unlock()
+			if reject != "" {
+				reply("550 " + reject)
+				continue
+			}
+			msg = SMTPMessage{From: addressOf(arg)}
+			reply("250 OK")
+		case "RCPT":
+			msg.To = append(msg.To, addressOf(arg))
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			msg.Data = string(data)
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+// addressOf 取出 "FROM:<a@b>" 中的地址
+func addressOf(arg string) string {
+	_, addr, _ := strings.Cut(arg, ":")
+	addr = strings.TrimSpace(addr)
+	if i := strings.IndexByte(addr, ' '); i >= 0 {
+		addr = addr[:i]
+	}
+	return strings.Trim(addr, "<>")
+}
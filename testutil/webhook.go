@@ -0,0 +1,47 @@
+package testutil
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// WebhookServer 本地 Webhook 替身：把收到的 JSON 请求体保存在内存中
+type WebhookServer struct {
+	URL string
+
+	mu       sync.Mutex
+	payloads []map[string]any
+}
+
+// NewWebhookServer 启动 Webhook 替身（测试结束时自动关闭）
+func NewWebhookServer(t testing.TB) *WebhookServer {
+	t.Helper()
+	s := &WebhookServer{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		s.mu.Lock()
+		s.payloads = append(s.payloads, payload)
+		s.mu.Unlock()
+	}))
+	s.URL = server.URL
+	t.Cleanup(server.Close)
+	return s
+}
+
+// Payloads 已收到的请求体
+func (s *WebhookServer) Payloads() []map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]map[string]any{}, s.payloads...)
+}
+
+// Reset 清空已收到的请求体
+func (s *WebhookServer) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.payloads = nil
+}